@@ -1,15 +1,18 @@
 package main
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/krane/krane/internal/api"
 	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/deployment"
 	"github.com/krane/krane/internal/docker"
 	"github.com/krane/krane/internal/job"
 	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/proxy"
 	"github.com/krane/krane/internal/scheduler"
 	"github.com/krane/krane/internal/store"
 	"github.com/krane/krane/internal/utils"
@@ -29,13 +32,32 @@ func init() {
 	utils.EnvOrDefault(constants.EnvProxyEnabled, "true")
 	utils.EnvOrDefault(constants.EnvProxyDashboardSecure, "false")
 	utils.EnvOrDefault(constants.EnvProxyDashboardAlias, "")
+	utils.EnvOrDefault(constants.EnvProxyNetwork, docker.KraneNetworkName)
+	utils.EnvOrDefault(constants.EnvProxyVersion, "")
 	utils.EnvOrDefault(constants.EnvLetsEncryptEmail, "")
+	utils.EnvOrDefault(constants.EnvCertResolver, proxy.DefaultCertResolver)
+	utils.EnvOrDefault(constants.EnvDiskUsageThreshold, "1gb")
+	utils.EnvOrDefault(constants.EnvDiskFullPrune, "false")
+	utils.EnvOrDefault(constants.EnvPruneImagesAfterDeploy, "false")
+	utils.EnvOrDefault(constants.EnvStreamKeepAliveMs, "30000")
+	utils.EnvOrDefault(constants.EnvResourceOvercommitFactor, "1")
+	utils.EnvOrDefault(constants.EnvMetricsExporters, "")
+	utils.EnvOrDefault(constants.EnvMetricsPushIntervalMs, "30000")
+	utils.EnvOrDefault(constants.EnvMetricsStatsDAddress, "127.0.0.1:8125")
+	utils.EnvOrDefault(constants.EnvMetricsOTLPEndpoint, "http://127.0.0.1:4318")
+	utils.EnvOrDefault(constants.EnvMetricsEndpointEnabled, "false")
+	utils.EnvOrDefault(constants.EnvMetricsEndpointToken, "")
+	utils.EnvOrDefault(constants.EnvAPIRequestTimeoutMs, "15000")
+	utils.EnvOrDefault(constants.EnvDeploymentHistoryLimit, "10")
 
 	logger.Configure()
 	logger.Info("Setting up Krane")
 
 	docker.Connect()
 	store.Connect(os.Getenv(constants.EnvDatabasePath))
+	if err := store.Client().Ping(); err != nil {
+		logger.Fatalf("Unable to access the state store at %s: %v", os.Getenv(constants.EnvDatabasePath), err)
+	}
 }
 
 func main() {
@@ -69,6 +91,22 @@ func main() {
 	workers := job.NewWorkerPool(wpSize, queue, store.Client())
 	workers.Start()
 
+	// run the deployments with a cron schedule when they are due
+	go job.RunRecurringJobs(context.Background(), job.RecurringJobsInterval, deployment.RunScheduled)
+
+	// run the automated runs deferred by a deploy window once the window opens
+	go deployment.RunDeferredRuns(context.Background(), deployment.DeferredRunsInterval)
+
+	// emit health and routing events, restart the containers failing their liveness probe and
+	// reload the deployments with reload_on_change when a bind mounted host file changed
+	go deployment.RunMonitors(context.Background(), deployment.MonitorInterval)
+
+	// redeploy the deployments with auto_update enabled when their image tag moves to a new digest
+	go deployment.RunAutoUpdates(context.Background(), deployment.AutoUpdateCheckInterval)
+
+	// if configured, push metrics to statsd and/or an opentelemetry collector, and collect them for GET /metrics
+	StartMetricsExporters()
+
 	// if enabled, ensure internal services are running
 	EnsureNetworkProxy()
 
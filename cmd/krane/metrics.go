@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/deployment"
+	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/metrics"
+	"github.com/krane/krane/internal/utils"
+)
+
+// StartMetricsExporters pushes the Krane metrics at an interval with every exporter enabled with the
+// environment variable METRICS_EXPORTERS (statsd, otlp or both). Nothing is pushed when no exporter is enabled.
+func StartMetricsExporters() {
+	exporters, err := metrics.ConfiguredExporters()
+	if err != nil {
+		logger.Errorf("Unable to configure the metrics exporters, %v", err)
+	}
+
+	// deployment health gauges are collected when pushing or scraping so they are exported without watch mode
+	if len(exporters) > 0 || utils.BoolEnv(constants.EnvMetricsEndpointEnabled) {
+		metrics.RegisterCollector(deployment.CollectHealthMetrics)
+	}
+
+	if len(exporters) == 0 {
+		return
+	}
+
+	for _, exporter := range exporters {
+		logger.Infof("Pushing metrics with the %s exporter every %s", exporter.Name(), metrics.PushInterval())
+	}
+	go metrics.Default().Push(context.Background(), exporters, metrics.PushInterval())
+}
@@ -1,18 +1,24 @@
 package main
 
 import (
+	"context"
 	"os"
 
 	"github.com/krane/krane/internal/constants"
 	"github.com/krane/krane/internal/deployment"
+	"github.com/krane/krane/internal/docker"
 	"github.com/krane/krane/internal/logger"
 	"github.com/krane/krane/internal/utils"
 )
 
+// proxySecure is set explicitly on the proxy tls so the proxy dashboard doesn't inherit the tls of the global defaults
+var proxySecure = utils.BoolEnv(constants.EnvProxyDashboardSecure)
+
 var proxyConfig = deployment.Config{
-	Name:     "krane-proxy",
+	Name:     deployment.ProxyDeploymentName,
 	Image:    "biensupernice/proxy",
-	Secure:   utils.BoolEnv(constants.EnvProxyDashboardSecure),
+	Secure:   proxySecure,
+	TLS:      deployment.TLS{Enabled: &proxySecure},
 	Alias:    []string{os.Getenv(constants.EnvProxyDashboardAlias)},
 	Scale:    1,
 	Internal: true,
@@ -37,6 +43,7 @@ func EnsureNetworkProxy() {
 	isEnabled := utils.BoolEnv(constants.EnvProxyEnabled)
 	if !isEnabled {
 		logger.Info("Network proxy not enabled")
+		warnIfNoProxy()
 		return
 	}
 
@@ -79,7 +86,11 @@ func EnsureNetworkProxy() {
 }
 
 func createProxy() error {
-	if err := deployment.SaveConfig(proxyConfig); err != nil {
+	// a proxy installed through the api keeps its configuration, the default proxy is saved again
+	// so changes to PROXY_DASHBOARD_ALIAS, PROXY_DASHBOARD_SECURE or LETSENCRYPT_EMAIL are applied
+	if installedThroughAPI() {
+		logger.Debug("Network proxy installed through the api found, redeploying the network proxy")
+	} else if err := deployment.SaveConfig(proxyConfig); err != nil {
 		return err
 	}
 
@@ -90,3 +101,34 @@ func createProxy() error {
 	logger.Debug("Network proxy deployment started")
 	return nil
 }
+
+// installedThroughAPI returns true if the network proxy deployment is a Traefik proxy installed with POST /system/proxy/install
+func installedThroughAPI() bool {
+	if !deployment.Exist(proxyConfig.Name) {
+		return false
+	}
+
+	config, err := deployment.GetDeploymentConfig(proxyConfig.Name)
+	return err == nil && config.Image == "traefik"
+}
+
+// warnIfNoProxy warns when deployments are routed through aliases but no Traefik proxy is running,
+// ie. the network proxy is disabled and Traefik isn't managed outside of Krane either
+func warnIfNoProxy() {
+	if _, ok, err := deployment.DetectProxy(context.Background()); err != nil || ok {
+		return
+	}
+
+	configs, err := deployment.GetAllDeploymentConfigs()
+	if err != nil {
+		return
+	}
+
+	for _, config := range configs {
+		if config.Routed() && !config.Internal {
+			logger.Warnf("No Traefik proxy found, deployments with aliases (ie. %s) will not be reachable. "+
+				"Enable %s or run Traefik on the %s network", config.Name, constants.EnvProxyEnabled, docker.ProxyNetworkName())
+			return
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/krane/krane/internal/api/middlewares"
 	"github.com/krane/krane/internal/constants"
 	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/utils"
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
@@ -23,10 +24,12 @@ func Run() {
 	withRoutes(router)
 
 	srv := http.Server{
-		Handler:      router,
-		Addr:         os.Getenv(constants.EnvListenAddress),
-		WriteTimeout: 15 * time.Second,
-		ReadTimeout:  15 * time.Second,
+		Handler: router,
+		Addr:    os.Getenv(constants.EnvListenAddress),
+		// read and write timeouts apply to the whole connection and would cut streams and uploads short,
+		// requests are timed out per route instead (see withRoute)
+		ReadHeaderTimeout: 15 * time.Second,
+		IdleTimeout:       60 * time.Second,
 	}
 
 	logger.Infof("Krane API on %s", srv.Addr)
@@ -41,7 +44,8 @@ func withBaseMiddlewares(router *mux.Router) {
 	router.Use(handlers.RecoveryHandler())
 	router.Use(handlers.CORS(
 		handlers.AllowedMethods([]string{http.MethodGet, http.MethodPost}),
-		handlers.AllowedOrigins([]string{"*"})))
+		handlers.AllowedOrigins([]string{"*"}),
+		handlers.ExposedHeaders([]string{controllers.TotalCountHeader})))
 }
 
 // withRoutes configures rest api endpoints and handlers
@@ -49,42 +53,107 @@ func withRoutes(router *mux.Router) {
 	noAuthRouter := router.PathPrefix("/").Subrouter()
 	withRoute(noAuthRouter, "/", controllers.RootPath).Methods(http.MethodGet)
 	withRoute(noAuthRouter, "/health", controllers.HealthCheck).Methods(http.MethodGet)
-	withRoute(noAuthRouter, "/login", controllers.RequestLoginPhrase).Methods(http.MethodGet)
-	withRoute(noAuthRouter, "/auth", controllers.AuthenticateClientJWT).Methods(http.MethodPost)
+	withRoute(noAuthRouter, "/schema/config", controllers.GetConfigSchema).Methods(http.MethodGet)
 
-	authRouter := router.PathPrefix("/").Subrouter()
+	// routes backed by the state store respond with 503 when the store is unavailable
+	loginRouter := router.PathPrefix("/").Subrouter()
+	withRoute(loginRouter, "/login", controllers.RequestLoginPhrase, middlewares.StoreAvailableMiddleware).Methods(http.MethodGet)
+	withRoute(loginRouter, "/auth", controllers.AuthenticateClientJWT).Methods(http.MethodPost)
+
+	// metrics scrapers authenticate with their own token (if any) instead of a session
+	if utils.BoolEnv(constants.EnvMetricsEndpointEnabled) {
+		metricsRouter := router.PathPrefix("/").Subrouter()
+		withRoute(metricsRouter, "/metrics", controllers.GetMetrics, middlewares.MetricsTokenMiddleware).Methods(http.MethodGet)
+	}
+
+	// deployment webhooks authenticate with the webhook token of the deployment instead of a session
+	webhookRouter := router.PathPrefix("/").Subrouter()
+	withRoute(webhookRouter, "/deployments/{deployment}/webhook", controllers.TriggerDeploymentWebhook, middlewares.StoreAvailableMiddleware).Methods(http.MethodPost)
+
+	authRoute := router.PathPrefix("/")
+	authRouter := authRoute.Subrouter()
+	authRouter.Use(middlewares.StoreAvailableMiddleware)
+	withRoute(noAuthRouter, "/openapi.json", openAPIHandler(router, authRoute)).Methods(http.MethodGet)
 	// deployments
 	withRoute(authRouter, "/deployments", controllers.GetAllDeployments, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
 	withRoute(authRouter, "/deployments", controllers.CreateOrUpdateDeployment, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
+	withRoute(authRouter, "/deployments/overlay", controllers.MergeDeploymentOverlay, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
+	withRoute(authRouter, "/deployments/actions", controllers.ApplyDeploymentsAction, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
+	withRoute(authRouter, "/deployments/validate", controllers.ValidateDeployment, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
+	withRoute(authRouter, "/deployments/import-from-container", controllers.ImportDeploymentFromContainer, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
 	withRoute(authRouter, "/deployments/{deployment}", controllers.GetDeployment, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
 	withRoute(authRouter, "/deployments/{deployment}", controllers.RunDeployment, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
 	withRoute(authRouter, "/deployments/{deployment}", controllers.DeleteDeployment, middlewares.ValidateSessionMiddleware).Methods(http.MethodDelete)
+	withRoute(authRouter, "/deployments/{deployment}/history", controllers.GetDeploymentHistory, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
+	withRoute(authRouter, "/deployments/{deployment}/rollback", controllers.RollbackDeployment, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
+	withRoute(authRouter, "/deployments/{deployment}/schedule", controllers.ScheduleDeployment, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
+	withRoute(authRouter, "/deployments/{deployment}/schedule", controllers.UnscheduleDeployment, middlewares.ValidateSessionMiddleware).Methods(http.MethodDelete)
+	withRoute(authRouter, "/deployments/{deployment}/export", controllers.ExportDeployment, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
+	withStreamingRoute(authRouter, "/deployments/{deployment}/rename", controllers.RenameDeployment, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
+	withRoute(authRouter, "/deployments/{deployment}/pin", controllers.PinDeployment, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
+	withRoute(authRouter, "/deployments/{deployment}/unpin", controllers.UnpinDeployment, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
+	withRoute(authRouter, "/deployments/{deployment}/webhook/token", controllers.GenerateDeploymentWebhookToken, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
+	withRoute(authRouter, "/deployments/{deployment}/webhook/token", controllers.RevokeDeploymentWebhookToken, middlewares.ValidateSessionMiddleware).Methods(http.MethodDelete)
+	withRoute(authRouter, "/deployments/{deployment}/status", controllers.GetDeploymentStatus, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
+	withRoute(authRouter, "/deployments/{deployment}/network", controllers.GetDeploymentNetworks, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
+	withRoute(authRouter, "/deployments/{deployment}/stats", controllers.GetDeploymentStats, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
+	withStreamingRoute(authRouter, "/deployments/{deployment}/stats/usage", controllers.GetDeploymentResourceUsage, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
+	withRoute(authRouter, "/deployments/{deployment}/disk", controllers.GetDeploymentDiskUsage, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
 	withRoute(authRouter, "/deployments/{deployment}/containers", controllers.GetDeploymentContainers, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
+	withRoute(authRouter, "/deployments/{deployment}/containers/{index:[0-9]+}/diff", middlewares.AdminOnly(controllers.GetDeploymentContainerDiff), middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
+	withStreamingRoute(authRouter, "/deployments/{deployment}/containers/{index:[0-9]+}/files", middlewares.AdminOnly(controllers.CopyFileFromDeploymentContainer), middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
+	withStreamingRoute(authRouter, "/deployments/{deployment}/containers/{index:[0-9]+}/files", middlewares.AdminOnly(controllers.CopyFileToDeploymentContainer), middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
+	withStreamingRoute(authRouter, "/deployments/{deployment}/containers/{index:[0-9]+}/restart", controllers.RestartDeploymentContainer, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
+	withRoute(authRouter, "/deployments/{deployment}/start", controllers.StartDeploymentContainers, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
+	withRoute(authRouter, "/deployments/{deployment}/stop", controllers.StopDeploymentContainers, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
+	withRoute(authRouter, "/deployments/{deployment}/restart", controllers.RestartDeploymentContainers, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
 	withRoute(authRouter, "/deployments/{deployment}/containers/start", controllers.StartDeploymentContainers, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
 	withRoute(authRouter, "/deployments/{deployment}/containers/stop", controllers.StopDeploymentContainers, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
 	withRoute(authRouter, "/deployments/{deployment}/containers/restart", controllers.RestartDeploymentContainers, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
 	// secrets
 	withRoute(authRouter, "/secrets/{deployment}", controllers.GetSecrets, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
 	withRoute(authRouter, "/secrets/{deployment}", controllers.CreateOrUpdateSecret, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
+	withRoute(authRouter, "/secrets/{deployment}/import", controllers.ImportSecrets, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
 	withRoute(authRouter, "/secrets/{deployment}/{key}", controllers.DeleteSecret, middlewares.ValidateSessionMiddleware).Methods(http.MethodDelete)
 	// jobs
 	withRoute(authRouter, "/jobs", controllers.GetJobsByDaysAgo, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
 	withRoute(authRouter, "/jobs/{deployment}", controllers.GetJobsByDeployment, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
 	withRoute(authRouter, "/jobs/{deployment}/{id}", controllers.GetJobByID, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
+	withRoute(authRouter, "/jobs/{deployment}/{id}", controllers.CancelJob, middlewares.ValidateSessionMiddleware).Methods(http.MethodDelete)
+	withRoute(authRouter, "/jobs/{deployment}/{id}/attempts", controllers.GetJobAttempts, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
 	// sessions
 	withRoute(authRouter, "/sessions", controllers.GetSessions, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
 	withRoute(authRouter, "/sessions", controllers.CreateSession, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
 	withRoute(authRouter, "/sessions/{id}", controllers.DeleteSession, middlewares.ValidateSessionMiddleware).Methods(http.MethodDelete)
+	// system
+	withRoute(authRouter, "/system/containers", controllers.GetSystemContainers, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
+	withRoute(authRouter, "/system/ports", controllers.GetSystemPorts, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
+	withRoute(authRouter, "/system/queue", controllers.GetSystemQueue, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
+	withRoute(authRouter, "/system/defaults", controllers.GetSystemDefaults, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
+	withRoute(authRouter, "/system/defaults", controllers.UpdateSystemDefaults, middlewares.ValidateSessionMiddleware).Methods(http.MethodPut)
+	withRoute(authRouter, "/system/registries", controllers.GetSystemRegistries, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
+	withRoute(authRouter, "/system/registries/{host}", controllers.SaveSystemRegistry, middlewares.ValidateSessionMiddleware).Methods(http.MethodPut)
+	withRoute(authRouter, "/system/registries/{host}", controllers.DeleteSystemRegistry, middlewares.ValidateSessionMiddleware).Methods(http.MethodDelete)
+	withRoute(authRouter, "/system/proxy", controllers.GetSystemProxy, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
+	withRoute(authRouter, "/system/proxy/install", controllers.InstallSystemProxy, middlewares.ValidateSessionMiddleware).Methods(http.MethodPost)
 	// realtime
-	withRoute(authRouter, "/ws/containers/{container}/logs", controllers.SubscribeToContainerLogs, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
-	withRoute(authRouter, "/ws/deployments/{deployment}/logs", controllers.SubscribeToDeploymentLogs, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
-	withRoute(authRouter, "/ws/deployments/{deployment}/events", controllers.SubscribeToDeploymentEvents, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
+	withStreamingRoute(authRouter, "/ws/containers/{container}/logs", controllers.SubscribeToContainerLogs, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
+	withStreamingRoute(authRouter, "/ws/deployments/{deployment}/logs", controllers.SubscribeToDeploymentLogs, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
+	withStreamingRoute(authRouter, "/ws/deployments/{deployment}/events", controllers.SubscribeToDeploymentEvents, middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
+	withStreamingRoute(authRouter, "/ws/deployments/{deployment}/containers/{index:[0-9]+}/debug", middlewares.AdminOnly(controllers.DebugDeploymentContainer), middlewares.ValidateSessionMiddleware).Methods(http.MethodGet)
 }
 
 type routeHandler func(http.ResponseWriter, *http.Request)
 
-func withRoute(r *mux.Router, path string, handler routeHandler, middlewares ...mux.MiddlewareFunc) *mux.Route {
-	for _, mw := range middlewares {
+// withRoute registers a handler timed out after API_REQUEST_TIMEOUT_MS
+func withRoute(r *mux.Router, path string, handler routeHandler, mws ...mux.MiddlewareFunc) *mux.Route {
+	return withStreamingRoute(r, path, middlewares.Timeout(handler, middlewares.RequestTimeout()), mws...)
+}
+
+// withStreamingRoute registers a handler without a timeout, for websockets and file transfers lasting as long as the client needs
+// and container restarts waiting for the stop grace period
+func withStreamingRoute(r *mux.Router, path string, handler routeHandler, mws ...mux.MiddlewareFunc) *mux.Route {
+	for _, mw := range mws {
 		r.Use(mw)
 	}
 	return r.HandleFunc(path, handler)
@@ -102,7 +102,8 @@ func AuthenticateClientJWT(w http.ResponseWriter, r *http.Request) {
 		ID:        sessionTkn.SessionID,
 		Token:     signedTkn,
 		ExpiresAt: utils.UnixToDate(utils.OneYear),
-		User:      "root", // TODO: handle unique users
+		User:      session.AdminUser, // TODO: handle unique users
+		Admin:     true,
 	}
 
 	if err := session.Save(newSession); err != nil {
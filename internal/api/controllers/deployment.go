@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 
@@ -12,7 +14,10 @@ import (
 
 	"github.com/krane/krane/internal/api/response"
 	"github.com/krane/krane/internal/deployment"
+	"github.com/krane/krane/internal/job"
+	"github.com/krane/krane/internal/logger"
 	"github.com/krane/krane/internal/session"
+	"github.com/krane/krane/internal/utils"
 )
 
 // WSUpgrader upgrades HTTP connections to WebSocket connections
@@ -47,41 +52,200 @@ func GetDeployment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response.HTTPOk(w, d)
+	response.HTTPOk(w, d.Redacted())
 	return
 }
 
-// GetAllDeployments returns a list of deployments with their configurations, containers and recent activity
-func GetAllDeployments(w http.ResponseWriter, _ *http.Request) {
-	deployments, err := deployment.GetAllDeployments()
+// TotalCountHeader is the header holding the number of deployments matching the filters across every page
+const TotalCountHeader = "X-Total-Count"
+
+// GetAllDeployments returns a page of deployments sorted by name with their configurations, containers and recent
+// activity. The page is set with the limit and offset query params and filtered with the tag query param.
+func GetAllDeployments(w http.ResponseWriter, r *http.Request) {
+	limit, err := strconv.Atoi(utils.QueryParamOrDefault(r, "limit", strconv.Itoa(deployment.DefaultListLimit)))
+	if err != nil || limit < 1 || limit > deployment.MaxListLimit {
+		response.HTTPBad(w, fmt.Errorf("invalid limit, expected a number between 1 and %d", deployment.MaxListLimit))
+		return
+	}
+
+	offset, err := strconv.Atoi(utils.QueryParamOrDefault(r, "offset", "0"))
+	if err != nil || offset < 0 {
+		response.HTTPBad(w, errors.New("invalid offset, expected a number of 0 or more"))
+		return
+	}
+
+	page, err := deployment.ListDeployments(deployment.ListOptions{
+		Tag:    utils.QueryParamOrDefault(r, "tag", ""),
+		Limit:  limit,
+		Offset: offset,
+	})
 	if err != nil {
 		response.HTTPBad(w, err)
 		return
 	}
 
+	deployments := make([]deployment.Deployment, 0, len(page.Deployments))
+	for _, d := range page.Deployments {
+		deployments = append(deployments, d.Redacted())
+	}
+
+	w.Header().Set(TotalCountHeader, strconv.Itoa(page.Total))
 	response.HTTPOk(w, deployments)
 	return
 }
 
-// CreateOrUpdateDeployment saves a deployment configuration
+// ChangeNoteHeader is the header used to attach a note to a deployment configuration change
+const ChangeNoteHeader = "X-Krane-Change-Note"
+
+// CreateOrUpdateDeployment saves a deployment configuration. A note describing the change
+// can be provided with the X-Krane-Change-Note header or the change_note body field.
 func CreateOrUpdateDeployment(w http.ResponseWriter, r *http.Request) {
+	// the config is decoded on its own, it records the fields set in the body for the global defaults
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
 	var config deployment.Config
+	if err := json.Unmarshal(raw, &config); err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
 
-	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+	var body struct {
+		ChangeNote string `json:"change_note"`
+	}
+	_ = json.Unmarshal(raw, &body)
+
+	if err := deployment.SaveConfigWithNote(config, changeNote(r, body.ChangeNote)); err != nil {
 		response.HTTPBad(w, err)
 		return
 	}
 
-	if err := deployment.SaveConfig(config); err != nil {
+	// ?lint=true responds with the lint warnings for the saved config
+	if r.URL.Query().Get("lint") == "true" {
+		response.HTTPOk(w, struct {
+			Config deployment.Config       `json:"config"`
+			Lint   []deployment.LintResult `json:"lint"`
+		}{config.Redacted(), deployment.Lint(config)})
+		return
+	}
+
+	response.HTTPOk(w, config.Redacted())
+	return
+}
+
+// changeNote returns the change note for a request, the header takes precedence over the body field
+func changeNote(r *http.Request, bodyNote string) string {
+	if note := r.Header.Get(ChangeNoteHeader); note != "" {
+		return note
+	}
+	return bodyNote
+}
+
+// MergeDeploymentOverlay responds with an overlay merged into a base deployment configuration. The merged
+// configuration is not saved, it is saved like any other configuration with POST /deployments.
+func MergeDeploymentOverlay(w http.ResponseWriter, r *http.Request) {
+	var overlay deployment.Overlay
+
+	if err := json.NewDecoder(r.Body).Decode(&overlay); err != nil {
 		response.HTTPBad(w, err)
 		return
 	}
 
+	config, err := overlay.Merge()
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	// the merged configuration is returned as is, the base and overlay (including credentials) come from the request
 	response.HTTPOk(w, config)
 	return
 }
 
-// DeleteDeployment deletes a deployments container resources and configuration
+// ImportDeploymentFromContainer responds with a deployment configuration generated from a container not managed by Krane.
+// When recreate is set the configuration is saved and deployed, replacing the container once the deployment succeeds.
+func ImportDeploymentFromContainer(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Container string `json:"container"`
+		Name      string `json:"name"`
+		Recreate  bool   `json:"recreate"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	if body.Container == "" {
+		response.HTTPBad(w, errors.New("container is required"))
+		return
+	}
+
+	imported, err := deployment.ImportFromContainer(r.Context(), body.Container, body.Name)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	if !body.Recreate {
+		response.HTTPOk(w, imported)
+		return
+	}
+
+	if err := deployment.RecreateImportedContainer(r.Context(), imported); err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPAcceptedWithBody(w, imported)
+	return
+}
+
+// ApplyDeploymentsAction applies an action (start, stop, restart) to every deployment matching a selector
+// and responds with the id of the job queued, or the error, for each deployment
+func ApplyDeploymentsAction(w http.ResponseWriter, r *http.Request) {
+	var action deployment.BulkAction
+
+	if err := json.NewDecoder(r.Body).Decode(&action); err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	results, err := action.Apply()
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPAcceptedWithBody(w, results)
+	return
+}
+
+// ValidateDeployment validates a deployment configuration without saving or running it
+func ValidateDeployment(w http.ResponseWriter, r *http.Request) {
+	var config deployment.Config
+
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	report := deployment.Validate(config)
+	if !report.Valid {
+		response.HTTPUnprocessableEntity(w, report)
+		return
+	}
+
+	response.HTTPOk(w, report)
+	return
+}
+
+// DeleteDeployment deletes a deployments container resources and configuration.
+// Containers are stopped gracefully before removal, ?force=true removes them immediately.
+// Named volumes created for the deployment are kept unless ?volumes=true.
 func DeleteDeployment(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	deploymentName := params["deployment"]
@@ -96,7 +260,11 @@ func DeleteDeployment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := deployment.Delete(deploymentName); err != nil {
+	opts := deployment.DeleteOptions{
+		Force:   r.URL.Query().Get("force") == "true",
+		Volumes: r.URL.Query().Get("volumes") == "true",
+	}
+	if err := deployment.DeleteWithOptions(deploymentName, opts); err != nil {
 		response.HTTPBad(w, err)
 		return
 	}
@@ -105,7 +273,10 @@ func DeleteDeployment(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
-// RunDeployment triggers a deployment run creating container resources
+// RunDeployment triggers a deployment run creating container resources.
+// With ?start=false the containers are created but not started, the current containers keep running
+// until the next run replaces them. Runs outside the deploy window of the deployment are rejected,
+// admin sessions can run it anyway with ?override_window=true.
 func RunDeployment(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	deploymentName := params["deployment"]
@@ -120,7 +291,17 @@ func RunDeployment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := deployment.Run(deploymentName); err != nil {
+	opts := deployment.RunOptions{
+		Start:          r.URL.Query().Get("start") != "false",
+		OverrideWindow: r.URL.Query().Get("override_window") == "true",
+	}
+
+	if s, ok := r.Context().Value("session").(session.Session); opts.OverrideWindow && (!ok || !s.IsAdmin()) {
+		response.HTTPForbidden(w, errors.New("admin session required to override the deploy window"))
+		return
+	}
+
+	if err := deployment.RunWithOptions(deploymentName, opts); err != nil {
 		response.HTTPBad(w, err)
 		return
 	}
@@ -129,6 +310,255 @@ func RunDeployment(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+// ExportDeployment returns the docker run command (?format=run, default) or docker compose file (?format=compose)
+// reproducing the containers of a deployment, secrets are masked
+func ExportDeployment(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	if !deployment.Exist(deploymentName) {
+		response.HTTPBad(w, fmt.Errorf("deployment %s does not exist", deploymentName))
+		return
+	}
+
+	format := deployment.ExportFormat(utils.QueryParamOrDefault(r, "format", string(deployment.ExportRun)))
+	export, err := deployment.Export(r.Context(), deploymentName, format)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	contentType := "text/x-shellscript"
+	if format == deployment.ExportCompose {
+		contentType = "application/x-yaml"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(export))
+	return
+}
+
+// GetDeploymentHistory returns the configuration revisions of a deployment and their change notes
+func GetDeploymentHistory(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	if !deployment.Exist(deploymentName) {
+		response.HTTPBad(w, fmt.Errorf("deployment %s does not exist", deploymentName))
+		return
+	}
+
+	history, err := deployment.GetHistory(deploymentName)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	redacted := make([]deployment.HistoryEntry, 0, len(history))
+	for _, entry := range history {
+		redacted = append(redacted, entry.Redacted())
+	}
+
+	response.HTTPOk(w, redacted)
+	return
+}
+
+// RollbackDeployment runs a deployment with the configuration of a previous successfully deployed revision,
+// ?n= selects how many deploys to go back (default 1), and responds with the revision rolled back to. Rollbacks outside
+// the deploy window of the deployment are rejected, admin sessions can roll back anyway with ?override_window=true.
+func RollbackDeployment(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	n, err := strconv.Atoi(utils.QueryParamOrDefault(r, "n", "1"))
+	if err != nil {
+		response.HTTPBad(w, fmt.Errorf("invalid number of revisions to rollback, %v", err))
+		return
+	}
+
+	overrideWindow := r.URL.Query().Get("override_window") == "true"
+	if s, ok := r.Context().Value("session").(session.Session); overrideWindow && (!ok || !s.IsAdmin()) {
+		response.HTTPForbidden(w, errors.New("admin session required to override the deploy window"))
+		return
+	}
+
+	target, err := deployment.Rollback(deploymentName, n, overrideWindow)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPAcceptedWithBody(w, target.Redacted())
+	return
+}
+
+// ScheduleDeployment registers a cron schedule running or restarting a deployment and responds with the schedule
+func ScheduleDeployment(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	var body struct {
+		Cron     string `json:"cron"`
+		Action   string `json:"action"`
+		Timezone string `json:"timezone"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	if !deployment.Exist(deploymentName) {
+		response.HTTPNotFound(w, fmt.Errorf("deployment %s does not exist", deploymentName))
+		return
+	}
+
+	schedule, err := deployment.Schedule(deploymentName, body.Cron, body.Action, body.Timezone)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPOk(w, schedule)
+	return
+}
+
+// UnscheduleDeployment removes the cron schedule of a deployment
+func UnscheduleDeployment(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	schedule, err := deployment.GetSchedule(deploymentName)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	if schedule == nil {
+		response.HTTPNotFound(w, fmt.Errorf("deployment %s is not scheduled", deploymentName))
+		return
+	}
+
+	if err := deployment.Unschedule(deploymentName); err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPNoContent(w)
+	return
+}
+
+// RenameDeployment renames a deployment keeping its secrets, jobs and history, its containers are
+// recreated under the new name and responds with the renamed deployment configuration once they are running.
+// The route is not timed out, it waits for the run under the new name.
+func RenameDeployment(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	var body struct {
+		NewName string `json:"new_name"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	if body.NewName == "" {
+		response.HTTPBad(w, errors.New("new deployment name not provided"))
+		return
+	}
+
+	config, err := deployment.Rename(deploymentName, body.NewName)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPOk(w, config.Redacted())
+	return
+}
+
+// PinDeployment pins a deployment to the image digest of its running containers
+func PinDeployment(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	if !deployment.Exist(deploymentName) {
+		response.HTTPBad(w, fmt.Errorf("deployment %s does not exist", deploymentName))
+		return
+	}
+
+	config, err := deployment.Pin(deploymentName)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPOk(w, config.Redacted())
+	return
+}
+
+// UnpinDeployment removes the pinned image digest of a deployment so it tracks its image tag again
+func UnpinDeployment(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	if !deployment.Exist(deploymentName) {
+		response.HTTPBad(w, fmt.Errorf("deployment %s does not exist", deploymentName))
+		return
+	}
+
+	config, err := deployment.Unpin(deploymentName)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPOk(w, config.Redacted())
+	return
+}
+
 // GetDeploymentContainers returns all containers for a deployment
 func GetDeploymentContainers(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
@@ -154,9 +584,8 @@ func GetDeploymentContainers(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
-// StartDeploymentContainers starts all containers (if any) for a deployment
-// Note: this does not create any containers, only start already existing ones
-func StartDeploymentContainers(w http.ResponseWriter, r *http.Request) {
+// GetDeploymentNetworks returns the networks a deployment's containers are attached to and their members
+func GetDeploymentNetworks(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	deploymentName := params["deployment"]
 
@@ -170,18 +599,97 @@ func StartDeploymentContainers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := deployment.StartContainers(deploymentName); err != nil {
+	networks, err := deployment.GetNetworks(deploymentName)
+	if err != nil {
 		response.HTTPBad(w, err)
 		return
 	}
 
-	response.HTTPAccepted(w)
+	response.HTTPOk(w, networks)
 	return
 }
 
-// StopDeploymentContainers stops all containers (if any) for a deployment
-// Note: this does not create any containers, only stops already existing ones
-func StopDeploymentContainers(w http.ResponseWriter, r *http.Request) {
+// GetDeploymentStatus returns the runtime status of a deployment's containers, containers
+// of a deleted deployment are reported as orphaned
+func GetDeploymentStatus(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	status, err := deployment.GetStatus(deploymentName)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPOk(w, status)
+	return
+}
+
+// GetDeploymentResourceUsage returns the cpu, memory and network usage of a deployment's running containers. With
+// the stream query param set to true, the usage is pushed as server-sent events until the client disconnects.
+func GetDeploymentResourceUsage(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	if !deployment.Exist(deploymentName) {
+		response.HTTPBad(w, fmt.Errorf("deployment %s does not exist", deploymentName))
+		return
+	}
+
+	if utils.QueryParamOrDefault(r, "stream", "false") != "true" {
+		usage, err := deployment.GetResourceUsage(r.Context(), deploymentName)
+		if err != nil {
+			response.HTTPBad(w, err)
+			return
+		}
+
+		response.HTTPOk(w, usage)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		response.HTTPBad(w, errors.New("streaming is not supported"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// the request context is cancelled when the client disconnects, stopping the stream
+	err := deployment.StreamResourceUsage(r.Context(), deploymentName, deployment.UsageStreamInterval, func(usage deployment.ResourceUsage) error {
+		bytes, err := json.Marshal(usage)
+		if err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(w, "event: stats\ndata: %s\n\n", bytes); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	})
+	if err != nil {
+		logger.Warnf("stats stream of deployment %s closed, %v", deploymentName, err)
+	}
+	return
+}
+
+// GetDeploymentDiskUsage returns the disk space used by a deployment's container logs, writable layers and volumes
+func GetDeploymentDiskUsage(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	deploymentName := params["deployment"]
 
@@ -195,18 +703,151 @@ func StopDeploymentContainers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := deployment.StopContainers(deploymentName); err != nil {
+	usage, err := deployment.GetDiskUsage(deploymentName)
+	if err != nil {
 		response.HTTPBad(w, err)
 		return
 	}
 
-	response.HTTPAccepted(w)
+	response.HTTPOk(w, usage)
 	return
 }
 
-// RestartDeploymentContainers re-creates all containers for a deployment
+// GetDeploymentContainerDiff returns the filesystem changes of a deployment container relative to its image, admin sessions only
+func GetDeploymentContainerDiff(w http.ResponseWriter, r *http.Request) {
+	container, ok := deploymentContainerFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	changes, err := container.Changes()
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPOk(w, changes)
+	return
+}
+
+// CopyFileFromDeploymentContainer returns a tar archive of a path inside a deployment container, admin sessions only
+func CopyFileFromDeploymentContainer(w http.ResponseWriter, r *http.Request) {
+	container, ok := deploymentContainerFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	reader, err := container.CopyFrom(r.URL.Query().Get("path"))
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+	defer reader.Close()
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, reader); err != nil {
+		// the status is already sent, abort the response so the client does not keep a truncated archive
+		logger.Warnf("unable to copy %s from container %s, %v", r.URL.Query().Get("path"), container.ID, err)
+		panic(http.ErrAbortHandler)
+	}
+	return
+}
+
+// CopyFileToDeploymentContainer extracts a tar archive from the request body into a path inside a deployment container, admin sessions only
+func CopyFileToDeploymentContainer(w http.ResponseWriter, r *http.Request) {
+	container, ok := deploymentContainerFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	archive := http.MaxBytesReader(w, r.Body, deployment.MaxContainerFileSize)
+	if err := container.CopyTo(r.URL.Query().Get("path"), archive); err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPNoContent(w)
+	return
+}
+
+// RestartDeploymentContainer restarts a single container of a deployment without affecting other containers.
+// The route is not timed out, the restart waits for the container to exit within the deployment stop_timeout.
+func RestartDeploymentContainer(w http.ResponseWriter, r *http.Request) {
+	container, ok := deploymentContainerFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	config, err := deployment.GetDeploymentConfig(mux.Vars(r)["deployment"])
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	if err := container.Restart(r.Context(), config.ContainerStopTimeout()); err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPNoContent(w)
+	return
+}
+
+// deploymentContainerFromRequest returns the deployment container referenced by the {deployment} and {index}
+// route params. If the container cannot be found an error response is written and false is returned.
+func deploymentContainerFromRequest(w http.ResponseWriter, r *http.Request) (deployment.KraneContainer, bool) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return deployment.KraneContainer{}, false
+	}
+
+	index, err := strconv.Atoi(params["index"])
+	if err != nil {
+		response.HTTPBad(w, errors.New("container index must be a number"))
+		return deployment.KraneContainer{}, false
+	}
+
+	if !deployment.Exist(deploymentName) {
+		response.HTTPBad(w, fmt.Errorf("deployment %s does not exist", deploymentName))
+		return deployment.KraneContainer{}, false
+	}
+
+	container, err := deployment.GetContainerByIndex(deploymentName, index)
+	if err != nil {
+		response.HTTPNotFound(w, err)
+		return deployment.KraneContainer{}, false
+	}
+
+	return container, true
+}
+
+// StartDeploymentContainers starts all containers (if any) for a deployment and responds with the id of the job.
+// The containers of a staged run (?start=false) are started instead and replace the previous containers.
+// Note: this does not create any containers, only starts already existing ones
+func StartDeploymentContainers(w http.ResponseWriter, r *http.Request) {
+	enqueueContainersJob(w, r, deployment.StartContainers)
+}
+
+// StopDeploymentContainers stops all containers (if any) for a deployment and responds with the id of the job
+// Note: this does not create any containers, only stops already existing ones
+func StopDeploymentContainers(w http.ResponseWriter, r *http.Request) {
+	enqueueContainersJob(w, r, deployment.StopContainers)
+}
+
+// RestartDeploymentContainers re-creates all containers for a deployment and responds with the id of the job
 // Note: this is the same as calling /deployments/{deployment} since both re-create container resources
 func RestartDeploymentContainers(w http.ResponseWriter, r *http.Request) {
+	enqueueContainersJob(w, r, deployment.RestartContainers)
+}
+
+// enqueueContainersJob enqueues a job for the containers of the deployment referenced by the {deployment} route
+// param and responds with the id of the job. Responds 404 if the deployment does not exist and 409 if the
+// deployment already has a job queued or in progress.
+func enqueueContainersJob(w http.ResponseWriter, r *http.Request, enqueue func(deployment string) (string, error)) {
 	params := mux.Vars(r)
 	deploymentName := params["deployment"]
 
@@ -216,16 +857,42 @@ func RestartDeploymentContainers(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if !deployment.Exist(deploymentName) {
-		response.HTTPBad(w, fmt.Errorf("deployment %s does not exist", deploymentName))
+		response.HTTPNotFound(w, fmt.Errorf("deployment %s does not exist", deploymentName))
+		return
+	}
+
+	if job.IsBusy(deploymentName) {
+		response.HTTPConflict(w, fmt.Errorf("deployment %s has jobs in progress, retry once they complete", deploymentName))
 		return
 	}
 
-	if err := deployment.RestartContainers(deploymentName); err != nil {
+	jobID, err := enqueue(deploymentName)
+	if err != nil {
 		response.HTTPBad(w, err)
 		return
 	}
 
-	response.HTTPAccepted(w)
+	response.HTTPAcceptedWithBody(w, map[string]string{"job_id": jobID})
+	return
+}
+
+// DebugDeploymentContainer opens a websocket connection streaming the shell of a debug container launched in the
+// network and pid namespaces of a deployment container (optionally with the provided image), until the client disconnects
+func DebugDeploymentContainer(w http.ResponseWriter, r *http.Request) {
+	container, ok := deploymentContainerFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	image := utils.QueryParamOrDefault(r, "image", deployment.DefaultDebugImage)
+
+	connection, err := WSUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	deployment.DebugContainer(connection, container, image)
 	return
 }
 
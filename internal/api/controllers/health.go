@@ -6,6 +6,7 @@ import (
 
 	"github.com/krane/krane/internal/api/response"
 	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/store"
 	"github.com/krane/krane/internal/utils"
 )
 
@@ -14,10 +15,12 @@ func HealthCheck(w http.ResponseWriter, r *http.Request) {
 	host, _ := os.Hostname()
 	response.HTTPOk(w, struct {
 		Docker    bool   `json:"docker"`
+		Store     bool   `json:"store"`
 		Host      string `json:"host"`
 		Timestamp string `json:"timestamp"`
 	}{
 		Docker:    docker.Ping(),
+		Store:     store.Client().Ping() == nil,
 		Host:      host,
 		Timestamp: utils.UTCDateString(),
 	})
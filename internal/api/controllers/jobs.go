@@ -10,6 +10,7 @@ import (
 
 	"github.com/krane/krane/internal/api/response"
 	"github.com/krane/krane/internal/deployment"
+	"github.com/krane/krane/internal/job"
 	"github.com/krane/krane/internal/utils"
 )
 
@@ -89,3 +90,109 @@ func GetJobByID(w http.ResponseWriter, r *http.Request) {
 	response.HTTPOk(w, j)
 	return
 }
+
+// GetJobAttempts returns the steps, durations, logs and error of every attempt of a job, a job is attempted once per retry
+func GetJobAttempts(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+	jobID := params["id"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	if jobID == "" {
+		response.HTTPBad(w, errors.New("job id not provided"))
+		return
+	}
+
+	if !deployment.Exist(deploymentName) {
+		response.HTTPBad(w, fmt.Errorf("deployment %s does not exist", deploymentName))
+		return
+	}
+
+	daysAgo := utils.QueryParamOrDefault(r, "days_ago", "365")
+	daysAgoNum, _ := strconv.Atoi(daysAgo)
+
+	j, err := deployment.GetJobByID(deploymentName, jobID, uint(daysAgoNum))
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	attempts := j.Status.Attempts
+	if attempts == nil {
+		attempts = make([]job.Attempt, 0)
+	}
+
+	response.HTTPOk(w, attempts)
+	return
+}
+
+// GetDeploymentStats returns deploy success and duration statistics for a deployment within a date range (default is 30d ago)
+func GetDeploymentStats(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	daysAgo := utils.QueryParamOrDefault(r, "days_ago", "30")
+	daysAgoNum, _ := strconv.Atoi(daysAgo)
+
+	if !deployment.Exist(deploymentName) {
+		response.HTTPBad(w, fmt.Errorf("deployment %s does not exist", deploymentName))
+		return
+	}
+
+	stats, err := deployment.GetDeployStats(deploymentName, uint(daysAgoNum))
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPOk(w, stats)
+	return
+}
+
+// CancelJob cancels a queued or running job of a deployment. A queued job is removed from the queue, a running job is
+// aborted at its current step and its previous containers are kept.
+func CancelJob(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+	jobID := params["id"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	if jobID == "" {
+		response.HTTPBad(w, errors.New("job id not provided"))
+		return
+	}
+
+	if !deployment.Exist(deploymentName) {
+		response.HTTPNotFound(w, fmt.Errorf("deployment %s does not exist", deploymentName))
+		return
+	}
+
+	if err := job.Cancel(deploymentName, jobID); err != nil {
+		if errors.Is(err, job.ErrJobNotFound) {
+			response.HTTPNotFound(w, fmt.Errorf("job %s %v", jobID, err))
+			return
+		}
+		if errors.Is(err, job.ErrJobCompleting) {
+			response.HTTPConflict(w, fmt.Errorf("job %s %v", jobID, err))
+			return
+		}
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPAccepted(w)
+	return
+}
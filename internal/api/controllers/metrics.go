@@ -0,0 +1,18 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/metrics"
+)
+
+// GetMetrics returns the Krane metrics in the prometheus text format
+func GetMetrics(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", metrics.PrometheusContentType)
+	w.WriteHeader(http.StatusOK)
+	if err := metrics.WritePrometheus(w, metrics.Default().Snapshot()); err != nil {
+		logger.Warnf("unable to write metrics %v", err)
+	}
+	return
+}
@@ -0,0 +1,15 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/krane/krane/internal/api/response"
+	"github.com/krane/krane/internal/deployment"
+	"github.com/krane/krane/internal/schema"
+)
+
+// GetConfigSchema returns the JSON Schema for deployment configurations
+func GetConfigSchema(w http.ResponseWriter, _ *http.Request) {
+	response.HTTPOk(w, schema.FromValue(deployment.Config{}))
+	return
+}
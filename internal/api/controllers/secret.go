@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
 
@@ -65,7 +67,60 @@ func CreateOrUpdateSecret(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
-// DeleteSecret removes a deployment secret
+// maxSecretImportSize is the max size in bytes of a secrets import payload
+const maxSecretImportSize = 1 << 20
+
+// ImportSecrets adds or updates deployment secrets in bulk from a dotenv payload, or a json object
+// of keys to values when sent as json. Malformed lines and invalid keys are reported in the summary
+// without aborting the import of the other secrets.
+func ImportSecrets(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name required"))
+		return
+	}
+
+	if !deployment.Exist(deploymentName) {
+		response.HTTPBad(w, fmt.Errorf("unable to find deployment %s", deploymentName))
+		return
+	}
+
+	payload, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxSecretImportSize))
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	var entries []deployment.SecretEntry
+	var malformed []deployment.SecretImportError
+	if strings.Contains(r.Header.Get("Content-Type"), "json") || strings.HasPrefix(strings.TrimSpace(string(payload)), "{") {
+		var secrets map[string]string
+		if err := json.Unmarshal(payload, &secrets); err != nil {
+			response.HTTPBad(w, fmt.Errorf("invalid json secrets, expected an object of keys to values, %v", err))
+			return
+		}
+		for key, value := range secrets {
+			entries = append(entries, deployment.SecretEntry{Key: key, Value: value})
+		}
+	} else {
+		entries, malformed = deployment.ParseDotenv(string(payload))
+	}
+
+	summary, err := deployment.ImportSecrets(deploymentName, entries)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+	summary.Skipped = append(malformed, summary.Skipped...)
+
+	response.HTTPOk(w, summary)
+	return
+}
+
+// DeleteSecret removes a deployment secret and returns the keys of the remaining secrets.
+// Deleting a secret that does not exist succeeds so retried deletes are safe.
 func DeleteSecret(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	deploymentName := params["deployment"]
@@ -81,11 +136,22 @@ func DeleteSecret(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !deployment.Exist(deploymentName) {
+		response.HTTPBad(w, fmt.Errorf("unable to find deployment %s", deploymentName))
+		return
+	}
+
 	if err := deployment.DeleteSecret(deploymentName, key); err != nil {
 		response.HTTPBad(w, err)
 		return
 	}
 
-	response.HTTPNoContent(w)
+	keys, err := deployment.GetSecretKeys(deploymentName)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPOk(w, keys)
 	return
 }
@@ -42,6 +42,11 @@ func CreateSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if strings.ToLower(user) == session.AdminUser {
+		response.HTTPBad(w, fmt.Errorf("user %s is reserved for sessions authenticated with the server key pair", session.AdminUser))
+		return
+	}
+
 	token := session.Token{SessionID: uuid.Generate().String()}
 	signedTkn, err := session.CreateSessionJWTToken(auth.GetServerPrivateKey(), token)
 	if err != nil {
@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/krane/krane/internal/api/response"
+	"github.com/krane/krane/internal/deployment"
+	"github.com/krane/krane/internal/job"
+)
+
+// GetSystemContainers returns every container managed by Krane grouped by deployment
+func GetSystemContainers(w http.ResponseWriter, _ *http.Request) {
+	containers, err := deployment.GetContainersGroupedByDeployment()
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPOk(w, containers)
+	return
+}
+
+// GetSystemDefaults returns the default configuration merged under every deployment configuration
+func GetSystemDefaults(w http.ResponseWriter, _ *http.Request) {
+	defaults, err := deployment.GetDefaults()
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPOk(w, defaults.Redacted())
+	return
+}
+
+// UpdateSystemDefaults replaces the default configuration merged under every deployment configuration
+func UpdateSystemDefaults(w http.ResponseWriter, r *http.Request) {
+	var defaults deployment.Config
+	if err := json.NewDecoder(r.Body).Decode(&defaults); err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	if err := deployment.SaveDefaults(defaults); err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPOk(w, defaults.Redacted())
+	return
+}
+
+// GetSystemQueue returns the jobs waiting for a worker and how many queued jobs were coalesced into newer jobs
+func GetSystemQueue(w http.ResponseWriter, _ *http.Request) {
+	response.HTTPOk(w, job.GetQueueStatus())
+	return
+}
+
+// GetSystemPorts returns the host ports bound by Krane managed containers and flags conflicting bindings
+func GetSystemPorts(w http.ResponseWriter, _ *http.Request) {
+	ports, err := deployment.GetHostPortBindings()
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPOk(w, ports)
+	return
+}
+
+// GetSystemProxy returns the status of the network proxy
+func GetSystemProxy(w http.ResponseWriter, _ *http.Request) {
+	status, err := deployment.GetProxyStatus()
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	if status.Config != nil {
+		redacted := status.Config.Redacted()
+		status.Config = &redacted
+	}
+
+	response.HTTPOk(w, status)
+	return
+}
+
+// InstallSystemProxy deploys a Traefik network proxy managed by Krane
+func InstallSystemProxy(w http.ResponseWriter, r *http.Request) {
+	var opts deployment.ProxyInstallOptions
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+			response.HTTPBad(w, err)
+			return
+		}
+	}
+
+	config, err := deployment.InstallProxy(opts)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPAcceptedWithBody(w, config.Redacted())
+	return
+}
+
+// GetSystemRegistries returns the registry hosts with saved credentials, passwords are never returned
+func GetSystemRegistries(w http.ResponseWriter, _ *http.Request) {
+	registries, err := deployment.GetAllRegistryCredentials()
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPOk(w, registries)
+	return
+}
+
+// SaveSystemRegistry saves the credentials used by deployments pulling images from a registry host
+func SaveSystemRegistry(w http.ResponseWriter, r *http.Request) {
+	host := mux.Vars(r)["host"]
+	if host == "" {
+		response.HTTPBad(w, errors.New("registry host not provided"))
+		return
+	}
+
+	var creds deployment.RegistryCredentials
+	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+	creds.Host = host
+
+	if err := deployment.SaveRegistryCredentials(creds); err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	saved, err := deployment.GetRegistryCredentials(host)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+	saved.Password = ""
+	saved.Token = ""
+
+	response.HTTPOk(w, saved)
+	return
+}
+
+// DeleteSystemRegistry removes the credentials saved for a registry host
+func DeleteSystemRegistry(w http.ResponseWriter, r *http.Request) {
+	host := mux.Vars(r)["host"]
+	if host == "" {
+		response.HTTPBad(w, errors.New("registry host not provided"))
+		return
+	}
+
+	if err := deployment.DeleteRegistryCredentials(host); err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPNoContent(w)
+	return
+}
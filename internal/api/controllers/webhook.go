@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/krane/krane/internal/api/response"
+	"github.com/krane/krane/internal/deployment"
+)
+
+// maxWebhookPayloadSize is the max size of a webhook call payload
+const maxWebhookPayloadSize = 64 << 10
+
+// GenerateDeploymentWebhookToken generates the token authenticating the webhook of a deployment, replacing its previous token
+func GenerateDeploymentWebhookToken(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	token, err := deployment.GenerateWebhookToken(deploymentName)
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPOk(w, map[string]string{"token": token})
+	return
+}
+
+// RevokeDeploymentWebhookToken revokes the webhook token of a deployment
+func RevokeDeploymentWebhookToken(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	if err := deployment.RevokeWebhookToken(deploymentName); err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	response.HTTPNoContent(w)
+	return
+}
+
+// TriggerDeploymentWebhook runs a deployment when its webhook is called with the deployment webhook token as a
+// bearer token. The optional json payload overrides the image tag or digest for this run only.
+func TriggerDeploymentWebhook(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deploymentName := params["deployment"]
+
+	if deploymentName == "" {
+		response.HTTPBad(w, errors.New("deployment name not provided"))
+		return
+	}
+
+	payload, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookPayloadSize))
+	if err != nil {
+		response.HTTPBad(w, err)
+		return
+	}
+
+	var trigger deployment.WebhookTrigger
+	if len(strings.TrimSpace(string(payload))) > 0 {
+		if err := json.Unmarshal(payload, &trigger); err != nil {
+			response.HTTPBad(w, fmt.Errorf("invalid webhook payload, %v", err))
+			return
+		}
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	err = deployment.TriggerWebhook(deploymentName, token, trigger, webhookCaller(r))
+
+	var unauthorized deployment.WebhookUnauthorizedError
+	var rateLimited deployment.WebhookRateLimitedError
+	switch {
+	case errors.As(err, &unauthorized):
+		response.HTTPUnauthorized(w, err)
+	case errors.As(err, &rateLimited):
+		response.HTTPTooManyRequests(w, err, int(math.Ceil(rateLimited.RetryAfter.Seconds())))
+	case err != nil:
+		response.HTTPBad(w, err)
+	default:
+		response.HTTPAccepted(w)
+	}
+	return
+}
+
+// webhookCaller returns the address and user agent (ie. a CI runner) of a webhook call
+func webhookCaller(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if agent := r.UserAgent(); agent != "" {
+		return fmt.Sprintf("%s [%s]", host, agent)
+	}
+	return host
+}
@@ -0,0 +1,34 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/krane/krane/internal/api/response"
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/logger"
+)
+
+// MetricsTokenMiddleware middleware authenticating metrics scrapers with the METRICS_ENDPOINT_TOKEN bearer
+// token instead of a session, so scrapers don't need to login. Requests are not authenticated when no token is set.
+func MetricsTokenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := os.Getenv(constants.EnvMetricsEndpointToken)
+		if token == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		value := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(value), []byte(token)) != 1 {
+			logger.Info("Invalid metrics token provided")
+			response.HTTPBad(w, errors.New("invalid metrics token"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -59,3 +59,21 @@ func ValidateSessionMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// AdminOnly wraps a handler so only admin sessions can use it, the session must already be validated by
+// ValidateSessionMiddleware. Unlike router middlewares it only guards the wrapped handler.
+func AdminOnly(next func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s, ok := r.Context().Value("session").(session.Session)
+		if !ok {
+			response.HTTPUnauthorized(w, errors.New("admin session required"))
+			return
+		}
+		if !s.IsAdmin() {
+			logger.Infof("Session is not allowed to use admin endpoint %s", r.URL.Path)
+			response.HTTPForbidden(w, errors.New("admin session required"))
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,22 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/krane/krane/internal/api/response"
+	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/store"
+)
+
+// StoreAvailableMiddleware middleware responding with 503 Service Unavailable when the state store cannot be accessed
+func StoreAvailableMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := store.Client().Ping(); err != nil {
+			logger.Warnf("rejecting request to %s, %v", r.URL.Path, err)
+			response.HTTPServiceUnavailable(w, store.ErrStoreUnavailable)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/krane/krane/internal/constants"
+)
+
+// DefaultRequestTimeout is the time a request has to be served when API_REQUEST_TIMEOUT_MS is not a valid duration
+const DefaultRequestTimeout = 15 * time.Second
+
+// RequestTimeout returns the time a request has to be served, 0 disables the timeout
+func RequestTimeout() time.Duration {
+	timeout, err := time.ParseDuration(os.Getenv(constants.EnvAPIRequestTimeoutMs) + "ms")
+	if err != nil || timeout < 0 {
+		return DefaultRequestTimeout
+	}
+	return timeout
+}
+
+// Timeout responds with a 503 when a request is not served within the timeout, a timeout of 0 returns the handler as is.
+// The response is buffered until the handler returns and the connection cannot be hijacked, streaming
+// handlers (websockets, file transfers) must not be wrapped.
+func Timeout(handler func(http.ResponseWriter, *http.Request), timeout time.Duration) func(http.ResponseWriter, *http.Request) {
+	if timeout == 0 {
+		return handler
+	}
+	return http.TimeoutHandler(http.HandlerFunc(handler), timeout, "request timed out").ServeHTTP
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/krane/krane/internal/api/response"
+	"github.com/krane/krane/internal/deployment"
+	"github.com/krane/krane/internal/schema"
+)
+
+// requestBodies are the json request bodies for routes keyed by "<METHOD> <path>"
+var requestBodies = map[string]interface{}{
+	"POST /deployments":          deployment.Config{},
+	"POST /deployments/validate": deployment.Config{},
+	"POST /deployments/overlay":  deployment.Overlay{},
+	"POST /deployments/actions":  deployment.BulkAction{},
+}
+
+var pathParamRegex = regexp.MustCompile(`{([^}:]+)(:[^}]+)?}`)
+
+// openAPIHandler returns a handler serving the OpenAPI document for the routes registered on a router.
+// Routes registered under the authenticated route require a session token.
+func openAPIHandler(router *mux.Router, authRoute *mux.Route) routeHandler {
+	return func(w http.ResponseWriter, r *http.Request) {
+		doc, err := openAPI(router, authRoute)
+		if err != nil {
+			response.HTTPBad(w, err)
+			return
+		}
+		response.HTTPOk(w, doc)
+	}
+}
+
+// openAPI generates an OpenAPI document by walking the routes registered on a router
+func openAPI(router *mux.Router, authRoute *mux.Route) (schema.Schema, error) {
+	paths := schema.Schema{}
+
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, ancestors []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil // subrouters and routes matching any method are not documented
+		}
+
+		secured := false
+		for _, a := range ancestors {
+			if a == authRoute {
+				secured = true
+			}
+		}
+
+		path := pathParamRegex.ReplaceAllString(template, "{$1}")
+		item, ok := paths[path].(schema.Schema)
+		if !ok {
+			item = schema.Schema{}
+			paths[path] = item
+		}
+
+		for _, method := range methods {
+			item[strings.ToLower(method)] = openAPIOperation(method, template, secured)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.Schema{
+		"openapi": "3.0.0",
+		"info": schema.Schema{
+			"title":   "Krane",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": schema.Schema{
+			"securitySchemes": schema.Schema{
+				"bearerAuth": schema.Schema{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}, nil
+}
+
+// openAPIOperation returns the OpenAPI operation for a route method
+func openAPIOperation(method string, template string, secured bool) schema.Schema {
+	path := pathParamRegex.ReplaceAllString(template, "{$1}")
+
+	params := make([]schema.Schema, 0)
+	for _, match := range pathParamRegex.FindAllStringSubmatch(template, -1) {
+		param := schema.Schema{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   schema.Schema{"type": "string"},
+		}
+		if match[2] != "" {
+			param["schema"] = schema.Schema{"type": "string", "pattern": fmt.Sprintf("^%s$", strings.TrimPrefix(match[2], ":"))}
+		}
+		params = append(params, param)
+	}
+
+	op := schema.Schema{
+		"operationId": fmt.Sprintf("%s %s", method, path),
+		"parameters":  params,
+		"responses": schema.Schema{
+			"default": schema.Schema{"description": "response"},
+		},
+	}
+
+	if secured {
+		op["security"] = []schema.Schema{{"bearerAuth": []string{}}}
+	}
+
+	if body, ok := requestBodies[fmt.Sprintf("%s %s", method, template)]; ok {
+		bodySchema := schema.FromValue(body)
+		delete(bodySchema, "$schema")
+		op["requestBody"] = schema.Schema{
+			"required": true,
+			"content": schema.Schema{
+				"application/json": schema.Schema{"schema": bodySchema},
+			},
+		}
+	}
+
+	return op
+}
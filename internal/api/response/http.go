@@ -3,6 +3,7 @@ package response
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 )
 
 // HTTPOk writes http response code 200
@@ -58,3 +59,54 @@ func HTTPNotFound(w http.ResponseWriter, err error) {
 	_, _ = w.Write([]byte(err.Error()))
 	return
 }
+
+// HTTPConflict writes http response code 409
+func HTTPConflict(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	_, _ = w.Write([]byte(err.Error()))
+	return
+}
+
+// HTTPUnprocessableEntity writes http response code 422 with a json body
+func HTTPUnprocessableEntity(w http.ResponseWriter, data interface{}) {
+	payload, _ := json.Marshal(data)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	_, _ = w.Write(payload)
+	return
+}
+
+// HTTPServiceUnavailable writes http response code 503
+func HTTPServiceUnavailable(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	_, _ = w.Write([]byte(err.Error()))
+	return
+}
+
+// HTTPUnauthorized writes http response code 401
+func HTTPUnauthorized(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	_, _ = w.Write([]byte(err.Error()))
+	return
+}
+
+// HTTPForbidden writes http response code 403
+func HTTPForbidden(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	_, _ = w.Write([]byte(err.Error()))
+	return
+}
+
+// HTTPTooManyRequests writes http response code 429 with the seconds to wait before retrying in the Retry-After header
+func HTTPTooManyRequests(w http.ResponseWriter, err error, retryAfterSeconds int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds))
+	w.WriteHeader(http.StatusTooManyRequests)
+	_, _ = w.Write([]byte(err.Error()))
+	return
+}
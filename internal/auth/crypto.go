@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/krane/krane/internal/constants"
+)
+
+// Encrypt encrypts data with AES-GCM using a key derived from the server private key. The returned
+// ciphertext is prefixed with the random nonce it was encrypted with.
+func Encrypt(plaintext []byte) ([]byte, error) {
+	gcm, err := serverCipher()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypt decrypts data encrypted with Encrypt
+func Decrypt(ciphertext []byte) ([]byte, error) {
+	gcm, err := serverCipher()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("unable to decrypt, ciphertext too short")
+	}
+
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt, the server private key may have changed")
+	}
+	return plaintext, nil
+}
+
+// serverCipher returns an AES-GCM cipher keyed with the sha256 hash of the server private key
+func serverCipher() (cipher.AEAD, error) {
+	privateKey := GetServerPrivateKey()
+	if privateKey == "" {
+		return nil, fmt.Errorf("%s is required to encrypt data", constants.EnvKranePrivateKey)
+	}
+
+	key := sha256.Sum256([]byte(privateKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
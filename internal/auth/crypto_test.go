@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/constants"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	os.Setenv(constants.EnvKranePrivateKey, "test-private-key")
+	defer os.Unsetenv(constants.EnvKranePrivateKey)
+
+	ciphertext, err := Encrypt([]byte("s3cret"))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(ciphertext), "s3cret")
+
+	plaintext, err := Decrypt(ciphertext)
+	assert.Nil(t, err)
+	assert.Equal(t, "s3cret", string(plaintext))
+
+	os.Setenv(constants.EnvKranePrivateKey, "another-private-key")
+	_, err = Decrypt(ciphertext)
+	assert.Error(t, err)
+}
+
+func TestEncryptRequiresPrivateKey(t *testing.T) {
+	os.Unsetenv(constants.EnvKranePrivateKey)
+	_, err := Encrypt([]byte("s3cret"))
+	assert.Error(t, err)
+}
@@ -6,4 +6,11 @@ const (
 	JobsCollectionName           = "jobs"
 	SessionsCollectionName       = "sessions"
 	SecretsCollectionName        = "secrets"
+	HistoryCollectionName        = "history"
+	SettingsCollectionName       = "settings"
+	RegistriesCollectionName     = "registries"
+	DeferredRunsCollectionName   = "deferred_runs"
+	StagedRunsCollectionName     = "staged_runs"
+	WebhookTokensCollectionName  = "webhook_tokens"
+	RecurringJobsCollectionName  = "recurring_jobs"
 )
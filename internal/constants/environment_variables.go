@@ -1,18 +1,34 @@
 package constants
 
 const (
-	EnvKranePrivateKey       = "KRANE_PRIVATE_KEY"
-	EnvLogLevel              = "LOG_LEVEL"
-	EnvListenAddress         = "LISTEN_ADDRESS"
-	EnvWatchMode             = "WATCH_MODE"
-	EnvDatabasePath          = "DB_PATH"
-	EnvWorkerPoolSize        = "WORKERPOOL_SIZE"
-	EnvJobQueueSize          = "JOB_QUEUE_SIZE"
-	EnvJobMaxRetryPolicy     = "JOB_MAX_RETRY_POLICY"
-	EnvDeploymentRetryPolicy = "DEPLOYMENT_RETRY_POLICY"
-	EnvSchedulerIntervalMs   = "SCHEDULER_INTERVAL_MS"
-	EnvProxyEnabled          = "PROXY_ENABLED"
-	EnvProxyDashboardSecure  = "PROXY_DASHBOARD_SECURE"
-	EnvProxyDashboardAlias   = "PROXY_DASHBOARD_ALIAS"
-	EnvLetsEncryptEmail      = "LETSENCRYPT_EMAIL"
+	EnvKranePrivateKey          = "KRANE_PRIVATE_KEY"
+	EnvLogLevel                 = "LOG_LEVEL"
+	EnvListenAddress            = "LISTEN_ADDRESS"
+	EnvWatchMode                = "WATCH_MODE"
+	EnvDatabasePath             = "DB_PATH"
+	EnvWorkerPoolSize           = "WORKERPOOL_SIZE"
+	EnvJobQueueSize             = "JOB_QUEUE_SIZE"
+	EnvJobMaxRetryPolicy        = "JOB_MAX_RETRY_POLICY"
+	EnvDeploymentRetryPolicy    = "DEPLOYMENT_RETRY_POLICY"
+	EnvSchedulerIntervalMs      = "SCHEDULER_INTERVAL_MS"
+	EnvProxyEnabled             = "PROXY_ENABLED"
+	EnvProxyDashboardSecure     = "PROXY_DASHBOARD_SECURE"
+	EnvProxyDashboardAlias      = "PROXY_DASHBOARD_ALIAS"
+	EnvProxyNetwork             = "PROXY_NETWORK"
+	EnvProxyVersion             = "PROXY_VERSION"
+	EnvLetsEncryptEmail         = "LETSENCRYPT_EMAIL"
+	EnvCertResolver             = "CERT_RESOLVER"
+	EnvDiskUsageThreshold       = "DISK_USAGE_THRESHOLD"
+	EnvDiskFullPrune            = "DISK_FULL_PRUNE"
+	EnvPruneImagesAfterDeploy   = "PRUNE_IMAGES_AFTER_DEPLOY"
+	EnvStreamKeepAliveMs        = "STREAM_KEEPALIVE_MS"
+	EnvResourceOvercommitFactor = "RESOURCE_OVERCOMMIT_FACTOR"
+	EnvMetricsExporters         = "METRICS_EXPORTERS"
+	EnvMetricsPushIntervalMs    = "METRICS_PUSH_INTERVAL_MS"
+	EnvMetricsStatsDAddress     = "METRICS_STATSD_ADDRESS"
+	EnvMetricsOTLPEndpoint      = "METRICS_OTLP_ENDPOINT"
+	EnvMetricsEndpointEnabled   = "METRICS_ENDPOINT_ENABLED"
+	EnvMetricsEndpointToken     = "METRICS_ENDPOINT_TOKEN"
+	EnvAPIRequestTimeoutMs      = "API_REQUEST_TIMEOUT_MS"
+	EnvDeploymentHistoryLimit   = "DEPLOYMENT_HISTORY_LIMIT"
 )
@@ -0,0 +1,102 @@
+package deployment
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Action is an operation applied to many deployments at once
+type Action string
+
+const (
+	StartAction   Action = "start"
+	StopAction    Action = "stop"
+	RestartAction Action = "restart"
+)
+
+// Selector selects deployments by name, labels or tags. A deployment is selected when its
+// name is listed or when it has every label and every tag of the selector.
+type Selector struct {
+	Names  []string          `json:"names"`
+	Labels map[string]string `json:"labels"`
+	Tags   []string          `json:"tags"`
+}
+
+// BulkAction is an action applied to every deployment matching a selector
+type BulkAction struct {
+	Selector Selector `json:"selector" binding:"required"`
+	Action   Action   `json:"action" binding:"required" enum:"start,stop,restart"`
+}
+
+// BulkActionResult is the outcome of an action for a single deployment
+type BulkActionResult struct {
+	JobID string `json:"job_id,omitempty"` // id of the job queued for the deployment
+	Error string `json:"error,omitempty"`  // why the action could not be queued for the deployment
+}
+
+// Matches returns true if a deployment config is selected
+func (s Selector) Matches(config Config) bool {
+	for _, name := range s.Names {
+		if name == config.Name {
+			return true
+		}
+	}
+
+	if len(s.Labels) == 0 && len(s.Tags) == 0 {
+		return false
+	}
+
+	for k, v := range s.Labels {
+		if config.Labels[k] != v {
+			return false
+		}
+	}
+	for _, tag := range s.Tags {
+		if !config.hasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply enqueues the action for every deployment matching the selector and returns the result for each
+// deployment keyed by deployment name. A deployment failing to queue the action does not stop the action
+// from being queued for the other deployments, its error is reported in its result.
+func (a BulkAction) Apply() (map[string]BulkActionResult, error) {
+	if len(a.Selector.Names) == 0 && len(a.Selector.Labels) == 0 && len(a.Selector.Tags) == 0 {
+		return nil, errors.New("selector must provide deployment names, labels or tags")
+	}
+
+	var action func(deployment string) (string, error)
+	switch a.Action {
+	case StartAction:
+		action = StartContainers
+	case StopAction:
+		action = StopContainers
+	case RestartAction:
+		action = RestartContainers
+	default:
+		return nil, fmt.Errorf("invalid action %s, must be one of start, stop, restart", a.Action)
+	}
+
+	configs, err := GetAllDeploymentConfigs()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]BulkActionResult)
+	for _, config := range configs {
+		if config.Internal || !a.Selector.Matches(config) {
+			continue
+		}
+
+		jobID, err := action(config.Name)
+		if err != nil {
+			results[config.Name] = BulkActionResult{Error: err.Error()}
+			continue
+		}
+		results[config.Name] = BulkActionResult{JobID: jobID}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,40 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectorMatches(t *testing.T) {
+	payments := Config{Name: "payments-api", Labels: map[string]string{"team": "payments", "tier": "api"}}
+	search := Config{Name: "search", Labels: map[string]string{"team": "search"}}
+
+	byLabel := Selector{Labels: map[string]string{"team": "payments"}}
+	assert.True(t, byLabel.Matches(payments))
+	assert.False(t, byLabel.Matches(search))
+
+	byName := Selector{Names: []string{"search"}}
+	assert.True(t, byName.Matches(search))
+	assert.False(t, byName.Matches(payments))
+
+	payments.Tags = []string{"team-payments", "critical"}
+	byTag := Selector{Tags: []string{"team-payments"}}
+	assert.True(t, byTag.Matches(payments))
+	assert.False(t, byTag.Matches(search))
+
+	// labels and tags must all match
+	assert.True(t, Selector{Labels: map[string]string{"tier": "api"}, Tags: []string{"critical"}}.Matches(payments))
+	assert.False(t, Selector{Labels: map[string]string{"tier": "web"}, Tags: []string{"critical"}}.Matches(payments))
+	assert.False(t, Selector{Tags: []string{"team-payments", "team-search"}}.Matches(payments))
+
+	assert.False(t, Selector{}.Matches(payments))
+}
+
+func TestBulkActionRequiresSelector(t *testing.T) {
+	_, err := BulkAction{Action: RestartAction}.Apply()
+	assert.Error(t, err)
+
+	_, err = BulkAction{Selector: Selector{Names: []string{"app"}}, Action: "deploy"}.Apply()
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package deployment
+
+import (
+	"strings"
+)
+
+// maxAliasLength is the max length of a RFC 1123 hostname
+const maxAliasLength = 253
+
+// aliasFieldErrors returns a validation error for every alias that is not a RFC 1123 hostname or is listed more than once.
+// Empty aliases are ignored, they are not routed.
+func (config Config) aliasFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+
+	seen := make(map[string]bool)
+	for _, alias := range config.Alias {
+		if alias == "" {
+			continue
+		}
+
+		if !isValidAlias(alias) {
+			errs = append(errs, newFieldError("alias", "invalid alias %q, expected a hostname like app.example.com", alias))
+			continue
+		}
+
+		domain := strings.ToLower(alias)
+		if seen[domain] {
+			errs = append(errs, newFieldError("alias", "alias %s is listed more than once", alias))
+		}
+		seen[domain] = true
+	}
+
+	return errs
+}
+
+// hasAlias returns true if the deployment has at least one non-empty alias
+func (config Config) hasAlias() bool {
+	for _, alias := range config.Alias {
+		if alias != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidAlias returns if an alias is a hostname made of RFC 1123 labels, wildcards are not supported
+func isValidAlias(alias string) bool {
+	if len(alias) > maxAliasLength {
+		return false
+	}
+
+	for _, label := range strings.Split(alias, ".") {
+		if len(label) > maxHostnameLength || !hostnameRegex.MatchString(label) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// aliasConflicts returns an error for every alias already claimed by another deployment. The configuration
+// of the replaced deployment is ignored, it is the previous name of a deployment being renamed.
+func (config Config) aliasConflicts(replaced string) []FieldError {
+	errs := make([]FieldError, 0)
+	if len(config.Alias) == 0 {
+		return errs
+	}
+
+	configs, err := GetAllDeploymentConfigs()
+	if err != nil {
+		return errs
+	}
+
+	for _, other := range configs {
+		if other.Name == config.Name || other.Name == replaced {
+			continue
+		}
+
+		claimed := make(map[string]bool)
+		for _, alias := range other.Alias {
+			claimed[strings.ToLower(alias)] = true
+		}
+
+		for _, alias := range config.Alias {
+			if alias != "" && claimed[strings.ToLower(alias)] {
+				errs = append(errs, newFieldError("alias", "alias %s is already claimed by deployment %s", alias, other.Name))
+			}
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,40 @@
+package deployment
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAliasFieldErrors(t *testing.T) {
+	assert.Empty(t, Config{Alias: []string{"api.example.com", "app.localhost", "example-1.com", ""}}.aliasFieldErrors())
+
+	assert.Len(t, Config{Alias: []string{"*.example.com"}}.aliasFieldErrors(), 1)
+	assert.Len(t, Config{Alias: []string{"-api.example.com"}}.aliasFieldErrors(), 1)
+	assert.Len(t, Config{Alias: []string{"api..example.com"}}.aliasFieldErrors(), 1)
+	assert.Len(t, Config{Alias: []string{"https://api.example.com"}}.aliasFieldErrors(), 1)
+	assert.Len(t, Config{Alias: []string{strings.Repeat("a", 64) + ".com"}}.aliasFieldErrors(), 1)
+	assert.Len(t, Config{Alias: []string{strings.Repeat("a.", 127) + "com"}}.aliasFieldErrors(), 1)
+	assert.Len(t, Config{Alias: []string{"api.example.com", "API.example.com"}}.aliasFieldErrors(), 1)
+}
+
+func TestAliasConflicts(t *testing.T) {
+	assert.Nil(t, SaveConfig(Config{Name: "alias-api", Image: "nginx", Alias: []string{"api.example.com"}}))
+	defer DeleteConfig("alias-api")
+
+	// the same domain can't be claimed by two deployments
+	err := SaveConfig(Config{Name: "alias-app", Image: "nginx", Alias: []string{"app.example.com", "API.example.com"}})
+	assert.EqualError(t, err, "alias API.example.com is already claimed by deployment alias-api")
+	assert.False(t, Validate(Config{Name: "alias-app", Image: "nginx", Alias: []string{"api.example.com"}}).Valid)
+
+	// a deployment keeps its own aliases when updated or renamed
+	assert.Nil(t, SaveConfig(Config{Name: "alias-api", Image: "nginx", Tag: "1.19", Alias: []string{"api.example.com"}}))
+	assert.Empty(t, Config{Name: "alias-api-v2", Alias: []string{"api.example.com"}}.aliasConflicts("alias-api"))
+}
+
+func TestAliasHostRule(t *testing.T) {
+	config := Config{Name: "hosts", Alias: []string{"api.example.com", "", "app.example.com", ""}, Labels: map[string]string{}}
+	labels := config.DockerLabels()
+	assert.Equal(t, "Host(`api.example.com`) || Host(`app.example.com`)", labels["traefik.http.routers.hosts-insecure.rule"])
+}
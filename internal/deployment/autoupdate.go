@@ -0,0 +1,299 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/job"
+	"github.com/krane/krane/internal/logger"
+)
+
+// AutoUpdateCheckInterval is how often the deployments are checked for a due auto update
+const AutoUpdateCheckInterval = 30 * time.Second
+
+// DefaultAutoUpdateInterval is the time between registry checks of a deployment when auto_update.interval is not set
+const DefaultAutoUpdateInterval = 5 * time.Minute
+
+// MinAutoUpdateInterval is the shortest time allowed between registry checks of a deployment
+const MinAutoUpdateInterval = time.Minute
+
+// autoUpdatePullTimeout is the max time to pull the image tag of a deployment when checking it for an update
+const autoUpdatePullTimeout = 10 * time.Minute
+
+// AutoUpdateTriggeredBy is recorded on the run jobs triggered by an auto update
+const AutoUpdateTriggeredBy = "auto-update"
+
+// AutoUpdate redeploys a deployment when the registry digest of its image tag changes (ie. a new build pushed as latest)
+type AutoUpdate struct {
+	Enabled  bool          `json:"enabled"`                    // check the registry for a new image digest and redeploy when it changed (default false)
+	Interval time.Duration `json:"interval" schema:"duration"` // time between registry checks (ie. 10m), at least a minute (default 5m)
+}
+
+var autoUpdateMu sync.Mutex
+
+// lastAutoUpdateChecks is when each deployment was last checked for an update
+var lastAutoUpdateChecks = make(map[string]time.Time)
+
+// autoUpdatedDigests is the last image digest each deployment was redeployed to by an auto update
+var autoUpdatedDigests = make(map[string]string)
+
+// UnmarshalJSON decodes an auto update with its interval as a duration string (ie. 10m) or a number of seconds
+func (a *AutoUpdate) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Enabled  bool            `json:"enabled"`
+		Interval json.RawMessage `json:"interval"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	a.Enabled = raw.Enabled
+	a.Interval = 0
+	if len(raw.Interval) == 0 || string(raw.Interval) == "null" {
+		return nil
+	}
+
+	var seconds uint
+	if err := json.Unmarshal(raw.Interval, &seconds); err == nil {
+		a.Interval = time.Duration(seconds) * time.Second
+		return nil
+	}
+
+	var interval string
+	if err := json.Unmarshal(raw.Interval, &interval); err != nil {
+		return fmt.Errorf("invalid auto update interval %s, expected a duration (ie. 10m)", string(raw.Interval))
+	}
+	if interval == "" {
+		return nil
+	}
+
+	d, err := time.ParseDuration(interval)
+	if err != nil {
+		return fmt.Errorf("invalid auto update interval %s, expected a duration (ie. 10m)", interval)
+	}
+	a.Interval = d
+	return nil
+}
+
+// MarshalJSON encodes an auto update with its interval as a duration string (ie. 10m0s), empty when not set
+func (a AutoUpdate) MarshalJSON() ([]byte, error) {
+	interval := ""
+	if a.Interval != 0 {
+		interval = a.Interval.String()
+	}
+	return json.Marshal(struct {
+		Enabled  bool   `json:"enabled"`
+		Interval string `json:"interval"`
+	}{a.Enabled, interval})
+}
+
+// CheckInterval returns the time between registry checks of a deployment
+func (a AutoUpdate) CheckInterval() time.Duration {
+	if a.Interval == 0 {
+		return DefaultAutoUpdateInterval
+	}
+	return a.Interval
+}
+
+// autoUpdateFieldErrors returns a validation error if the auto update interval is shorter than a minute
+func (config Config) autoUpdateFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+	if config.AutoUpdate.Interval != 0 && config.AutoUpdate.Interval < MinAutoUpdateInterval {
+		errs = append(errs, newFieldError("auto_update", "invalid auto update interval %s, must be at least %s", config.AutoUpdate.Interval, MinAutoUpdateInterval))
+	}
+	return errs
+}
+
+// RunAutoUpdates checks the deployments with auto_update enabled for a new image digest until ctx is done
+func RunAutoUpdates(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			checkAutoUpdates(ctx, time.Now())
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// checkAutoUpdates checks the deployments due for an auto update one at a time, so a single
+// image pull runs for auto updates at once
+func checkAutoUpdates(ctx context.Context, now time.Time) {
+	configs, err := GetAllDeploymentConfigs()
+	if err != nil {
+		logger.Warnf("unable to get deployments to auto update %v", err)
+		return
+	}
+
+	names := make(map[string]bool, len(configs))
+	for _, config := range configs {
+		names[config.Name] = true
+		if !autoUpdateEnabled(config) || !autoUpdateDue(config.Name, config.AutoUpdate.CheckInterval(), now) {
+			continue
+		}
+
+		if err := checkAutoUpdate(ctx, config); err != nil {
+			logger.Warnf("unable to check deployment %s for an update, %v", config.Name, err)
+		}
+	}
+
+	forgetAutoUpdates(names)
+}
+
+// autoUpdateEnabled returns true if a deployment tracks its image tag for updates. Deployments pinned to a digest
+// and deployments with variants (each with its own image) are not auto updated.
+func autoUpdateEnabled(config Config) bool {
+	return config.AutoUpdate.Enabled && config.Digest == "" && len(config.Variants) == 0
+}
+
+// autoUpdateDue returns true if a deployment was not checked for an update during the last interval, and records the check
+func autoUpdateDue(deployment string, interval time.Duration, now time.Time) bool {
+	autoUpdateMu.Lock()
+	defer autoUpdateMu.Unlock()
+
+	if last, ok := lastAutoUpdateChecks[deployment]; ok && now.Sub(last) < interval {
+		return false
+	}
+	lastAutoUpdateChecks[deployment] = now
+	return true
+}
+
+// checkAutoUpdate pulls the image tag of a deployment and runs the deployment pinned to the pulled digest when
+// it differs from the digest of its running containers. Pulling an unchanged tag only fetches its manifest.
+func checkAutoUpdate(ctx context.Context, config Config) error {
+	containers, err := GetContainersByDeployment(config.Name)
+	if err != nil {
+		return err
+	}
+
+	var running *KraneContainer
+	for i := range containers {
+		if containers[i].State.Running {
+			running = &containers[i]
+			break
+		}
+	}
+
+	// deployments without running containers are left to their next run
+	if running == nil {
+		return nil
+	}
+
+	runningDigests, err := docker.GetClient().GetImageDigests(ctx, running.ImageID)
+	if err != nil {
+		return err
+	}
+
+	latest, err := registryDigest(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	if !shouldAutoUpdate(config.Name, runningDigests, latest) {
+		return nil
+	}
+
+	logger.Infof("auto updating deployment %s to %s (tag %s)", config.Name, latest, config.Tag)
+	e := createEventEmitter(config.Name, "")
+	e.Phase = AutoUpdatePhase
+	e.emit(fmt.Sprintf("image tag %s changed, redeploying %s", config.Tag, latest))
+
+	return RunWithOptions(config.Name, RunOptions{
+		Start:       true,
+		Automated:   true,
+		Digest:      latest,
+		TriggeredBy: AutoUpdateTriggeredBy,
+	})
+}
+
+// registryDigest pulls the image tag of a deployment and returns the digest the registry resolved the tag to
+func registryDigest(ctx context.Context, config Config) (string, error) {
+	if err := config.ResolveRegistryCredentials(); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, autoUpdatePullTimeout)
+	defer cancel()
+
+	ref := config.ImageRef()
+	reader, err := docker.GetClient().PullImage(ctx, ref, config.pullCredentials())
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	if _, err := io.Copy(ioutil.Discard, reader); err != nil {
+		return "", err
+	}
+
+	repoDigests, err := docker.GetClient().GetImageDigests(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+
+	digest := findImageDigest(repoDigests)
+	if digest == "" {
+		return "", fmt.Errorf("image %s has no repository digest", ref)
+	}
+	return digest, nil
+}
+
+// shouldAutoUpdate returns true if a deployment must be redeployed to the latest digest of its image tag. Deployments
+// with a queued or executing job are checked again later so registry updates never queue concurrent runs, and a
+// digest is deployed once so a digest failing to deploy is not retried until the tag moves again.
+func shouldAutoUpdate(deployment string, runningDigests []string, latest string) bool {
+	if hasImageDigest(runningDigests, latest) {
+		return false
+	}
+
+	if job.IsBusy(deployment) {
+		logger.Debugf("deployment %s has a job in progress, auto update to %s postponed", deployment, latest)
+		return false
+	}
+
+	autoUpdateMu.Lock()
+	defer autoUpdateMu.Unlock()
+
+	if autoUpdatedDigests[deployment] == latest {
+		return false
+	}
+	autoUpdatedDigests[deployment] = latest
+	return true
+}
+
+// hasImageDigest returns true if one of the repository digests (ie. nginx@sha256:...) is at a digest
+func hasImageDigest(repoDigests []string, digest string) bool {
+	for _, repoDigest := range repoDigests {
+		if strings.HasSuffix(repoDigest, "@"+digest) {
+			return true
+		}
+	}
+	return false
+}
+
+// forgetAutoUpdates drops the auto update state of the deployments not in names (ie. deleted deployments)
+func forgetAutoUpdates(names map[string]bool) {
+	autoUpdateMu.Lock()
+	defer autoUpdateMu.Unlock()
+
+	for deployment := range lastAutoUpdateChecks {
+		if !names[deployment] {
+			delete(lastAutoUpdateChecks, deployment)
+		}
+	}
+	for deployment := range autoUpdatedDigests {
+		if !names[deployment] {
+			delete(autoUpdatedDigests, deployment)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package deployment
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	runningDigest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	pushedDigest  = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+func TestAutoUpdateIntervalJSON(t *testing.T) {
+	var a AutoUpdate
+	assert.NoError(t, json.Unmarshal([]byte(`{"enabled": true, "interval": "10m"}`), &a))
+	assert.Equal(t, AutoUpdate{Enabled: true, Interval: 10 * time.Minute}, a)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"enabled": true, "interval": 120}`), &a))
+	assert.Equal(t, 2*time.Minute, a.Interval)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"enabled": true}`), &a))
+	assert.Equal(t, DefaultAutoUpdateInterval, a.CheckInterval())
+
+	assert.Error(t, json.Unmarshal([]byte(`{"interval": "often"}`), &a))
+
+	bytes, _ := json.Marshal(AutoUpdate{Enabled: true, Interval: 10 * time.Minute})
+	assert.JSONEq(t, `{"enabled": true, "interval": "10m0s"}`, string(bytes))
+}
+
+func TestAutoUpdateFieldErrors(t *testing.T) {
+	config := Config{AutoUpdate: AutoUpdate{Enabled: true, Interval: 30 * time.Second}}
+	assert.Len(t, config.autoUpdateFieldErrors(), 1)
+
+	config.AutoUpdate.Interval = time.Minute
+	assert.Empty(t, config.autoUpdateFieldErrors())
+
+	config.AutoUpdate.Interval = 0
+	assert.Empty(t, config.autoUpdateFieldErrors())
+}
+
+func TestAutoUpdateEnabled(t *testing.T) {
+	config := Config{Name: "my-app", AutoUpdate: AutoUpdate{Enabled: true}}
+	assert.True(t, autoUpdateEnabled(config))
+
+	pinned := config
+	pinned.Digest = runningDigest
+	assert.False(t, autoUpdateEnabled(pinned))
+
+	variants := config
+	variants.Variants = []Variant{{Name: "b"}}
+	assert.False(t, autoUpdateEnabled(variants))
+}
+
+func TestAutoUpdateDue(t *testing.T) {
+	defer forgetAutoUpdates(map[string]bool{})
+	now := time.Now()
+
+	assert.True(t, autoUpdateDue("auto-due", time.Minute, now))
+	assert.False(t, autoUpdateDue("auto-due", time.Minute, now.Add(30*time.Second)))
+	assert.True(t, autoUpdateDue("auto-due", time.Minute, now.Add(time.Minute)))
+}
+
+func TestShouldAutoUpdateDebounces(t *testing.T) {
+	defer forgetAutoUpdates(map[string]bool{})
+	running := []string{"docker.io/my-app@" + runningDigest}
+
+	assert.False(t, shouldAutoUpdate("auto-debounce", running, runningDigest))
+
+	// a changed digest is deployed once, repeated checks of the same digest do not queue more runs
+	assert.True(t, shouldAutoUpdate("auto-debounce", running, pushedDigest))
+	assert.False(t, shouldAutoUpdate("auto-debounce", running, pushedDigest))
+
+	forgetAutoUpdates(map[string]bool{})
+	assert.True(t, shouldAutoUpdate("auto-debounce", running, pushedDigest))
+}
+
+func TestHasImageDigest(t *testing.T) {
+	repoDigests := []string{"docker.io/nginx@" + runningDigest}
+	assert.True(t, hasImageDigest(repoDigests, runningDigest))
+	assert.False(t, hasImageDigest(repoDigests, pushedDigest))
+	assert.False(t, hasImageDigest(nil, runningDigest))
+}
@@ -0,0 +1,125 @@
+package deployment
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/store"
+)
+
+// configCache is an in-memory cache of serialized deployment configurations. Configurations are kept
+// serialized so every read returns a copy that callers can modify without affecting the cache.
+// Writes hold the lock while writing to the store so the cache and the store are updated in the same order.
+type configCache struct {
+	mu      sync.RWMutex
+	configs map[string][]byte
+	loaded  bool // whether every configuration in the store has been loaded into the cache
+}
+
+var configs = &configCache{configs: make(map[string][]byte)}
+
+// get returns a cached configuration, reading through to the store on a cache miss
+func (c *configCache) get(deployment string) ([]byte, error) {
+	c.mu.RLock()
+	bytes, ok := c.configs[deployment]
+	loaded := c.loaded
+	c.mu.RUnlock()
+
+	if ok || loaded {
+		return bytes, nil
+	}
+
+	return c.refresh(deployment)
+}
+
+// refresh reads a configuration from the store and updates the cache with it
+func (c *configCache) refresh(deployment string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	bytes, err := store.Client().Get(constants.DeploymentsCollectionName, deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes == nil {
+		delete(c.configs, deployment)
+	} else {
+		c.configs[deployment] = bytes
+	}
+	return bytes, nil
+}
+
+// all returns every cached configuration sorted by deployment name, loading them from the store on first use
+func (c *configCache) all() ([][]byte, error) {
+	c.mu.RLock()
+	if c.loaded {
+		defer c.mu.RUnlock()
+		return c.sorted(), nil
+	}
+	c.mu.RUnlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.loaded {
+		all, err := store.Client().GetAll(constants.DeploymentsCollectionName)
+		if err != nil {
+			return nil, err
+		}
+
+		c.configs = make(map[string][]byte, len(all))
+		for _, bytes := range all {
+			config, err := DeSerializeConfig(bytes)
+			if err != nil {
+				continue
+			}
+			c.configs[config.Name] = bytes
+		}
+		c.loaded = true
+	}
+
+	return c.sorted(), nil
+}
+
+// sorted returns the cached configurations sorted by deployment name, the lock must be held
+func (c *configCache) sorted() [][]byte {
+	names := make([]string, 0, len(c.configs))
+	for name := range c.configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	all := make([][]byte, 0, len(names))
+	for _, name := range names {
+		all = append(all, c.configs[name])
+	}
+	return all
+}
+
+// put writes a configuration to the store and the cache
+func (c *configCache) put(deployment string, bytes []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := store.Client().Put(constants.DeploymentsCollectionName, deployment, bytes); err != nil {
+		return err
+	}
+
+	c.configs[deployment] = bytes
+	return nil
+}
+
+// remove deletes a configuration from the store and the cache
+func (c *configCache) remove(deployment string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := store.Client().Remove(constants.DeploymentsCollectionName, deployment); err != nil {
+		return err
+	}
+
+	delete(c.configs, deployment)
+	return nil
+}
@@ -0,0 +1,98 @@
+package deployment
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/store"
+)
+
+func TestConfigCacheReturnsCopies(t *testing.T) {
+	assert.Nil(t, SaveConfig(Config{Name: "cache-app", Image: "nginx", Labels: map[string]string{"team": "web"}}))
+	defer DeleteConfig("cache-app")
+	defer DeleteHistoryCollection("cache-app")
+
+	config, err := GetDeploymentConfig("cache-app")
+	assert.Nil(t, err)
+	config.Labels["team"] = "modified"
+
+	config, err = GetDeploymentConfig("cache-app")
+	assert.Nil(t, err)
+	assert.Equal(t, "web", config.Labels["team"])
+}
+
+func TestConfigCacheInvalidation(t *testing.T) {
+	assert.Nil(t, SaveConfig(Config{Name: "cache-update", Image: "nginx", Tag: "1.18"}))
+	defer DeleteHistoryCollection("cache-update")
+
+	all, err := GetAllDeploymentConfigs()
+	assert.Nil(t, err)
+	assert.Contains(t, names(all), "cache-update")
+
+	assert.Nil(t, SaveConfig(Config{Name: "cache-update", Image: "nginx", Tag: "1.19"}))
+	config, err := GetDeploymentConfig("cache-update")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.19", config.Tag)
+
+	assert.Nil(t, DeleteConfig("cache-update"))
+	assert.False(t, Exist("cache-update"))
+
+	all, err = GetAllDeploymentConfigs()
+	assert.Nil(t, err)
+	assert.NotContains(t, names(all), "cache-update")
+}
+
+func TestGetDeploymentConfigFromStoreBypassesCache(t *testing.T) {
+	assert.Nil(t, SaveConfig(Config{Name: "cache-bypass", Image: "nginx", Tag: "1.18"}))
+	defer DeleteConfig("cache-bypass")
+	defer DeleteHistoryCollection("cache-bypass")
+
+	// written to the store without going through the cache
+	bytes, _ := Config{Name: "cache-bypass", Image: "nginx", Tag: "1.19"}.Serialize()
+	assert.Nil(t, store.Client().Put(constants.DeploymentsCollectionName, "cache-bypass", bytes))
+
+	cached, _ := GetDeploymentConfig("cache-bypass")
+	assert.Equal(t, "1.18", cached.Tag)
+
+	stored, err := GetDeploymentConfigFromStore("cache-bypass")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.19", stored.Tag)
+
+	// the cache is refreshed by reads bypassing it
+	cached, _ = GetDeploymentConfig("cache-bypass")
+	assert.Equal(t, "1.19", cached.Tag)
+}
+
+func TestConfigCacheConcurrentSaves(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("cache-concurrent-%d", i)
+			assert.Nil(t, SaveConfig(Config{Name: name, Image: "nginx"}))
+			_, err := GetDeploymentConfig(name)
+			assert.Nil(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("cache-concurrent-%d", i)
+		assert.True(t, Exist(name))
+		assert.Nil(t, DeleteConfig(name))
+		assert.Nil(t, DeleteHistoryCollection(name))
+	}
+}
+
+func names(configs []Config) []string {
+	names := make([]string, 0, len(configs))
+	for _, c := range configs {
+		names = append(names, c.Name)
+	}
+	return names
+}
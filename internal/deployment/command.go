@@ -0,0 +1,44 @@
+package deployment
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CommandArgs is a container command or entrypoint in the exec form, an executable followed by its arguments
+type CommandArgs []string
+
+// UnmarshalJSON decodes a list of strings. Configurations saved before commands were lists stored
+// the command as a single string, it is kept as a single element so those containers run the same command
+// as before (splitting it would break quoted arguments).
+func (args *CommandArgs) UnmarshalJSON(data []byte) error {
+	var legacy string
+	if err := json.Unmarshal(data, &legacy); err == nil {
+		if legacy == "" {
+			*args = nil
+			return nil
+		}
+		*args = CommandArgs{legacy}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("invalid command %s, expected a list of strings", string(data))
+	}
+	*args = list
+	return nil
+}
+
+// commandFieldErrors returns a validation error if the command or entrypoint do not start with an executable
+func (config Config) commandFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+	if len(config.Command) > 0 && strings.TrimSpace(config.Command[0]) == "" {
+		errs = append(errs, newFieldError("command", "command must start with an executable"))
+	}
+	if len(config.Entrypoint) > 0 && strings.TrimSpace(config.Entrypoint[0]) == "" {
+		errs = append(errs, newFieldError("entrypoint", "entrypoint must start with an executable"))
+	}
+	return errs
+}
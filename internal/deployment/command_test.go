@@ -0,0 +1,40 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandArgsUnmarshal(t *testing.T) {
+	config, err := DeSerializeConfig([]byte(`{"name":"worker","command":["bin/worker","--queue","mail"],"entrypoint":["/entrypoint.sh"]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, CommandArgs{"bin/worker", "--queue", "mail"}, config.Command)
+	assert.Equal(t, CommandArgs{"/entrypoint.sh"}, config.Entrypoint)
+
+	// configurations saved with a string command keep loading
+	config, err = DeSerializeConfig([]byte(`{"name":"web","command":"nginx -g 'daemon off;'"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, CommandArgs{"nginx -g 'daemon off;'"}, config.Command)
+
+	config, err = DeSerializeConfig([]byte(`{"name":"web","command":""}`))
+	assert.Nil(t, err)
+	assert.Nil(t, config.Command)
+
+	config, err = DeSerializeConfig([]byte(`{"name":"web"}`))
+	assert.Nil(t, err)
+	assert.Nil(t, config.Command)
+
+	_, err = DeSerializeConfig([]byte(`{"name":"web","command":[1,2]}`))
+	assert.Error(t, err)
+}
+
+func TestCommandFieldErrors(t *testing.T) {
+	assert.Empty(t, Config{}.commandFieldErrors())
+	assert.Empty(t, Config{Command: CommandArgs{"bin/worker"}, Entrypoint: CommandArgs{"tini", "--"}}.commandFieldErrors())
+
+	errs := Config{Command: CommandArgs{"", "--queue"}, Entrypoint: CommandArgs{" "}}.commandFieldErrors()
+	assert.Len(t, errs, 2)
+	assert.Equal(t, "command", errs[0].Field)
+	assert.Equal(t, "entrypoint", errs[1].Field)
+}
@@ -1,46 +1,109 @@
 package deployment
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
 	"github.com/lithammer/shortuuid/v3"
 
-	"github.com/krane/krane/internal/constants"
 	"github.com/krane/krane/internal/docker"
 	"github.com/krane/krane/internal/logger"
 	"github.com/krane/krane/internal/proxy"
-	"github.com/krane/krane/internal/store"
 )
 
+var platformRegex = regexp.MustCompile(`^[a-z0-9]+/[a-z0-9_]+(/[a-z0-9]+)?$`)
+
+// DefaultContainerCreateTimeout is the max duration for creating a container when a deployment does not configure one
+const DefaultContainerCreateTimeout = 120 * time.Second
+
+// DefaultContainerStopTimeout is the time a stopped container is given to exit before it is killed when a deployment does not configure one
+const DefaultContainerStopTimeout = 60 * time.Second
+
 // Config represents a deployment configuration
 type Config struct {
-	Name       string            `json:"name" binding:"required"`  // deployment name
-	Image      string            `json:"image" binding:"required"` // container image
-	Registry   Registry          `json:"registry"`                 // container registry credentials / auth
-	Tag        string            `json:"tag"`                      // container image tag
-	Alias      []string          `json:"alias"`                    // custom domain aliases (my-app.example.com or my-app.localhost)
-	Env        map[string]string `json:"env"`                      // deployment environment variables
-	Secrets    map[string]string `json:"secrets"`                  // deployment secrets resolved as environment variables
-	Labels     map[string]string `json:"labels"`                   // container labels
-	Ports      map[string]string `json:"ports"`                    // container ports to expose from the container to the host
-	TargetPort string            `json:"target_port"`              // the target port to load-balance request through
-	Volumes    map[string]string `json:"volumes"`                  // container volumes
-	Command    string            `json:"command"`                  // container start command
-	Entrypoint string            `json:"entrypoint"`               // container entrypoint
-	Scale      int               `json:"scale"`                    // number of containers to create for the deployment
-	Secure     bool              `json:"secure"`                   // enable/disable secure communication over HTTPS/TLS w/ auto generated certs
-	Internal   bool              `json:"internal"`                 // whether a deployment is internal (ie. krane-proxy)
-	RateLimit  uint              `json:"rate_limit"`               // requests per second for a given deployment (default 0, which means no rate limit)
+	Name                 string            `json:"name" binding:"required"`  // deployment name
+	Image                string            `json:"image" binding:"required"` // container image
+	Registry             Registry          `json:"registry"`                 // container registry credentials / auth
+	Tag                  string            `json:"tag"`                      // container image tag
+	Digest               string            `json:"digest"`                   // container image digest, when set the deployment is pinned to the digest instead of the tag
+	Alias                []string          `json:"alias"`                    // custom domain aliases (my-app.example.com or my-app.localhost)
+	Env                  map[string]string `json:"env"`                      // deployment environment variables
+	Secrets              map[string]string `json:"secrets"`                  // deployment secrets resolved as environment variables
+	Labels               map[string]string `json:"labels"`                   // container labels
+	Tags                 []string          `json:"tags"`                     // labels grouping deployments (ie. team-web), the deployments list can be filtered by tag
+	Ports                map[string]string `json:"ports"`                    // container ports to expose from the container to the host
+	PortMappings         []PortMapping     `json:"port_mappings"`            // container ports to expose to the host over tcp, udp or sctp, in addition to ports
+	TargetPort           string            `json:"target_port"`              // the target port to load-balance request through
+	Volumes              map[string]string `json:"volumes"`                  // container volumes
+	Mounts               []VolumeMount     `json:"mounts"`                   // named volumes (created if missing) or host paths mounted into the containers, optionally read-only
+	Command              CommandArgs       `json:"command"`                  // container start command and its arguments, overrides the image command (default the image command)
+	Entrypoint           CommandArgs       `json:"entrypoint"`               // container entrypoint and its arguments, overrides the image entrypoint (default the image entrypoint)
+	Scale                int               `json:"scale"`                    // number of containers to create for the deployment
+	MinHealthy           *int              `json:"min_healthy"`              // number of containers required to pass the health check for a deployment to succeed, 0 tolerates any (default is scale)
+	HealthCheck          HealthCheck       `json:"health_check"`             // how containers are probed before they are considered healthy
+	Readiness            ReadinessProbe    `json:"readiness"`                // http probe by the network proxy, containers not ready are removed from the load balancer without being restarted
+	Liveness             LivenessProbe     `json:"liveness"`                 // probe while monitoring the deployment, containers not alive are restarted
+	Secure               bool              `json:"secure"`                   // enable/disable secure communication over HTTPS/TLS w/ auto generated certs
+	TLS                  TLS               `json:"tls"`                      // https served by the network proxy, overrides secure when tls.enabled is set (ie. in the global defaults)
+	Internal             bool              `json:"internal"`                 // whether a deployment is internal (ie. krane-proxy)
+	NetworkMode          string            `json:"network_mode"`             // host or none to keep containers off the krane network, ports and aliases are ignored (default krane network)
+	RateLimit            uint              `json:"rate_limit"`               // average requests per second for a given deployment (default 0, which means no rate limit)
+	RateLimitBurst       uint              `json:"rate_limit_burst"`         // max requests allowed at once above the rate limit (default 0, which uses the proxy default of 1)
+	AllowedIPs           []string          `json:"allowed_ips"`              // CIDR ranges allowed to reach the deployment through the proxy, other clients are denied (default any)
+	AccessLog            bool              `json:"access_log"`               // enable/disable proxy access logs for requests to the deployment (default false)
+	Variants             []Variant         `json:"variants"`                 // images to split traffic between under the deployment (A/B testing)
+	CreateTimeout        uint              `json:"create_timeout"`           // max time in seconds to create a single container (default 120)
+	StopTimeout          *uint             `json:"stop_timeout"`             // time in seconds a stopped container is given to exit before it is killed, 0 kills it immediately (default 60)
+	PullProgressInterval uint              `json:"pull_progress_interval"`   // seconds between image pull progress summaries, 0 streams every pull message (default 0)
+	ShmSize              string            `json:"shm_size"`                 // size of /dev/shm for the containers (ie. 256mb), defaults to the docker default of 64mb
+	Init                 bool              `json:"init"`                     // run an init process (tini) as PID 1 in the containers to reap zombie processes (default false)
+	User                 string            `json:"user"`                     // user (name or uid) and optionally group (name or gid) the container runs as (ie. 1000:1000), defaults to the image user
+	GroupAdd             []string          `json:"group_add"`                // supplementary groups (names or gids) of the container user (ie. a group owning a bind mounted volume)
+	Timezone             string            `json:"timezone"`                 // tz database timezone of the containers (ie. America/New_York) set as TZ, defaults to the image timezone (usually UTC)
+	Locale               string            `json:"locale"`                   // locale of the containers (ie. en_US.UTF-8) set as LANG and LC_ALL
+	MountLocaltime       bool              `json:"mount_localtime"`          // bind mount the timezone file of the docker host read-only as /etc/localtime, for apps ignoring TZ (default false)
+	Resources            Resources         `json:"resources"`                // cpu and memory limits of each container, absolute or a percentage of the host (ie. 25%)
+	ResolvedResources    ResolvedResources `json:"-"`                        // resource limits resolved against the docker host at deploy time
+	Priority             int               `json:"priority"`                 // deployments with a higher priority are processed first when many are queued at once (default 0)
+	DeployTimeout        uint              `json:"deploy_timeout"`           // max time in seconds for a deployment run including retries (default 0, which means no timeout)
+	Platform             string            `json:"platform"`                 // platform (ie. linux/arm64) the deployment image must be built for, must match the docker host platform
+	ReadinessWebhook     ReadinessWebhook  `json:"readiness_webhook"`        // external endpoint confirming the deploy is ready once its containers are healthy
+	DeployWindow         DeployWindow      `json:"deploy_window"`            // when automated runs deploy the deployment, runs outside the window are deferred (default anytime)
+	Hostname             string            `json:"hostname"`                 // hostname of the containers suffixed with the container index when scale > 1 (default the deployment name)
+	ReloadOnChange       bool              `json:"reload_on_change"`         // redeploy when a referenced secret or a bind mounted host file changes, for apps reading them at startup (default false)
+	RestartPolicy        string            `json:"restart_policy"`           // docker restart policy of the containers: no, always, unless-stopped or on-failure with optional max retries (ie. on-failure:5), default no
+	Strategy             string            `json:"strategy"`                 // how containers are replaced: recreate, or blue-green to switch all traffic to the new containers once they are healthy (default recreate)
+	PreDeploy            Hook              `json:"pre_deploy"`               // command run in a throwaway container from the deployment image before the new containers are created, a failure aborts the deploy
+	PostDeploy           Hook              `json:"post_deploy"`              // command run in a throwaway container from the deployment image once the previous containers are removed
+	Notifications        []Notification    `json:"notifications"`            // endpoints notified with the outcome of every job of the deployment (ie. deploy succeeded or failed)
+	AutoUpdate           AutoUpdate        `json:"auto_update"`              // redeploy when the registry digest of the image tag changes, checked at an interval (default disabled)
+
+	fields         map[string]json.RawMessage // fields set in the json the configuration was decoded from, nil when not decoded from json
+	variantWeights []proxy.WeightedService    // services of the variants sharing the proxy routers, only set on the config of a variant
 }
 
 // SaveConfig a deployment configuration into the db
 func SaveConfig(config Config) error {
+	return SaveConfigWithNote(config, "")
+}
+
+// SaveConfigWithNote saves a deployment configuration into the db and records
+// it in the deployment history along with a note describing the change
+func SaveConfigWithNote(config Config, note string) error {
+	return saveConfig(config, note, "")
+}
+
+// saveConfig saves a deployment configuration replacing the configuration of another deployment, the aliases
+// of the replaced deployment are not considered conflicting. The replaced deployment is empty unless renaming.
+func saveConfig(config Config, note string, replaced string) error {
+	config.restoreRegistryCredentials(replaced)
 	config.applyDefaults()
 
 	if err := config.isValid(); err != nil {
@@ -48,8 +111,47 @@ func SaveConfig(config Config) error {
 		return err
 	}
 
+	// a domain can only be routed to a single deployment
+	if errs := config.aliasConflicts(replaced); len(errs) > 0 {
+		logger.Errorf("deployment config is not valid %v", errs[0])
+		return errs[0]
+	}
+
 	bytes, _ := config.Serialize()
-	return store.Client().Put(constants.DeploymentsCollectionName, config.Name, bytes)
+	if err := configs.put(config.Name, bytes); err != nil {
+		return err
+	}
+
+	if _, err := recordHistory(config, note); err != nil {
+		logger.Errorf("unable to record deployment history %v", err)
+	}
+
+	return nil
+}
+
+// Redacted returns the deployment configuration with its registry credentials masked, for api responses
+func (config Config) Redacted() Config {
+	config.Registry = config.Registry.Redacted()
+	return config
+}
+
+// restoreRegistryCredentials replaces the masked registry credentials of a configuration with the credentials
+// stored for the deployment, or for the replaced deployment when renaming
+func (config *Config) restoreRegistryCredentials(replaced string) {
+	if config.Registry.Password != redactedValue && config.Registry.Token != redactedValue {
+		return
+	}
+
+	name := config.Name
+	if replaced != "" {
+		name = replaced
+	}
+
+	stored, err := GetDeploymentConfigFromStore(name)
+	if err != nil {
+		stored = Config{}
+	}
+	config.Registry = config.Registry.withStoredCredentials(stored.Registry)
 }
 
 // Serialize returns the bytes for a deployment config
@@ -64,8 +166,10 @@ func DeSerializeConfig(bytes []byte) (Config, error) {
 	return config, err
 }
 
-// applyDefaults applies default deployment configuration values
+// applyDefaults applies the server-level default configuration and default deployment configuration values
 func (config *Config) applyDefaults() {
+	config.applyGlobalDefaults()
+
 	if config.Registry.URL == "" {
 		config.Registry.URL = "docker.io"
 	}
@@ -78,6 +182,10 @@ func (config *Config) applyDefaults() {
 		config.Labels = make(map[string]string, 0)
 	}
 
+	if config.Tags == nil {
+		config.Tags = make([]string, 0)
+	}
+
 	if config.Secrets == nil {
 		config.Secrets = make(map[string]string, 0)
 	}
@@ -103,16 +211,68 @@ func (config *Config) applyDefaults() {
 
 // isValid returns an error if a deployment Configuration is not valid
 func (config Config) isValid() error {
-	isValidName := config.isValidName()
-	if !isValidName {
-		return fmt.Errorf("invalid name %s in deployment config", config.Name)
+	if errs := config.fieldErrors(); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// fieldErrors returns a validation error for every invalid field in a deployment configuration
+func (config Config) fieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+
+	if !config.isValidName() {
+		errs = append(errs, newFieldError("name", "invalid name %s in deployment config", config.Name))
 	}
 
 	if config.Image == "" {
-		return errors.New("image required in deployment config")
+		errs = append(errs, newFieldError("image", "image required in deployment config"))
 	}
 
-	return nil
+	if config.Scale < 0 {
+		errs = append(errs, newFieldError("scale", "scale %d must be 0 or more", config.Scale))
+	}
+
+	if config.MinHealthy != nil && (*config.MinHealthy < 0 || *config.MinHealthy > config.Scale) {
+		errs = append(errs, newFieldError("min_healthy", "min_healthy %d must be between 0 and scale %d", *config.MinHealthy, config.Scale))
+	}
+
+	if config.Digest != "" && !digestRegex.MatchString(config.Digest) {
+		errs = append(errs, newFieldError("digest", "invalid image digest %s", config.Digest))
+	}
+
+	if config.RateLimitBurst > 0 && config.RateLimit == 0 {
+		errs = append(errs, newFieldError("rate_limit_burst", "rate_limit_burst %d set without a rate_limit", config.RateLimitBurst))
+	}
+
+	errs = append(errs, config.aliasFieldErrors()...)
+	errs = append(errs, config.labelFieldErrors()...)
+	errs = append(errs, config.tagFieldErrors()...)
+	errs = append(errs, config.envFieldErrors()...)
+	errs = append(errs, config.portFieldErrors()...)
+	errs = append(errs, config.hostnameFieldErrors()...)
+	errs = append(errs, config.shmSizeFieldErrors()...)
+	errs = append(errs, config.mountFieldErrors()...)
+	errs = append(errs, config.resourcesFieldErrors()...)
+	errs = append(errs, config.healthCheckFieldErrors()...)
+	errs = append(errs, config.probesFieldErrors()...)
+	errs = append(errs, config.tlsFieldErrors()...)
+	errs = append(errs, config.allowedIPsFieldErrors()...)
+	errs = append(errs, config.networkModeFieldErrors()...)
+	errs = append(errs, config.restartPolicyFieldErrors()...)
+	errs = append(errs, config.strategyFieldErrors()...)
+	errs = append(errs, config.userFieldErrors()...)
+	errs = append(errs, config.localeFieldErrors()...)
+	errs = append(errs, config.platformFieldErrors()...)
+	errs = append(errs, config.readinessWebhookFieldErrors()...)
+	errs = append(errs, config.notificationsFieldErrors()...)
+	errs = append(errs, config.autoUpdateFieldErrors()...)
+	errs = append(errs, config.deployWindowFieldErrors()...)
+	errs = append(errs, config.variantFieldErrors()...)
+	errs = append(errs, config.hooksFieldErrors()...)
+	errs = append(errs, config.commandFieldErrors()...)
+
+	return errs
 }
 
 // isValidName return if a deployment name is valid or not
@@ -136,13 +296,27 @@ func (config Config) isValidName() bool {
 	return match.MatchString(config.Name)
 }
 
-// GetDeploymentConfig returns a deployments configuration
+// GetDeploymentConfig returns a deployments configuration, served from the config cache when cached
 func GetDeploymentConfig(deployment string) (Config, error) {
-	bytes, err := store.Client().Get(constants.DeploymentsCollectionName, deployment)
+	bytes, err := configs.get(deployment)
 	if err != nil {
 		return Config{}, err
 	}
+	return deserializeDeploymentConfig(deployment, bytes)
+}
+
+// GetDeploymentConfigFromStore returns a deployments configuration read from the store bypassing the
+// config cache, for reads that must observe the stored configuration. The cache is refreshed with it.
+func GetDeploymentConfigFromStore(deployment string) (Config, error) {
+	bytes, err := configs.refresh(deployment)
+	if err != nil {
+		return Config{}, err
+	}
+	return deserializeDeploymentConfig(deployment, bytes)
+}
 
+// deserializeDeploymentConfig returns a deployment configuration from bytes, an error if the deployment was not found
+func deserializeDeploymentConfig(deployment string, bytes []byte) (Config, error) {
 	if bytes == nil {
 		return Config{}, fmt.Errorf("deployment %s not found", deployment)
 	}
@@ -155,9 +329,9 @@ func GetDeploymentConfig(deployment string) (Config, error) {
 	return config, nil
 }
 
-// GetAllDeploymentConfigs returns a list of all deployment configurations
+// GetAllDeploymentConfigs returns a list of all deployment configurations sorted by name
 func GetAllDeploymentConfigs() ([]Config, error) {
-	bytes, err := store.Client().GetAll(constants.DeploymentsCollectionName)
+	bytes, err := configs.all()
 	if err != nil {
 		return make([]Config, 0), err
 	}
@@ -173,68 +347,225 @@ func GetAllDeploymentConfigs() ([]Config, error) {
 
 // DeleteSecret removes a deployment configuration from the db
 func DeleteConfig(deployment string) error {
-	return store.Client().Remove(constants.DeploymentsCollectionName, deployment)
+	return configs.remove(deployment)
 }
 
-// Empty returns true if a config has not defined a deployment name or image
-func (config Config) Empty() bool {
-	return config.Name == "" || config.Image == ""
+// MinHealthyContainers returns the number of containers required to be healthy for a deployment
+// to be considered successful. When min_healthy is not set, every container must be healthy.
+func (config Config) MinHealthyContainers() int {
+	if config.MinHealthy == nil {
+		return config.Scale
+	}
+	return *config.MinHealthy
 }
 
-// DockerConfig returns the docker configuration for creating a container
-func (config Config) DockerConfig() docker.DockerConfig {
-	kraneNetwork, err := docker.GetClient().GetNetworkByName(docker.KraneNetworkName)
+// ImageRef returns the image reference for a deployment, pinned to its digest (if any)
+func (config Config) ImageRef() string {
+	if config.Digest != "" {
+		return docker.ImageDigestRef(config.Registry.URL, config.Image, config.Digest)
+	}
+	return docker.ImageRef(config.Registry.URL, config.Image, config.Tag)
+}
+
+// ContainerCreateTimeout returns the max duration for creating a single container
+func (config Config) ContainerCreateTimeout() time.Duration {
+	if config.CreateTimeout == 0 {
+		return DefaultContainerCreateTimeout
+	}
+	return time.Duration(config.CreateTimeout) * time.Second
+}
+
+// ContainerStopTimeout returns the time a stopped container is given to exit before it is killed
+func (config Config) ContainerStopTimeout() time.Duration {
+	if config.StopTimeout == nil {
+		return DefaultContainerStopTimeout
+	}
+	return time.Duration(*config.StopTimeout) * time.Second
+}
+
+// ShmSizeBytes returns the size of /dev/shm in bytes, 0 if not set or invalid
+func (config Config) ShmSizeBytes() int64 {
+	if config.ShmSize == "" {
+		return 0
+	}
+
+	size, err := units.RAMInBytes(config.ShmSize)
+	if err != nil || size < 0 {
+		return 0
+	}
+	return size
+}
+
+// shmSizeFieldErrors returns a validation error if the shm size cannot be parsed or exceeds the host memory
+func (config Config) shmSizeFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+	if config.ShmSize == "" {
+		return errs
+	}
+
+	size, err := units.RAMInBytes(config.ShmSize)
+	if err != nil || size <= 0 {
+		return append(errs, newFieldError("shm_size", "invalid shm_size %s, expected a size like 256mb", config.ShmSize))
+	}
+
+	// the host memory is only checked when connected to docker
+	if docker.GetClient() == nil {
+		return errs
+	}
+
+	hostMemory, err := docker.GetClient().HostMemory(context.Background())
 	if err != nil {
-		return docker.DockerConfig{}
+		logger.Warnf("unable to get docker host memory to validate shm_size %v", err)
+		return errs
+	}
+
+	if size > hostMemory {
+		errs = append(errs, newFieldError("shm_size", "shm_size %s exceeds the host memory of %s", config.ShmSize, units.BytesSize(float64(hostMemory))))
+	}
+
+	return errs
+}
+
+// platformFieldErrors returns a validation error if the platform is malformed or not supported by the docker host
+func (config Config) platformFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+	if config.Platform == "" {
+		return errs
+	}
+
+	if !platformRegex.MatchString(config.Platform) {
+		return append(errs, newFieldError("platform", "invalid platform %s, expected os/arch like linux/arm64", config.Platform))
+	}
+
+	// the host platform is only checked when connected to docker
+	if docker.GetClient() == nil {
+		return errs
 	}
 
-	var command []string
-	var entrypoint []string
+	hostPlatform, err := docker.GetClient().HostPlatform(context.Background())
+	if err != nil {
+		logger.Warnf("unable to get docker host platform to validate platform %v", err)
+		return errs
+	}
 
-	if config.Command != "" {
-		command = append(command, config.Command)
+	if config.osArch() != hostPlatform {
+		errs = append(errs, newFieldError("platform", "platform %s is not supported by the docker host platform %s", config.Platform, hostPlatform))
 	}
 
-	if config.Entrypoint != "" {
-		entrypoint = append(entrypoint, config.Entrypoint)
+	return errs
+}
+
+// osArch returns the os/arch part of the deployment platform without the architecture variant (ie. linux/arm/v7 -> linux/arm)
+func (config Config) osArch() string {
+	parts := strings.SplitN(config.Platform, "/", 3)
+	if len(parts) < 2 {
+		return config.Platform
 	}
+	return docker.Platform(parts[0], parts[1])
+}
 
-	containerName := fmt.Sprintf("%s-%s", config.Name, shortuuid.New())
-	imageName := fmt.Sprintf("%s/%s", config.Registry.URL, config.Image)
-	return docker.DockerConfig{
-		ContainerName: containerName,
-		Image:         imageName,
-		NetworkID:     kraneNetwork.ID,
-		Aliases:       config.Alias,
+// Empty returns true if a config has not defined a deployment name or image
+func (config Config) Empty() bool {
+	return config.Name == "" || config.Image == ""
+}
+
+// containerName returns a unique name for the container at an index of the deployment. Containers of a deployment
+// with more than one container are named after their index (ie. my-app-2-<id>) so replicas can be told apart, the
+// random id keeps names unique while the containers of a previous run are still around.
+func (config Config) containerName(index int) string {
+	if config.Scale > 1 {
+		return fmt.Sprintf("%s-%d-%s", config.Name, index, shortuuid.New())
+	}
+	return fmt.Sprintf("%s-%s", config.Name, shortuuid.New())
+}
+
+// DockerConfig returns the docker configuration for creating the container at an index of the deployment
+func (config Config) DockerConfig(index int) docker.DockerConfig {
+	dockerConfig := docker.DockerConfig{
+		ContainerName: config.containerName(index),
+		Hostname:      config.containerHostname(index),
+		Image:         config.ImageRef(),
 		Labels:        config.DockerLabels(),
-		Ports:         config.DockerPorts(),
-		PortSet:       config.DockerPortSet(),
 		VolumeMounts:  config.DockerVolumeMount(),
 		VolumeSet:     config.DockerVolumeSet(),
 		Env:           config.DockerEnvs(),
-		Command:       command,
-		Entrypoint:    entrypoint,
+		Command:       config.Command,
+		Entrypoint:    config.Entrypoint,
+		ShmSize:       config.ShmSizeBytes(),
+		Init:          config.Init,
+		User:          config.User,
+		GroupAdd:      config.GroupAdd,
+		Memory:        config.ResolvedResources.Memory,
+		NanoCPUs:      config.ResolvedResources.NanoCPUs,
+		MemorySwap:    config.ResolvedResources.MemorySwap,
+		CPUShares:     config.ResolvedResources.CPUShares,
+		NetworkMode:   config.NetworkMode,
+		RestartPolicy: config.DockerRestartPolicy(),
+		// deployment containers are long-running services, they are never auto removed
+		// so crashed containers can be inspected
+		AutoRemove: false,
+	}
+
+	// containers off the krane network are not attached to any network and don't publish ports
+	if docker.IsIsolatedNetworkMode(config.NetworkMode) {
+		return dockerConfig
 	}
+
+	kraneNetwork, err := docker.GetClient().GetNetworkByName(docker.KraneNetworkName)
+	if err != nil {
+		return docker.DockerConfig{}
+	}
+
+	// routed deployments are attached to the proxy network so the proxy can reach them
+	extraNetworks := make([]string, 0)
+	if config.Routed() && docker.ProxyNetworkName() != docker.KraneNetworkName {
+		proxyNetwork, err := docker.GetClient().GetNetworkByName(docker.ProxyNetworkName())
+		if err != nil {
+			logger.Warnf("unable to find proxy network %s %v", docker.ProxyNetworkName(), err)
+		} else {
+			extraNetworks = append(extraNetworks, proxyNetwork.ID)
+		}
+	}
+
+	dockerConfig.NetworkID = kraneNetwork.ID
+	dockerConfig.ExtraNetworks = extraNetworks
+	dockerConfig.Aliases = config.Alias
+	dockerConfig.Ports = config.DockerPorts()
+	dockerConfig.PortSet = config.DockerPortSet()
+
+	return dockerConfig
+}
+
+// Routed returns true if a deployment is publicly routed by the network proxy (it has aliases or is the proxy itself)
+func (config Config) Routed() bool {
+	return config.Internal || config.hasAlias()
 }
 
 // DockerEnvs returns a list of formatted Docker environment variables
 func (config Config) DockerEnvs() []string {
-	envs := make([]string, 0)
+	// timezone and locale convenience variables, unless set in the deployment env
+	envs := config.localeEnvs()
 
-	// environment variables sourced from the deployment config
-	for k, v := range config.Env {
-		envs = append(envs, fmt.Sprintf("%s=%s", k, v))
+	// environment variables sourced from the deployment config, sorted so recreated containers get the same env
+	for _, k := range sortedKeys(config.Env) {
+		if config.reservedEnv(k) {
+			continue
+		}
+		envs = append(envs, formatEnv(k, config.Env[k]))
 	}
 
 	// secrets specified in the deployment config which work the same as environment variables
 	// but with resolved values located server side
-	for key, alias := range config.Secrets {
+	for _, key := range sortedKeys(config.Secrets) {
+		if config.reservedEnv(key) {
+			continue
+		}
 		secret, err := GetSecret(config.Name, key)
 		if err != nil || secret == nil {
-			logger.Infof("unable to resolve secret for %s with alias %s", config.Name, alias)
+			logger.Infof("unable to resolve secret for %s with alias %s", config.Name, config.Secrets[key])
 			continue
 		}
-		envs = append(envs, fmt.Sprintf("%s=%s", key, secret.Value))
+		envs = append(envs, formatEnv(key, secret.Value))
 	}
 
 	return envs
@@ -251,20 +582,42 @@ func (config Config) DockerLabels() map[string]string {
 func (config Config) ApplyProxyLabels() {
 	// default traefik labels
 	config.Labels["traefik.enable"] = "true"
-	config.Labels["traefik.docker.network"] = docker.KraneNetworkName
+	config.Labels["traefik.docker.network"] = docker.ProxyNetworkName()
 
 	// router labels
-	for k, v := range proxy.TraefikRouterLabels(config.Name, config.Alias, config.Secure) {
+	for k, v := range proxy.TraefikRouterLabels(config.routerName(), config.Alias, config.TLSEnabled(), config.CertResolver()) {
 		config.Labels[k] = v
 	}
 
 	// middleware labels
-	for k, v := range proxy.TraefikMiddlewareLabels(config.Name, config.Secure, config.RateLimit) {
+	for k, v := range proxy.TraefikMiddlewareLabels(config.routerName(), config.TLSEnabled(), config.RateLimit, config.RateLimitBurst, config.AllowedIPs) {
+		config.Labels[k] = v
+	}
+
+	// observability labels
+	for k, v := range proxy.TraefikObservabilityLabels(config.routerName(), config.AccessLog, proxyVersion()) {
 		config.Labels[k] = v
 	}
 
 	// service labels
-	for k, v := range proxy.TraefikServiceLabels(config.Name, config.Ports, config.TargetPort) {
+	for k, v := range proxy.TraefikServiceLabels(config.serviceName(), config.Ports, config.TargetPort) {
+		config.Labels[k] = v
+	}
+
+	// weighted service labels splitting the traffic between variants
+	if len(config.variantWeights) > 0 {
+		for k, v := range proxy.TraefikWeightedServiceLabels(config.routerName(), config.variantWeights, config.Ports, config.TargetPort, config.TLSEnabled()) {
+			config.Labels[k] = v
+		}
+	}
+
+	// readiness labels
+	for k, v := range config.readinessLabels() {
+		config.Labels[k] = v
+	}
+
+	// blue-green router priority labels
+	for k, v := range config.blueGreenLabels() {
 		config.Labels[k] = v
 	}
 }
@@ -279,6 +632,12 @@ func (config Config) DockerVolumeMount() []mount.Mount {
 			Target: containerVolume,
 		})
 	}
+	for _, m := range config.Mounts {
+		volumes = append(volumes, m.dockerMount())
+	}
+	if localtime, ok := config.localtimeMount(); ok {
+		volumes = append(volumes, localtime)
+	}
 	return volumes
 }
 
@@ -288,15 +647,20 @@ func (config Config) DockerVolumeSet() map[string]struct{} {
 	for _, containerVolume := range config.Volumes {
 		volumes[containerVolume] = struct{}{}
 	}
+	for _, m := range config.Mounts {
+		volumes[m.Target] = struct{}{}
+	}
 	return volumes
 }
 
 // DockerPorts returns Docker formatted port map
 func (config Config) DockerPorts() nat.PortMap {
 	bindings := nat.PortMap{}
-	for hostPort, containerPort := range config.Ports {
-		if hostPort == "" {
-			// randomly assign a host port if no explicit host port to bind to was provided
+	for _, p := range config.portMappings() {
+		hostPort := p.HostPort
+		if hostPort == "" && p.Protocol == TCP {
+			// randomly assign a host port if no explicit host port to bind to was provided,
+			// docker assigns a free port itself for the other protocols
 			freePort, err := findFreePort()
 			if err != nil {
 				logger.Errorf("Error looking for a free port on host machine %v", err)
@@ -305,16 +669,14 @@ func (config Config) DockerPorts() nat.PortMap {
 			hostPort = freePort
 		}
 
-		hostBinding := nat.PortBinding{HostPort: hostPort}
-
-		// TODO: expose functionality for binding to other protocols besides tcp
-		cPort, err := nat.NewPort(string(TCP), containerPort)
+		cPort, err := nat.NewPort(string(p.Protocol), p.ContainerPort)
 		if err != nil {
 			logger.Errorf("Error creating a new container port %v", err)
 			continue
 		}
 
-		bindings[cPort] = []nat.PortBinding{hostBinding}
+		// a container port can be bound to several host ports
+		bindings[cPort] = append(bindings[cPort], nat.PortBinding{HostPort: hostPort})
 	}
 
 	return bindings
@@ -323,8 +685,8 @@ func (config Config) DockerPorts() nat.PortMap {
 // DockerPortSet returns Docker formatted port set
 func (config Config) DockerPortSet() nat.PortSet {
 	bindings := nat.PortSet{}
-	for _, containerPort := range config.Ports {
-		cPort, err := nat.NewPort(string(TCP), containerPort)
+	for _, p := range config.portMappings() {
+		cPort, err := nat.NewPort(string(p.Protocol), p.ContainerPort)
 		if err != nil {
 			logger.Errorf("Error creating a new container port %v", err)
 			continue
@@ -356,5 +718,12 @@ func (config *Config) ResolveRegistryCredentials() error {
 		}
 		config.Registry.Password = secret.Value
 	}
+	if strings.HasPrefix(config.Registry.Token, "@") {
+		secret, err := GetSecret(config.Name, strings.Trim(config.Registry.Token, "@"))
+		if err != nil {
+			return fmt.Errorf("secret \"%s\" not found", config.Registry.Token)
+		}
+		config.Registry.Token = secret.Value
+	}
 	return nil
 }
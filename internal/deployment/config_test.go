@@ -3,6 +3,7 @@ package deployment
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -122,3 +123,108 @@ func TestResolveRegistryCredentials_SecretNotFound(t *testing.T) {
 	err := config.ResolveRegistryCredentials()
 	assert.Error(t, err, "secret \"@TEST_URL\" not found")
 }
+
+func TestMinHealthyDeploymentConfig(t *testing.T) {
+	none, two, four, negative := 0, 2, 4, -1
+	assert.Nil(t, Config{Name: "example", Image: "biensupernice/krane", Scale: 3, MinHealthy: &two}.isValid())
+	assert.Nil(t, Config{Name: "example", Image: "biensupernice/krane", Scale: 3, MinHealthy: &none}.isValid())
+	assert.Error(t, Config{Name: "example", Image: "biensupernice/krane", Scale: 3, MinHealthy: &four}.isValid())
+	assert.Error(t, Config{Name: "example", Image: "biensupernice/krane", Scale: 3, MinHealthy: &negative}.isValid())
+
+	assert.Equal(t, 3, Config{Scale: 3}.MinHealthyContainers())
+	assert.Equal(t, 2, Config{Scale: 3, MinHealthy: &two}.MinHealthyContainers())
+	assert.Equal(t, 0, Config{Scale: 3, MinHealthy: &none}.MinHealthyContainers())
+
+	config, err := DeSerializeConfig([]byte(`{"name":"worker","scale":3,"min_healthy":0}`))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, config.MinHealthyContainers())
+}
+
+func TestContainerStopTimeout(t *testing.T) {
+	immediate, drain := uint(0), uint(300)
+	assert.Equal(t, DefaultContainerStopTimeout, Config{}.ContainerStopTimeout())
+	assert.Equal(t, time.Duration(0), Config{StopTimeout: &immediate}.ContainerStopTimeout())
+	assert.Equal(t, 5*time.Minute, Config{StopTimeout: &drain}.ContainerStopTimeout())
+
+	config, err := DeSerializeConfig([]byte(`{"name":"worker","stop_timeout":0}`))
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), config.ContainerStopTimeout())
+}
+
+func TestShmSize(t *testing.T) {
+	assert.Equal(t, int64(0), Config{}.ShmSizeBytes())
+	assert.Equal(t, int64(256*1024*1024), Config{ShmSize: "256mb"}.ShmSizeBytes())
+	assert.Empty(t, Config{ShmSize: "1g"}.shmSizeFieldErrors())
+
+	errs := Config{ShmSize: "lots"}.shmSizeFieldErrors()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "shm_size", errs[0].Field)
+}
+
+func TestRouted(t *testing.T) {
+	assert.False(t, Config{Name: "worker"}.Routed())
+	assert.False(t, Config{Name: "worker", Alias: []string{""}}.Routed())
+	assert.True(t, Config{Name: "app", Alias: []string{"app.example.com"}}.Routed())
+	assert.True(t, Config{Name: "krane-proxy", Internal: true}.Routed())
+}
+
+func TestPlatformConfig(t *testing.T) {
+	assert.Nil(t, Config{Name: "platform-app", Image: "nginx", Platform: "linux/arm64"}.isValid())
+	assert.Nil(t, Config{Name: "platform-app", Image: "nginx", Platform: "linux/arm/v7"}.isValid())
+	assert.Error(t, Config{Name: "platform-app", Image: "nginx", Platform: "arm64"}.isValid())
+	assert.Error(t, Config{Name: "platform-app", Image: "nginx", Platform: "Linux/ARM64"}.isValid())
+
+	assert.Equal(t, "linux/arm", Config{Platform: "linux/arm/v7"}.osArch())
+	assert.Equal(t, "linux/amd64", Config{Platform: "linux/amd64"}.osArch())
+}
+
+func TestNetworkModeConfig(t *testing.T) {
+	assert.Empty(t, Config{}.networkModeFieldErrors())
+	assert.Empty(t, Config{NetworkMode: "host"}.networkModeFieldErrors())
+	assert.Empty(t, Config{NetworkMode: "none"}.networkModeFieldErrors())
+	assert.Len(t, Config{NetworkMode: "bridge"}.networkModeFieldErrors(), 1)
+	assert.Len(t, Config{NetworkMode: "none", HealthCheck: HealthCheck{Port: "8080"}}.networkModeFieldErrors(), 1)
+
+	noUser := func(Config) (string, bool) { return "", false }
+	routed := Config{Name: "host-app", Image: "nginx", NetworkMode: "host", Alias: []string{"app.example.com"}}
+	assert.Contains(t, lintRules(routed.lint(noUser)), "unroutable-network-mode")
+	routed.NetworkMode = ""
+	assert.NotContains(t, lintRules(routed.lint(noUser)), "unroutable-network-mode")
+}
+
+func TestScaleDeploymentConfig(t *testing.T) {
+	assert.Nil(t, Config{Name: "example", Image: "biensupernice/krane", Scale: 0}.isValid())
+	assert.Error(t, Config{Name: "example", Image: "biensupernice/krane", Scale: -1}.isValid())
+}
+
+func TestContainerName(t *testing.T) {
+	single := Config{Name: "example", Scale: 1}
+	assert.Regexp(t, `^example-[a-zA-Z0-9]+$`, single.containerName(0))
+	assert.NotEqual(t, single.containerName(0), single.containerName(0))
+
+	assert.Regexp(t, `^example-2-[a-zA-Z0-9]+$`, Config{Name: "example", Scale: 3}.containerName(2))
+}
+
+func TestRateLimitLabels(t *testing.T) {
+	limited := Config{Name: "limited", Alias: []string{"app.example.com"}, RateLimit: 100, RateLimitBurst: 50, Labels: map[string]string{}}
+	labels := limited.DockerLabels()
+	assert.Equal(t, "100", labels["traefik.http.middlewares.limited-ratelimit.ratelimit.average"])
+	assert.Equal(t, "50", labels["traefik.http.middlewares.limited-ratelimit.ratelimit.burst"])
+	assert.Equal(t, "limited-ratelimit", labels["traefik.http.routers.limited-insecure.middlewares"])
+
+	// secure deployments redirect http requests, https requests are rate limited
+	secure := Config{Name: "secure", Alias: []string{"app.example.com"}, Secure: true, RateLimit: 10, Labels: map[string]string{}}
+	labels = secure.DockerLabels()
+	assert.Equal(t, "redirect-to-https,secure-ratelimit", labels["traefik.http.routers.secure-insecure.middlewares"])
+	assert.Equal(t, "secure-ratelimit", labels["traefik.http.routers.secure-secure.middlewares"])
+	assert.NotContains(t, labels, "traefik.http.middlewares.secure-ratelimit.ratelimit.burst")
+
+	// no rate limit labels are emitted without a rate limit
+	unlimited := Config{Name: "unlimited", Alias: []string{"app.example.com"}, Labels: map[string]string{}}
+	for k := range unlimited.DockerLabels() {
+		assert.NotContains(t, k, "ratelimit")
+		assert.NotContains(t, k, "middlewares")
+	}
+
+	assert.Len(t, Config{Name: "burst", Image: "nginx", RateLimitBurst: 5}.fieldErrors(), 1)
+}
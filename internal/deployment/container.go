@@ -3,11 +3,15 @@ package deployment
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/docker/docker/api/types"
 
 	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/logger"
 )
 
 // KraneContainer represents a Krane managed container
@@ -19,6 +23,7 @@ type KraneContainer struct {
 	Image      string            `json:"image"`
 	ImageID    string            `json:"image_id"`
 	CreatedAt  int64             `json:"created_at"`
+	Restarts   int               `json:"restarts"` // times the container was restarted by its docker restart policy
 	Labels     map[string]string `json:"labels"`
 	State      ContainerState    `json:"state"`
 	Ports      []Port            `json:"ports"`
@@ -51,12 +56,9 @@ const (
 	ContainerCreated ContainerStatus = "created"
 )
 
-// ContainerCreate creates a docker container from a deployment config
-func ContainerCreate(config Config) (KraneContainer, error) {
-	ctx := context.Background()
-	defer ctx.Done()
-
-	mappedConfig := config.DockerConfig()
+// ContainerCreate creates the docker container at an index of a deployment from the deployment config
+func ContainerCreate(ctx context.Context, config Config, index int) (KraneContainer, error) {
+	mappedConfig := config.DockerConfig(index)
 	body, err := docker.GetClient().CreateContainer(ctx, mappedConfig)
 	if err != nil {
 		return KraneContainer{}, err
@@ -66,34 +68,46 @@ func ContainerCreate(config Config) (KraneContainer, error) {
 	// about the resources it created, we need to inspect the containers for full details
 	json, err := docker.GetClient().GetOneContainer(ctx, body.ID)
 	if err != nil {
-		return KraneContainer{}, err
+		return KraneContainer{}, fmt.Errorf("unable to inspect created container %s, %w", mappedConfig.ContainerName, err)
 	}
 
 	return fromDockerContainerToKcontainer(json), nil
 }
 
 // Start starts a Krane managed Docker Container
-func (c KraneContainer) Start() error {
-	ctx := context.Background()
-	defer ctx.Done()
-
+func (c KraneContainer) Start(ctx context.Context) error {
 	return docker.GetClient().StartContainer(ctx, c.ID)
 }
 
-// Stop stops a Krane managed Docker Container
-func (c KraneContainer) Stop() error {
-	ctx := context.Background()
-	defer ctx.Done()
+// Stop stops a Krane managed Docker Container, killing it if it did not exit within the stop timeout
+func (c KraneContainer) Stop(ctx context.Context, timeout time.Duration) error {
+	return docker.GetClient().StopContainer(ctx, c.ID, timeout)
+}
 
-	return docker.GetClient().StopContainer(ctx, c.ID)
+// Restart restarts a Krane managed Docker Container, the container is given the stop timeout to exit before it is killed
+func (c KraneContainer) Restart(ctx context.Context, timeout time.Duration) error {
+	return docker.GetClient().RestartContainer(ctx, c.ID, timeout)
 }
 
-// Remove removes a Krane managed Docker container
-func (c KraneContainer) Remove() error {
-	ctx := context.Background()
-	defer ctx.Done()
+// Remove removes a Krane managed Docker container, force removes the container even if it is running
+func (c KraneContainer) Remove(ctx context.Context, force bool) error {
+	return docker.GetClient().RemoveContainer(ctx, c.ID, force)
+}
 
-	return docker.GetClient().RemoveContainer(ctx, c.ID, true)
+// StopAndRemove stops a Krane managed Docker container within the stop timeout before removing it,
+// falling back to force removing the container if it does not stop cleanly
+func (c KraneContainer) StopAndRemove(ctx context.Context, timeout time.Duration) error {
+	if err := c.Stop(ctx, timeout); err != nil {
+		logger.Warnf("container %s did not stop cleanly, force removing it: %v", c.Name, err)
+		return c.Remove(ctx, true)
+	}
+
+	if err := c.Remove(ctx, false); err != nil {
+		logger.Warnf("unable to remove stopped container %s, force removing it: %v", c.Name, err)
+		return c.Remove(ctx, true)
+	}
+
+	return nil
 }
 
 // fromDockerContainerToKcontainer converts a docker container into a KraneContainer
@@ -106,14 +120,21 @@ func fromDockerContainerToKcontainer(container types.ContainerJSON) KraneContain
 	ports := fromPortMapToPortList(container.NetworkSettings.Ports)
 	volumes := fromMountPointToVolumeList(container.Mounts)
 
+	// containers with a host or none network mode are not attached to the krane network
+	networkID := ""
+	if endpoint, ok := container.NetworkSettings.Networks[docker.KraneNetworkName]; ok && endpoint != nil {
+		networkID = endpoint.NetworkID
+	}
+
 	return KraneContainer{
 		ID:         container.ID,
 		Deployment: container.Config.Labels[docker.ContainerDeploymentLabel],
-		Name:       container.Config.Hostname,
-		NetworkID:  container.NetworkSettings.Networks[docker.KraneNetworkName].NetworkID,
+		Name:       strings.TrimPrefix(container.Name, "/"),
+		NetworkID:  networkID,
 		Image:      container.Config.Image,
 		ImageID:    container.ContainerJSONBase.Image,
 		CreatedAt:  createdAt.Unix(),
+		Restarts:   container.RestartCount,
 		Labels:     container.Config.Labels,
 		State:      state,
 		Ports:      ports,
@@ -146,14 +167,21 @@ func GetContainers() ([]KraneContainer, error) {
 	ctx := context.Background()
 	defer ctx.Done()
 
-	allContainers, err := docker.GetClient().GetAllContainers(&ctx)
+	return listContainers(ctx, map[string]string{docker.ContainerDeploymentLabel: ""})
+}
+
+// listContainers returns the Krane managed containers (running or stopped) matching a set of labels
+func listContainers(ctx context.Context, labels map[string]string) ([]KraneContainer, error) {
+	dockerContainers, err := docker.GetClient().ListContainers(ctx, docker.ListContainersOptions{
+		All:    true,
+		Labels: labels,
+	})
 	if err != nil {
 		return make([]KraneContainer, 0), err
 	}
 
-	// filter for Krane managed containers
 	containers := make([]KraneContainer, 0)
-	for _, container := range allContainers {
+	for _, container := range dockerContainers {
 		if isKraneManagedContainer(container) {
 			containers = append(containers, fromDockerContainerToKcontainer(container))
 		}
@@ -169,56 +197,201 @@ func isKraneManagedContainer(container types.ContainerJSON) bool {
 
 // GetContainersByDeployment get containers filtered by deployment
 func GetContainersByDeployment(deployment string) ([]KraneContainer, error) {
-	allContainers, err := GetContainers()
+	ctx := context.Background()
+	defer ctx.Done()
+
+	return listContainers(ctx, map[string]string{docker.ContainerDeploymentLabel: deployment})
+}
+
+// DeploymentContainers are the containers of a single deployment
+type DeploymentContainers struct {
+	Deployment string           `json:"deployment"`
+	Containers []KraneContainer `json:"containers"`
+}
+
+// GetContainersGroupedByDeployment returns all Krane managed containers (running or stopped) grouped by deployment
+func GetContainersGroupedByDeployment() ([]DeploymentContainers, error) {
+	containers, err := GetContainers()
 	if err != nil {
-		return make([]KraneContainer, 0), err
+		return make([]DeploymentContainers, 0), err
 	}
 
-	// filter by deployment
-	containers := make([]KraneContainer, 0)
-	for _, container := range allContainers {
-		if deployment == container.Deployment {
-			containers = append(containers, container)
+	groups := make(map[string][]KraneContainer)
+	for _, c := range containers {
+		groups[c.Deployment] = append(groups[c.Deployment], c)
+	}
+
+	grouped := make([]DeploymentContainers, 0, len(groups))
+	for deployment, containers := range groups {
+		grouped = append(grouped, DeploymentContainers{Deployment: deployment, Containers: containers})
+	}
+
+	sort.Slice(grouped, func(i, j int) bool { return grouped[i].Deployment < grouped[j].Deployment })
+
+	return grouped, nil
+}
+
+// GetContainerByIndex returns the container at a given index for a deployment. Containers are
+// ordered by creation time so an index refers to the same container across requests.
+func GetContainerByIndex(deployment string, index int) (KraneContainer, error) {
+	containers, err := GetContainersByDeployment(deployment)
+	if err != nil {
+		return KraneContainer{}, err
+	}
+
+	if index < 0 || index >= len(containers) {
+		return KraneContainer{}, fmt.Errorf("container index %d out of range for deployment %s", index, deployment)
+	}
+
+	sort.SliceStable(containers, func(i, j int) bool {
+		if containers[i].CreatedAt == containers[j].CreatedAt {
+			return containers[i].Name < containers[j].Name
 		}
+		return containers[i].CreatedAt < containers[j].CreatedAt
+	})
+
+	return containers[index], nil
+}
+
+// maxConcurrentHealthChecks is the max number of containers of a deployment health checked at once
+const maxConcurrentHealthChecks = 10
+
+// healthCheckBackoff is the delay added between each health check attempt of a container
+const healthCheckBackoff = 10 * time.Second
+
+// healthCheckPolicy is how many times and how often a container is probed before it is considered unhealthy
+type healthCheckPolicy struct {
+	Retries  int           // attempts after the first one
+	Backoff  time.Duration // delay added between each attempt, the delay grows linearly with the attempts
+	Interval time.Duration // fixed delay between attempts, overrides the backoff when set
+}
+
+// delay returns how long to wait before an attempt, the first attempt (0) is not delayed
+func (p healthCheckPolicy) delay(attempt int) time.Duration {
+	if attempt == 0 {
+		return 0
+	}
+	if p.Interval > 0 {
+		return p.Interval
 	}
+	return p.Backoff * time.Duration(attempt)
+}
 
-	return containers, nil
+// UnhealthyContainerError is reported for a container that did not pass its health check within its retries
+type UnhealthyContainerError struct {
+	Container string
+	Attempts  int
+	Err       error // error of the last attempt
 }
 
-// RetriableContainersHealthCheck returns an error if a container is considered unhealthy
-func RetriableContainersHealthCheck(containers []KraneContainer, retries int) error {
-	for _, c := range containers {
-		for i := 0; i <= retries; i++ {
-			expBackOff := time.Duration(10 * i)
-			time.Sleep(expBackOff * time.Second)
-
-			isRunning, err := c.Running()
-			if err != nil {
-				if i == retries {
-					return fmt.Errorf("container is not healthy %v", err)
-				}
-				continue
-			}
+// Error returns a string representation of an UnhealthyContainerError
+func (e UnhealthyContainerError) Error() string {
+	return fmt.Sprintf("container %s unhealthy after %d attempt(s), %v", e.Container, e.Attempts, e.Err)
+}
+
+// RetriableContainersHealthCheck returns an error if less than minHealthy containers are considered healthy.
+// Every container is checked even after minHealthy is reached so that all replicas are given a chance to come up.
+// Containers are checked concurrently so the health check takes about as long as the slowest container.
+func RetriableContainersHealthCheck(ctx context.Context, config Config, containers []KraneContainer, minHealthy int, retries int) error {
+	policy := config.HealthCheck.policy()
+	policy.Retries = retries
+
+	unhealthy, err := checkContainersHealth(ctx, containers, policy, config.healthProbe())
+	if err != nil {
+		return err
+	}
+
+	healthy := len(containers) - len(unhealthy)
+	if healthy < minHealthy {
+		return fmt.Errorf("%d/%d container(s) healthy, %d required, %s",
+			healthy, len(containers), minHealthy, joinErrors(unhealthy))
+	}
 
-			if !isRunning {
-				if i == retries {
-					return fmt.Errorf("container is not healthy %v", err)
-				}
-				continue
+	return nil
+}
+
+// checkContainersHealth probes containers concurrently (up to maxConcurrentHealthChecks at once) until each
+// passes the probe or is out of retries, returning the unhealthy containers in the order they were provided
+func checkContainersHealth(
+	ctx context.Context,
+	containers []KraneContainer,
+	policy healthCheckPolicy,
+	probe func(KraneContainer, context.Context) (bool, error)) ([]UnhealthyContainerError, error) {
+	results := make([]error, len(containers))
+	gate := make(chan struct{}, maxConcurrentHealthChecks)
+
+	var wg sync.WaitGroup
+	for i, c := range containers {
+		wg.Add(1)
+		go func(i int, c KraneContainer) {
+			defer wg.Done()
+
+			select {
+			case gate <- struct{}{}:
+				defer func() { <-gate }()
+			case <-ctx.Done():
+				return
 			}
 
-			// if reached here container in a running state
-			break
+			results[i] = checkContainerHealth(ctx, c, policy, probe)
+		}(i, c)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("health check aborted %v", ctx.Err())
+	}
+
+	unhealthy := make([]UnhealthyContainerError, 0)
+	for i, c := range containers {
+		if results[i] != nil {
+			unhealthy = append(unhealthy, UnhealthyContainerError{Container: c.Name, Attempts: policy.Retries + 1, Err: results[i]})
 		}
 	}
-	return nil
+	return unhealthy, nil
 }
 
-// Running returns whether a container is in a running state
-func (c KraneContainer) Running() (bool, error) {
-	ctx := context.Background()
-	defer ctx.Done()
+// checkContainerHealth returns nil once a container passes the probe, or the error of the last attempt
+func checkContainerHealth(
+	ctx context.Context,
+	c KraneContainer,
+	policy healthCheckPolicy,
+	probe func(KraneContainer, context.Context) (bool, error)) error {
+	var lastErr error
+	for i := 0; i <= policy.Retries; i++ {
+		select {
+		case <-time.After(policy.delay(i)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 
+		healthy, err := probe(c, ctx)
+		if err == nil && healthy {
+			return nil
+		}
+
+		lastErr = err
+		if lastErr == nil {
+			lastErr = fmt.Errorf("container %s did not pass the probe", c.Name)
+		}
+		logger.Debugf("container %s health check attempt %d/%d failed, %v", c.Name, i+1, policy.Retries+1, lastErr)
+	}
+
+	logger.Warnf("container %s is not healthy %v", c.Name, lastErr)
+	return lastErr
+}
+
+// joinErrors returns the errors of unhealthy containers separated by semicolons
+func joinErrors(unhealthy []UnhealthyContainerError) string {
+	messages := make([]string, 0, len(unhealthy))
+	for _, u := range unhealthy {
+		messages = append(messages, u.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Running returns whether a container is in a running state
+func (c KraneContainer) Running(ctx context.Context) (bool, error) {
 	resp, err := docker.GetClient().GetOneContainer(ctx, c.ID)
 	if err != nil {
 		return false, err
@@ -230,3 +403,44 @@ func (c KraneContainer) Running() (bool, error) {
 
 	return false, fmt.Errorf("container %s is not in running state", c.ID)
 }
+
+// ContainerChange represents a filesystem change in a container relative to its image
+type ContainerChange struct {
+	Kind string `json:"kind"` // added, modified, deleted
+	Path string `json:"path"`
+}
+
+// Changes returns the filesystem changes of a container relative to its image
+func (c KraneContainer) Changes() ([]ContainerChange, error) {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	dockerChanges, err := docker.GetClient().GetContainerChanges(ctx, c.ID)
+	if err != nil {
+		return make([]ContainerChange, 0), err
+	}
+
+	changes := make([]ContainerChange, 0)
+	for _, change := range dockerChanges {
+		changes = append(changes, ContainerChange{
+			Kind: fromDockerChangeKind(change.Kind),
+			Path: change.Path,
+		})
+	}
+
+	return changes, nil
+}
+
+// fromDockerChangeKind converts a docker change kind (0: modified, 1: added, 2: deleted) into a readable kind
+func fromDockerChangeKind(kind int) string {
+	switch kind {
+	case 0:
+		return "modified"
+	case 1:
+		return "added"
+	case 2:
+		return "deleted"
+	default:
+		return "unknown"
+	}
+}
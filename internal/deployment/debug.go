@@ -0,0 +1,125 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/utils"
+)
+
+// DefaultDebugImage is the image of debug containers when none is provided, it ships a full networking and debugging toolset
+const DefaultDebugImage = "nicolaka/netshoot"
+
+// debugOutputBufferSize is the max size of a shell output message sent to a debug client
+const debugOutputBufferSize = 32 * 1024
+
+// DebugContainer launches a debug container sharing the network and pid namespaces of a deployment container
+// and streams its shell to a websocket client: client messages are written to the shell stdin and the shell
+// output is sent as binary messages. The debug container is removed once the client disconnects or the shell exits.
+// Debug containers are useful for images without a shell (ie. distroless), the debug image brings its own tools.
+func DebugContainer(client *websocket.Conn, target KraneContainer, image string) {
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if image == "" {
+		image = DefaultDebugImage
+	}
+
+	if !target.State.Running {
+		writeDebugError(client, fmt.Errorf("container %s is not running", target.Name))
+		return
+	}
+
+	reader, err := docker.GetClient().PullImage(ctx, image, docker.RegistryCredentials{})
+	if err != nil {
+		writeDebugError(client, fmt.Errorf("unable to pull debug image %s, %v", image, err))
+		return
+	}
+	_, err = io.Copy(ioutil.Discard, reader)
+	_ = reader.Close()
+	if err != nil {
+		writeDebugError(client, fmt.Errorf("unable to pull debug image %s, %v", image, err))
+		return
+	}
+
+	name := fmt.Sprintf("%s-debug-%s", target.Name, utils.ShortID())
+	id, err := docker.GetClient().CreateDebugContainer(ctx, name, image, target.ID)
+	if err != nil {
+		writeDebugError(client, err)
+		return
+	}
+
+	// the debug container is force removed even if the shell is still running
+	defer func() {
+		if err := docker.GetClient().RemoveContainer(context.Background(), id, true); err != nil {
+			logger.Debugf("unable to remove debug container %s, %v", name, err)
+		}
+	}()
+
+	// attaching before starting the shell ensures no output is missed
+	shell, err := docker.GetClient().AttachContainer(ctx, id)
+	if err != nil {
+		writeDebugError(client, fmt.Errorf("unable to attach to debug container %s, %v", name, err))
+		return
+	}
+	defer shell.Close()
+
+	if err := docker.GetClient().StartContainer(ctx, id); err != nil {
+		writeDebugError(client, fmt.Errorf("unable to start debug container %s, %v", name, err))
+		return
+	}
+
+	logger.Infof("debug container %s started for container %s with image %s", name, target.Name, image)
+	streamDebugShell(client, shell.Conn, shell.Reader)
+	logger.Infof("debug container %s for container %s disconnected", name, target.Name)
+}
+
+// streamDebugShell copies websocket messages to the shell input and the shell output to the websocket until either side closes
+func streamDebugShell(client *websocket.Conn, input io.Writer, output io.Reader) {
+	done := make(chan struct{}, 2)
+
+	go func() {
+		defer func() { done <- struct{}{} }()
+		for {
+			_, msg, err := client.ReadMessage()
+			if err != nil {
+				return
+			}
+			if _, err := input.Write(msg); err != nil {
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer func() { done <- struct{}{} }()
+		buf := make([]byte, debugOutputBufferSize)
+		for {
+			n, err := output.Read(buf)
+			if n > 0 {
+				if err := client.WriteMessage(websocket.BinaryMessage, buf[:n]); err != nil {
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	<-done
+}
+
+// writeDebugError reports an error launching a debug container to the client before the connection is closed
+func writeDebugError(client *websocket.Conn, err error) {
+	logger.Warnf("unable to debug container, %v", err)
+	_ = client.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("error: %v", err)))
+}
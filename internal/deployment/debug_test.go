@@ -0,0 +1,54 @@
+package deployment
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamDebugShell(t *testing.T) {
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+
+	done := make(chan struct{})
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		streamDebugShell(conn, stdinWriter, stdoutReader)
+		close(done)
+	}))
+	defer server.Close()
+
+	conn := dial(t, server)
+	defer conn.Close()
+
+	// client messages are written to the shell stdin
+	assert.Nil(t, conn.WriteMessage(websocket.TextMessage, []byte("ls\n")))
+	buf := make([]byte, 3)
+	_, err := io.ReadFull(stdinReader, buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "ls\n", string(buf))
+
+	// shell output is sent to the client as binary messages
+	go stdoutWriter.Write([]byte("bin etc\n"))
+	msgType, msg, err := conn.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, websocket.BinaryMessage, msgType)
+	assert.Equal(t, "bin etc\n", string(msg))
+
+	// the stream ends once the shell exits
+	_ = stdoutWriter.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the stream to end when the shell exits")
+	}
+}
@@ -0,0 +1,182 @@
+package deployment
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/store"
+)
+
+const defaultsKey = "defaults"
+
+// GetDefaults returns the server-level default configuration merged under every deployment configuration
+func GetDefaults() (Config, error) {
+	bytes, err := store.Client().Get(constants.SettingsCollectionName, defaultsKey)
+	if err != nil {
+		return Config{}, err
+	}
+
+	if bytes == nil {
+		return Config{}, nil
+	}
+
+	return DeSerializeConfig(bytes)
+}
+
+// SaveDefaults stores the server-level default configuration. Defaults are applied
+// to a deployment the next time its configuration is saved.
+func SaveDefaults(defaults Config) error {
+	if defaults.Name != "" {
+		return errors.New("default configuration cannot set a deployment name")
+	}
+
+	if defaults.Digest != "" {
+		return errors.New("default configuration cannot pin an image digest")
+	}
+
+	if fields := defaults.deploymentSpecificFields(); len(fields) > 0 {
+		return fmt.Errorf("default configuration cannot set %s, specific to each deployment", strings.Join(fields, ", "))
+	}
+
+	// defaults returned by the api have their registry credentials masked
+	if stored, err := GetDefaults(); err == nil {
+		defaults.Registry = defaults.Registry.withStoredCredentials(stored.Registry)
+	}
+
+	bytes, _ := defaults.Serialize()
+	return store.Client().Put(constants.SettingsCollectionName, defaultsKey, bytes)
+}
+
+// deploymentSpecificFields returns the fields set in a configuration that only make sense for a single deployment,
+// ie. an alias or a fixed host port shared by every deployment would conflict
+func (config Config) deploymentSpecificFields() []string {
+	fields := make([]string, 0)
+	if len(config.Alias) > 0 {
+		fields = append(fields, "alias")
+	}
+	if config.Internal {
+		fields = append(fields, "internal")
+	}
+	if len(config.Labels) > 0 {
+		fields = append(fields, "labels")
+	}
+	if len(config.Ports) > 0 {
+		fields = append(fields, "ports")
+	}
+	if len(config.PortMappings) > 0 {
+		fields = append(fields, "port_mappings")
+	}
+	if config.Hostname != "" {
+		fields = append(fields, "hostname")
+	}
+	if len(config.Variants) > 0 {
+		fields = append(fields, "variants")
+	}
+	return fields
+}
+
+// UnmarshalJSON decodes a deployment configuration and records the fields set in the json,
+// so values set explicitly (including false, 0 or an empty list) are not replaced by the defaults
+func (config *Config) UnmarshalJSON(data []byte) error {
+	type plainConfig Config
+	if err := json.Unmarshal(data, (*plainConfig)(config)); err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	config.fields = fields
+	return nil
+}
+
+// applyGlobalDefaults merges the server-level default configuration under a deployment configuration
+func (config *Config) applyGlobalDefaults() {
+	defaults, err := GetDefaults()
+	if err != nil {
+		logger.Errorf("unable to get the default deployment configuration %v", err)
+		return
+	}
+
+	mergeDefaults(reflect.ValueOf(config).Elem(), reflect.ValueOf(defaults), config.fields)
+}
+
+// mergeDefaults sets every unset field in dst to its value in defaults. A field is set when it is in the json fields
+// dst was decoded from, or when it is not zero-valued. Struct fields are merged field by field and maps are merged
+// key by key, explicit values in dst always win. The registry is merged as a whole, its credentials only belong
+// to its url. Fields are nil when dst was not decoded from json.
+func mergeDefaults(dst, defaults reflect.Value, fields map[string]json.RawMessage) {
+	for i := 0; i < dst.NumField(); i++ {
+		structField := dst.Type().Field(i)
+		if structField.PkgPath != "" {
+			continue
+		}
+
+		field := dst.Field(i)
+		value := defaults.Field(i)
+		raw, present := fields[jsonFieldName(structField)]
+
+		switch field.Kind() {
+		case reflect.Struct:
+			nested := nestedFields(fields, raw, present)
+			if field.Type() == reflect.TypeOf(Registry{}) {
+				// a deployment setting any registry field never sends the default credentials to its own registry
+				if len(nested) == 0 && field.IsZero() {
+					field.Set(value)
+				}
+				continue
+			}
+			mergeDefaults(field, value, nested)
+		case reflect.Map:
+			if value.Len() == 0 {
+				continue
+			}
+			if field.IsNil() {
+				field.Set(reflect.MakeMapWithSize(field.Type(), value.Len()))
+			}
+			iter := value.MapRange()
+			for iter.Next() {
+				if !field.MapIndex(iter.Key()).IsValid() {
+					field.SetMapIndex(iter.Key(), iter.Value())
+				}
+			}
+		case reflect.Slice:
+			if !present && field.Len() == 0 && value.Len() > 0 {
+				field.Set(reflect.AppendSlice(reflect.MakeSlice(field.Type(), 0, value.Len()), value))
+			}
+		default:
+			if !present && field.IsZero() {
+				field.Set(value)
+			}
+		}
+	}
+}
+
+// jsonFieldName returns the name of a struct field in json
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// nestedFields returns the json fields set in a struct field, nil when the parent fields are unknown. A struct field
+// missing from the json (or not an object) has no field set.
+func nestedFields(fields map[string]json.RawMessage, raw json.RawMessage, present bool) map[string]json.RawMessage {
+	if fields == nil {
+		return nil
+	}
+
+	nested := make(map[string]json.RawMessage)
+	if present {
+		_ = json.Unmarshal(raw, &nested)
+	}
+	return nested
+}
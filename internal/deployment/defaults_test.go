@@ -0,0 +1,106 @@
+package deployment
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/store"
+)
+
+func TestApplyGlobalDefaults(t *testing.T) {
+	defer store.Client().Remove(constants.SettingsCollectionName, defaultsKey)
+
+	err := SaveDefaults(Config{
+		Tag:      "stable",
+		Scale:    2,
+		Env:      map[string]string{"TEAM": "platform", "TIER": "web"},
+		Tags:     []string{"team-web"},
+		Registry: Registry{URL: "ghcr.io"},
+	})
+	assert.Nil(t, err)
+
+	config := Config{
+		Name:  "defaults-app",
+		Image: "biensupernice/krane",
+		Scale: 3,
+		Env:   map[string]string{"TIER": "api"},
+	}
+	config.applyDefaults()
+
+	assert.Equal(t, "stable", config.Tag)
+	assert.Equal(t, 3, config.Scale)
+	assert.Equal(t, map[string]string{"TEAM": "platform", "TIER": "api"}, config.Env)
+	assert.Equal(t, []string{"team-web"}, config.Tags)
+	assert.Equal(t, "ghcr.io", config.Registry.URL)
+	assert.Equal(t, "defaults-app", config.Name)
+}
+
+func TestGlobalDefaultsKeepExplicitZeroValues(t *testing.T) {
+	defer store.Client().Remove(constants.SettingsCollectionName, defaultsKey)
+
+	retries := uint(3)
+	assert.Nil(t, SaveDefaults(Config{
+		Scale:       2,
+		Init:        true,
+		Tags:        []string{"team-web"},
+		HealthCheck: HealthCheck{Retries: &retries, Interval: 5},
+	}))
+
+	var config Config
+	assert.Nil(t, json.Unmarshal([]byte(`{"name": "explicit-app", "image": "nginx", "init": false, "tags": [], "health_check": {"retries": 0}}`), &config))
+	config.applyDefaults()
+
+	assert.False(t, config.Init)
+	assert.Equal(t, 2, config.Scale)
+	assert.Empty(t, config.Tags)
+	assert.Equal(t, 0, config.HealthCheck.retries())
+	assert.Equal(t, uint(5), config.HealthCheck.Interval)
+}
+
+func TestGlobalDefaultsRegistryMergedAsAWhole(t *testing.T) {
+	defer store.Client().Remove(constants.SettingsCollectionName, defaultsKey)
+
+	assert.Nil(t, SaveDefaults(Config{Registry: Registry{URL: "ghcr.io", Username: "bot", Password: "s3cret"}}))
+
+	// a deployment setting its own registry url does not inherit the default credentials
+	var config Config
+	assert.Nil(t, json.Unmarshal([]byte(`{"name": "other-registry-app", "image": "nginx", "registry": {"url": "quay.io"}}`), &config))
+	config.applyDefaults()
+	assert.Equal(t, Registry{URL: "quay.io"}, config.Registry)
+
+	config = Config{Name: "other-registry-app", Image: "nginx", Registry: Registry{URL: "quay.io"}}
+	config.applyDefaults()
+	assert.Equal(t, Registry{URL: "quay.io"}, config.Registry)
+
+	// a deployment without any registry field inherits the default registry
+	config = Config{}
+	assert.Nil(t, json.Unmarshal([]byte(`{"name": "default-registry-app", "image": "nginx"}`), &config))
+	config.applyDefaults()
+	assert.Equal(t, Registry{URL: "ghcr.io", Username: "bot", Password: "s3cret"}, config.Registry)
+}
+
+func TestSaveDefaultsRejectsName(t *testing.T) {
+	assert.Error(t, SaveDefaults(Config{Name: "app"}))
+	assert.Error(t, SaveDefaults(Config{Digest: "sha256:abc"}))
+}
+
+func TestSaveDefaultsRejectsDeploymentSpecificFields(t *testing.T) {
+	assert.Error(t, SaveDefaults(Config{Alias: []string{"default.localhost"}}))
+	assert.Error(t, SaveDefaults(Config{Internal: true}))
+	assert.Error(t, SaveDefaults(Config{Labels: map[string]string{"team": "platform"}}))
+	assert.Error(t, SaveDefaults(Config{Ports: map[string]string{"8080": "80"}}))
+}
+
+func TestNoGlobalDefaults(t *testing.T) {
+	defaults, err := GetDefaults()
+	assert.Nil(t, err)
+	assert.Equal(t, Config{}, defaults)
+
+	config := Config{Name: "no-defaults", Image: "biensupernice/krane"}
+	config.applyDefaults()
+	assert.Equal(t, "latest", config.Tag)
+	assert.Equal(t, "docker.io", config.Registry.URL)
+}
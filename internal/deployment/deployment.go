@@ -1,7 +1,10 @@
 package deployment
 
 import (
+	"context"
 	"fmt"
+	"time"
+
 	"github.com/docker/distribution/uuid"
 
 	"github.com/krane/krane/internal/constants"
@@ -11,11 +14,25 @@ import (
 	"github.com/krane/krane/internal/utils"
 )
 
+// ProxyDrainDelay is how long the containers of a deleted deployment are given for the proxy to deregister them
+// once they are disconnected from the proxy network, before they are stopped
+const ProxyDrainDelay = 5 * time.Second
+
 // Deployment represent a Krane deployment and its configuration, current container resources, and job history
 type Deployment struct {
-	Config     Config           `json:"config"`
-	Containers []KraneContainer `json:"containers"`
-	Jobs       []job.Job        `json:"jobs"`
+	Config     Config            `json:"config"`
+	Containers []KraneContainer  `json:"containers"`
+	Jobs       []job.Job         `json:"jobs"`
+	Routing    *RoutingStatus    `json:"routing,omitempty"`  // only set for deployments routed by the network proxy
+	Deferred   *DeferredRun      `json:"deferred,omitempty"` // automated run waiting for the deploy window to open
+	Staged     *StagedRun        `json:"staged,omitempty"`   // run whose containers were created without being started
+	Schedule   *job.RecurringJob `json:"schedule,omitempty"` // cron schedule running an action on the deployment
+}
+
+// Redacted returns the deployment with the registry credentials of its configuration masked, for api responses
+func (d Deployment) Redacted() Deployment {
+	d.Config = d.Config.Redacted()
+	return d
 }
 
 // Exist returns true if a deployment exist, false otherwise
@@ -34,6 +51,17 @@ func Exist(deployment string) bool {
 
 // GetDeployment returns a single deployment
 func GetDeployment(deployment string) (Deployment, error) {
+	d, err := getDeployment(deployment)
+	if err != nil {
+		return Deployment{}, err
+	}
+
+	d.Routing = GetRoutingStatus(context.Background(), d.Config)
+	return d, nil
+}
+
+// getDeployment returns a single deployment without its routing status
+func getDeployment(deployment string) (Deployment, error) {
 	config, err := GetDeploymentConfig(deployment)
 	if err != nil {
 		return Deployment{}, err
@@ -49,10 +77,28 @@ func GetDeployment(deployment string) (Deployment, error) {
 		return Deployment{}, err
 	}
 
+	deferred, err := GetDeferredRun(deployment)
+	if err != nil {
+		logger.Warnf("unable to get the deferred run of deployment %s, %v", deployment, err)
+	}
+
+	schedule, err := GetSchedule(deployment)
+	if err != nil {
+		logger.Warnf("unable to get the schedule of deployment %s, %v", deployment, err)
+	}
+
+	staged, err := GetStagedRun(deployment)
+	if err != nil {
+		logger.Warnf("unable to get the staged run of deployment %s, %v", deployment, err)
+	}
+
 	return Deployment{
 		Config:     config,
 		Containers: containers,
 		Jobs:       jobs,
+		Deferred:   deferred,
+		Schedule:   schedule,
+		Staged:     staged,
 	}, nil
 }
 
@@ -63,27 +109,75 @@ func GetAllDeployments() ([]Deployment, error) {
 		return []Deployment{}, err
 	}
 
+	return getDeployments(configs)
+}
+
+// getDeployments returns the deployments of a list of configurations
+func getDeployments(configs []Config) ([]Deployment, error) {
 	deployments := make([]Deployment, 0)
+	if len(configs) == 0 {
+		return deployments, nil
+	}
+
+	// the proxy is detected once for all deployments
+	proxy, detected, detectErr := DetectProxy(context.Background())
+	if detectErr != nil {
+		logger.Warnf("unable to detect the network proxy %v", detectErr)
+	}
+
 	for _, config := range configs {
-		d, err := GetDeployment(config.Name)
+		d, err := getDeployment(config.Name)
 		if err != nil {
 			return []Deployment{}, err
 		}
 
+		if detectErr == nil {
+			d.Routing = routingStatus(d.Config, proxy, detected)
+		}
+
 		deployments = append(deployments, d)
 	}
 
 	return deployments, nil
 }
 
+// RunOptions configure how a deployment run creates container resources
+type RunOptions struct {
+	Start          bool             // start the containers once created, when false containers are left in the created state
+	Replace        []KraneContainer // containers outside the deployment removed once the run succeeds (ie. a container imported into the deployment)
+	Automated      bool             // run triggered without a user (webhook, scheduled run), deferred when outside the deploy window
+	OverrideWindow bool             // run even outside the deploy window, only allowed for admin sessions
+	Tag            string           // image tag deployed instead of the configured tag for this run only
+	Digest         string           // image digest deployed instead of the configured tag or digest for this run only
+	TriggeredBy    string           // who or what triggered the run, recorded with the run job
+
+	handoff *portHandoff  // fixed host ports handed over from the replaced containers, set by the run job
+	done    func(job.Job) // called once the run job completes, whether it succeeded or failed (ie. a rename waiting for its run)
+}
+
 // Run a deployment runs the current configuration for a
 // deployment creating or re-creating container resources
 func Run(deployment string) error {
-	config, err := GetDeploymentConfig(deployment)
+	return RunWithOptions(deployment, RunOptions{Start: true})
+}
+
+// RunWithOptions runs the current configuration for a deployment with run options
+func RunWithOptions(deployment string, opts RunOptions) error {
+	config, err := GetDeploymentConfigFromStore(deployment)
 	if err != nil {
 		return err
 	}
 
+	if open, reason := config.DeployWindow.Open(time.Now()); !open && !opts.OverrideWindow {
+		if opts.Automated {
+			return deferRun(config, opts, reason)
+		}
+		return DeployWindowClosedError{Deployment: config.Name, Reason: reason}
+	}
+
+	// the image overrides are not saved, the next runs deploy the configured image again
+	config = config.withImageOverride(opts.Tag, opts.Digest)
+
 	type RunDeploymentJobArgs struct {
 		Config             Config
 		ContainersToRemove []KraneContainer
@@ -96,6 +190,12 @@ func Run(deployment string) error {
 		Deployment:  config.Name,
 		Type:        string(RunDeploymentJobType),
 		RetryPolicy: utils.UIntEnv(constants.EnvDeploymentRetryPolicy),
+		Priority:    config.Priority,
+		Timeout:     time.Duration(config.DeployTimeout) * time.Second,
+		Note:        linkHistoryToJob(config.Name, jobID),
+		TriggeredBy: opts.TriggeredBy,
+		Coalesce:    opts.done == nil, // queued runs are replaced by newer runs deploying the latest configuration, unless the run is waited on
+		OnComplete:  opts.done,
 		Args: &RunDeploymentJobArgs{
 			Config:             config,
 			ContainersToRemove: []KraneContainer{},
@@ -116,6 +216,12 @@ func Run(deployment string) error {
 				return err
 			}
 
+			// ensure history collections
+			if err := CreateHistoryCollection(deploymentName); err != nil {
+				logger.Errorf("unable to create history collection %v", err)
+				return err
+			}
+
 			// get containers (if any) currently part of this deployment
 			containers, err := GetContainersByDeployment(deploymentName)
 			if err != nil {
@@ -123,79 +229,44 @@ func Run(deployment string) error {
 				return err
 			}
 
-			// update job arguments to process them for deletion later on
-			jobArgs.ContainersToRemove = containers
+			// update job arguments to process them for deletion later on, containers created without
+			// being started are staged alongside the current containers which keep serving the deployment
+			if opts.Start {
+				jobArgs.ContainersToRemove = append(containers, opts.Replace...)
+			}
 
 			return nil
 		},
 		Run: func(args interface{}) error {
 			jobArgs := args.(*RunDeploymentJobArgs)
+			runOpts := opts
+			runOpts.handoff = newPortHandoff(jobArgs.Config, jobArgs.ContainersToRemove)
 			config := jobArgs.Config
-
-			// resolve registry credentials
-			if err := config.ResolveRegistryCredentials(); err != nil {
-				logger.Errorf("unable to resolve registry credentials: %v", err)
-				return err
+			if opts.Start {
+				config = config.withBlueGreenStage(jobArgs.ContainersToRemove)
 			}
-
-			// pull image
-			logger.Debugf("Pulling image for deployment %s", config.Name)
-			pullImageReader, err := docker.GetClient().PullImage(
-				config.Image, config.Tag, docker.RegistryCredentials{
-					URL:      config.Registry.URL,
-					Username: config.Registry.Username,
-					Password: config.Registry.Password,
-				})
-			if err != nil {
-				logger.Errorf("unable to pull image %v", err)
-				return err
-			}
-			e.emitStream(pullImageReader)
-
-			// create containers
-			containersCreated := make([]KraneContainer, 0)
-			for i := 0; i < config.Scale; i++ {
-				c, err := ContainerCreate(config)
-				if err != nil {
-					logger.Errorf("unable to create container %v", err)
-					return err
-				}
-				containersCreated = append(containersCreated, c)
-			}
-			logger.Debugf("%d/%d container(s) for deployment %s created", config.Scale, len(containersCreated), config.Name)
-
-			// start containers
-			containersStarted := make([]KraneContainer, 0)
-			for _, c := range containersCreated {
-				if err := c.Start(); err != nil {
-					logger.Errorf("unable to start container %v", err)
-					return err
-				}
-				containersStarted = append(containersStarted, c)
+			return createContainerResources(job.Context(jobID), config, runOpts, e)
+		},
+		Finally: func(args interface{}) error {
+			// the current containers are only replaced once the staged containers are started
+			if !opts.Start {
+				return nil
 			}
-			logger.Debugf("%d/%d container(s) for deployment %s started", len(containersStarted), len(containersCreated), config.Name)
 
-			// health check
-			retries := 10
-			if err := RetriableContainersHealthCheck(containersStarted, retries); err != nil {
-				logger.Errorf("containers did not pass health check %v", err)
+			jobArgs := args.(*RunDeploymentJobArgs)
+			if err := removeContainers(job.Context(jobID), jobArgs.ContainersToRemove, jobArgs.Config.ContainerStopTimeout()); err != nil {
 				return err
 			}
-			logger.Debugf("Deployment %s health check complete", config.Name)
-			return nil
-		},
-		Finally: func(args interface{}) error {
-			jobArgs := args.(*RunDeploymentJobArgs)
 
-			for _, c := range jobArgs.ContainersToRemove {
-				logger.Debugf("Removing container %s", c.Name)
-				err := c.Remove()
-				if err != nil {
-					logger.Errorf("unable to remove container %v", err)
-					return err
-				}
+			// the containers of a staged run (if any) were replaced along with the current containers
+			if err := deleteStagedRun(jobArgs.Config.Name); err != nil {
+				logger.Warnf("unable to remove the staged run of deployment %s, %v", jobArgs.Config.Name, err)
 			}
 
+			// only revisions whose containers were started can be rolled back to
+			markRevisionDeployed(jobArgs.Config.Name, jobID)
+			runPostDeployHook(job.Context(jobID), jobArgs.Config, e)
+			pruneImagesAfterDeploy(job.Context(jobID), e)
 			return nil
 		},
 	})
@@ -203,24 +274,46 @@ func Run(deployment string) error {
 	return nil
 }
 
-// Delete removes a deployments container resources and configuration.
+// DeleteOptions configure how a deployment is deleted
+type DeleteOptions struct {
+	Force   bool // remove the containers immediately instead of stopping them gracefully
+	Volumes bool // also remove the named volumes created for the deployment mounts
+}
+
+// Delete removes a deployments container resources and configuration. Containers are stopped
+// gracefully before being removed unless force is set, in which case they are removed immediately.
 // Note: This will also remove any existing collections created for the deployment (Secrets, Jobs, Config etc...)
-func Delete(deployment string) error {
+func Delete(deployment string, force bool) error {
+	return DeleteWithOptions(deployment, DeleteOptions{Force: force})
+}
+
+// DeleteWithOptions removes a deployments container resources and configuration with delete options.
+// Named volumes are kept unless the options remove them, volumes still used by other containers are never removed.
+func DeleteWithOptions(deployment string, opts DeleteOptions) error {
 	type DeleteDeploymentJobArgs struct {
 		Deployment string
+		Force      bool
+		Volumes    bool
 	}
 
+	jobID := uuid.Generate().String()
 	go enqueue(job.Job{
-		ID:          uuid.Generate().String(),
+		ID:          jobID,
 		Deployment:  deployment,
 		Type:        string(DeleteDeploymentJobType),
 		RetryPolicy: utils.UIntEnv(constants.EnvDeploymentRetryPolicy),
 		Args: DeleteDeploymentJobArgs{
 			Deployment: deployment,
+			Force:      opts.Force,
+			Volumes:    opts.Volumes,
 		},
 		Run: func(args interface{}) error {
 			jobArgs := args.(DeleteDeploymentJobArgs)
 			deploymentName := jobArgs.Deployment
+			ctx := job.Context(jobID)
+
+			// containers are stopped with the default stop timeout if the config cannot be read
+			config, _ := GetDeploymentConfig(deploymentName)
 
 			// get current containers
 			containers, err := GetContainersByDeployment(deploymentName)
@@ -229,15 +322,32 @@ func Delete(deployment string) error {
 				return err
 			}
 
-			// remove containers
-			for _, c := range containers {
-				if err := c.Remove(); err != nil {
-					logger.Errorf("unable to remove container %v", err)
+			// forced deletes remove the containers right away, without draining or stopping them
+			if jobArgs.Force {
+				for _, c := range containers {
+					if err := c.Remove(ctx, true); err != nil {
+						logger.Errorf("unable to remove container %v", err)
+						return err
+					}
+				}
+			} else {
+				// containers are drained from the proxy before being stopped so no new request is routed to a
+				// stopping container, then stopped so the processes are given the stop grace period to shut down
+				drainContainers(ctx, config, containers)
+				if err := removeContainers(ctx, containers, config.ContainerStopTimeout()); err != nil {
 					return err
 				}
 			}
 			logger.Debugf("%d container(s) for deployment %s removed", len(containers), deploymentName)
 
+			// volumes can only be removed once the containers mounting them are removed
+			if jobArgs.Volumes {
+				if err := removeVolumes(ctx, deploymentName); err != nil {
+					logger.Errorf("unable to remove volumes %v", err)
+					return err
+				}
+			}
+
 			return nil
 		},
 		Finally: func(args interface{}) error {
@@ -258,6 +368,29 @@ func Delete(deployment string) error {
 				return err
 			}
 
+			// delete history collection
+			logger.Debugf("removing history collection for deployment %s", deploymentName)
+			if err := DeleteHistoryCollection(deploymentName); err != nil {
+				logger.Errorf("unable to remove history collection %v", err)
+				return err
+			}
+
+			if err := deleteDeferredRun(deploymentName); err != nil {
+				logger.Warnf("unable to remove deferred run of deployment %s, %v", deploymentName, err)
+			}
+
+			if err := deleteStagedRun(deploymentName); err != nil {
+				logger.Warnf("unable to remove staged run of deployment %s, %v", deploymentName, err)
+			}
+
+			if err := Unschedule(deploymentName); err != nil {
+				logger.Warnf("unable to remove schedule of deployment %s, %v", deploymentName, err)
+			}
+
+			if err := RevokeWebhookToken(deploymentName); err != nil {
+				logger.Warnf("unable to remove webhook token of deployment %s, %v", deploymentName, err)
+			}
+
 			// delete deployment configuration
 			logger.Debugf("removing config for deployment %s", deploymentName)
 			if err := DeleteConfig(deploymentName); err != nil {
@@ -272,15 +405,19 @@ func Delete(deployment string) error {
 	return nil
 }
 
-// StartContainers starts current existing containers (if any) for a deployment
+// StartContainers starts current existing containers (if any) for a deployment and returns the id of the job.
+// Deployments with a staged run (run without starting its containers) start the staged containers instead
+// and replace the previous containers once the staged containers pass the health check.
 // Note: this does not re-create container resources, only start existing ones
-func StartContainers(deployment string) error {
+func StartContainers(deployment string) (string, error) {
 	type StartContainersJobArgs struct {
 		Deployment string
 	}
 
+	jobID := uuid.Generate().String()
+	e := createEventEmitter(deployment, jobID)
 	go enqueue(job.Job{
-		ID:          uuid.Generate().String(),
+		ID:          jobID,
 		Deployment:  deployment,
 		Type:        string(StartContainersJobType),
 		RetryPolicy: utils.UIntEnv(constants.EnvDeploymentRetryPolicy),
@@ -290,6 +427,21 @@ func StartContainers(deployment string) error {
 		Run: func(args interface{}) error {
 			jobArgs := args.(StartContainersJobArgs)
 			deploymentName := jobArgs.Deployment
+			ctx := job.Context(jobID)
+
+			staged, err := GetStagedRun(deploymentName)
+			if err != nil {
+				logger.Errorf("unable to get staged run %v", err)
+				return err
+			}
+
+			if staged != nil {
+				config, err := GetDeploymentConfig(deploymentName)
+				if err != nil {
+					return err
+				}
+				return activateStagedRun(ctx, config, *staged, e)
+			}
 
 			// get current containers
 			containers, err := GetContainersByDeployment(deploymentName)
@@ -305,7 +457,7 @@ func StartContainers(deployment string) error {
 			// start containers
 			for _, c := range containers {
 				logger.Debugf("Starting container %s", c.Name)
-				if err := c.Start(); err != nil {
+				if err := c.Start(ctx); err != nil {
 					logger.Errorf("unable to start container %v", err)
 					return err
 				}
@@ -315,18 +467,19 @@ func StartContainers(deployment string) error {
 			return nil
 		},
 	})
-	return nil
+	return jobID, nil
 }
 
-// StopContainers stops current existing containers (if any) for a deployment
+// StopContainers stops current existing containers (if any) for a deployment and returns the id of the job
 // Note: this does not re-create container resources, only stop existing ones
-func StopContainers(deployment string) error {
+func StopContainers(deployment string) (string, error) {
 	type StopContainersJobArgs struct {
 		Deployment string
 	}
 
+	jobID := uuid.Generate().String()
 	go enqueue(job.Job{
-		ID:          uuid.Generate().String(),
+		ID:          jobID,
 		Deployment:  deployment,
 		Type:        string(StopContainersJobType),
 		RetryPolicy: utils.UIntEnv(constants.EnvDeploymentRetryPolicy),
@@ -336,6 +489,10 @@ func StopContainers(deployment string) error {
 		Run: func(args interface{}) error {
 			jobArgs := args.(StopContainersJobArgs)
 			deploymentName := jobArgs.Deployment
+			ctx := job.Context(jobID)
+
+			// containers are stopped with the default stop timeout if the config cannot be read
+			config, _ := GetDeploymentConfig(deploymentName)
 
 			// get current containers
 			containers, err := GetContainersByDeployment(deploymentName)
@@ -351,7 +508,7 @@ func StopContainers(deployment string) error {
 			// stop containers
 			for _, c := range containers {
 				logger.Debugf("Stopping container %s", c.Name)
-				if err := c.Stop(); err != nil {
+				if err := c.Stop(ctx, config.ContainerStopTimeout()); err != nil {
 					logger.Errorf("unable to stop container %v", err)
 					return err
 				}
@@ -361,15 +518,15 @@ func StopContainers(deployment string) error {
 			return nil
 		},
 	})
-	return nil
+	return jobID, nil
 }
 
-// RestartContainers will re-create container resources for a deployment
+// RestartContainers will re-create container resources for a deployment and returns the id of the job
 // Note: this almost the same call as 'Run' since they both re-create container resources based on the current configuration
-func RestartContainers(deployment string) error {
+func RestartContainers(deployment string) (string, error) {
 	config, err := GetDeploymentConfig(deployment)
 	if err != nil {
-		return fmt.Errorf("unable to get configuration for deployment %s", deployment)
+		return "", fmt.Errorf("unable to get configuration for deployment %s", deployment)
 	}
 
 	type RestartContainersJobArgs struct {
@@ -384,6 +541,8 @@ func RestartContainers(deployment string) error {
 		Deployment:  deployment,
 		Type:        string(RestartContainersJobType),
 		RetryPolicy: utils.UIntEnv(constants.EnvDeploymentRetryPolicy),
+		Priority:    config.Priority,
+		Timeout:     time.Duration(config.DeployTimeout) * time.Second,
 		Args: &RestartContainersJobArgs{
 			ContainersToRemove: []KraneContainer{},
 			Config:             config,
@@ -404,72 +563,313 @@ func RestartContainers(deployment string) error {
 		},
 		Run: func(args interface{}) error {
 			jobArgs := args.(*RestartContainersJobArgs)
-			config := jobArgs.Config
-
-			// resolve registry credentials
-			if err := config.ResolveRegistryCredentials(); err != nil {
-				logger.Errorf("unable to resolve registry credentials: %v", err)
+			config := jobArgs.Config.withBlueGreenStage(jobArgs.ContainersToRemove)
+			return createContainerResources(job.Context(jobID), config, RunOptions{Start: true}, e)
+		},
+		Finally: func(args interface{}) error {
+			jobArgs := args.(*RestartContainersJobArgs)
+			if err := removeContainers(job.Context(jobID), jobArgs.ContainersToRemove, jobArgs.Config.ContainerStopTimeout()); err != nil {
 				return err
 			}
 
-			// pull image
-			logger.Debugf("Pulling image for deployment %s", config.Name)
-			pullImageReader, err := docker.GetClient().PullImage(
-				config.Image, config.Tag, docker.RegistryCredentials{
-					URL:      config.Registry.URL,
-					Username: config.Registry.Username,
-					Password: config.Registry.Password,
-				})
-			if err != nil {
-				logger.Errorf("unable to pull image %v", err)
-				return err
+			// the containers of a staged run (if any) were replaced along with the current containers
+			if err := deleteStagedRun(jobArgs.Config.Name); err != nil {
+				logger.Warnf("unable to remove the staged run of deployment %s, %v", jobArgs.Config.Name, err)
 			}
-			e.emitStream(pullImageReader)
 
-			// create containers
-			containersCreated := make([]KraneContainer, 0)
-			for i := 0; i < config.Scale; i++ {
-				c, err := ContainerCreate(config)
-				if err != nil {
-					logger.Errorf("unable to create container %v", err)
-					return err
-				}
-				containersCreated = append(containersCreated, c)
-			}
-			logger.Debugf("%d/%d container(s) for deployment %s created", len(containersCreated), config.Scale, config.Name)
+			runPostDeployHook(job.Context(jobID), jobArgs.Config, e)
+			return nil
+		},
+	})
+	return jobID, nil
+}
 
-			// start containers
-			containersStarted := make([]KraneContainer, 0)
-			for _, c := range containersCreated {
-				if err := c.Start(); err != nil {
-					logger.Errorf("unable to start container %v", err)
-					return err
-				}
-				containersStarted = append(containersStarted, c)
+// createContainerResources creates the container resources for a deployment.
+// If any step fails, the containers created during the run are removed.
+func createContainerResources(ctx context.Context, config Config, opts RunOptions, e *EventEmitter) error {
+	warnIfProxyMissing(ctx, config, e)
+	warnIfUnroutable(config, e)
+
+	// the pre-deploy hook runs before any container is created so the previous containers keep serving if it fails
+	if opts.Start {
+		if err := runPreDeployHook(ctx, config, e); err != nil {
+			logger.Errorf("pre-deploy hook failed %v", err)
+			if ctx.Err() == context.DeadlineExceeded {
+				return deployTimeoutError(ctx, config)
 			}
-			logger.Debugf("%d/%d container(s) for deployment %s started", len(containersStarted), len(containersCreated), config.Name)
+			return err
+		}
+	}
 
-			retries := 10
-			if err := RetriableContainersHealthCheck(containersStarted, retries); err != nil {
-				logger.Errorf("containers did not pass health check %v", err)
-				return err
-			}
-			logger.Debugf("Deployment %s health check complete", config.Name)
+	containersCreated, err := deployContainers(ctx, config, opts, e)
 
-			return nil
-		},
-		Finally: func(args interface{}) error {
-			jobArgs := args.(*RestartContainersJobArgs)
-			for _, c := range jobArgs.ContainersToRemove {
-				logger.Debugf("Removing container %s", c.Name)
-				if err := c.Remove(); err != nil {
-					logger.Errorf("unable to remove container %v", err)
-					return err
-				}
-			}
+	// the deploy is only complete once the readiness webhook (if any) confirms it
+	if err == nil && opts.Start && config.ReadinessWebhook.URL != "" {
+		job.RecordStep(ctx, "readiness_webhook")
+		err = confirmReadiness(ctx, config, containersCreated, e)
+	}
 
-			return nil
-		},
-	})
+	// the previous containers are only removed once traffic switched to the blue-green containers
+	if err == nil && opts.Start {
+		job.RecordStep(ctx, "switch_traffic")
+		err = switchTraffic(ctx, config, containersCreated, e)
+	}
+
+	// containers created without being started are staged until the deployment is started
+	if err == nil && !opts.Start {
+		job.RecordStep(ctx, "stage_containers")
+		err = stageRun(ctx, config, containersCreated, e)
+	}
+
+	// a run cancelled (or timed out) between steps keeps the previous containers serving
+	if err == nil && ctx.Err() != nil {
+		err = fmt.Errorf("deploy aborted, %v", ctx.Err())
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	// the job context may already be done, cleanup uses its own context
+	// so the containers created during this run are always removed
+	logger.Debugf("Removing %d container(s) created for deployment %s", len(containersCreated), config.Name)
+	if err := removeContainers(context.Background(), containersCreated, config.ContainerStopTimeout()); err != nil {
+		logger.Errorf("unable to cleanup containers %v", err)
+	}
+
+	// the previous containers stopped to free their host ports keep serving once the new containers are removed
+	opts.handoff.restore(e)
+
+	// running out of disk space is reported on its own since the deploy is not retried
+	reportIfDiskFull(err, e)
+
+	if ctx.Err() == context.DeadlineExceeded {
+		timeoutErr := deployTimeoutError(ctx, config)
+		e.emit(fmt.Sprintf("Deploy timed out, removed the %d container(s) created by the run", len(containersCreated)))
+		return timeoutErr
+	}
+
+	if ctx.Err() == context.Canceled {
+		e.emit(fmt.Sprintf("Deploy cancelled, removed the %d container(s) created by the run", len(containersCreated)))
+		return fmt.Errorf("deploy cancelled, %v", err)
+	}
+
+	return err
+}
+
+// deployContainers creates the containers for a deployment or for each of its variants (if any).
+// The containers created are returned even when a step fails.
+func deployContainers(ctx context.Context, config Config, opts RunOptions, e *EventEmitter) ([]KraneContainer, error) {
+	if len(config.Variants) > 0 {
+		return deployVariants(ctx, config, opts, e)
+	}
+	return deployReplicas(ctx, config, config.MinHealthyContainers(), opts, e)
+}
+
+// deployReplicas pulls the image for a deployment, creates and starts its containers and waits for
+// at least minHealthy containers to pass the health check. The containers created are returned even when a step fails.
+// If the run options do not start containers, the containers are only created.
+func deployReplicas(ctx context.Context, config Config, minHealthy int, opts RunOptions, e *EventEmitter) ([]KraneContainer, error) {
+	containersCreated := make([]KraneContainer, 0)
+
+	// resolve registry credentials
+	job.RecordStep(ctx, "pull_image")
+	if err := config.ResolveRegistryCredentials(); err != nil {
+		logger.Errorf("unable to resolve registry credentials: %v", err)
+		return containersCreated, err
+	}
+
+	// pull image
+	logger.Debugf("Pulling image for deployment %s", config.Name)
+	pullStart := time.Now()
+	pullImageReader, err := docker.GetClient().PullImage(ctx, config.ImageRef(), config.pullCredentials())
+	if err != nil {
+		logger.Errorf("unable to pull image %v", err)
+		return containersCreated, err
+	}
+	err = e.emitStream(config.ImageRef(), pullImageReader, time.Duration(config.PullProgressInterval)*time.Second)
+	_ = pullImageReader.Close()
+	job.RecordDuration(ctx, "pull_image", time.Since(pullStart))
+	if err != nil {
+		logger.Errorf("unable to pull image %v", err)
+		return containersCreated, err
+	}
+
+	// the docker api version used by Krane cannot request a platform when pulling,
+	// so the pulled image is verified to be built for the requested platform instead
+	job.RecordStep(ctx, "verify_image")
+	if config.Platform != "" {
+		if err := verifyImagePlatform(ctx, config); err != nil {
+			logger.Errorf("unable to verify image platform %v", err)
+			return containersCreated, err
+		}
+	}
+
+	// the proxy must ignore blue-green containers until they are healthy
+	if err := verifyBlueGreenImage(ctx, config); err != nil {
+		logger.Errorf("unable to verify image health check %v", err)
+		return containersCreated, err
+	}
+
+	// label containers with the metadata of this deploy
+	config = config.withDeployLabels(ctx, time.Now())
+
+	// resolve percentage resource limits against the docker host
+	job.RecordStep(ctx, "resolve_resources")
+	config, err = config.withResolvedResources(ctx)
+	if err != nil {
+		logger.Errorf("unable to resolve resources %v", err)
+		return containersCreated, err
+	}
+
+	// create missing named volumes and verify bind mounted host paths
+	job.RecordStep(ctx, "prepare_mounts")
+	if err := prepareMounts(ctx, config, e); err != nil {
+		logger.Errorf("unable to prepare mounts %v", err)
+		return containersCreated, err
+	}
+
+	// create containers
+	job.RecordStep(ctx, "create_container")
+	for i := 0; i < config.Scale; i++ {
+		c, err := containerCreateWithTimeout(ctx, config, i)
+		if err != nil {
+			logger.Errorf("container create failed %v", err)
+			return containersCreated, err
+		}
+		containersCreated = append(containersCreated, c)
+	}
+	logger.Debugf("%d/%d container(s) for deployment %s created", len(containersCreated), config.Scale, config.Name)
+
+	if !opts.Start {
+		logger.Debugf("Skipping start for deployment %s, containers left in created state", config.Name)
+		return containersCreated, nil
+	}
+
+	// replaced containers bound to the same fixed host ports are stopped so the new containers can bind them
+	job.RecordStep(ctx, "stop_replaced_containers")
+	if err := opts.handoff.stop(ctx, e); err != nil {
+		logger.Errorf("unable to hand over host ports %v", err)
+		return containersCreated, err
+	}
+
+	// start containers
+	job.RecordStep(ctx, "start_containers")
+	containersStartTime := time.Now()
+	containersStarted := make([]KraneContainer, 0)
+	for _, c := range containersCreated {
+		if err := c.Start(ctx); err != nil {
+			logger.Errorf("unable to start container %v", err)
+			return containersCreated, err
+		}
+		containersStarted = append(containersStarted, c)
+	}
+	job.RecordDuration(ctx, "start_containers", time.Since(containersStartTime))
+	logger.Debugf("%d/%d container(s) for deployment %s started", len(containersStarted), len(containersCreated), config.Name)
+
+	// health check, containers are given the initial delay to boot before they are probed
+	job.RecordStep(ctx, "health_check")
+	if delay := time.Duration(config.HealthCheck.InitialDelay) * time.Second; delay > 0 {
+		logger.Debugf("Waiting %s before health checking deployment %s", delay, config.Name)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return containersCreated, fmt.Errorf("health check aborted %v", ctx.Err())
+		}
+	}
+
+	healthCheckStart := time.Now()
+	err = RetriableContainersHealthCheck(ctx, config, containersStarted, minHealthy, config.HealthCheck.retries())
+	job.RecordDuration(ctx, "health_check", time.Since(healthCheckStart))
+	if err != nil {
+		logger.Errorf("containers did not pass health check %v", err)
+		return containersCreated, err
+	}
+	logger.Debugf("Deployment %s health check complete", config.Name)
+
+	return containersCreated, nil
+}
+
+// containerCreateWithTimeout creates a container for a deployment, failing if the create
+// takes longer than the deployment create timeout. The create duration is recorded into the job.
+func containerCreateWithTimeout(ctx context.Context, config Config, index int) (KraneContainer, error) {
+	timeout := config.ContainerCreateTimeout()
+	createCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	c, err := ContainerCreate(createCtx, config, index)
+	elapsed := time.Since(start)
+	job.RecordDuration(ctx, "create_container", elapsed)
+
+	if err != nil {
+		if createCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			return c, fmt.Errorf("container create for deployment %s exceeded timeout of %s", config.Name, timeout)
+		}
+		return c, err
+	}
+
+	if elapsed > timeout/2 {
+		logger.Warnf("slow container create for deployment %s, container %s created in %s", config.Name, c.Name, elapsed)
+	} else {
+		logger.Debugf("container %s for deployment %s created in %s", c.Name, config.Name, elapsed)
+	}
+
+	return c, nil
+}
+
+// verifyImagePlatform returns an error if the deployment image was not built for the deployment platform
+func verifyImagePlatform(ctx context.Context, config Config) error {
+	imagePlatform, err := docker.GetClient().ImagePlatform(ctx, config.ImageRef())
+	if err != nil {
+		return err
+	}
+
+	if imagePlatform != config.osArch() {
+		return docker.PlatformMismatchError{Ref: config.ImageRef(), Expected: config.Platform, Actual: imagePlatform}
+	}
+
+	return nil
+}
+
+// drainContainers disconnects the running containers of a routed deployment from the proxy network and waits
+// ProxyDrainDelay for the proxy to deregister them, so the proxy stops routing new requests to the containers
+func drainContainers(ctx context.Context, config Config, containers []KraneContainer) {
+	if !config.Routed() || docker.IsIsolatedNetworkMode(config.NetworkMode) {
+		return
+	}
+
+	drained := 0
+	for _, c := range containers {
+		if !c.State.Running {
+			continue
+		}
+		if err := docker.GetClient().DisconnectNetwork(ctx, docker.ProxyNetworkName(), c.ID); err != nil {
+			logger.Warnf("unable to drain container %s from the proxy network %v", c.Name, err)
+			continue
+		}
+		drained++
+	}
+
+	if drained == 0 {
+		return
+	}
+
+	logger.Debugf("%d container(s) of deployment %s drained from the proxy, waiting %s", drained, config.Name, ProxyDrainDelay)
+	select {
+	case <-time.After(ProxyDrainDelay):
+	case <-ctx.Done():
+	}
+}
+
+// removeContainers stops and removes a list of containers within the stop timeout, containers that do not stop cleanly are force removed
+func removeContainers(ctx context.Context, containers []KraneContainer, stopTimeout time.Duration) error {
+	for _, c := range containers {
+		logger.Debugf("Removing container %s", c.Name)
+		if err := c.StopAndRemove(ctx, stopTimeout); err != nil {
+			logger.Errorf("unable to remove container %v", err)
+			return err
+		}
+	}
 	return nil
 }
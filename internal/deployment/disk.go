@@ -0,0 +1,172 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-units"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/utils"
+)
+
+// DiskUsage is the disk space consumed by a deployment's container logs, writable layers and volumes
+type DiskUsage struct {
+	Deployment       string               `json:"deployment"`
+	TotalBytes       int64                `json:"total_bytes"`
+	ThresholdBytes   int64                `json:"threshold_bytes"`   // 0 when no threshold is configured
+	ExceedsThreshold bool                 `json:"exceeds_threshold"` // whether the total exceeds the threshold
+	Containers       []ContainerDiskUsage `json:"containers"`
+	Volumes          []VolumeDiskUsage    `json:"volumes"`
+}
+
+// ContainerDiskUsage is the disk space consumed by a single container
+type ContainerDiskUsage struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	LogPath       string `json:"log_path"`
+	LogSizeBytes  int64  `json:"log_size_bytes"`
+	LogReadable   bool   `json:"log_readable"`   // false when the log file is not accessible from Krane (ie. not mounted into the Krane container)
+	WritableBytes int64  `json:"writable_bytes"` // size of the files created or changed in the container
+	RootFsBytes   int64  `json:"root_fs_bytes"`  // total size of the container filesystem including its image
+}
+
+// VolumeDiskUsage is the disk space consumed by a docker volume mounted by a deployment
+type VolumeDiskUsage struct {
+	Name      string `json:"name"`
+	SizeBytes int64  `json:"size_bytes"`
+	RefCount  int64  `json:"ref_count"` // number of containers referencing the volume
+}
+
+// GetDiskUsage returns the disk usage of a deployment's containers and volumes
+func GetDiskUsage(deployment string) (DiskUsage, error) {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	du, err := docker.GetClient().GetDiskUsage(ctx)
+	if err != nil {
+		return DiskUsage{}, err
+	}
+
+	logFiles := make(map[string]string)
+	for _, c := range du.Containers {
+		if c.Labels[docker.ContainerDeploymentLabel] != deployment {
+			continue
+		}
+		container, err := docker.GetClient().GetOneContainer(ctx, c.ID)
+		if err != nil {
+			return DiskUsage{}, err
+		}
+		logFiles[c.ID] = container.LogPath
+	}
+
+	return diskUsage(deployment, du, logFiles, diskUsageThreshold()), nil
+}
+
+// diskUsage computes the disk usage of a deployment from the docker host disk usage and the log files of its containers
+func diskUsage(deployment string, du types.DiskUsage, logFiles map[string]string, threshold int64) DiskUsage {
+	usage := DiskUsage{
+		Deployment:     deployment,
+		ThresholdBytes: threshold,
+		Containers:     make([]ContainerDiskUsage, 0),
+		Volumes:        make([]VolumeDiskUsage, 0),
+	}
+
+	volumes := make(map[string]bool)
+	for _, c := range du.Containers {
+		if c.Labels[docker.ContainerDeploymentLabel] != deployment {
+			continue
+		}
+
+		containerUsage := ContainerDiskUsage{
+			ID:            c.ID,
+			LogPath:       logFiles[c.ID],
+			WritableBytes: c.SizeRw,
+			RootFsBytes:   c.SizeRootFs,
+		}
+		if len(c.Names) > 0 {
+			containerUsage.Name = c.Names[0]
+		}
+
+		if info, err := os.Stat(containerUsage.LogPath); err == nil {
+			containerUsage.LogSizeBytes = info.Size()
+			containerUsage.LogReadable = true
+		}
+
+		for _, m := range c.Mounts {
+			if m.Type == mount.TypeVolume {
+				volumes[m.Name] = true
+			}
+		}
+
+		usage.TotalBytes += containerUsage.LogSizeBytes + containerUsage.WritableBytes
+		usage.Containers = append(usage.Containers, containerUsage)
+	}
+
+	for _, v := range du.Volumes {
+		if !volumes[v.Name] {
+			continue
+		}
+
+		volumeUsage := VolumeDiskUsage{Name: v.Name}
+		if v.UsageData != nil {
+			volumeUsage.SizeBytes = v.UsageData.Size
+			volumeUsage.RefCount = v.UsageData.RefCount
+		}
+
+		usage.TotalBytes += volumeUsage.SizeBytes
+		usage.Volumes = append(usage.Volumes, volumeUsage)
+	}
+
+	usage.ExceedsThreshold = threshold > 0 && usage.TotalBytes > threshold
+	return usage
+}
+
+// diskUsageThreshold returns the configured deployment disk usage threshold in bytes, 0 if not configured
+func diskUsageThreshold() int64 {
+	value := os.Getenv(constants.EnvDiskUsageThreshold)
+	if value == "" {
+		return 0
+	}
+
+	threshold, err := units.RAMInBytes(value)
+	if err != nil {
+		logger.Warnf("invalid %s %s: %v", constants.EnvDiskUsageThreshold, value, err)
+		return 0
+	}
+	return threshold
+}
+
+// reportIfDiskFull emits a disk full event when a deploy failed because the docker host ran out of disk space.
+// When DISK_FULL_PRUNE is enabled, dangling images are pruned so the next deploy has space to pull into.
+func reportIfDiskFull(err error, e *EventEmitter) {
+	var diskFullErr docker.DiskFullError
+	if !errors.As(err, &diskFullErr) {
+		return
+	}
+
+	e.Phase = DiskFullPhase
+	logger.Warnf("deployment %s failed, %v", e.Deployment, diskFullErr)
+	e.emit(fmt.Sprintf("Disk full on the docker host, unable to %s %s. The deploy will not be retried until disk space is reclaimed", diskFullErr.Op, diskFullErr.Ref))
+
+	if !utils.BoolEnv(constants.EnvDiskFullPrune) {
+		return
+	}
+
+	// the job context may already be done, pruning uses its own context
+	reclaimed, pruneErr := docker.GetClient().PruneDanglingImages(context.Background())
+	if pruneErr != nil {
+		logger.Errorf("unable to prune dangling images %v", pruneErr)
+		e.emit(fmt.Sprintf("Unable to prune dangling images to reclaim disk space: %v", pruneErr))
+		return
+	}
+
+	logger.Infof("pruned dangling images reclaiming %s", units.BytesSize(float64(reclaimed)))
+	e.emit(fmt.Sprintf("Pruned dangling images reclaiming %s of disk space", units.BytesSize(float64(reclaimed))))
+}
@@ -0,0 +1,70 @@
+package deployment
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/docker"
+)
+
+func TestDiskUsage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "krane-disk")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "c1-json.log")
+	assert.Nil(t, ioutil.WriteFile(logPath, make([]byte, 100), 0600))
+
+	du := types.DiskUsage{
+		Containers: []*types.Container{
+			{
+				ID:         "c1",
+				Names:      []string{"/app-1"},
+				Labels:     map[string]string{docker.ContainerDeploymentLabel: "app"},
+				SizeRw:     50,
+				SizeRootFs: 5000,
+				Mounts:     []types.MountPoint{{Type: mount.TypeVolume, Name: "app-data"}, {Type: mount.TypeBind, Source: "/tmp"}},
+			},
+			{
+				ID:     "c2",
+				Labels: map[string]string{docker.ContainerDeploymentLabel: "other"},
+				SizeRw: 1000,
+				Mounts: []types.MountPoint{{Type: mount.TypeVolume, Name: "other-data"}},
+			},
+		},
+		Volumes: []*types.Volume{
+			{Name: "app-data", UsageData: &types.VolumeUsageData{Size: 25, RefCount: 1}},
+			{Name: "other-data", UsageData: &types.VolumeUsageData{Size: 1000, RefCount: 1}},
+		},
+	}
+
+	usage := diskUsage("app", du, map[string]string{"c1": logPath}, 150)
+	assert.Equal(t, int64(175), usage.TotalBytes)
+	assert.True(t, usage.ExceedsThreshold)
+	assert.Len(t, usage.Containers, 1)
+	assert.Equal(t, "/app-1", usage.Containers[0].Name)
+	assert.Equal(t, int64(100), usage.Containers[0].LogSizeBytes)
+	assert.True(t, usage.Containers[0].LogReadable)
+	assert.Len(t, usage.Volumes, 1)
+	assert.Equal(t, "app-data", usage.Volumes[0].Name)
+
+	assert.False(t, diskUsage("app", du, map[string]string{"c1": logPath}, 0).ExceedsThreshold)
+}
+
+func TestDiskUsageUnreadableLog(t *testing.T) {
+	du := types.DiskUsage{
+		Containers: []*types.Container{
+			{ID: "c1", Labels: map[string]string{docker.ContainerDeploymentLabel: "app"}, SizeRw: 10},
+		},
+	}
+
+	usage := diskUsage("app", du, map[string]string{"c1": "/does/not/exist.log"}, 0)
+	assert.False(t, usage.Containers[0].LogReadable)
+	assert.Equal(t, int64(10), usage.TotalBytes)
+}
@@ -0,0 +1,90 @@
+package deployment
+
+import (
+	"bufio"
+	"strings"
+)
+
+// SecretEntry is a secret key/value pair read from an import payload along with the line it was read from
+type SecretEntry struct {
+	Line  int // line of the entry in a dotenv payload, 0 for json payloads
+	Key   string
+	Value string
+}
+
+// SecretImportError reports an entry of an import payload that was skipped
+type SecretImportError struct {
+	Line  int    `json:"line,omitempty"` // line of the entry in a dotenv payload
+	Key   string `json:"key,omitempty"`
+	Error string `json:"error"`
+}
+
+// ParseDotenv returns the secret entries of a dotenv payload. Blank lines and comments are skipped, lines
+// may start with export and values may be single or double quoted. Malformed lines are returned as errors
+// without stopping the parsing of the following lines.
+func ParseDotenv(payload string) ([]SecretEntry, []SecretImportError) {
+	entries := make([]SecretEntry, 0)
+	errs := make([]SecretImportError, 0)
+
+	scanner := bufio.NewScanner(strings.NewReader(payload))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		text = strings.TrimSpace(strings.TrimPrefix(text, "export "))
+		separator := strings.Index(text, "=")
+		if separator < 0 {
+			errs = append(errs, SecretImportError{Line: line, Error: "expected KEY=VALUE"})
+			continue
+		}
+
+		key := strings.TrimSpace(text[:separator])
+		if key == "" {
+			errs = append(errs, SecretImportError{Line: line, Error: "missing key before ="})
+			continue
+		}
+
+		value, ok := dotenvValue(strings.TrimSpace(text[separator+1:]))
+		if !ok {
+			errs = append(errs, SecretImportError{Line: line, Key: key, Error: "unterminated quoted value"})
+			continue
+		}
+
+		entries = append(entries, SecretEntry{Line: line, Key: key, Value: value})
+	}
+
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, SecretImportError{Error: err.Error()})
+	}
+
+	return entries, errs
+}
+
+// dotenvValue returns the value of a dotenv entry without its quotes, false if a quoted value is not terminated.
+// Double quoted values support \n, \" and \\ escapes, unquoted values end at an inline comment.
+func dotenvValue(raw string) (string, bool) {
+	if raw == "" {
+		return "", true
+	}
+
+	quote := raw[0]
+	if quote != '"' && quote != '\'' {
+		if comment := strings.Index(raw, " #"); comment >= 0 {
+			raw = strings.TrimSpace(raw[:comment])
+		}
+		return raw, true
+	}
+
+	end := strings.LastIndexByte(raw, quote)
+	if end == 0 {
+		return "", false
+	}
+
+	value := raw[1:end]
+	if quote == '"' {
+		value = strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`).Replace(value)
+	}
+	return value, true
+}
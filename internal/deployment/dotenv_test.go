@@ -0,0 +1,36 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDotenv(t *testing.T) {
+	payload := `# database
+DB_HOST=db.internal
+export DB_PASSWORD="hunter2 \"quoted\""
+
+API_TOKEN='abc#123'
+LOG_LEVEL=info # inline comment
+not a secret
+=missing-key
+BROKEN="unterminated
+EMPTY=
+`
+	entries, errs := ParseDotenv(payload)
+
+	assert.Equal(t, []SecretEntry{
+		{Line: 2, Key: "DB_HOST", Value: "db.internal"},
+		{Line: 3, Key: "DB_PASSWORD", Value: `hunter2 "quoted"`},
+		{Line: 5, Key: "API_TOKEN", Value: "abc#123"},
+		{Line: 6, Key: "LOG_LEVEL", Value: "info"},
+		{Line: 10, Key: "EMPTY", Value: ""},
+	}, entries)
+
+	assert.Equal(t, []SecretImportError{
+		{Line: 7, Error: "expected KEY=VALUE"},
+		{Line: 8, Error: "missing key before ="},
+		{Line: 9, Key: "BROKEN", Error: "unterminated quoted value"},
+	}, errs)
+}
@@ -0,0 +1,68 @@
+package deployment
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/krane/krane/internal/logger"
+)
+
+// ReservedEnvPrefix is the environment variable namespace reserved for Krane, deployment env and secrets cannot use it
+const ReservedEnvPrefix = "KRANE_"
+
+// envFieldErrors returns a validation error for every env or secret key that is not a valid name,
+// uses the reserved Krane namespace or is set both as an env and a secret
+func (config Config) envFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+
+	for _, k := range sortedKeys(config.Env) {
+		errs = append(errs, envKeyFieldErrors("env", k)...)
+		if _, ok := config.Secrets[k]; ok {
+			errs = append(errs, newFieldError("env", "%s is set both as an env and a secret", k))
+		}
+	}
+
+	for _, k := range sortedKeys(config.Secrets) {
+		errs = append(errs, envKeyFieldErrors("secrets", k)...)
+	}
+
+	return errs
+}
+
+// envKeyFieldErrors returns a validation error if an environment variable name is empty, contains = or is reserved
+func envKeyFieldErrors(field string, key string) []FieldError {
+	errs := make([]FieldError, 0)
+	switch {
+	case key == "" || strings.Contains(key, "="):
+		errs = append(errs, newFieldError(field, "invalid environment variable name %q", key))
+	case strings.HasPrefix(strings.ToUpper(key), ReservedEnvPrefix):
+		errs = append(errs, newFieldError(field, "%s uses the reserved %s namespace", key, ReservedEnvPrefix))
+	}
+	return errs
+}
+
+// reservedEnv returns whether an environment variable is in the reserved Krane namespace, logging it is ignored.
+// Configurations saved before the namespace was reserved can still have such variables.
+func (config Config) reservedEnv(key string) bool {
+	if !strings.HasPrefix(strings.ToUpper(key), ReservedEnvPrefix) {
+		return false
+	}
+	logger.Warnf("ignoring environment variable %s of deployment %s, %s is reserved", key, config.Name, ReservedEnvPrefix)
+	return true
+}
+
+// sortedKeys returns the keys of a map sorted, so the containers env is the same across deploys
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatEnv returns an environment variable as KEY=value, the value is kept as is even when it contains =
+func formatEnv(key string, value string) string {
+	return fmt.Sprintf("%s=%s", key, value)
+}
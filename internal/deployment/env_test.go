@@ -0,0 +1,39 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDockerEnvsSortedWithSecrets(t *testing.T) {
+	_, err := AddSecret("env-app", "DB_PASSWORD", "p=ss")
+	assert.Nil(t, err)
+	defer func() { _ = DeleteSecretsCollection("env-app") }()
+
+	config := Config{
+		Name:    "env-app",
+		Env:     map[string]string{"PORT": "8080", "DATABASE_URL": "postgres://db?sslmode=disable", "KRANE_DEBUG": "1"},
+		Secrets: map[string]string{"DB_PASSWORD": "@DB_PASSWORD", "MISSING": "@MISSING"},
+	}
+
+	expected := []string{"DATABASE_URL=postgres://db?sslmode=disable", "PORT=8080", "DB_PASSWORD=p=ss"}
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, expected, config.DockerEnvs())
+	}
+}
+
+func TestEnvFieldErrors(t *testing.T) {
+	config := Config{
+		Env:     map[string]string{"PORT": "8080", "krane_token": "x", "A=B": "c", "TOKEN": "x"},
+		Secrets: map[string]string{"TOKEN": "@TOKEN", "KRANE_KEY": "@KEY"},
+	}
+
+	errs := config.envFieldErrors()
+	assert.Len(t, errs, 4)
+	assert.Equal(t, "env", errs[0].Field)
+	assert.Equal(t, "secrets", errs[3].Field)
+
+	config = Config{Env: map[string]string{"PORT": "8080"}, Secrets: map[string]string{"TOKEN": "@TOKEN"}}
+	assert.Empty(t, config.envFieldErrors())
+}
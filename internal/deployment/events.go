@@ -4,9 +4,13 @@ import (
 	"bufio"
 	"encoding/json"
 	"io"
+	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/job"
 	"github.com/krane/krane/internal/logger"
 )
 
@@ -18,18 +22,23 @@ type EventEmitter struct {
 }
 
 type Event struct {
-	JobID   string `json:"job_id"`
-	Message string `json:"message"`
-	Phase   Phase  `json:"phase"`
+	JobID   string  `json:"job_id"`
+	Message string  `json:"message"`
+	Phase   Phase   `json:"phase"`
+	Health  *Health `json:"health,omitempty"` // deployment health, set on health transition events
 }
 
 var eventClients = make(map[string][]*websocket.Conn)
+var eventClientsMu sync.Mutex
 
 func createEventEmitter(deployment string, jobID string) *EventEmitter {
+	eventClientsMu.Lock()
+	defer eventClientsMu.Unlock()
+
 	return &EventEmitter{
 		Deployment: deployment,
 		JobID:      jobID,
-		Clients:    eventClients[deployment],
+		Clients:    append([]*websocket.Conn{}, eventClients[deployment]...),
 	}
 }
 
@@ -37,13 +46,22 @@ func createEventEmitter(deployment string, jobID string) *EventEmitter {
 // In order to allow clients to filter events for specific deployment runs, the job id
 // was added into the event payload, the job id is returned when triggering a deployment run.
 func (e EventEmitter) emit(message string) {
-	go func(clients []*websocket.Conn, jobID string, deployment string, phase Phase) {
+	e.emitEvent(Event{JobID: e.JobID, Message: message, Phase: e.Phase})
+}
+
+// emitHealth broadcasts a deployment health event to all clients connected to that deployment
+func (e EventEmitter) emitHealth(message string, health Health) {
+	e.emitEvent(Event{JobID: e.JobID, Message: message, Phase: e.Phase, Health: &health})
+}
+
+// emitEvent broadcasts an event to all clients connected to that deployment, events of a running job are
+// also recorded in the logs of its current attempt
+func (e EventEmitter) emitEvent(event Event) {
+	job.RecordLog(e.JobID, event.Message)
+
+	go func(clients []*websocket.Conn, deployment string) {
+		bytes, _ := json.Marshal(event)
 		for _, client := range clients {
-			bytes, _ := json.Marshal(Event{
-				JobID:   jobID,
-				Message: message,
-				Phase:   phase,
-			})
 			if err := client.WriteMessage(websocket.TextMessage, bytes); err != nil {
 				// this will log when a client has disconnected at which point the
 				// connection is not valid causing a write error. This should not
@@ -53,50 +71,132 @@ func (e EventEmitter) emit(message string) {
 				return
 			}
 		}
-	}(e.Clients, e.JobID, e.Deployment, e.Phase)
+	}(e.Clients, e.Deployment)
 }
 
+// streamBufferSize is the number of stream messages buffered for clients before messages are dropped
+const streamBufferSize = 256
+
 // emitStream broadcast a stream of data to all clients connected to the deployment.
-// A stream could be the data when pulling an image, reading container logs etc... where an io.Reader is returned
-func (e EventEmitter) emitStream(reader io.Reader) {
-	buffReader := bufio.NewReader(reader)
-	for {
-		bytes, _, err := buffReader.ReadLine()
-		if err != nil {
-			return
+// A stream could be the data when pulling an image, reading container logs etc... where an io.Reader is returned.
+// The stream is read until the end and the first error reported by the stream (if any) is returned.
+//
+// The stream is consumed independently from clients so slow or disconnected clients never throttle
+// the stream (ie. an image pull), messages are dropped for clients that can't keep up. When a progress
+// interval is provided, image layer messages are summarized into a progress event at most once per interval.
+func (e EventEmitter) emitStream(ref string, reader io.Reader, progressInterval time.Duration) error {
+	messages := make(chan string, streamBufferSize)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		e.broadcast(messages)
+	}()
+
+	var streamErr error
+	var dropped int
+	var lastProgress time.Time
+	progress := docker.NewPullProgress(ref)
+	send := func(message string) {
+		select {
+		case messages <- message:
+		default:
+			dropped++
+		}
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+
+		if err := docker.StreamMessageError(ref, line); err != nil && streamErr == nil {
+			streamErr = err
+		}
+
+		if progressInterval > 0 && progress.Update(line) {
+			if time.Since(lastProgress) >= progressInterval {
+				lastProgress = time.Now()
+				send(progress.String())
+			}
+			continue
 		}
 
+		send(string(line))
+	}
+
+	if progressInterval > 0 {
+		send(progress.String())
+	}
+
+	close(messages)
+	wg.Wait()
+
+	if dropped > 0 {
+		logger.Debugf("%d stream message(s) for deployment %s dropped, clients too slow", dropped, e.Deployment)
+	}
+
+	if err := scanner.Err(); err != nil && streamErr == nil {
+		streamErr = err
+	}
+
+	return streamErr
+}
+
+// broadcast writes messages to the connected clients until the messages channel is closed.
+// Clients failing a write are unsubscribed and skipped for the remaining messages.
+func (e EventEmitter) broadcast(messages <-chan string) {
+	clients := append([]*websocket.Conn{}, e.Clients...)
+	for message := range messages {
 		data, _ := json.Marshal(Event{
 			JobID:   e.JobID,
-			Message: string(bytes),
+			Message: message,
 			Phase:   e.Phase,
 		})
-		for _, client := range e.Clients {
+
+		connected := clients[:0]
+		for _, client := range clients {
 			if err := client.WriteMessage(websocket.TextMessage, data); err != nil {
 				// this will log when a client has disconnected at which point the
 				// connection is not valid causing a write error. This should not
 				// affect other clients or streaming logs in general.
 				logger.Debugf("client %v disconnected", client.RemoteAddr())
 				UnSubscribeFromDeploymentEvents(client, e.Deployment)
-				return
+				continue
 			}
+			connected = append(connected, client)
 		}
+		clients = connected
 	}
 }
 
-// SubscribeToDeploymentEvents allows clients to subscribes to a particular deployments events
+// SubscribeToDeploymentEvents allows clients to subscribes to a particular deployments events.
+// Clients are kept alive with pings and unsubscribed once they go away.
 func SubscribeToDeploymentEvents(client *websocket.Conn, deployment string) {
+	eventClientsMu.Lock()
 	eventClients[deployment] = append(eventClients[deployment], client)
+	eventClientsMu.Unlock()
+
+	gone := keepAlive(client, keepAliveInterval())
+	go func() {
+		<-gone
+		UnSubscribeFromDeploymentEvents(client, deployment)
+	}()
 }
 
 // UnSubscribeFromDeploymentEvents unsubscribes a client from deployment events
 func UnSubscribeFromDeploymentEvents(client *websocket.Conn, deployment string) {
+	eventClientsMu.Lock()
+	defer eventClientsMu.Unlock()
+
 	for i, c := range eventClients[deployment] {
 		if c == client {
 			if err := client.Close(); err != nil {
 				logger.Warnf("unable to properly close client connection %v", err)
 			}
 			eventClients[deployment] = append(eventClients[deployment][:i], eventClients[deployment][i+1:]...)
+			return
 		}
 	}
 }
@@ -0,0 +1,354 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+
+	"github.com/krane/krane/internal/docker"
+)
+
+// ExportFormat is the format a deployment is exported as
+type ExportFormat string
+
+const (
+	ExportRun     ExportFormat = "run"     // docker run command
+	ExportCompose ExportFormat = "compose" // docker compose file
+)
+
+// redactedValue replaces the value of secrets in exports
+const redactedValue = "<redacted>"
+
+var shellSafeRegex = regexp.MustCompile(`^[a-zA-Z0-9@%+=:,./_-]+$`)
+
+// exportSpec is the effective container configuration of a deployment, with its secrets masked
+type exportSpec struct {
+	Name         string
+	Image        string
+	Hostname     string
+	Scale        int
+	Ports        []string // host:container or container port bindings
+	Env          []string
+	Volumes      []string // source:target[:ro] bind mounts and named volumes
+	NamedVolumes []string // named volumes mounted
+	Labels       map[string]string
+	Command      []string
+	Entrypoint   []string
+	Network      string
+	User         string
+	GroupAdd     []string
+	Init         bool
+	Restart      string // restart policy in the docker --restart syntax, empty for the no policy
+	ShmSize      int64
+	Memory       int64
+	NanoCPUs     int64
+	MemorySwap   int64
+	CPUShares    int64
+	Unsupported  []string // settings of the deployment that cannot be exported
+}
+
+// Export returns the docker run command or docker compose file reproducing the containers of a deployment.
+// Secrets are masked and resource limits in percentage of the host are resolved against the docker host.
+func Export(ctx context.Context, deployment string, format ExportFormat) (string, error) {
+	config, err := GetDeploymentConfig(deployment)
+	if err != nil {
+		return "", err
+	}
+
+	if config.Empty() {
+		return "", fmt.Errorf("deployment %s does not exist", deployment)
+	}
+
+	config, err = config.withResolvedResources(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	spec := config.exportSpec()
+	switch format {
+	case ExportRun:
+		return spec.dockerRun(), nil
+	case ExportCompose:
+		return spec.compose(), nil
+	default:
+		return "", fmt.Errorf("unknown export format %s, expected %s or %s", format, ExportRun, ExportCompose)
+	}
+}
+
+// exportSpec returns the container configuration of a deployment as created by Krane, with its secrets masked
+func (config Config) exportSpec() exportSpec {
+	spec := exportSpec{
+		Name:       config.Name,
+		Image:      config.ImageRef(),
+		Hostname:   config.containerHostname(0),
+		Scale:      config.Scale,
+		Env:        config.localeEnvs(),
+		Labels:     config.DockerLabels(),
+		Network:    docker.KraneNetworkName,
+		User:       config.User,
+		GroupAdd:   config.GroupAdd,
+		Init:       config.Init,
+		Restart:    formatRestartPolicy(config.DockerRestartPolicy()),
+		ShmSize:    config.ShmSizeBytes(),
+		Memory:     config.ResolvedResources.Memory,
+		NanoCPUs:   config.ResolvedResources.NanoCPUs,
+		MemorySwap: config.ResolvedResources.MemorySwap,
+		CPUShares:  config.ResolvedResources.CPUShares,
+	}
+
+	if docker.IsIsolatedNetworkMode(config.NetworkMode) {
+		spec.Network = config.NetworkMode
+	} else {
+		for _, p := range config.portMappings() {
+			port := p.ContainerPort
+			if p.HostPort != "" {
+				port = fmt.Sprintf("%s:%s", p.HostPort, p.ContainerPort)
+			}
+			if p.Protocol != TCP {
+				port = fmt.Sprintf("%s/%s", port, p.Protocol)
+			}
+			spec.Ports = append(spec.Ports, port)
+		}
+	}
+
+	for _, k := range sortedKeys(config.Env) {
+		if !strings.HasPrefix(strings.ToUpper(k), ReservedEnvPrefix) {
+			spec.Env = append(spec.Env, formatEnv(k, config.Env[k]))
+		}
+	}
+	for _, k := range sortedKeys(config.Secrets) {
+		if !strings.HasPrefix(strings.ToUpper(k), ReservedEnvPrefix) {
+			spec.Env = append(spec.Env, formatEnv(k, redactedValue))
+		}
+	}
+
+	for _, m := range config.DockerVolumeMount() {
+		volume := fmt.Sprintf("%s:%s", m.Source, m.Target)
+		if m.ReadOnly {
+			volume += ":ro"
+		}
+		spec.Volumes = append(spec.Volumes, volume)
+		if m.Type == mount.TypeVolume {
+			spec.NamedVolumes = append(spec.NamedVolumes, m.Source)
+		}
+	}
+	sort.Strings(spec.Volumes)
+	sort.Strings(spec.NamedVolumes)
+
+	spec.Command = config.Command
+	spec.Entrypoint = config.Entrypoint
+
+	if config.Routed() && docker.ProxyNetworkName() != docker.KraneNetworkName && spec.Network == docker.KraneNetworkName {
+		spec.Unsupported = append(spec.Unsupported, fmt.Sprintf("containers are also attached to the proxy network %s", docker.ProxyNetworkName()))
+	}
+	if config.Liveness.Port != "" || config.HealthCheck.probed() {
+		spec.Unsupported = append(spec.Unsupported, "the health check and liveness probe are run by Krane")
+	}
+
+	return spec
+}
+
+// dockerRun returns the docker run command creating a container of the deployment
+func (s exportSpec) dockerRun() string {
+	var b strings.Builder
+	for _, note := range s.notes() {
+		b.WriteString(fmt.Sprintf("# %s\n", note))
+	}
+
+	args := []string{"docker", "run", "--detach", "--name", s.Hostname}
+	if s.Network != docker.NetworkModeHost {
+		args = append(args, "--hostname", s.Hostname)
+	}
+	args = append(args, "--network", s.Network)
+	for _, p := range s.Ports {
+		args = append(args, "--publish", p)
+	}
+	for _, e := range s.Env {
+		args = append(args, "--env", e)
+	}
+	for _, v := range s.Volumes {
+		args = append(args, "--volume", v)
+	}
+	for _, k := range sortedKeys(s.Labels) {
+		args = append(args, "--label", fmt.Sprintf("%s=%s", k, s.Labels[k]))
+	}
+	if s.User != "" {
+		args = append(args, "--user", s.User)
+	}
+	for _, g := range s.GroupAdd {
+		args = append(args, "--group-add", g)
+	}
+	if s.Init {
+		args = append(args, "--init")
+	}
+	if s.Restart != "" {
+		args = append(args, "--restart", s.Restart)
+	}
+	if s.ShmSize > 0 {
+		args = append(args, "--shm-size", fmt.Sprintf("%db", s.ShmSize))
+	}
+	if s.Memory > 0 {
+		args = append(args, "--memory", fmt.Sprintf("%db", s.Memory))
+	}
+	if s.MemorySwap > 0 {
+		args = append(args, "--memory-swap", fmt.Sprintf("%db", s.MemorySwap))
+	} else if s.MemorySwap < 0 {
+		args = append(args, "--memory-swap", "-1")
+	}
+	if s.NanoCPUs > 0 {
+		args = append(args, "--cpus", formatCPUs(s.NanoCPUs))
+	}
+	if s.CPUShares > 0 {
+		args = append(args, "--cpu-shares", strconv.FormatInt(s.CPUShares, 10))
+	}
+	// docker run only takes the entrypoint executable, its arguments are passed before the command
+	if len(s.Entrypoint) > 0 {
+		args = append(args, "--entrypoint", s.Entrypoint[0])
+	}
+	args = append(args, s.Image)
+	if len(s.Entrypoint) > 1 {
+		args = append(args, s.Entrypoint[1:]...)
+	}
+	args = append(args, s.Command...)
+
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	b.WriteString(strings.Join(quoted, " \\\n  "))
+	b.WriteString("\n")
+	return b.String()
+}
+
+// compose returns a docker compose file with a service creating the containers of the deployment
+func (s exportSpec) compose() string {
+	var b strings.Builder
+	for _, note := range s.notes() {
+		b.WriteString(fmt.Sprintf("# %s\n", note))
+	}
+
+	line := func(indent int, format string, args ...interface{}) {
+		b.WriteString(strings.Repeat("  ", indent))
+		b.WriteString(fmt.Sprintf(format, args...))
+		b.WriteString("\n")
+	}
+	list := func(key string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		line(2, "%s:", key)
+		for _, v := range values {
+			line(3, "- %s", yamlQuote(v))
+		}
+	}
+
+	line(0, "version: %s", yamlQuote("3.8"))
+	line(0, "services:")
+	line(1, "%s:", s.Name)
+	line(2, "image: %s", yamlQuote(s.Image))
+	if s.Network != docker.NetworkModeHost {
+		line(2, "hostname: %s", yamlQuote(s.Hostname))
+	}
+	if docker.IsIsolatedNetworkMode(s.Network) {
+		line(2, "network_mode: %s", yamlQuote(s.Network))
+	} else {
+		line(2, "networks:")
+		line(3, "- %s", s.Network)
+	}
+	if s.Scale > 1 {
+		line(2, "scale: %d", s.Scale)
+	}
+	list("ports", s.Ports)
+	list("environment", s.Env)
+	list("volumes", s.Volumes)
+	if len(s.Labels) > 0 {
+		line(2, "labels:")
+		for _, k := range sortedKeys(s.Labels) {
+			line(3, "%s: %s", yamlQuote(k), yamlQuote(s.Labels[k]))
+		}
+	}
+	if s.User != "" {
+		line(2, "user: %s", yamlQuote(s.User))
+	}
+	list("group_add", s.GroupAdd)
+	if s.Init {
+		line(2, "init: true")
+	}
+	if s.Restart != "" {
+		line(2, "restart: %s", yamlQuote(s.Restart))
+	}
+	if s.ShmSize > 0 {
+		line(2, "shm_size: %s", yamlQuote(fmt.Sprintf("%db", s.ShmSize)))
+	}
+	if s.Memory > 0 {
+		line(2, "mem_limit: %s", yamlQuote(fmt.Sprintf("%db", s.Memory)))
+	}
+	if s.MemorySwap > 0 {
+		line(2, "memswap_limit: %s", yamlQuote(fmt.Sprintf("%db", s.MemorySwap)))
+	} else if s.MemorySwap < 0 {
+		line(2, "memswap_limit: -1")
+	}
+	if s.NanoCPUs > 0 {
+		line(2, "cpus: %s", yamlQuote(formatCPUs(s.NanoCPUs)))
+	}
+	if s.CPUShares > 0 {
+		line(2, "cpu_shares: %d", s.CPUShares)
+	}
+	list("entrypoint", s.Entrypoint)
+	list("command", s.Command)
+
+	if !docker.IsIsolatedNetworkMode(s.Network) {
+		line(0, "networks:")
+		line(1, "%s:", s.Network)
+		line(2, "external: true")
+	}
+	if len(s.NamedVolumes) > 0 {
+		// volumes are named explicitly so compose does not prefix them with the project name
+		line(0, "volumes:")
+		for _, v := range s.NamedVolumes {
+			line(1, "%s:", v)
+			line(2, "name: %s", yamlQuote(v))
+		}
+	}
+	return b.String()
+}
+
+// notes returns the comments heading an export
+func (s exportSpec) notes() []string {
+	notes := []string{fmt.Sprintf("exported from the krane deployment %s, secrets are redacted", s.Name)}
+	if s.Scale > 1 {
+		notes = append(notes, fmt.Sprintf("the deployment runs %d containers suffixed with their index (ie. %s)", s.Scale, s.Hostname))
+	}
+	for _, u := range s.Unsupported {
+		notes = append(notes, fmt.Sprintf("not exported: %s", u))
+	}
+	return notes
+}
+
+// formatCPUs returns a cpu limit in units of 1e-9 cpus as a number of cpus (ie. 0.5)
+func formatCPUs(nanoCPUs int64) string {
+	return strconv.FormatFloat(float64(nanoCPUs)/1e9, 'f', -1, 64)
+}
+
+// shellQuote returns an argument quoted for a POSIX shell, arguments without special characters are left as is
+func shellQuote(arg string) string {
+	if shellSafeRegex.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// yamlQuote returns a string as a double quoted yaml scalar, json strings are valid yaml scalars
+func yamlQuote(value string) string {
+	var b strings.Builder
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(value)
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,73 @@
+package deployment
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func exportableConfig() Config {
+	return Config{
+		Name:     "my-app",
+		Image:    "nginx",
+		Tag:      "1.19",
+		Registry: Registry{URL: "docker.io"},
+		Scale:    1,
+		Env:      map[string]string{"GREETING": "it's me", "PORT": "8080"},
+		Secrets:  map[string]string{"DB_PASSWORD": "@DB_PASSWORD"},
+		Ports:    map[string]string{"8080": "80"},
+		Volumes:  map[string]string{"/srv/data": "/data"},
+		Labels:   map[string]string{},
+		Command:  CommandArgs{"nginx", "-g", "daemon off;"},
+		User:     "1000:1000",
+		ShmSize:  "128mb",
+	}
+}
+
+func TestExportDockerRun(t *testing.T) {
+	run := exportableConfig().exportSpec().dockerRun()
+
+	assert.True(t, strings.HasPrefix(run, "# exported from the krane deployment my-app, secrets are redacted\ndocker \\\n  run \\\n  --detach \\\n  --name \\\n  my-app"))
+	assert.Contains(t, run, "--publish \\\n  8080:80")
+	assert.Contains(t, run, `--env \`+"\n  'GREETING=it'\\''s me'")
+	assert.Contains(t, run, "'DB_PASSWORD=<redacted>'")
+	assert.Contains(t, run, "--volume \\\n  /srv/data:/data")
+	assert.Contains(t, run, "--shm-size \\\n  134217728b")
+	assert.Contains(t, run, "--label \\\n  krane.deployment=my-app")
+	assert.True(t, strings.HasSuffix(run, "docker.io/nginx:1.19 \\\n  nginx \\\n  -g \\\n  'daemon off;'\n"))
+	assert.NotContains(t, run, "hunter2")
+}
+
+func TestExportCompose(t *testing.T) {
+	config := exportableConfig()
+	config.Scale = 2
+	compose := config.exportSpec().compose()
+
+	assert.Contains(t, compose, "services:\n  my-app:\n    image: \"docker.io/nginx:1.19\"\n    hostname: \"my-app-0\"\n    networks:\n      - krane\n    scale: 2\n")
+	assert.Contains(t, compose, "    environment:\n      - \"GREETING=it's me\"\n      - \"PORT=8080\"\n      - \"DB_PASSWORD=<redacted>\"\n")
+	assert.Contains(t, compose, "    user: \"1000:1000\"\n")
+	assert.Contains(t, compose, "# the deployment runs 2 containers suffixed with their index (ie. my-app-0)\n")
+	assert.True(t, strings.HasSuffix(compose, "networks:\n  krane:\n    external: true\n"))
+}
+
+func TestExportIsolatedNetworkMode(t *testing.T) {
+	config := exportableConfig()
+	config.NetworkMode = "host"
+	spec := config.exportSpec()
+
+	assert.Empty(t, spec.Ports)
+	assert.NotContains(t, spec.dockerRun(), "--hostname")
+	assert.Contains(t, spec.compose(), "    network_mode: \"host\"\n")
+	assert.NotContains(t, spec.compose(), "external: true")
+}
+
+func TestExportNamedVolumes(t *testing.T) {
+	config := exportableConfig()
+	config.Mounts = []VolumeMount{{Source: "cache", Target: "/cache", ReadOnly: true}}
+	spec := config.exportSpec()
+
+	assert.Equal(t, []string{"/srv/data:/data", "cache:/cache:ro"}, spec.Volumes)
+	assert.Contains(t, spec.dockerRun(), "--volume \\\n  cache:/cache:ro")
+	assert.True(t, strings.HasSuffix(spec.compose(), "volumes:\n  cache:\n    name: \"cache\"\n"))
+}
@@ -0,0 +1,103 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/krane/krane/internal/docker"
+)
+
+// MaxContainerFileSize is the max size in bytes of a file archive copied into or out of a container
+const MaxContainerFileSize = 50 * 1024 * 1024 // 50mb
+
+// CopyTo extracts a tar archive into a path inside a Krane managed container
+func (c KraneContainer) CopyTo(containerPath string, archive io.Reader) error {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	if err := isValidContainerPath(containerPath); err != nil {
+		return err
+	}
+
+	return docker.GetClient().CopyToContainer(ctx, c.ID, containerPath, archive)
+}
+
+// CopyFrom returns a tar archive of a path inside a Krane managed container.
+// The caller is responsible for closing the returned reader.
+func (c KraneContainer) CopyFrom(containerPath string) (io.ReadCloser, error) {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	if err := isValidContainerPath(containerPath); err != nil {
+		return nil, err
+	}
+
+	reader, stat, err := docker.GetClient().CopyFromContainer(ctx, c.ID, containerPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if stat.Size > MaxContainerFileSize {
+		_ = reader.Close()
+		return nil, fmt.Errorf("%s exceeds the max file size of %d bytes", containerPath, MaxContainerFileSize)
+	}
+
+	// the stat size of a directory is not the size of its content, the archive itself is limited while it is read
+	return newSizeLimitedReader(reader, containerPath, MaxContainerFileSize), nil
+}
+
+// sizeLimitedReader fails reading an archive once more than limit bytes were read from it
+type sizeLimitedReader struct {
+	io.ReadCloser
+	path  string
+	limit int64
+	read  int64
+}
+
+// newSizeLimitedReader returns a reader failing once more than limit bytes were read from reader
+func newSizeLimitedReader(reader io.ReadCloser, path string, limit int64) *sizeLimitedReader {
+	return &sizeLimitedReader{ReadCloser: reader, path: path, limit: limit}
+}
+
+// Read reads from the archive and returns an error once the archive exceeds the limit
+func (r *sizeLimitedReader) Read(p []byte) (int, error) {
+	if r.read > r.limit {
+		return 0, r.limitError()
+	}
+
+	// read at most a byte past the limit to detect archives exceeding it
+	if remaining := r.limit - r.read + 1; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+	if r.read > r.limit {
+		return n - int(r.read-r.limit), r.limitError()
+	}
+	return n, err
+}
+
+func (r *sizeLimitedReader) limitError() error {
+	return fmt.Errorf("%s exceeds the max file size of %d bytes", r.path, r.limit)
+}
+
+// isValidContainerPath returns an error if a path is not an absolute and clean path
+func isValidContainerPath(containerPath string) error {
+	if containerPath == "" {
+		return fmt.Errorf("container path required")
+	}
+
+	if !path.IsAbs(containerPath) {
+		return fmt.Errorf("container path %s must be absolute", containerPath)
+	}
+
+	if strings.Contains(containerPath, "..") {
+		return fmt.Errorf("container path %s must not contain '..'", containerPath)
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package deployment
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidContainerPaths(t *testing.T) {
+	assert.Nil(t, isValidContainerPath("/etc/nginx/nginx.conf"))
+	assert.Nil(t, isValidContainerPath("/var/log"))
+}
+
+func TestInvalidContainerPaths(t *testing.T) {
+	assert.Error(t, isValidContainerPath(""))
+	assert.Error(t, isValidContainerPath("etc/nginx/nginx.conf"))
+	assert.Error(t, isValidContainerPath("/var/log/../../etc/shadow"))
+}
+
+func TestSizeLimitedReaderFailsOverLimit(t *testing.T) {
+	reader := newSizeLimitedReader(ioutil.NopCloser(strings.NewReader("0123456789")), "/var/log", 5)
+
+	b, err := ioutil.ReadAll(reader)
+	assert.Error(t, err)
+	assert.Equal(t, "01234", string(b))
+}
+
+func TestSizeLimitedReaderWithinLimit(t *testing.T) {
+	reader := newSizeLimitedReader(ioutil.NopCloser(strings.NewReader("0123456789")), "/var/log", 10)
+
+	b, err := ioutil.ReadAll(reader)
+	assert.Nil(t, err)
+	assert.Equal(t, "0123456789", string(b))
+}
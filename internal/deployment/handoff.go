@@ -0,0 +1,92 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/krane/krane/internal/logger"
+)
+
+// portHandoff hands the fixed host ports of a deployment over from the containers replaced by a run to the new containers.
+// A host port can only be bound by one container, so new containers binding the same fixed host port as a running
+// container would fail to start with "address already in use". Instead the replaced containers bound to those ports
+// are stopped right before the new containers start (once the image is pulled and containers created, keeping the
+// downtime minimal) and are started back if the run fails.
+type portHandoff struct {
+	ports      []string         // fixed host ports of the deployment bound by replaced containers
+	containers []KraneContainer // replaced containers bound to the fixed host ports
+	stopped    []KraneContainer // replaced containers stopped by the handoff
+	timeout    time.Duration    // time the replaced containers are given to exit before they are killed
+}
+
+// newPortHandoff returns the port handoff for the containers replaced by a run, nil if no replaced container
+// is bound to a fixed host port of the deployment and the containers can overlap
+func newPortHandoff(config Config, replaced []KraneContainer) *portHandoff {
+	fixed := make(map[string]bool)
+	for _, p := range config.portMappings() {
+		if p.HostPort != "" {
+			fixed[p.hostBinding()] = true
+		}
+	}
+
+	handoff := &portHandoff{ports: make([]string, 0), containers: make([]KraneContainer, 0), timeout: config.ContainerStopTimeout()}
+	seen := make(map[string]bool)
+	for _, c := range replaced {
+		conflicts := false
+		for _, p := range c.Ports {
+			binding := PortMapping{HostPort: p.HostPort, Protocol: PortProtocol(p.Type)}.hostBinding()
+			if !fixed[binding] {
+				continue
+			}
+			conflicts = true
+			if !seen[binding] {
+				seen[binding] = true
+				handoff.ports = append(handoff.ports, binding)
+			}
+		}
+		if conflicts && c.State.Running {
+			handoff.containers = append(handoff.containers, c)
+		}
+	}
+
+	if len(handoff.containers) == 0 {
+		return nil
+	}
+	return handoff
+}
+
+// stop stops the replaced containers bound to the fixed host ports, containers already stopped by the handoff are skipped
+func (h *portHandoff) stop(ctx context.Context, e *EventEmitter) error {
+	if h == nil || len(h.stopped) > 0 {
+		return nil
+	}
+
+	e.emit(fmt.Sprintf("Stopping %d container(s) bound to host port(s) %s before starting the new containers",
+		len(h.containers), strings.Join(h.ports, ", ")))
+	for _, c := range h.containers {
+		if err := c.Stop(ctx, h.timeout); err != nil {
+			return fmt.Errorf("unable to stop container %s bound to host port(s) %s, %w", c.Name, strings.Join(h.ports, ", "), err)
+		}
+		h.stopped = append(h.stopped, c)
+	}
+	return nil
+}
+
+// restore starts the replaced containers stopped by the handoff back, once the new containers are removed after a failed run
+func (h *portHandoff) restore(e *EventEmitter) {
+	if h == nil || len(h.stopped) == 0 {
+		return
+	}
+
+	for _, c := range h.stopped {
+		if err := c.Start(context.Background()); err != nil {
+			logger.Errorf("unable to restart replaced container %v", err)
+			e.emit(fmt.Sprintf("Container %s could not be started back after the failed deploy: %v", c.Name, err))
+			continue
+		}
+	}
+	e.emit(fmt.Sprintf("Started %d previous container(s) back after the failed deploy", len(h.stopped)))
+	h.stopped = nil
+}
@@ -0,0 +1,39 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPortHandoff(t *testing.T) {
+	config := Config{Name: "handoff", Scale: 1, Ports: map[string]string{"8080": "80", "": "9000"}}
+	running := ContainerState{Running: true}
+	replaced := []KraneContainer{
+		{ID: "bound", State: running, Ports: []Port{{HostPort: "8080", ContainerPort: "80", Type: "tcp"}}},
+		{ID: "random", State: running, Ports: []Port{{HostPort: "32768", ContainerPort: "9000", Type: "tcp"}}},
+		{ID: "stopped", State: ContainerState{Running: false}, Ports: []Port{{HostPort: "8080", ContainerPort: "80", Type: "tcp"}}},
+	}
+
+	handoff := newPortHandoff(config, replaced)
+	assert.NotNil(t, handoff)
+	assert.Equal(t, []string{"8080/tcp"}, handoff.ports)
+	assert.Len(t, handoff.containers, 1)
+	assert.Equal(t, "bound", handoff.containers[0].ID)
+
+	// host ports are only handed over between bindings of the same protocol
+	udp := Config{Name: "handoff", Scale: 1, PortMappings: []PortMapping{{HostPort: "8080", ContainerPort: "80", Protocol: UDP}}}
+	assert.Nil(t, newPortHandoff(udp, replaced))
+
+	// containers without fixed host ports can overlap
+	assert.Nil(t, newPortHandoff(Config{Name: "handoff", Ports: map[string]string{"": "80"}}, replaced))
+	assert.Nil(t, newPortHandoff(config, []KraneContainer{}))
+}
+
+func TestNilPortHandoff(t *testing.T) {
+	var handoff *portHandoff
+	e := createEventEmitter("handoff", "")
+	assert.Nil(t, handoff.stop(context.Background(), e))
+	handoff.restore(e)
+}
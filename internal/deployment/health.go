@@ -0,0 +1,75 @@
+package deployment
+
+import (
+	"fmt"
+	"sync"
+)
+
+// HealthStatus represents the health of a deployment as a whole
+type HealthStatus string
+
+const (
+	Healthy   HealthStatus = "HEALTHY"   // every expected container is healthy
+	Degraded  HealthStatus = "DEGRADED"  // some but not all expected containers are healthy
+	Unhealthy HealthStatus = "UNHEALTHY" // no container is healthy
+)
+
+// Health is the health of a deployment computed from its containers
+type Health struct {
+	Status   HealthStatus `json:"status"`
+	Healthy  int          `json:"healthy"`  // number of healthy containers
+	Expected int          `json:"expected"` // number of containers expected by the deployment scale
+}
+
+var healthMu sync.Mutex
+var lastHealth = make(map[string]HealthStatus)
+
+// GetHealth returns the health of a deployment based on the state of its containers
+func (d Deployment) GetHealth() Health {
+	healthy := 0
+	for _, c := range d.Containers {
+		if c.healthy() {
+			healthy++
+		}
+	}
+
+	// a deployment scaled to 0 expects no container and is healthy
+	expected := d.Config.Scale
+	status := Degraded
+	switch {
+	case healthy >= expected:
+		status = Healthy
+	case healthy == 0:
+		status = Unhealthy
+	}
+
+	return Health{Status: status, Healthy: healthy, Expected: expected}
+}
+
+// healthy returns whether a container is running and not reported as unhealthy by its docker health check
+func (c KraneContainer) healthy() bool {
+	if !c.State.Running {
+		return false
+	}
+	return c.State.Health == nil || c.State.Health.Status != "unhealthy"
+}
+
+// MonitorHealth computes the health of a deployment and emits a health event to the deployment event
+// subscribers when its health status has changed since it was last monitored. Returns the deployment health.
+func MonitorHealth(d Deployment) Health {
+	health := d.GetHealth()
+
+	healthMu.Lock()
+	previous, seen := lastHealth[d.Config.Name]
+	lastHealth[d.Config.Name] = health.Status
+	healthMu.Unlock()
+
+	if seen && previous != health.Status {
+		e := createEventEmitter(d.Config.Name, "")
+		e.Phase = HealthPhase
+		e.emitHealth(fmt.Sprintf("deployment %s %s -> %s, %d/%d container(s) healthy",
+			d.Config.Name, previous, health.Status, health.Healthy, health.Expected), health)
+	}
+
+	return health
+}
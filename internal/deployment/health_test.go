@@ -0,0 +1,97 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/metrics"
+)
+
+func TestDeploymentHealth(t *testing.T) {
+	running := KraneContainer{State: ContainerState{Running: true}}
+	stopped := KraneContainer{State: ContainerState{Running: false}}
+	failing := KraneContainer{State: ContainerState{Running: true, Health: &types.Health{Status: "unhealthy"}}}
+
+	d := Deployment{Config: Config{Name: "health", Scale: 2}, Containers: []KraneContainer{running, running}}
+	assert.Equal(t, Health{Status: Healthy, Healthy: 2, Expected: 2}, d.GetHealth())
+
+	d.Containers = []KraneContainer{running, failing}
+	assert.Equal(t, Health{Status: Degraded, Healthy: 1, Expected: 2}, d.GetHealth())
+
+	d.Containers = []KraneContainer{stopped}
+	assert.Equal(t, Health{Status: Unhealthy, Healthy: 0, Expected: 2}, d.GetHealth())
+
+	// a deployment scaled to 0 expects no container
+	d = Deployment{Config: Config{Name: "health", Scale: 0}, Containers: []KraneContainer{}}
+	assert.Equal(t, Health{Status: Healthy, Healthy: 0, Expected: 0}, d.GetHealth())
+}
+
+func TestMonitorHealthTracksTransitions(t *testing.T) {
+	d := Deployment{Config: Config{Name: "health-monitor", Scale: 1}, Containers: []KraneContainer{{State: ContainerState{Running: true}}}}
+	assert.Equal(t, Healthy, MonitorHealth(d).Status)
+
+	d.Containers = []KraneContainer{}
+	assert.Equal(t, Unhealthy, MonitorHealth(d).Status)
+	assert.Equal(t, Unhealthy, lastHealth["health-monitor"])
+}
+
+func TestCheckContainersHealthConcurrently(t *testing.T) {
+	containers := []KraneContainer{{Name: "app-1"}, {Name: "app-2"}, {Name: "app-3"}}
+
+	var mu sync.Mutex
+	attempts := make(map[string]int)
+	running := func(c KraneContainer, ctx context.Context) (bool, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts[c.Name]++
+		if c.Name == "app-2" {
+			return false, fmt.Errorf("container %s is not in running state", c.Name)
+		}
+		return attempts[c.Name] > 1, nil
+	}
+
+	start := time.Now()
+	unhealthy, err := checkContainersHealth(context.Background(), containers, healthCheckPolicy{Retries: 2, Backoff: 100 * time.Millisecond}, running)
+	assert.Nil(t, err)
+	assert.Len(t, unhealthy, 1)
+	assert.Equal(t, "container app-2 unhealthy after 3 attempt(s), container app-2 is not in running state", unhealthy[0].Error())
+	assert.Equal(t, 3, attempts["app-2"])
+	assert.Equal(t, 2, attempts["app-1"])
+
+	// checked concurrently the slowest container takes 300ms, sequentially it would take 500ms
+	assert.Less(t, int64(time.Since(start)), int64(450*time.Millisecond))
+}
+
+func TestCheckContainersHealthAborted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	running := func(c KraneContainer, ctx context.Context) (bool, error) { return true, nil }
+	_, err := checkContainersHealth(ctx, []KraneContainer{{Name: "app-1"}}, healthCheckPolicy{Retries: 2, Backoff: time.Millisecond}, running)
+	assert.Error(t, err)
+}
+
+func TestSetHealthMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	registry.SetGauge(HealthMetric, metrics.Labels{"deployment": "removed", "status": string(Healthy)}, 1)
+
+	d := Deployment{Config: Config{Name: "app", Scale: 2}, Containers: []KraneContainer{{State: ContainerState{Running: true}}}}
+	d.Containers = append(d.Containers, KraneContainer{State: ContainerState{Running: false}})
+	setHealthMetrics(registry, []Deployment{d})
+
+	snapshot := registry.Snapshot()
+	assert.Len(t, snapshot, 4)
+	assert.Equal(t, ExpectedContainersMetric, snapshot[0].Name)
+	assert.Equal(t, 2.0, snapshot[0].Value)
+	assert.Equal(t, HealthyContainersMetric, snapshot[1].Name)
+	assert.Equal(t, 1.0, snapshot[1].Value)
+	assert.Equal(t, metrics.Labels{"deployment": "app", "status": string(Degraded)}, snapshot[2].Labels)
+	assert.Equal(t, RunningContainersMetric, snapshot[3].Name)
+	assert.Equal(t, 1.0, snapshot[3].Value)
+}
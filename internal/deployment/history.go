@@ -0,0 +1,202 @@
+package deployment
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/store"
+)
+
+// HistoryEntry is a revision of a deployment configuration
+type HistoryEntry struct {
+	ID         string `json:"id"`
+	Deployment string `json:"deployment"`
+	Config     Config `json:"config"`
+	Note       string `json:"note"`   // optional note describing the change
+	JobID      string `json:"job_id"` // id of the first deployment run using this revision (if any)
+	CreatedAt  int64  `json:"created_at"`
+	DeployedAt int64  `json:"deployed_at,omitempty"` // unix time of the last successful run of this revision, 0 if it never deployed successfully
+}
+
+// DefaultHistoryLimit is the number of successfully deployed revisions kept per deployment
+const DefaultHistoryLimit = 10
+
+// recordHistory stores a new revision for a deployment configuration
+func recordHistory(config Config, note string) (HistoryEntry, error) {
+	now := time.Now()
+	entry := HistoryEntry{
+		// zero padded unix nano ids keep history entries sorted by creation time
+		ID:         fmt.Sprintf("%020d", now.UnixNano()),
+		Deployment: config.Name,
+		Config:     config,
+		Note:       note,
+		CreatedAt:  now.Unix(),
+	}
+
+	return entry, saveHistoryEntry(entry)
+}
+
+// saveHistoryEntry upserts a history entry
+func saveHistoryEntry(entry HistoryEntry) error {
+	bytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return store.Client().Put(getHistoryCollectionName(entry.Deployment), entry.ID, bytes)
+}
+
+// GetHistory returns the configuration revisions for a deployment sorted from newest to oldest
+func GetHistory(deployment string) ([]HistoryEntry, error) {
+	bytes, err := store.Client().GetAll(getHistoryCollectionName(deployment))
+	if err != nil {
+		return make([]HistoryEntry, 0), err
+	}
+
+	history := make([]HistoryEntry, 0)
+	for _, b := range bytes {
+		var entry HistoryEntry
+		if err := json.Unmarshal(b, &entry); err != nil {
+			logger.Errorf("unable to deserialize history entry %v", err)
+			continue
+		}
+		history = append(history, entry)
+	}
+
+	sort.Slice(history, func(i, j int) bool { return history[i].ID > history[j].ID })
+
+	return history, nil
+}
+
+// linkHistoryToJob associates the latest revision of a deployment with the job running it.
+// Revisions already run by a previous job are left unchanged. The note of the revision is returned.
+func linkHistoryToJob(deployment string, jobID string) string {
+	history, err := GetHistory(deployment)
+	if err != nil || len(history) == 0 {
+		return ""
+	}
+
+	latest := history[0]
+	if latest.JobID != "" {
+		return ""
+	}
+
+	latest.JobID = jobID
+	if err := saveHistoryEntry(latest); err != nil {
+		logger.Errorf("unable to link history entry to job %v", err)
+	}
+
+	return latest.Note
+}
+
+// markRevisionDeployed records the revision deployed by a job as successfully deployed, then prunes the history
+// of the deployment down to the history limit
+func markRevisionDeployed(deployment string, jobID string) {
+	history, err := GetHistory(deployment)
+	if err != nil || len(history) == 0 {
+		return
+	}
+
+	revision := revisionForJob(deployment, jobID)
+	for _, entry := range history {
+		if entry.ID != revision {
+			continue
+		}
+
+		entry.DeployedAt = time.Now().Unix()
+		if err := saveHistoryEntry(entry); err != nil {
+			logger.Warnf("unable to mark revision %s of deployment %s deployed, %v", entry.ID, deployment, err)
+			return
+		}
+		break
+	}
+
+	history, err = GetHistory(deployment)
+	if err != nil {
+		return
+	}
+
+	for _, id := range prunedRevisions(history, historyLimit()) {
+		if err := store.Client().Remove(getHistoryCollectionName(deployment), id); err != nil {
+			logger.Warnf("unable to prune revision %s of deployment %s, %v", id, deployment, err)
+		}
+	}
+}
+
+// prunedRevisions returns the ids of the revisions to remove from a history (sorted from newest to oldest) to keep
+// the limit most recently deployed revisions. Revisions never deployed are removed once they are older than every
+// revision kept, the latest revision is always kept.
+func prunedRevisions(history []HistoryEntry, limit int) []string {
+	deployed := make([]HistoryEntry, 0)
+	for _, entry := range history {
+		if entry.DeployedAt > 0 {
+			deployed = append(deployed, entry)
+		}
+	}
+
+	if len(deployed) <= limit {
+		return make([]string, 0)
+	}
+
+	sort.SliceStable(deployed, func(i, j int) bool { return deployed[i].DeployedAt > deployed[j].DeployedAt })
+	kept := make(map[string]bool, limit)
+	oldest := history[0].ID
+	for _, entry := range deployed[:limit] {
+		kept[entry.ID] = true
+		if entry.ID < oldest {
+			oldest = entry.ID
+		}
+	}
+
+	pruned := make([]string, 0)
+	for i, entry := range history {
+		if i == 0 || kept[entry.ID] {
+			continue
+		}
+		if entry.DeployedAt > 0 || entry.ID < oldest {
+			pruned = append(pruned, entry.ID)
+		}
+	}
+	return pruned
+}
+
+// historyLimit returns the number of successfully deployed revisions kept per deployment
+func historyLimit() int {
+	value := os.Getenv(constants.EnvDeploymentHistoryLimit)
+	if value == "" {
+		return DefaultHistoryLimit
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 {
+		logger.Warnf("invalid %s %s, using %d", constants.EnvDeploymentHistoryLimit, value, DefaultHistoryLimit)
+		return DefaultHistoryLimit
+	}
+	return limit
+}
+
+// CreateHistoryCollection creates the history collection for a deployment
+func CreateHistoryCollection(deployment string) error {
+	return store.Client().CreateCollection(getHistoryCollectionName(deployment))
+}
+
+// DeleteHistoryCollection deletes the history collection for a deployment
+func DeleteHistoryCollection(deployment string) error {
+	return store.Client().DeleteCollection(getHistoryCollectionName(deployment))
+}
+
+func getHistoryCollectionName(deployment string) string {
+	return strings.ToLower(fmt.Sprintf("%s-%s", deployment, constants.HistoryCollectionName))
+}
+
+// Redacted returns the history entry with the registry credentials of its configuration masked, for api responses
+func (entry HistoryEntry) Redacted() HistoryEntry {
+	entry.Config = entry.Config.Redacted()
+	return entry
+}
@@ -0,0 +1,49 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveConfigRecordsHistory(t *testing.T) {
+	assert.Nil(t, SaveConfigWithNote(Config{Name: "history", Image: "nginx", Tag: "1.18"}, "initial"))
+	assert.Nil(t, SaveConfigWithNote(Config{Name: "history", Image: "nginx", Tag: "1.19"}, "bumped to 1.19"))
+	defer DeleteConfig("history")
+	defer DeleteHistoryCollection("history")
+
+	history, err := GetHistory("history")
+	assert.Nil(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, "bumped to 1.19", history[0].Note)
+	assert.Equal(t, "1.19", history[0].Config.Tag)
+	assert.Equal(t, "initial", history[1].Note)
+}
+
+func TestLinkHistoryToJob(t *testing.T) {
+	assert.Nil(t, SaveConfigWithNote(Config{Name: "history-job", Image: "nginx"}, "fixes CVE"))
+	defer DeleteConfig("history-job")
+	defer DeleteHistoryCollection("history-job")
+
+	assert.Equal(t, "fixes CVE", linkHistoryToJob("history-job", "job-1"))
+
+	// a revision is only linked to the first job running it
+	assert.Equal(t, "", linkHistoryToJob("history-job", "job-2"))
+
+	history, _ := GetHistory("history-job")
+	assert.Equal(t, "job-1", history[0].JobID)
+}
+
+func TestMarkRevisionDeployed(t *testing.T) {
+	assert.Nil(t, SaveConfigWithNote(Config{Name: "history-deployed", Image: "nginx", Tag: "1.18"}, "initial"))
+	assert.Nil(t, SaveConfigWithNote(Config{Name: "history-deployed", Image: "nginx", Tag: "1.19"}, "bumped to 1.19"))
+	defer DeleteConfig("history-deployed")
+	defer DeleteHistoryCollection("history-deployed")
+
+	linkHistoryToJob("history-deployed", "job-1")
+	markRevisionDeployed("history-deployed", "job-1")
+
+	history, _ := GetHistory("history-deployed")
+	assert.NotZero(t, history[0].DeployedAt)
+	assert.Zero(t, history[1].DeployedAt)
+}
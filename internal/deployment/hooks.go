@@ -0,0 +1,205 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/job"
+	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/utils"
+)
+
+// DefaultHookTimeout is the max time for a hook command to exit when the hook does not configure one
+const DefaultHookTimeout = 5 * time.Minute
+
+// HookContainerLabel labels hook containers with the name of their deployment. Hook containers are not labeled
+// as deployment containers so they are never routed to or mistaken for the containers of the deployment.
+const HookContainerLabel = "krane.hook.deployment"
+
+// hookOutputLines is the number of output lines of a hook command recorded in the deployment events
+const hookOutputLines = 50
+
+// Hook is a command run in a throwaway container from the deployment image (like docker run --rm) during a deploy
+type Hook struct {
+	Command []string `json:"command"` // command and arguments run in the hook container, the hook is disabled when empty
+	Timeout uint     `json:"timeout"` // max time in seconds for the command to exit (default 300)
+}
+
+// HookName names the hooks of a deployment
+type HookName string
+
+const (
+	PreDeployHook  HookName = "pre_deploy"  // run before the new containers are created, a failure aborts the deploy
+	PostDeployHook HookName = "post_deploy" // run once the previous containers are removed
+)
+
+// HookFailedError is returned when a hook command exits with a non-zero code. Running the
+// same command again is expected to fail the same way so the error is permanent.
+type HookFailedError struct {
+	Deployment string
+	Hook       HookName
+	ExitCode   int64
+}
+
+// Error returns a string representation of a HookFailedError
+func (e HookFailedError) Error() string {
+	return fmt.Sprintf("%s hook of deployment %s exited with code %d", e.Hook, e.Deployment, e.ExitCode)
+}
+
+// Permanent returns true since the hook command failed
+func (e HookFailedError) Permanent() bool { return true }
+
+// Enabled returns true if the hook has a command to run
+func (h Hook) Enabled() bool {
+	return len(h.Command) > 0
+}
+
+// HookTimeout returns the max duration for the hook command to exit
+func (h Hook) HookTimeout() time.Duration {
+	if h.Timeout == 0 {
+		return DefaultHookTimeout
+	}
+	return time.Duration(h.Timeout) * time.Second
+}
+
+// hooksFieldErrors returns a validation error for every hook with a malformed command
+func (config Config) hooksFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+	errs = append(errs, config.PreDeploy.fieldErrors(PreDeployHook)...)
+	errs = append(errs, config.PostDeploy.fieldErrors(PostDeployHook)...)
+	return errs
+}
+
+// fieldErrors returns a validation error if a hook configures a timeout without a command or its command has no executable
+func (h Hook) fieldErrors(name HookName) []FieldError {
+	errs := make([]FieldError, 0)
+	if !h.Enabled() {
+		if h.Timeout > 0 {
+			errs = append(errs, newFieldError(string(name), "%s timeout set without a command", name))
+		}
+		return errs
+	}
+
+	if strings.TrimSpace(h.Command[0]) == "" {
+		errs = append(errs, newFieldError(string(name), "%s command must start with an executable", name))
+	}
+
+	return errs
+}
+
+// runPreDeployHook runs the pre-deploy hook of a deployment (if any), the deploy is aborted if it fails
+func runPreDeployHook(ctx context.Context, config Config, e *EventEmitter) error {
+	if !config.PreDeploy.Enabled() {
+		return nil
+	}
+
+	job.RecordStep(ctx, string(PreDeployHook))
+	start := time.Now()
+	err := runHook(ctx, config, PreDeployHook, config.PreDeploy, e)
+	job.RecordDuration(ctx, string(PreDeployHook), time.Since(start))
+	return err
+}
+
+// runPostDeployHook runs the post-deploy hook of a deployment (if any). The deploy already completed
+// so a failure is only reported, failing the job would retry the whole deploy.
+func runPostDeployHook(ctx context.Context, config Config, e *EventEmitter) {
+	if !config.PostDeploy.Enabled() {
+		return
+	}
+
+	if err := runHook(ctx, config, PostDeployHook, config.PostDeploy, e); err != nil {
+		logger.Errorf("post-deploy hook failed %v", err)
+		e.emit(fmt.Sprintf("Post-deploy hook failed: %v", err))
+	}
+}
+
+// runHook runs a hook command in a throwaway container from the deployment image and waits for it to exit.
+// The hook container is removed once its output is recorded in the deployment events.
+func runHook(ctx context.Context, config Config, name HookName, hook Hook, e *EventEmitter) error {
+	ctx, cancel := context.WithTimeout(ctx, hook.HookTimeout())
+	defer cancel()
+
+	e.Phase = HookPhase
+	e.emit(fmt.Sprintf("Running %s hook: %s", name, strings.Join(hook.Command, " ")))
+
+	if err := pullHookImage(ctx, config); err != nil {
+		return fmt.Errorf("unable to pull image for %s hook, %v", name, err)
+	}
+
+	body, err := docker.GetClient().CreateContainer(ctx, config.hookDockerConfig(name, hook))
+	if err != nil {
+		return fmt.Errorf("unable to create %s hook container, %v", name, err)
+	}
+
+	// the hook container is removed even if the hook timed out
+	defer func() {
+		if err := docker.GetClient().RemoveContainer(context.Background(), body.ID, true); err != nil {
+			logger.Warnf("unable to remove %s hook container of deployment %s, %v", name, config.Name, err)
+		}
+	}()
+
+	if err := docker.GetClient().StartContainer(ctx, body.ID); err != nil {
+		return fmt.Errorf("unable to start %s hook container, %v", name, err)
+	}
+
+	exitCode, err := docker.GetClient().WaitContainer(ctx, body.ID)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("%s hook did not exit within %s", name, hook.HookTimeout())
+		}
+		return fmt.Errorf("unable to wait for %s hook container, %v", name, err)
+	}
+
+	output, err := docker.GetClient().ContainerOutput(context.Background(), body.ID, hookOutputLines)
+	if err != nil {
+		logger.Debugf("unable to read output of %s hook container, %v", name, err)
+	}
+	if output = strings.TrimSpace(output); output != "" {
+		e.emit(output)
+	}
+
+	if exitCode != 0 {
+		return HookFailedError{Deployment: config.Name, Hook: name, ExitCode: exitCode}
+	}
+
+	e.emit(fmt.Sprintf("%s hook completed", name))
+	return nil
+}
+
+// pullHookImage pulls the deployment image so the hook can run before the deployment containers are created
+func pullHookImage(ctx context.Context, config Config) error {
+	if err := config.ResolveRegistryCredentials(); err != nil {
+		return err
+	}
+
+	reader, err := docker.GetClient().PullImage(ctx, config.ImageRef(), config.pullCredentials())
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	_, err = io.Copy(ioutil.Discard, reader)
+	return err
+}
+
+// hookDockerConfig returns the docker configuration of a hook container. Hook containers share the environment,
+// mounts and network of the deployment containers but publish no ports and take no network alias or routing labels.
+func (config Config) hookDockerConfig(name HookName, hook Hook) docker.DockerConfig {
+	dockerConfig := config.DockerConfig(0)
+	dockerConfig.ContainerName = fmt.Sprintf("%s-%s-%s", config.Name, strings.Replace(string(name), "_", "-", -1), utils.ShortID())
+	dockerConfig.Labels = map[string]string{HookContainerLabel: config.Name}
+	dockerConfig.Command = hook.Command
+	dockerConfig.Ports = nil
+	dockerConfig.PortSet = nil
+	dockerConfig.Aliases = nil
+	dockerConfig.ExtraNetworks = nil
+	dockerConfig.RestartPolicy = container.RestartPolicy{}
+	return dockerConfig
+}
@@ -0,0 +1,32 @@
+package deployment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHookFieldErrors(t *testing.T) {
+	assert.Empty(t, Config{}.hooksFieldErrors())
+	assert.Empty(t, Config{PreDeploy: Hook{Command: []string{"rake", "db:migrate"}}}.hooksFieldErrors())
+
+	errs := Config{PreDeploy: Hook{Command: []string{" ", "db:migrate"}}}.hooksFieldErrors()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "pre_deploy", errs[0].Field)
+
+	errs = Config{PostDeploy: Hook{Timeout: 30}}.hooksFieldErrors()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "post_deploy", errs[0].Field)
+}
+
+func TestHookTimeout(t *testing.T) {
+	assert.Equal(t, DefaultHookTimeout, Hook{}.HookTimeout())
+	assert.Equal(t, 30*time.Second, Hook{Timeout: 30}.HookTimeout())
+}
+
+func TestHookFailedError(t *testing.T) {
+	err := HookFailedError{Deployment: "app", Hook: PreDeployHook, ExitCode: 1}
+	assert.Equal(t, "pre_deploy hook of deployment app exited with code 1", err.Error())
+	assert.True(t, err.Permanent())
+}
@@ -0,0 +1,62 @@
+package deployment
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/krane/krane/internal/docker"
+)
+
+// maxHostnameLength is the max length of a RFC 1123 label
+const maxHostnameLength = 63
+
+var hostnameRegex = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?$`)
+var invalidHostnameCharsRegex = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// containerHostname returns the hostname of the container at an index of a deployment: the configured hostname or
+// the deployment name, suffixed with the index when the deployment has more than one container. Hostnames are
+// truncated to a valid RFC 1123 label so a deployment always gets the same hostnames.
+func (config Config) containerHostname(index int) string {
+	base := config.Hostname
+	if base == "" {
+		base = strings.Trim(invalidHostnameCharsRegex.ReplaceAllString(strings.ToLower(config.Name), "-"), "-")
+	}
+
+	suffix := ""
+	if config.Scale > 1 {
+		suffix = fmt.Sprintf("-%d", index)
+	}
+
+	if len(base)+len(suffix) > maxHostnameLength {
+		base = strings.TrimRight(base[:maxHostnameLength-len(suffix)], "-")
+	}
+	return base + suffix
+}
+
+// hostnameFieldErrors returns a validation error if the hostname is not a RFC 1123 label with room for the container index
+func (config Config) hostnameFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+	if config.Hostname == "" {
+		return errs
+	}
+
+	if !hostnameRegex.MatchString(config.Hostname) {
+		errs = append(errs, newFieldError("hostname", "invalid hostname %s, expected letters, digits and hyphens not starting or ending with a hyphen", config.Hostname))
+	}
+
+	maxLength := maxHostnameLength
+	if config.Scale > 1 {
+		maxLength -= len(fmt.Sprintf("-%d", config.Scale-1))
+	}
+	if len(config.Hostname) > maxLength {
+		errs = append(errs, newFieldError("hostname", "hostname %s is longer than %d characters", config.Hostname, maxLength))
+	}
+
+	// docker rejects a hostname for containers sharing the host network
+	if config.NetworkMode == docker.NetworkModeHost {
+		errs = append(errs, newFieldError("hostname", "hostname can't be set with network_mode %s", config.NetworkMode))
+	}
+
+	return errs
+}
@@ -0,0 +1,32 @@
+package deployment
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerHostname(t *testing.T) {
+	assert.Equal(t, "my-app", Config{Name: "my-app", Scale: 1}.containerHostname(0))
+	assert.Equal(t, "my-app-2", Config{Name: "my-app", Scale: 3}.containerHostname(2))
+	assert.Equal(t, "my-app", Config{Name: "my_app", Scale: 1}.containerHostname(0))
+	assert.Equal(t, "api-1", Config{Name: "my-app", Hostname: "api", Scale: 2}.containerHostname(1))
+
+	// long hostnames are truncated the same way on every deploy
+	long := Config{Name: strings.Repeat("a", 40) + "-" + strings.Repeat("b", 40), Scale: 12}
+	hostname := long.containerHostname(11)
+	assert.Equal(t, strings.Repeat("a", 40)+"-"+strings.Repeat("b", 19)+"-11", hostname)
+	assert.Len(t, hostname, maxHostnameLength)
+	assert.Equal(t, hostname, long.containerHostname(11))
+}
+
+func TestHostnameFieldErrors(t *testing.T) {
+	assert.Empty(t, Config{Hostname: "web-1", Scale: 1}.hostnameFieldErrors())
+	assert.Empty(t, Config{Scale: 1}.hostnameFieldErrors())
+
+	assert.Len(t, Config{Hostname: "-web", Scale: 1}.hostnameFieldErrors(), 1)
+	assert.Len(t, Config{Hostname: "web.example.com", Scale: 1}.hostnameFieldErrors(), 1)
+	assert.Len(t, Config{Hostname: strings.Repeat("a", 62), Scale: 2}.hostnameFieldErrors(), 1)
+	assert.Len(t, Config{Hostname: "web", Scale: 1, NetworkMode: "host"}.hostnameFieldErrors(), 1)
+}
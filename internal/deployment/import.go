@@ -0,0 +1,229 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/logger"
+)
+
+// secretCandidateRegex matches environment variable names that likely hold a secret value
+var secretCandidateRegex = regexp.MustCompile(`(?i)(PASSWORD|PASSWD|SECRET|TOKEN|API_?KEY|PRIVATE_?KEY|ACCESS_?KEY|CREDENTIAL|AUTH)`)
+
+// ImportedConfig is a best-effort deployment configuration generated from an existing container, to review before it is saved
+type ImportedConfig struct {
+	Container        string   `json:"container"`         // id of the container the configuration was imported from
+	Config           Config   `json:"config"`            // imported deployment configuration
+	SecretCandidates []string `json:"secret_candidates"` // env keys that look like secrets and should be moved to the deployment secrets
+	Warnings         []string `json:"warnings"`          // container settings that could not be imported as is
+}
+
+// ImportFromContainer inspects a container not managed by Krane and returns a deployment configuration
+// reproducing it (image, ports, env, volumes, labels...). The deployment is named after the container unless a name is provided.
+func ImportFromContainer(ctx context.Context, containerID string, name string) (ImportedConfig, error) {
+	json, err := docker.GetClient().GetOneContainer(ctx, containerID)
+	if err != nil {
+		return ImportedConfig{}, fmt.Errorf("unable to inspect container %s, %w", containerID, err)
+	}
+
+	if isKraneManagedContainer(json) {
+		return ImportedConfig{}, fmt.Errorf("container %s is already managed by deployment %s", containerID, json.Config.Labels[docker.ContainerDeploymentLabel])
+	}
+
+	// the image defaults (ie. PATH) are part of the container config, they are left out of the imported config
+	imageConfig, err := docker.GetClient().GetImageConfig(ctx, json.Image)
+	if err != nil {
+		logger.Warnf("unable to inspect image of container %s, image defaults are imported, %v", containerID, err)
+	}
+
+	if name == "" {
+		name = strings.TrimPrefix(json.Name, "/")
+	}
+
+	return configFromContainer(json, imageConfig, name), nil
+}
+
+// RecreateImportedContainer saves an imported deployment configuration and runs it, adopting the
+// container it was imported from: the container is removed once the deployment succeeds. Docker cannot
+// relabel an existing container so it is replaced by containers created from the deployment configuration.
+func RecreateImportedContainer(ctx context.Context, imported ImportedConfig) error {
+	if Exist(imported.Config.Name) {
+		return fmt.Errorf("deployment %s already exists", imported.Config.Name)
+	}
+
+	json, err := docker.GetClient().GetOneContainer(ctx, imported.Container)
+	if err != nil {
+		return fmt.Errorf("unable to inspect container %s, %w", imported.Container, err)
+	}
+
+	note := fmt.Sprintf("imported from container %s", strings.TrimPrefix(json.Name, "/"))
+	if err := SaveConfigWithNote(imported.Config, note); err != nil {
+		return err
+	}
+
+	return RunWithOptions(imported.Config.Name, RunOptions{
+		Start:   true,
+		Replace: []KraneContainer{fromDockerContainerToKcontainer(json)},
+	})
+}
+
+// configFromContainer returns the deployment configuration of a container, leaving out the defaults of its image
+func configFromContainer(json types.ContainerJSON, image *container.Config, name string) ImportedConfig {
+	if image == nil {
+		image = &container.Config{}
+	}
+
+	imported := ImportedConfig{
+		Container:        json.ID,
+		SecretCandidates: make([]string, 0),
+		Warnings:         make([]string, 0),
+	}
+
+	config := Config{
+		Name:    name,
+		Env:     make(map[string]string),
+		Labels:  make(map[string]string),
+		Ports:   make(map[string]string),
+		Volumes: make(map[string]string),
+		Scale:   1,
+	}
+
+	registry, repository, tag, digest, err := parseImageReference(json.Config.Image)
+	if err != nil {
+		imported.Warnings = append(imported.Warnings, fmt.Sprintf("unable to parse image %s, %v", json.Config.Image, err))
+		repository = json.Config.Image
+	}
+	config.Registry.URL = registry
+	config.Image = repository
+	config.Tag = tag
+	config.Digest = digest
+
+	imageEnv := make(map[string]bool)
+	for _, env := range image.Env {
+		imageEnv[env] = true
+	}
+	for _, env := range json.Config.Env {
+		if imageEnv[env] {
+			continue
+		}
+		kv := strings.SplitN(env, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		config.Env[kv[0]] = kv[1]
+		if secretCandidateRegex.MatchString(kv[0]) {
+			imported.SecretCandidates = append(imported.SecretCandidates, kv[0])
+		}
+	}
+	sort.Strings(imported.SecretCandidates)
+
+	for k, v := range json.Config.Labels {
+		if _, ok := image.Labels[k]; ok || strings.HasPrefix(k, ReservedLabelPrefix) {
+			continue
+		}
+		config.Labels[k] = v
+	}
+
+	if json.HostConfig != nil {
+		imported.Warnings = append(imported.Warnings, importPorts(&config, json.HostConfig)...)
+
+		mode := string(json.HostConfig.NetworkMode)
+		if docker.IsIsolatedNetworkMode(mode) {
+			config.NetworkMode = mode
+		} else if mode != "" && mode != "default" && mode != "bridge" {
+			imported.Warnings = append(imported.Warnings, fmt.Sprintf("network mode %s is not imported, containers are attached to the krane network", mode))
+		}
+
+		config.Init = json.HostConfig.Init != nil && *json.HostConfig.Init
+		config.RestartPolicy = formatRestartPolicy(json.HostConfig.RestartPolicy)
+	}
+
+	// named volumes and read-only bind mounts are imported as mounts, other bind mounts as volumes
+	for _, m := range json.Mounts {
+		switch {
+		case m.Type == mount.TypeVolume:
+			config.Mounts = append(config.Mounts, VolumeMount{Source: m.Name, Target: m.Destination, ReadOnly: !m.RW})
+		case !m.RW:
+			config.Mounts = append(config.Mounts, VolumeMount{Source: m.Source, Target: m.Destination, ReadOnly: true})
+		default:
+			config.Volumes[m.Source] = m.Destination
+		}
+	}
+
+	if !equalStrings(json.Config.Cmd, image.Cmd) {
+		config.Command = append(CommandArgs{}, json.Config.Cmd...)
+	}
+	if !equalStrings(json.Config.Entrypoint, image.Entrypoint) {
+		config.Entrypoint = append(CommandArgs{}, json.Config.Entrypoint...)
+	}
+
+	imported.Config = config
+	return imported
+}
+
+// importPorts sets the tcp port bindings of a container as deployment ports and the bindings over other protocols
+// as port mappings, returning a warning for every binding that cannot be imported as is
+func importPorts(config *Config, hostConfig *container.HostConfig) []string {
+	warnings := make([]string, 0)
+	for port, bindings := range hostConfig.PortBindings {
+		for _, binding := range bindings {
+			if !isWildcardIP(binding.HostIP) {
+				warnings = append(warnings, fmt.Sprintf("port %s is bound to every interface instead of %s", port, binding.HostIP))
+			}
+			if port.Proto() == string(TCP) {
+				config.Ports[binding.HostPort] = port.Port()
+				continue
+			}
+			config.PortMappings = append(config.PortMappings, PortMapping{
+				HostPort:      binding.HostPort,
+				ContainerPort: port.Port(),
+				Protocol:      PortProtocol(port.Proto()),
+			})
+		}
+	}
+	return warnings
+}
+
+// parseImageReference splits an image reference (ie. ghcr.io/org/app:v1) into its registry, repository, tag and digest
+func parseImageReference(ref string) (registry, repository, tag, digest string, err error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "docker.io", "", "", "", err
+	}
+
+	registry = reference.Domain(named)
+	repository = reference.Path(named)
+	if registry == "docker.io" {
+		repository = strings.TrimPrefix(repository, "library/")
+	}
+
+	if tagged, ok := named.(reference.Tagged); ok {
+		tag = tagged.Tag()
+	}
+	if digested, ok := named.(reference.Digested); ok {
+		digest = digested.Digest().String()
+	}
+
+	return registry, repository, tag, digest, nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
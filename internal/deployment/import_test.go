@@ -0,0 +1,99 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+	"github.com/stretchr/testify/assert"
+)
+
+func importableContainer() types.ContainerJSON {
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:    "abc123",
+			Name:  "/my-app",
+			Image: "sha256:def456",
+			HostConfig: &container.HostConfig{
+				NetworkMode:   "default",
+				RestartPolicy: container.RestartPolicy{Name: "on-failure", MaximumRetryCount: 3},
+				PortBindings: nat.PortMap{
+					"80/tcp":  []nat.PortBinding{{HostPort: "8080"}},
+					"53/udp":  []nat.PortBinding{{HostPort: "53"}},
+					"443/tcp": []nat.PortBinding{{HostIP: "127.0.0.1", HostPort: "8443"}},
+				},
+			},
+		},
+		Config: &container.Config{
+			Image:  "ghcr.io/org/app:v1.2",
+			Env:    []string{"PATH=/usr/bin", "NODE_ENV=production", "DB_PASSWORD=hunter2", "API_TOKEN=abc"},
+			Labels: map[string]string{"maintainer": "org", "team": "web", "krane.deployment.job-id": "1"},
+			Cmd:    []string{"node", "server.js"},
+		},
+		Mounts: []types.MountPoint{
+			{Type: mount.TypeBind, Source: "/srv/data", Destination: "/data", RW: true},
+			{Type: mount.TypeBind, Source: "/srv/config", Destination: "/config"},
+			{Type: mount.TypeVolume, Name: "cache", Source: "/var/lib/docker/volumes/cache/_data", Destination: "/cache", RW: true},
+		},
+	}
+}
+
+func TestConfigFromContainer(t *testing.T) {
+	image := &container.Config{
+		Env:    []string{"PATH=/usr/bin"},
+		Labels: map[string]string{"maintainer": "org"},
+		Cmd:    []string{"node", "index.js"},
+	}
+
+	imported := configFromContainer(importableContainer(), image, "my-app")
+	config := imported.Config
+
+	assert.Equal(t, "abc123", imported.Container)
+	assert.Equal(t, "my-app", config.Name)
+	assert.Equal(t, "ghcr.io", config.Registry.URL)
+	assert.Equal(t, "org/app", config.Image)
+	assert.Equal(t, "v1.2", config.Tag)
+
+	// image defaults are left out
+	assert.Equal(t, map[string]string{"NODE_ENV": "production", "DB_PASSWORD": "hunter2", "API_TOKEN": "abc"}, config.Env)
+	assert.Equal(t, []string{"API_TOKEN", "DB_PASSWORD"}, imported.SecretCandidates)
+	assert.Equal(t, map[string]string{"team": "web"}, config.Labels)
+	assert.Equal(t, CommandArgs{"node", "server.js"}, config.Command)
+	assert.Empty(t, config.Entrypoint)
+	assert.Equal(t, "on-failure:3", config.RestartPolicy)
+
+	assert.Equal(t, map[string]string{"8080": "80", "8443": "443"}, config.Ports)
+	assert.Equal(t, []PortMapping{{HostPort: "53", ContainerPort: "53", Protocol: UDP}}, config.PortMappings)
+	assert.Equal(t, map[string]string{"/srv/data": "/data"}, config.Volumes)
+	assert.Equal(t, []VolumeMount{
+		{Source: "/srv/config", Target: "/config", ReadOnly: true},
+		{Source: "cache", Target: "/cache"},
+	}, config.Mounts)
+	assert.Len(t, imported.Warnings, 1) // host ip binding
+}
+
+func TestConfigFromContainerWithoutImageConfig(t *testing.T) {
+	imported := configFromContainer(importableContainer(), nil, "my-app")
+	assert.Equal(t, "/usr/bin", imported.Config.Env["PATH"])
+	assert.Equal(t, "org", imported.Config.Labels["maintainer"])
+}
+
+func TestParseImageReference(t *testing.T) {
+	registry, repository, tag, digest, err := parseImageReference("nginx")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"docker.io", "nginx", "", ""}, []string{registry, repository, tag, digest})
+
+	registry, repository, tag, _, err = parseImageReference("localhost:5000/team/api:2.0")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"localhost:5000", "team/api", "2.0"}, []string{registry, repository, tag})
+
+	_, repository, _, digest, err = parseImageReference("redis@sha256:0123456789012345678901234567890123456789012345678901234567890123")
+	assert.Nil(t, err)
+	assert.Equal(t, "redis", repository)
+	assert.Equal(t, "sha256:0123456789012345678901234567890123456789012345678901234567890123", digest)
+
+	_, _, _, _, err = parseImageReference("Not A Valid Image")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,16 @@
+package deployment
+
+import (
+	"github.com/krane/krane/internal/proxy/middlewares"
+)
+
+// allowedIPsFieldErrors returns a validation error for every allowed ip range that is not a cidr (ie. 10.0.0.0/8) or an ip address
+func (config Config) allowedIPsFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+	for _, entry := range config.AllowedIPs {
+		if _, ok := middlewares.ParseSourceRange(entry); !ok {
+			errs = append(errs, newFieldError("allowed_ips", "invalid allowed ip range %s, expected a cidr like 10.0.0.0/8 or an ip address", entry))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,44 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowedIPsFieldErrors(t *testing.T) {
+	assert.Empty(t, Config{AllowedIPs: []string{"10.0.0.0/8", "192.168.1.10/32", "2001:db8::/32"}}.allowedIPsFieldErrors())
+	assert.Empty(t, Config{AllowedIPs: []string{"10.0.0.1", "2001:db8::1"}}.allowedIPsFieldErrors())
+	assert.Len(t, Config{AllowedIPs: []string{"10.0.0.0/33", "office"}}.allowedIPsFieldErrors(), 2)
+}
+
+func TestIPAllowListLabels(t *testing.T) {
+	config := Config{Name: "vpn", Alias: []string{"vpn.example.com"}, AllowedIPs: []string{"10.0.0.0/8", "172.16.0.0/12"}, Labels: map[string]string{}}
+	labels := config.DockerLabels()
+	assert.Equal(t, "10.0.0.0/8,172.16.0.0/12", labels["traefik.http.middlewares.vpn-ipallowlist.ipallowlist.sourcerange"])
+	assert.Equal(t, "vpn-ipallowlist", labels["traefik.http.routers.vpn-insecure.middlewares"])
+
+	// the allowlist applies to both routers of secure deployments, before the rate limit
+	config.Secure = true
+	config.RateLimit = 10
+	labels = config.DockerLabels()
+	assert.Equal(t, "redirect-to-https,vpn-ipallowlist,vpn-ratelimit", labels["traefik.http.routers.vpn-insecure.middlewares"])
+	assert.Equal(t, "vpn-ipallowlist,vpn-ratelimit", labels["traefik.http.routers.vpn-secure.middlewares"])
+
+	// single addresses are allowed as a range of one address
+	config.AllowedIPs = []string{"203.0.113.42", "2001:db8::1"}
+	labels = config.DockerLabels()
+	assert.Equal(t, "203.0.113.42/32,2001:db8::1/128", labels["traefik.http.middlewares.vpn-ipallowlist.ipallowlist.sourcerange"])
+
+	// an allowlist without a valid range denies every client
+	closed := Config{Name: "closed", Alias: []string{"closed.example.com"}, AllowedIPs: []string{"office"}, Labels: map[string]string{}}
+	labels = closed.DockerLabels()
+	assert.Equal(t, "0.0.0.0/32,::/128", labels["traefik.http.middlewares.closed-ipallowlist.ipallowlist.sourcerange"])
+	assert.Equal(t, "closed-ipallowlist", labels["traefik.http.routers.closed-insecure.middlewares"])
+
+	// no middleware is created without allowed ips
+	open := Config{Name: "open", Alias: []string{"open.example.com"}, Labels: map[string]string{}}
+	for k := range open.DockerLabels() {
+		assert.NotContains(t, k, "ipallowlist")
+	}
+}
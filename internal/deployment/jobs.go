@@ -19,12 +19,28 @@ const (
 	RestartContainersJobType JobType = "RESTART_CONTAINERS"
 )
 
-// enqueue queues up deployment job for processing
+// enqueue queues up deployment job for processing, the deployment notification endpoints are notified once it completes.
+// The completion handler of the job (if any) is still called, also when the job could not be queued.
 func enqueue(j job.Job) {
+	done := j.OnComplete
+	notify := notifyOnComplete(j.Deployment)
+	j.OnComplete = func(completed job.Job) {
+		if notify != nil {
+			notify(completed)
+		}
+		if done != nil {
+			done(completed)
+		}
+	}
+
 	enqueuer := job.NewEnqueuer(job.Queue())
 	queuedJob, err := enqueuer.Enqueue(j)
 	if err != nil {
 		logger.Errorf("Error enqueuing deployment job %v", err)
+		if done != nil {
+			j.WithError(err)
+			done(j)
+		}
 		return
 	}
 	logger.Debugf("Deployment job %s queued for processing", queuedJob.Deployment)
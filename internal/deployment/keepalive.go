@@ -0,0 +1,66 @@
+package deployment
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/utils"
+)
+
+// keepAliveWriteWait is the max time to write a ping to a websocket client
+const keepAliveWriteWait = 10 * time.Second
+
+// keepAliveInterval returns the interval between websocket pings, 0 disables pings
+func keepAliveInterval() time.Duration {
+	return time.Duration(utils.UIntEnv(constants.EnvStreamKeepAliveMs)) * time.Millisecond
+}
+
+// keepAlive pings a websocket client at an interval so proxies do not close idle streams, and
+// watches the connection for the client going away. Clients not answering a ping (with a pong)
+// before the next ping are considered dead. The returned channel is closed once the client is gone.
+func keepAlive(client *websocket.Conn, interval time.Duration) <-chan struct{} {
+	gone := make(chan struct{})
+
+	if interval > 0 {
+		deadline := func() time.Time { return time.Now().Add(2 * interval) }
+		_ = client.SetReadDeadline(deadline())
+		client.SetPongHandler(func(string) error {
+			return client.SetReadDeadline(deadline())
+		})
+	}
+
+	// reading is required to process pongs and close messages, messages sent by clients are ignored
+	go func() {
+		defer close(gone)
+		for {
+			if _, _, err := client.ReadMessage(); err != nil {
+				logger.Debugf("client %v disconnected, %v", client.RemoteAddr(), err)
+				return
+			}
+		}
+	}()
+
+	if interval > 0 {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					if err := client.WriteControl(websocket.PingMessage, nil, time.Now().Add(keepAliveWriteWait)); err != nil {
+						logger.Debugf("unable to ping client %v, %v", client.RemoteAddr(), err)
+						_ = client.Close()
+						return
+					}
+				case <-gone:
+					return
+				}
+			}
+		}()
+	}
+
+	return gone
+}
@@ -0,0 +1,94 @@
+package deployment
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// keepAliveServer returns a websocket server running keepAlive on every connection
+func keepAliveServer(interval time.Duration, gone chan<- (<-chan struct{})) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		gone <- keepAlive(conn, interval)
+	}))
+}
+
+func dial(t *testing.T, server *httptest.Server) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.Nil(t, err)
+	return conn
+}
+
+func TestKeepAliveResponsiveClient(t *testing.T) {
+	gone := make(chan (<-chan struct{}), 1)
+	server := keepAliveServer(20*time.Millisecond, gone)
+	defer server.Close()
+
+	conn := dial(t, server)
+	defer conn.Close()
+
+	// reading processes pings and answers them with pongs
+	pings := make(chan struct{}, 10)
+	conn.SetPingHandler(func(data string) error {
+		pings <- struct{}{}
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	serverGone := <-gone
+	select {
+	case <-serverGone:
+		t.Fatal("responsive client should be kept alive")
+	case <-time.After(150 * time.Millisecond):
+	}
+	assert.NotEmpty(t, pings)
+}
+
+func TestKeepAliveDisconnectedClient(t *testing.T) {
+	gone := make(chan (<-chan struct{}), 1)
+	server := keepAliveServer(20*time.Millisecond, gone)
+	defer server.Close()
+
+	conn := dial(t, server)
+	serverGone := <-gone
+	_ = conn.Close()
+
+	select {
+	case <-serverGone:
+	case <-time.After(time.Second):
+		t.Fatal("disconnected client should be detected")
+	}
+}
+
+func TestKeepAliveUnresponsiveClient(t *testing.T) {
+	gone := make(chan (<-chan struct{}), 1)
+	server := keepAliveServer(20*time.Millisecond, gone)
+	defer server.Close()
+
+	// the client never reads so pings are never answered
+	conn := dial(t, server)
+	defer conn.Close()
+
+	select {
+	case <-<-gone:
+	case <-time.After(time.Second):
+		t.Fatal("unresponsive client should be detected")
+	}
+}
@@ -0,0 +1,95 @@
+package deployment
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/job"
+	"github.com/krane/krane/internal/logger"
+)
+
+// ReservedLabelPrefix is the label namespace managed by Krane, deployment labels cannot use it
+const ReservedLabelPrefix = "krane."
+
+// Labels set on deployment containers at create time so a container can be traced back to the deploy that created it
+const (
+	ContainerJobIDLabel       = "krane.deployment.job-id"
+	ContainerImageDigestLabel = "krane.deployment.image-digest"
+	ContainerRevisionLabel    = "krane.deployment.revision"
+	ContainerDeployedAtLabel  = "krane.deployment.deployed-at"
+)
+
+// labelFieldErrors returns a validation error for every deployment label in the reserved Krane namespace
+func (config Config) labelFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+
+	keys := make([]string, 0, len(config.Labels))
+	for k := range config.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if strings.HasPrefix(k, ReservedLabelPrefix) {
+			errs = append(errs, newFieldError("labels", "label %s uses the reserved %s namespace", k, ReservedLabelPrefix))
+		}
+	}
+
+	return errs
+}
+
+// withDeployLabels returns a copy of a deployment config labeled with the metadata of the deploy creating
+// its containers: the job, the image digest, the config revision (history entry) and the deploy time
+func (config Config) withDeployLabels(ctx context.Context, deployedAt time.Time) Config {
+	jobID := job.IDFromContext(ctx)
+
+	// labels are copied so deploy labels don't leak into the saved configuration
+	labels := make(map[string]string, len(config.Labels)+4)
+	for k, v := range config.Labels {
+		labels[k] = v
+	}
+
+	labels[ContainerJobIDLabel] = jobID
+	labels[ContainerImageDigestLabel] = config.imageDigest(ctx)
+	labels[ContainerRevisionLabel] = revisionForJob(config.Name, jobID)
+	labels[ContainerDeployedAtLabel] = deployedAt.UTC().Format(time.RFC3339)
+
+	config.Labels = labels
+	return config
+}
+
+// imageDigest returns the digest of the deployment image, the pinned digest or the digest of the pulled image
+func (config Config) imageDigest(ctx context.Context) string {
+	if config.Digest != "" {
+		return config.Digest
+	}
+
+	if docker.GetClient() == nil {
+		return ""
+	}
+
+	repoDigests, err := docker.GetClient().GetImageDigests(ctx, config.ImageRef())
+	if err != nil {
+		logger.Warnf("unable to get image digest for %s %v", config.ImageRef(), err)
+		return ""
+	}
+	return findImageDigest(repoDigests)
+}
+
+// revisionForJob returns the id of the history entry deployed by a job, defaulting to the latest history entry
+func revisionForJob(deployment, jobID string) string {
+	history, err := GetHistory(deployment)
+	if err != nil || len(history) == 0 {
+		return ""
+	}
+
+	for _, entry := range history {
+		if jobID != "" && entry.JobID == jobID {
+			return entry.ID
+		}
+	}
+	return history[0].ID
+}
@@ -0,0 +1,55 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReservedLabels(t *testing.T) {
+	assert.Nil(t, Config{Name: "labels-app", Image: "nginx", Labels: map[string]string{"team": "web"}}.isValid())
+	assert.Error(t, Config{Name: "labels-app", Image: "nginx", Labels: map[string]string{"krane.deployment.job-id": "x"}}.isValid())
+	assert.Error(t, Config{Name: "labels-app", Image: "nginx", Labels: map[string]string{"krane.deployment": "other"}}.isValid())
+}
+
+func TestWithDeployLabels(t *testing.T) {
+	assert.Nil(t, SaveConfigWithNote(Config{Name: "labels-app", Image: "nginx"}, "first"))
+	assert.Nil(t, SaveConfigWithNote(Config{Name: "labels-app", Image: "nginx", Tag: "1.19"}, "second"))
+	defer DeleteConfig("labels-app")
+	defer DeleteHistoryCollection("labels-app")
+
+	history, _ := GetHistory("labels-app")
+	deployedAt := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	config := Config{
+		Name:   "labels-app",
+		Image:  "nginx",
+		Digest: "sha256:2ae23fe0fea9fe0c4229ab1b52e7b4e3c1d5e3fc9c1d6ff2f6f5f1d8e7f7a6b5",
+		Labels: map[string]string{"team": "web"},
+	}
+	labeled := config.withDeployLabels(context.Background(), deployedAt)
+
+	assert.Equal(t, "web", labeled.Labels["team"])
+	assert.Equal(t, "", labeled.Labels[ContainerJobIDLabel])
+	assert.Equal(t, config.Digest, labeled.Labels[ContainerImageDigestLabel])
+	assert.Equal(t, history[0].ID, labeled.Labels[ContainerRevisionLabel])
+	assert.Equal(t, "2020-10-01T12:00:00Z", labeled.Labels[ContainerDeployedAtLabel])
+
+	// the original config labels are left untouched
+	assert.Len(t, config.Labels, 1)
+}
+
+func TestRevisionForJob(t *testing.T) {
+	assert.Nil(t, SaveConfigWithNote(Config{Name: "revision-app", Image: "nginx"}, "first"))
+	linkHistoryToJob("revision-app", "job-1")
+	assert.Nil(t, SaveConfigWithNote(Config{Name: "revision-app", Image: "nginx", Tag: "1.19"}, "second"))
+	defer DeleteConfig("revision-app")
+	defer DeleteHistoryCollection("revision-app")
+
+	history, _ := GetHistory("revision-app")
+	assert.Equal(t, history[1].ID, revisionForJob("revision-app", "job-1"))
+	assert.Equal(t, history[0].ID, revisionForJob("revision-app", "job-2"))
+	assert.Equal(t, "", revisionForJob("missing-app", "job-1"))
+}
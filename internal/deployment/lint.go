@@ -0,0 +1,121 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/krane/krane/internal/docker"
+)
+
+// LintSeverity is how strongly a lint warning suggests changing a deployment config
+type LintSeverity string
+
+const (
+	LintInfo    LintSeverity = "info"    // a suggestion, the config is fine for most deployments
+	LintWarning LintSeverity = "warning" // a practice likely to cause an issue in production
+)
+
+// LintResult is a non-blocking best practice warning for a deployment config
+type LintResult struct {
+	Rule     string       `json:"rule"`
+	Severity LintSeverity `json:"severity"`
+	Field    string       `json:"field"`
+	Message  string       `json:"message"`
+}
+
+// Lint returns best practice warnings for a deployment config. Unlike validation errors,
+// lint warnings never prevent a deployment config from being saved or deployed.
+func Lint(config Config) []LintResult {
+	config.applyDefaults()
+	return config.lint(imageUser)
+}
+
+// lint returns the best practice warnings for a deployment config, the image user is resolved with the provided function
+func (config Config) lint(user func(Config) (string, bool)) []LintResult {
+	results := make([]LintResult, 0)
+
+	if config.Digest == "" && (config.Tag == "" || config.Tag == "latest") {
+		results = append(results, LintResult{
+			Rule:     "unpinned-image",
+			Severity: LintWarning,
+			Field:    "tag",
+			Message:  fmt.Sprintf("image %s uses the latest tag, pin a version tag or a digest so deploys are reproducible", config.Image),
+		})
+	}
+
+	if config.Resources.Memory == "" {
+		results = append(results, LintResult{
+			Rule:     "no-memory-limit",
+			Severity: LintWarning,
+			Field:    "resources.memory",
+			Message:  "no memory limit set, a single container can consume the memory of the whole host",
+		})
+	}
+
+	if !config.HealthCheck.probed() {
+		results = append(results, LintResult{
+			Rule:     "no-health-check",
+			Severity: LintInfo,
+			Field:    "health_check",
+			Message:  "no health check set, containers are considered healthy as soon as they are running",
+		})
+	}
+
+	if config.User != "" {
+		if isRootUser(config.User) {
+			results = append(results, LintResult{
+				Rule:     "runs-as-root",
+				Severity: LintWarning,
+				Field:    "user",
+				Message:  fmt.Sprintf("containers run as user %s, set a non-root user", config.User),
+			})
+		}
+	} else if u, ok := user(config); ok && isRootUser(u) {
+		results = append(results, LintResult{
+			Rule:     "runs-as-root",
+			Severity: LintWarning,
+			Field:    "image",
+			Message:  fmt.Sprintf("image %s runs as root, set a non-root USER in the image or the deployment user", config.Image),
+		})
+	}
+
+	if docker.IsIsolatedNetworkMode(config.NetworkMode) && config.Routed() {
+		results = append(results, LintResult{
+			Rule:     "unroutable-network-mode",
+			Severity: LintWarning,
+			Field:    "network_mode",
+			Message:  fmt.Sprintf("aliases are not routed by Traefik with network_mode %s unless the proxy is configured manually", config.NetworkMode),
+		})
+	}
+
+	if policy := config.DockerRestartPolicy(); policy.IsNone() {
+		results = append(results, LintResult{
+			Rule:     "no-restart-policy",
+			Severity: LintInfo,
+			Field:    "restart_policy",
+			Message:  "no restart policy, containers that exit are not restarted until the deployment is run again",
+		})
+	}
+
+	return results
+}
+
+// imageUser returns the user the deployment image runs as, false if the image is not available on the docker host
+func imageUser(config Config) (string, bool) {
+	if docker.GetClient() == nil {
+		return "", false
+	}
+
+	user, err := docker.GetClient().GetImageUser(context.Background(), config.ImageRef())
+	if err != nil {
+		return "", false
+	}
+	return user, true
+}
+
+// isRootUser returns true if a container user (ie. root, 0 or 0:0) is the root user
+func isRootUser(user string) bool {
+	name := strings.Split(user, ":")[0]
+	return name == "" || name == "root" || name == "0"
+}
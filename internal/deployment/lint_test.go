@@ -0,0 +1,55 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func lintRules(results []LintResult) []string {
+	rules := make([]string, 0)
+	for _, r := range results {
+		rules = append(rules, r.Rule)
+	}
+	return rules
+}
+
+func TestLint(t *testing.T) {
+	noUser := func(Config) (string, bool) { return "", false }
+
+	config := Config{Name: "lint-app", Image: "nginx", Tag: "latest"}
+	assert.Equal(t, []string{"unpinned-image", "no-memory-limit", "no-health-check", "no-restart-policy"}, lintRules(config.lint(noUser)))
+
+	config = Config{
+		Name:        "lint-app",
+		Image:       "nginx",
+		Tag:         "1.19",
+		Resources:   Resources{Memory: "256mb"},
+		HealthCheck: HealthCheck{Port: "80"},
+	}
+	assert.Equal(t, []string{"no-restart-policy"}, lintRules(config.lint(noUser)))
+
+	config.RestartPolicy = "unless-stopped"
+	assert.Empty(t, lintRules(config.lint(noUser)))
+	config.RestartPolicy = ""
+
+	root := func(Config) (string, bool) { return "", true }
+	assert.Contains(t, lintRules(config.lint(root)), "runs-as-root")
+
+	nonRoot := func(Config) (string, bool) { return "1000:1000", true }
+	assert.NotContains(t, lintRules(config.lint(nonRoot)), "runs-as-root")
+
+	// the deployment user takes precedence over the image user
+	config.User = "1000"
+	assert.NotContains(t, lintRules(config.lint(root)), "runs-as-root")
+	config.User = "root"
+	assert.Contains(t, lintRules(config.lint(nonRoot)), "runs-as-root")
+}
+
+func TestIsRootUser(t *testing.T) {
+	assert.True(t, isRootUser(""))
+	assert.True(t, isRootUser("root"))
+	assert.True(t, isRootUser("0:0"))
+	assert.False(t, isRootUser("nginx"))
+	assert.False(t, isRootUser("1000"))
+}
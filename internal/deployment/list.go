@@ -0,0 +1,106 @@
+package deployment
+
+import (
+	"regexp"
+	"sort"
+)
+
+// Pagination of the deployments list
+const (
+	DefaultListLimit = 25  // deployments returned per page when no limit is provided
+	MaxListLimit     = 100 // max deployments returned per page
+)
+
+var tagRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,62}$`)
+
+// ListOptions filter and paginate the deployments list
+type ListOptions struct {
+	Tag    string // only list deployments with the tag, empty lists every deployment
+	Limit  int    // max deployments returned (default DefaultListLimit)
+	Offset int    // deployments skipped before the first one returned
+}
+
+// DeploymentPage is a page of the deployments list
+type DeploymentPage struct {
+	Deployments []Deployment
+	Total       int // deployments matching the filters across every page
+	Limit       int
+	Offset      int
+}
+
+// ListDeployments returns a page of deployments sorted by name, filtered by tag. Only the deployments of the
+// page are resolved against docker, an offset past the last deployment returns an empty page.
+func ListDeployments(opts ListOptions) (DeploymentPage, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultListLimit
+	}
+
+	configs, err := GetAllDeploymentConfigs()
+	if err != nil {
+		return DeploymentPage{}, err
+	}
+
+	page, total := pageConfigs(configs, opts)
+	deployments, err := getDeployments(page)
+	if err != nil {
+		return DeploymentPage{}, err
+	}
+
+	return DeploymentPage{
+		Deployments: deployments,
+		Total:       total,
+		Limit:       opts.Limit,
+		Offset:      opts.Offset,
+	}, nil
+}
+
+// pageConfigs returns the configs of a page sorted by name and the number of configs matching the tag filter
+func pageConfigs(configs []Config, opts ListOptions) ([]Config, int) {
+	matching := make([]Config, 0, len(configs))
+	for _, config := range configs {
+		if opts.Tag == "" || config.hasTag(opts.Tag) {
+			matching = append(matching, config)
+		}
+	}
+	sort.SliceStable(matching, func(i, j int) bool { return matching[i].Name < matching[j].Name })
+
+	total := len(matching)
+	if opts.Offset < 0 || opts.Offset >= total {
+		return make([]Config, 0), total
+	}
+
+	end := opts.Offset + opts.Limit
+	if end > total {
+		end = total
+	}
+	return matching[opts.Offset:end], total
+}
+
+// hasTag returns whether a deployment is tagged with a tag
+func (config Config) hasTag(tag string) bool {
+	for _, t := range config.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// tagFieldErrors returns a validation error for every invalid or duplicate deployment tag
+func (config Config) tagFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+
+	seen := make(map[string]bool, len(config.Tags))
+	for _, tag := range config.Tags {
+		if !tagRegex.MatchString(tag) {
+			errs = append(errs, newFieldError("tags", "invalid tag %s, expected up to 63 lowercase letters, digits, dots, dashes or underscores", tag))
+			continue
+		}
+		if seen[tag] {
+			errs = append(errs, newFieldError("tags", "duplicate tag %s", tag))
+		}
+		seen[tag] = true
+	}
+
+	return errs
+}
@@ -0,0 +1,44 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPageConfigs(t *testing.T) {
+	configs := []Config{
+		{Name: "web", Tags: []string{"team-web"}},
+		{Name: "api", Tags: []string{"team-web", "backend"}},
+		{Name: "worker", Tags: []string{"backend"}},
+		{Name: "cron"},
+	}
+
+	page, total := pageConfigs(configs, ListOptions{Limit: 2})
+	assert.Equal(t, []string{"api", "cron"}, names(page))
+	assert.Equal(t, 4, total)
+
+	page, total = pageConfigs(configs, ListOptions{Limit: 2, Offset: 3})
+	assert.Equal(t, []string{"worker"}, names(page))
+	assert.Equal(t, 4, total)
+
+	page, total = pageConfigs(configs, ListOptions{Tag: "backend", Limit: 25})
+	assert.Equal(t, []string{"api", "worker"}, names(page))
+	assert.Equal(t, 2, total)
+
+	// an offset past the last deployment is an empty page
+	page, total = pageConfigs(configs, ListOptions{Limit: 25, Offset: 10})
+	assert.Empty(t, page)
+	assert.Equal(t, 4, total)
+
+	page, total = pageConfigs(configs, ListOptions{Tag: "unknown", Limit: 25})
+	assert.Empty(t, page)
+	assert.Equal(t, 0, total)
+}
+
+func TestTagFieldErrors(t *testing.T) {
+	assert.Nil(t, Config{Name: "tags-app", Image: "nginx", Tags: []string{"team-web", "v1.2", "backend_jobs"}}.isValid())
+	assert.Error(t, Config{Name: "tags-app", Image: "nginx", Tags: []string{"Team Web"}}.isValid())
+	assert.Error(t, Config{Name: "tags-app", Image: "nginx", Tags: []string{""}}.isValid())
+	assert.Error(t, Config{Name: "tags-app", Image: "nginx", Tags: []string{"web", "web"}}.isValid())
+}
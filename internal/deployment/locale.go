@@ -0,0 +1,79 @@
+package deployment
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"time"
+
+	"github.com/docker/docker/api/types/mount"
+)
+
+// localeRegex matches POSIX locale names (ie. en_US.UTF-8, fr_CA, C.UTF-8)
+var localeRegex = regexp.MustCompile(`^([a-z]{2,3}(_[A-Z]{2})?|C|POSIX)(\.[A-Za-z0-9-]+)?(@[a-z]+)?$`)
+
+// zoneinfoDir is the directory of the tz database on the docker host
+const zoneinfoDir = "/usr/share/zoneinfo"
+
+// localtimePath is where the timezone of a container is read from when TZ is not set
+const localtimePath = "/etc/localtime"
+
+// localeEnvs returns the environment variables setting the timezone and locale of the containers,
+// variables explicitly set in the deployment env take precedence
+func (config Config) localeEnvs() []string {
+	envs := make([]string, 0)
+	add := func(key, value string) {
+		if _, ok := config.Env[key]; ok || value == "" {
+			return
+		}
+		envs = append(envs, fmt.Sprintf("%s=%s", key, value))
+	}
+
+	add("TZ", config.Timezone)
+	add("LANG", config.Locale)
+	add("LC_ALL", config.Locale)
+	return envs
+}
+
+// localtimeMount returns the read-only bind mount of the timezone file of the docker host as the container localtime,
+// for apps reading /etc/localtime instead of TZ. False if the localtime is not mounted.
+func (config Config) localtimeMount() (mount.Mount, bool) {
+	if !config.MountLocaltime || config.Timezone == "" {
+		return mount.Mount{}, false
+	}
+
+	return mount.Mount{
+		Type:     mount.TypeBind,
+		Source:   path.Join(zoneinfoDir, config.Timezone),
+		Target:   localtimePath,
+		ReadOnly: true,
+	}, true
+}
+
+// localeFieldErrors returns a validation error if the timezone is not in the tz database or the locale is not a valid locale name
+func (config Config) localeFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+
+	if config.Timezone != "" {
+		// Local is the timezone of the Krane host, not a tz database name
+		if _, err := time.LoadLocation(config.Timezone); err != nil || config.Timezone == "Local" {
+			errs = append(errs, newFieldError("timezone", "unknown timezone %s, expected a tz database name (ie. America/New_York)", config.Timezone))
+		}
+	}
+
+	if config.Locale != "" && !localeRegex.MatchString(config.Locale) {
+		errs = append(errs, newFieldError("locale", "invalid locale %s, expected a locale name (ie. en_US.UTF-8)", config.Locale))
+	}
+
+	if config.MountLocaltime && config.Timezone == "" {
+		errs = append(errs, newFieldError("mount_localtime", "mount_localtime requires a timezone"))
+	}
+
+	for _, target := range config.Volumes {
+		if target == localtimePath && config.MountLocaltime {
+			errs = append(errs, newFieldError("mount_localtime", "mount_localtime conflicts with the %s volume", localtimePath))
+		}
+	}
+
+	return errs
+}
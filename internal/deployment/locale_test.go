@@ -0,0 +1,40 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocaleEnvs(t *testing.T) {
+	config := Config{Timezone: "America/New_York", Locale: "en_US.UTF-8"}
+	assert.Equal(t, []string{"TZ=America/New_York", "LANG=en_US.UTF-8", "LC_ALL=en_US.UTF-8"}, config.localeEnvs())
+
+	// the deployment env takes precedence
+	config.Env = map[string]string{"LC_ALL": "C"}
+	assert.Equal(t, []string{"TZ=America/New_York", "LANG=en_US.UTF-8"}, config.localeEnvs())
+
+	assert.Empty(t, Config{}.localeEnvs())
+}
+
+func TestLocaltimeMount(t *testing.T) {
+	_, ok := Config{Timezone: "Europe/Paris"}.localtimeMount()
+	assert.False(t, ok)
+
+	m, ok := Config{Timezone: "Europe/Paris", MountLocaltime: true}.localtimeMount()
+	assert.True(t, ok)
+	assert.Equal(t, mount.Mount{Type: mount.TypeBind, Source: "/usr/share/zoneinfo/Europe/Paris", Target: "/etc/localtime", ReadOnly: true}, m)
+}
+
+func TestLocaleConfig(t *testing.T) {
+	assert.Empty(t, Config{Timezone: "UTC", Locale: "C.UTF-8"}.localeFieldErrors())
+	assert.Empty(t, Config{Locale: "fr_CA"}.localeFieldErrors())
+	assert.Empty(t, Config{Locale: "de_DE.UTF-8@euro"}.localeFieldErrors())
+
+	assert.Len(t, Config{Timezone: "Mars/Olympus_Mons"}.localeFieldErrors(), 1)
+	assert.Len(t, Config{Timezone: "Local"}.localeFieldErrors(), 1)
+	assert.Len(t, Config{Locale: "english"}.localeFieldErrors(), 1)
+	assert.Len(t, Config{MountLocaltime: true}.localeFieldErrors(), 1)
+	assert.Len(t, Config{Timezone: "UTC", MountLocaltime: true, Volumes: map[string]string{"/etc/localtime": "/etc/localtime"}}.localeFieldErrors(), 1)
+}
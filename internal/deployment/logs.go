@@ -1,6 +1,8 @@
 package deployment
 
 import (
+	"context"
+
 	"github.com/gorilla/websocket"
 
 	"github.com/krane/krane/internal/docker"
@@ -12,6 +14,10 @@ func SubscribeToDeploymentLogs(client *websocket.Conn, deployment string) {
 	data := make(chan []byte)
 	done := make(chan bool)
 
+	// stop streaming container logs once the client is gone
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	containers, err := GetContainersByDeployment(deployment)
 	if err != nil {
 		logger.Warnf("unable to get containers for deployment %s, %v", deployment, err)
@@ -23,7 +29,7 @@ func SubscribeToDeploymentLogs(client *websocket.Conn, deployment string) {
 	}
 
 	for _, container := range containers {
-		if err := docker.GetClient().StreamContainerLogs(container.ID, data, done); err != nil {
+		if err := docker.GetClient().StreamContainerLogs(ctx, container.ID, data, done); err != nil {
 			logger.Warnf("error grabbing container reader, %v", err)
 			if err := client.Close(); err != nil {
 				logger.Warnf("error closing client connection, %v", err)
@@ -33,6 +39,7 @@ func SubscribeToDeploymentLogs(client *websocket.Conn, deployment string) {
 		}
 	}
 
+	gone := keepAlive(client, keepAliveInterval())
 	for {
 		select {
 		case bytes := <-data:
@@ -48,6 +55,9 @@ func SubscribeToDeploymentLogs(client *websocket.Conn, deployment string) {
 				logger.Warnf("error closing client connection when unsubscribing from container logs, %v", err)
 				return
 			}
+		case <-gone:
+			_ = client.Close()
+			return
 		}
 	}
 }
@@ -57,7 +67,11 @@ func SubscribeToContainerLogs(client *websocket.Conn, containerID string) {
 	data := make(chan []byte)
 	done := make(chan bool)
 
-	if err := docker.GetClient().StreamContainerLogs(containerID, data, done); err != nil {
+	// stop streaming container logs once the client is gone
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := docker.GetClient().StreamContainerLogs(ctx, containerID, data, done); err != nil {
 		logger.Warnf("error grabbing container reader, %v", err)
 		if err := client.Close(); err != nil {
 			logger.Warnf("error closing client connection, %v", err)
@@ -66,6 +80,7 @@ func SubscribeToContainerLogs(client *websocket.Conn, containerID string) {
 		return
 	}
 
+	gone := keepAlive(client, keepAliveInterval())
 	for {
 		select {
 		case bytes := <-data:
@@ -81,6 +96,9 @@ func SubscribeToContainerLogs(client *websocket.Conn, containerID string) {
 				logger.Warnf("error closing client connection when unsubscribing from container logs, %v", err)
 				return
 			}
+		case <-gone:
+			_ = client.Close()
+			return
 		}
 	}
 }
@@ -0,0 +1,54 @@
+package deployment
+
+import (
+	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/metrics"
+)
+
+// Deployment health metrics
+const (
+	HealthMetric             = "krane_deployment_health"              // 1 for the current health status of a deployment
+	HealthyContainersMetric  = "krane_deployment_healthy_containers"  // number of healthy containers of a deployment
+	ExpectedContainersMetric = "krane_deployment_expected_containers" // number of containers expected by the deployment scale
+	RunningContainersMetric  = "krane_deployment_running_containers"  // number of running containers of a deployment
+)
+
+// CollectHealthMetrics sets the health gauges of every deployment, gauges of removed deployments are dropped
+func CollectHealthMetrics() {
+	deployments, err := GetAllDeployments()
+	if err != nil {
+		logger.Warnf("unable to collect deployment health metrics %v", err)
+		return
+	}
+
+	setHealthMetrics(metrics.Default(), deployments)
+}
+
+// setHealthMetrics replaces the health gauges of a registry with the health of the deployments
+func setHealthMetrics(registry *metrics.Registry, deployments []Deployment) {
+	registry.ResetGauges(HealthMetric)
+	registry.ResetGauges(HealthyContainersMetric)
+	registry.ResetGauges(ExpectedContainersMetric)
+	registry.ResetGauges(RunningContainersMetric)
+
+	for _, d := range deployments {
+		health := d.GetHealth()
+		labels := metrics.Labels{"deployment": d.Config.Name}
+
+		registry.SetGauge(HealthMetric, metrics.Labels{"deployment": d.Config.Name, "status": string(health.Status)}, 1)
+		registry.SetGauge(HealthyContainersMetric, labels, float64(health.Healthy))
+		registry.SetGauge(ExpectedContainersMetric, labels, float64(health.Expected))
+		registry.SetGauge(RunningContainersMetric, labels, float64(d.runningContainers()))
+	}
+}
+
+// runningContainers returns the number of running containers of a deployment
+func (d Deployment) runningContainers() int {
+	running := 0
+	for _, c := range d.Containers {
+		if c.State.Running {
+			running++
+		}
+	}
+	return running
+}
@@ -0,0 +1,49 @@
+package deployment
+
+import (
+	"context"
+	"time"
+
+	"github.com/krane/krane/internal/logger"
+)
+
+// MonitorInterval is how often the deployments are monitored, independently of watch mode
+const MonitorInterval = 10 * time.Second
+
+// RunMonitors monitors the deployments on an interval until ctx is done
+func RunMonitors(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			monitorDeployments(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// monitorDeployments runs the monitors of every deployment once
+func monitorDeployments(ctx context.Context) {
+	deployments, err := GetAllDeployments()
+	if err != nil {
+		logger.Warnf("unable to get deployments to monitor %v", err)
+		return
+	}
+
+	for _, d := range deployments {
+		// emits a health event when the deployment health changed since the last poll
+		MonitorHealth(d)
+
+		// reports the containers pulled from or added back to the proxy load balancer by their health check
+		MonitorRoutingPool(d)
+
+		// restarts the containers failing their liveness probe
+		MonitorLiveness(ctx, d)
+
+		// reloads deployments with reload_on_change when a bind mounted host file changed
+		MonitorMountedFiles(ctx, d.Config)
+	}
+}
@@ -0,0 +1,114 @@
+package deployment
+
+import (
+	"context"
+	"sort"
+
+	"github.com/krane/krane/internal/docker"
+)
+
+// Network represents a docker network a deployment's containers are attached to
+type Network struct {
+	ID      string          `json:"id"`
+	Name    string          `json:"name"`
+	Driver  string          `json:"driver"`
+	Members []NetworkMember `json:"members"`
+}
+
+// NetworkMember represents a container attached to a network
+type NetworkMember struct {
+	ContainerID string   `json:"container_id"`
+	Name        string   `json:"name"`
+	Deployment  string   `json:"deployment"` // empty if the container is not managed by Krane
+	Aliases     []string `json:"aliases"`
+	IPv4Address string   `json:"ipv4_address"`
+	IPv6Address string   `json:"ipv6_address"`
+	MacAddress  string   `json:"mac_address"`
+}
+
+// GetNetworks returns the networks a deployment's containers are attached to along with every member of those networks
+func GetNetworks(deployment string) ([]Network, error) {
+	ctx := context.Background()
+	defer ctx.Done()
+
+	containers, err := GetContainersByDeployment(deployment)
+	if err != nil {
+		return make([]Network, 0), err
+	}
+
+	// collect the networks the deployment containers are attached to
+	networkIDs := make(map[string]bool)
+	for _, c := range containers {
+		container, err := docker.GetClient().GetOneContainer(ctx, c.ID)
+		if err != nil {
+			return make([]Network, 0), err
+		}
+
+		for _, endpoint := range container.NetworkSettings.Networks {
+			networkIDs[endpoint.NetworkID] = true
+		}
+	}
+
+	networks := make([]Network, 0)
+	for networkID := range networkIDs {
+		n, err := docker.GetClient().InspectNetwork(ctx, networkID)
+		if err != nil {
+			return make([]Network, 0), err
+		}
+
+		members := make([]NetworkMember, 0)
+		for containerID, endpoint := range n.Containers {
+			member := NetworkMember{
+				ContainerID: containerID,
+				Name:        endpoint.Name,
+				Aliases:     make([]string, 0),
+				IPv4Address: endpoint.IPv4Address,
+				IPv6Address: endpoint.IPv6Address,
+				MacAddress:  endpoint.MacAddress,
+			}
+
+			// aliases are only available when inspecting the member container
+			container, err := docker.GetClient().GetOneContainer(ctx, containerID)
+			if err == nil {
+				member.Deployment = container.Config.Labels[docker.ContainerDeploymentLabel]
+				if settings, ok := container.NetworkSettings.Networks[n.Name]; ok && settings.Aliases != nil {
+					member.Aliases = settings.Aliases
+				}
+			}
+
+			members = append(members, member)
+		}
+
+		sort.Slice(members, func(i, j int) bool { return members[i].Name < members[j].Name })
+
+		networks = append(networks, Network{
+			ID:      n.ID,
+			Name:    n.Name,
+			Driver:  n.Driver,
+			Members: members,
+		})
+	}
+
+	sort.Slice(networks, func(i, j int) bool { return networks[i].Name < networks[j].Name })
+
+	return networks, nil
+}
+
+// networkModeFieldErrors returns a validation error if the network mode is not supported
+func (config Config) networkModeFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+	if config.NetworkMode == "" {
+		return errs
+	}
+
+	if !docker.IsIsolatedNetworkMode(config.NetworkMode) {
+		return append(errs, newFieldError("network_mode", "invalid network_mode %s, expected %s or %s", config.NetworkMode, docker.NetworkModeHost, docker.NetworkModeNone))
+	}
+
+	// health checks probe containers over the krane network
+	if config.HealthCheck.probed() {
+		errs = append(errs, newFieldError("health_check", "health check can't be probed with network_mode %s", config.NetworkMode))
+	}
+
+	return errs
+}
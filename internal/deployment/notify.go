@@ -0,0 +1,155 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/krane/krane/internal/job"
+	"github.com/krane/krane/internal/logger"
+)
+
+// DefaultNotificationRetries is the number of times a failed notification is posted again
+const DefaultNotificationRetries = 3
+
+// maxNotificationBackoff is the max delay between notification attempts
+const maxNotificationBackoff = 30 * time.Second
+
+// notificationTimeout is the max time a single notification attempt waits for a response
+const notificationTimeout = 10 * time.Second
+
+// Notification is an endpoint notified when a job of the deployment completes (ie. a Slack incoming webhook relay)
+type Notification struct {
+	URL     string `json:"url"`     // url the job outcome is posted to as json
+	Retries *uint  `json:"retries"` // times a failed post is retried with backoff, 0 posts once (default 3)
+}
+
+// JobNotification is the payload posted to the notification endpoints of a deployment when one of its jobs completes
+type JobNotification struct {
+	Deployment      string `json:"deployment"`
+	Action          string `json:"action"` // job type (ie. RUN_DEPLOYMENT)
+	JobID           string `json:"job_id"`
+	Outcome         string `json:"outcome"` // succeeded, failed, cancelled or coalesced
+	DurationSeconds int64  `json:"duration_seconds"`
+	Error           string `json:"error,omitempty"` // error of the last failed attempt
+}
+
+// Job notification outcomes
+const (
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+	JobCoalesced = "coalesced"
+)
+
+// RetryCount returns the number of times a failed notification is posted again
+func (n Notification) RetryCount() uint {
+	if n.Retries == nil {
+		return DefaultNotificationRetries
+	}
+	return *n.Retries
+}
+
+// notificationsFieldErrors returns a validation error for every notification url that is not a valid http(s) url
+func (config Config) notificationsFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+	for _, n := range config.Notifications {
+		u, err := url.Parse(n.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, newFieldError("notifications", "invalid notification url %s, expected an http(s) url", n.URL))
+		}
+	}
+	return errs
+}
+
+// notifyOnComplete returns a job completion handler posting the job outcome to the notification endpoints of a deployment.
+// The endpoints are read when the job is queued, so a deleted deployment still notifies the completion of its delete job.
+func notifyOnComplete(deployment string) func(j job.Job) {
+	config, err := GetDeploymentConfig(deployment)
+	if err != nil || len(config.Notifications) == 0 {
+		return nil
+	}
+
+	notifications := config.Notifications
+	return func(j job.Job) {
+		payload, _ := json.Marshal(jobNotification(j))
+
+		// notifications are posted in the background so retries don't hold up the next jobs of the deployment
+		for _, n := range notifications {
+			go func(n Notification) {
+				if err := postNotification(context.Background(), n, payload); err != nil {
+					logger.Warnf("unable to notify %s of job %s for deployment %s, %v", n.URL, j.ID, j.Deployment, err)
+				}
+			}(n)
+		}
+	}
+}
+
+// jobNotification returns the notification payload of a completed job
+func jobNotification(j job.Job) JobNotification {
+	notification := JobNotification{
+		Deployment:      j.Deployment,
+		Action:          j.Type,
+		JobID:           j.ID,
+		Outcome:         JobSucceeded,
+		DurationSeconds: j.EndTime - j.StartTime,
+	}
+
+	switch {
+	case j.CoalescedInto != "":
+		notification.Outcome = JobCoalesced
+	case j.Cancelled:
+		notification.Outcome = JobCancelled
+	case !j.Succeeded():
+		notification.Outcome = JobFailed
+	}
+
+	if !j.Succeeded() && len(j.Status.Failures) > 0 {
+		notification.Error = j.Status.Failures[len(j.Status.Failures)-1].Message
+	}
+
+	return notification
+}
+
+// postNotification posts a payload to a notification endpoint until it responds with a 2xx status. Failed requests,
+// 5xx and 429 responses are retried with backoff up to the notification retry count, other responses are not retried.
+func postNotification(ctx context.Context, n Notification, payload []byte) error {
+	backoff := time.Second
+	var lastErr error
+	for attempt := uint(0); attempt <= n.RetryCount(); attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return lastErr
+			}
+
+			backoff *= 2
+			if backoff > maxNotificationBackoff {
+				backoff = maxNotificationBackoff
+			}
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, notificationTimeout)
+		status, err := postWebhook(attemptCtx, n.URL, payload)
+		cancel()
+
+		switch {
+		case err == nil && status >= 200 && status < 300:
+			return nil
+		case err == nil && status < http.StatusInternalServerError && status != http.StatusTooManyRequests:
+			return fmt.Errorf("notification rejected with status %d", status)
+		case err == nil:
+			lastErr = fmt.Errorf("status %d", status)
+		default:
+			lastErr = err
+		}
+
+		logger.Debugf("notification attempt %d to %s failed, %v", attempt+1, n.URL, lastErr)
+	}
+
+	return lastErr
+}
@@ -0,0 +1,71 @@
+package deployment
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/job"
+)
+
+func TestNotificationsFieldErrors(t *testing.T) {
+	assert.Empty(t, Config{Notifications: []Notification{{URL: "https://hooks.example.com/deploys"}}}.notificationsFieldErrors())
+	assert.Len(t, Config{Notifications: []Notification{{URL: "hooks.example.com"}, {URL: "ftp://example.com"}}}.notificationsFieldErrors(), 2)
+}
+
+func TestJobNotification(t *testing.T) {
+	succeeded := job.Job{ID: "1", Deployment: "app", Type: string(RunDeploymentJobType), State: job.Completed, StartTime: 100, EndTime: 130}
+	succeeded.Status.ExecutionCount = 1
+	notification := jobNotification(succeeded)
+	assert.Equal(t, JobNotification{Deployment: "app", Action: "RUN_DEPLOYMENT", JobID: "1", Outcome: JobSucceeded, DurationSeconds: 30}, notification)
+
+	failed := job.Job{ID: "2", Deployment: "app", State: job.Completed}
+	failed.Status.ExecutionCount = 2
+	failed.Status.FailureCount = 2
+	failed.Status.Failures = []job.Error{{Execution: 1, Message: "pull failed"}, {Execution: 2, Message: "image not found"}}
+	notification = jobNotification(failed)
+	assert.Equal(t, JobFailed, notification.Outcome)
+	assert.Equal(t, "image not found", notification.Error)
+
+	failed.Cancelled = true
+	assert.Equal(t, JobCancelled, jobNotification(failed).Outcome)
+
+	coalesced := job.Job{ID: "3", Deployment: "app", State: job.Completed, CoalescedInto: "4"}
+	assert.Equal(t, JobCoalesced, jobNotification(coalesced).Outcome)
+}
+
+func TestPostNotificationRetries(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, `{"deployment":"app"}`, string(body))
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	assert.Nil(t, postNotification(context.Background(), Notification{URL: server.URL}, []byte(`{"deployment":"app"}`)))
+	assert.Equal(t, 2, attempts)
+}
+
+func TestPostNotificationRejected(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	noRetries := uint(0)
+	assert.EqualError(t, postNotification(context.Background(), Notification{URL: server.URL}, []byte(`{}`)), "notification rejected with status 404")
+	assert.NotNil(t, postNotification(context.Background(), Notification{URL: "http://127.0.0.1:1", Retries: &noRetries}, []byte(`{}`)))
+	assert.Equal(t, 1, attempts)
+}
@@ -0,0 +1,54 @@
+package deployment
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Overlay is a base deployment configuration with an environment specific overlay applied on top of it
+type Overlay struct {
+	Base    json.RawMessage `json:"base" binding:"required"`    // base deployment configuration
+	Overlay json.RawMessage `json:"overlay" binding:"required"` // partial configuration merged on top of the base configuration
+}
+
+// Merge deep-merges the overlay into the base configuration. Objects are merged
+// key by key, any other value (including lists) in the overlay replaces the base value.
+func (o Overlay) Merge() (Config, error) {
+	var base map[string]interface{}
+	if err := json.Unmarshal(o.Base, &base); err != nil {
+		return Config{}, fmt.Errorf("invalid base configuration: %v", err)
+	}
+
+	var overlay map[string]interface{}
+	if len(o.Overlay) > 0 {
+		if err := json.Unmarshal(o.Overlay, &overlay); err != nil {
+			return Config{}, fmt.Errorf("invalid overlay configuration: %v", err)
+		}
+	}
+
+	bytes, err := json.Marshal(deepMerge(base, overlay))
+	if err != nil {
+		return Config{}, err
+	}
+
+	return DeSerializeConfig(bytes)
+}
+
+// deepMerge merges src into dst, nested objects are merged while any other value is replaced
+func deepMerge(dst, src map[string]interface{}) map[string]interface{} {
+	if dst == nil {
+		dst = make(map[string]interface{})
+	}
+
+	for key, srcValue := range src {
+		srcMap, srcIsMap := srcValue.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			dst[key] = deepMerge(dstMap, srcMap)
+			continue
+		}
+		dst[key] = srcValue
+	}
+
+	return dst
+}
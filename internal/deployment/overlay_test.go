@@ -0,0 +1,34 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverlayMerge(t *testing.T) {
+	o := Overlay{
+		Base:    []byte(`{"name":"app","image":"app","tag":"1.0","scale":1,"alias":["app.localhost"],"env":{"LOG_LEVEL":"debug","PORT":"8080"}}`),
+		Overlay: []byte(`{"tag":"1.1","scale":3,"alias":["app.example.com"],"env":{"LOG_LEVEL":"info"}}`),
+	}
+
+	config, err := o.Merge()
+	assert.Nil(t, err)
+	assert.Equal(t, "app", config.Name)
+	assert.Equal(t, "1.1", config.Tag)
+	assert.Equal(t, 3, config.Scale)
+
+	// lists replace
+	assert.Equal(t, []string{"app.example.com"}, config.Alias)
+
+	// maps merge
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "info", "PORT": "8080"}, config.Env)
+}
+
+func TestOverlayMergeInvalid(t *testing.T) {
+	_, err := Overlay{Base: []byte(`{"name":`), Overlay: []byte(`{}`)}.Merge()
+	assert.Error(t, err)
+
+	_, err = Overlay{Base: []byte(`{"name":"app"}`), Overlay: []byte(`[]`)}.Merge()
+	assert.Error(t, err)
+}
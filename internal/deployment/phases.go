@@ -12,4 +12,12 @@ const (
 	PullImagePhase       Phase = "PULL_IMAGE"
 	CreateContainerPhase Phase = "CREATE_CONTAINER"
 	StartContainerPhase  Phase = "START_CONTAINER"
+	HealthPhase          Phase = "DEPLOYMENT_HEALTH"
+	ReadinessPhase       Phase = "DEPLOYMENT_READINESS"
+	DiskFullPhase        Phase = "DISK_FULL"
+	RoutingPhase         Phase = "DEPLOYMENT_ROUTING"
+	DeferredPhase        Phase = "DEPLOYMENT_DEFERRED"
+	ReloadPhase          Phase = "DEPLOYMENT_RELOAD"
+	HookPhase            Phase = "DEPLOYMENT_HOOK"
+	AutoUpdatePhase      Phase = "DEPLOYMENT_AUTO_UPDATE"
 )
@@ -0,0 +1,89 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/logger"
+)
+
+var digestRegex = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// Pin pins a deployment to the image digest of its running containers. Future runs
+// use the pinned digest instead of pulling the (possibly changed) image tag.
+// The tag is kept in the deployment config so it can be unpinned later.
+func Pin(deployment string) (Config, error) {
+	config, err := GetDeploymentConfig(deployment)
+	if err != nil {
+		return Config{}, err
+	}
+
+	if len(config.Variants) > 0 {
+		return Config{}, fmt.Errorf("unable to pin deployment %s, deployments with variants cannot be pinned", deployment)
+	}
+
+	containers, err := GetContainersByDeployment(deployment)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var running *KraneContainer
+	for i := range containers {
+		if containers[i].State.Running {
+			running = &containers[i]
+			break
+		}
+	}
+
+	if running == nil {
+		return Config{}, fmt.Errorf("unable to pin deployment %s, no running containers found", deployment)
+	}
+
+	digests, err := docker.GetClient().GetImageDigests(context.Background(), running.ImageID)
+	if err != nil {
+		return Config{}, err
+	}
+
+	digest := findImageDigest(digests)
+	if digest == "" {
+		return Config{}, fmt.Errorf("unable to pin deployment %s, image %s has no repository digest", deployment, running.Image)
+	}
+
+	config.Digest = digest
+	if err := SaveConfigWithNote(config, fmt.Sprintf("pinned to %s", digest)); err != nil {
+		return Config{}, err
+	}
+
+	logger.Debugf("deployment %s pinned to %s (tag %s)", deployment, digest, config.Tag)
+	return config, nil
+}
+
+// Unpin removes the pinned image digest from a deployment so it tracks its image tag again
+func Unpin(deployment string) (Config, error) {
+	config, err := GetDeploymentConfig(deployment)
+	if err != nil {
+		return Config{}, err
+	}
+
+	config.Digest = ""
+	if err := SaveConfigWithNote(config, fmt.Sprintf("unpinned, tracking tag %s", config.Tag)); err != nil {
+		return Config{}, err
+	}
+
+	logger.Debugf("deployment %s unpinned, tracking tag %s", deployment, config.Tag)
+	return config, nil
+}
+
+// findImageDigest returns the digest of the first repository digest (ie. nginx@sha256:...)
+func findImageDigest(repoDigests []string) string {
+	for _, repoDigest := range repoDigests {
+		parts := strings.SplitN(repoDigest, "@", 2)
+		if len(parts) == 2 && digestRegex.MatchString(parts[1]) {
+			return parts[1]
+		}
+	}
+	return ""
+}
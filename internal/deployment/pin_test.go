@@ -0,0 +1,33 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testDigest = "sha256:2d4cc3ec2a8f1b2bfb9d5a5a2f3e0e6b3b6c4c4a5b2d4e0a1f9e1b2c3d4e5f60"
+
+func TestFindImageDigest(t *testing.T) {
+	assert.Equal(t, testDigest, findImageDigest([]string{"nginx@" + testDigest}))
+	assert.Equal(t, "", findImageDigest([]string{}))
+	assert.Equal(t, "", findImageDigest([]string{"nginx@sha256:invalid"}))
+}
+
+func TestImageRefUsesDigest(t *testing.T) {
+	config := Config{Image: "nginx", Tag: "latest", Registry: Registry{URL: "docker.io"}}
+	assert.Equal(t, "docker.io/nginx:latest", config.ImageRef())
+
+	config.Digest = testDigest
+	assert.Equal(t, "docker.io/nginx@"+testDigest, config.ImageRef())
+}
+
+func TestUnpin(t *testing.T) {
+	assert.Nil(t, SaveConfig(Config{Name: "unpin", Image: "nginx", Tag: "1.19", Digest: testDigest}))
+	defer DeleteConfig("unpin")
+
+	config, err := Unpin("unpin")
+	assert.Nil(t, err)
+	assert.Empty(t, config.Digest)
+	assert.Equal(t, "1.19", config.Tag)
+}
@@ -1,8 +1,11 @@
 package deployment
 
 import (
+	"fmt"
 	"net"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/docker/go-connections/nat"
 )
@@ -17,9 +20,93 @@ type Port struct {
 type PortProtocol string
 
 const (
-	TCP PortProtocol = "tcp"
+	TCP  PortProtocol = "tcp"
+	UDP  PortProtocol = "udp"
+	SCTP PortProtocol = "sctp"
 )
 
+// PortMapping binds a container port to a host port over a protocol
+type PortMapping struct {
+	HostPort      string       `json:"host_port"`      // host port, a free port is assigned when empty
+	ContainerPort string       `json:"container_port"` // container port
+	Protocol      PortProtocol `json:"protocol"`       // tcp, udp or sctp (default tcp)
+}
+
+// protocol returns the protocol of the port mapping, tcp when omitted
+func (p PortMapping) protocol() PortProtocol {
+	if p.Protocol == "" {
+		return TCP
+	}
+	return PortProtocol(strings.ToLower(string(p.Protocol)))
+}
+
+// hostBinding returns the host port and protocol bound by the mapping (ie. 53/udp)
+func (p PortMapping) hostBinding() string {
+	return fmt.Sprintf("%s/%s", p.HostPort, p.protocol())
+}
+
+// portMappings returns the tcp ports and the port mappings of a deployment with their protocol defaulted, sorted
+// by host port then protocol. Ports are bound over tcp, port mappings are needed for the other protocols.
+func (config Config) portMappings() []PortMapping {
+	mappings := make([]PortMapping, 0, len(config.Ports)+len(config.PortMappings))
+	for _, hostPort := range sortedKeys(config.Ports) {
+		mappings = append(mappings, PortMapping{HostPort: hostPort, ContainerPort: config.Ports[hostPort], Protocol: TCP})
+	}
+
+	sorted := make([]PortMapping, 0, len(config.PortMappings))
+	for _, p := range config.PortMappings {
+		p.Protocol = p.protocol()
+		sorted = append(sorted, p)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		pi, _ := strconv.Atoi(sorted[i].HostPort)
+		pj, _ := strconv.Atoi(sorted[j].HostPort)
+		if pi != pj {
+			return pi < pj
+		}
+		return sorted[i].Protocol < sorted[j].Protocol
+	})
+
+	return append(mappings, sorted...)
+}
+
+// portFieldErrors returns a validation error for every port mapping with an invalid port or protocol,
+// and for every host port bound more than once over the same protocol by the deployment
+func (config Config) portFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+
+	for _, p := range config.PortMappings {
+		if !isValidPort(p.ContainerPort) {
+			errs = append(errs, newFieldError("port_mappings", "invalid container port %s, expected a port between 1 and 65535", p.ContainerPort))
+		}
+		if p.HostPort != "" && !isValidPort(p.HostPort) {
+			errs = append(errs, newFieldError("port_mappings", "invalid host port %s, expected a port between 1 and 65535", p.HostPort))
+		}
+		if protocol := p.protocol(); protocol != TCP && protocol != UDP && protocol != SCTP {
+			errs = append(errs, newFieldError("port_mappings", "unknown protocol %s for port %s, expected tcp, udp or sctp", p.Protocol, p.ContainerPort))
+		}
+	}
+
+	bound := make(map[string]bool)
+	for _, p := range config.portMappings() {
+		if p.HostPort == "" {
+			continue
+		}
+		if bound[p.hostBinding()] {
+			errs = append(errs, newFieldError("port_mappings", "host port %s is bound more than once", p.hostBinding()))
+		}
+		bound[p.hostBinding()] = true
+	}
+
+	return errs
+}
+
+// isValidPort returns true if a port is a number between 1 and 65535
+func isValidPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
 func fromPortMapToPortList(pMap nat.PortMap) []Port {
 	bindings := make([]Port, 0)
 
@@ -37,6 +124,78 @@ func fromPortMapToPortList(pMap nat.PortMap) []Port {
 	return bindings
 }
 
+// HostPortBinding is a host port bound by a Krane managed container
+type HostPortBinding struct {
+	HostIP        string `json:"host_ip"`
+	HostPort      string `json:"host_port"`
+	Protocol      string `json:"protocol"`
+	ContainerPort string `json:"container_port"`
+	Deployment    string `json:"deployment"`
+	ContainerID   string `json:"container_id"`
+	ContainerName string `json:"container_name"`
+	Conflict      bool   `json:"conflict"` // whether another container is bound to the same host port, protocol and ip
+}
+
+// GetHostPortBindings returns every host port bound by Krane managed containers
+func GetHostPortBindings() ([]HostPortBinding, error) {
+	containers, err := GetContainers()
+	if err != nil {
+		return make([]HostPortBinding, 0), err
+	}
+	return hostPortBindings(containers), nil
+}
+
+// hostPortBindings returns the host port bindings of a list of containers sorted by host port,
+// bindings from different containers on the same port, protocol and overlapping ips are flagged as conflicts
+func hostPortBindings(containers []KraneContainer) []HostPortBinding {
+	bindings := make([]HostPortBinding, 0)
+	for _, c := range containers {
+		for _, p := range c.Ports {
+			if p.HostPort == "" {
+				continue
+			}
+			bindings = append(bindings, HostPortBinding{
+				HostIP:        p.IP,
+				HostPort:      p.HostPort,
+				Protocol:      p.Type,
+				ContainerPort: p.ContainerPort,
+				Deployment:    c.Deployment,
+				ContainerID:   c.ID,
+				ContainerName: c.Name,
+			})
+		}
+	}
+
+	for i := range bindings {
+		for j := i + 1; j < len(bindings); j++ {
+			a, b := bindings[i], bindings[j]
+			if a.ContainerID == b.ContainerID || a.HostPort != b.HostPort || a.Protocol != b.Protocol {
+				continue
+			}
+			if isWildcardIP(a.HostIP) || isWildcardIP(b.HostIP) || a.HostIP == b.HostIP {
+				bindings[i].Conflict = true
+				bindings[j].Conflict = true
+			}
+		}
+	}
+
+	sort.SliceStable(bindings, func(i, j int) bool {
+		pi, _ := strconv.Atoi(bindings[i].HostPort)
+		pj, _ := strconv.Atoi(bindings[j].HostPort)
+		if pi != pj {
+			return pi < pj
+		}
+		return bindings[i].Protocol < bindings[j].Protocol
+	})
+
+	return bindings
+}
+
+// isWildcardIP returns true if a host ip binds to every interface
+func isWildcardIP(ip string) bool {
+	return ip == "" || ip == "0.0.0.0" || ip == "::"
+}
+
 // findFreePort returns a free port on the host machine
 func findFreePort() (string, error) {
 	addr, err := net.ResolveTCPAddr(string(TCP), "localhost:0")
@@ -0,0 +1,85 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostPortBindings(t *testing.T) {
+	containers := []KraneContainer{
+		{ID: "a", Deployment: "app", Ports: []Port{
+			{IP: "0.0.0.0", HostPort: "8080", Type: "tcp", ContainerPort: "80"},
+			{IP: "::", HostPort: "8080", Type: "tcp", ContainerPort: "80"},
+			{IP: "0.0.0.0", HostPort: "53", Type: "udp", ContainerPort: "53"},
+		}},
+		{ID: "b", Deployment: "other", Ports: []Port{
+			{IP: "127.0.0.1", HostPort: "8080", Type: "tcp", ContainerPort: "3000"},
+			{IP: "0.0.0.0", HostPort: "53", Type: "tcp", ContainerPort: "53"},
+			{IP: "", HostPort: "", Type: "tcp", ContainerPort: "9000"},
+		}},
+	}
+
+	bindings := hostPortBindings(containers)
+	assert.Len(t, bindings, 5)
+
+	assert.Equal(t, "53", bindings[0].HostPort)
+	assert.Equal(t, "tcp", bindings[0].Protocol)
+	assert.False(t, bindings[0].Conflict)
+	assert.Equal(t, "udp", bindings[1].Protocol)
+	assert.False(t, bindings[1].Conflict)
+
+	for _, b := range bindings[2:] {
+		assert.Equal(t, "8080", b.HostPort)
+		assert.True(t, b.Conflict)
+	}
+}
+
+func TestHostPortBindingsNoConflictOnDifferentIPs(t *testing.T) {
+	containers := []KraneContainer{
+		{ID: "a", Ports: []Port{{IP: "10.0.0.1", HostPort: "80", Type: "tcp"}}},
+		{ID: "b", Ports: []Port{{IP: "10.0.0.2", HostPort: "80", Type: "tcp"}}},
+	}
+
+	for _, b := range hostPortBindings(containers) {
+		assert.False(t, b.Conflict)
+	}
+}
+
+func TestPortMappings(t *testing.T) {
+	config := Config{
+		Ports: map[string]string{"8080": "80"},
+		PortMappings: []PortMapping{
+			{HostPort: "53", ContainerPort: "53", Protocol: "UDP"},
+			{HostPort: "53", ContainerPort: "53"},
+			{ContainerPort: "514", Protocol: UDP},
+		},
+	}
+
+	assert.Equal(t, []PortMapping{
+		{HostPort: "8080", ContainerPort: "80", Protocol: TCP},
+		{HostPort: "", ContainerPort: "514", Protocol: UDP},
+		{HostPort: "53", ContainerPort: "53", Protocol: TCP},
+		{HostPort: "53", ContainerPort: "53", Protocol: UDP},
+	}, config.portMappings())
+	assert.Empty(t, config.portFieldErrors())
+
+	ports := config.DockerPorts()
+	assert.Len(t, ports, 4)
+	assert.Equal(t, "53", ports["53/udp"][0].HostPort)
+	assert.Equal(t, "", ports["514/udp"][0].HostPort)
+	assert.Len(t, config.DockerPortSet(), 4)
+}
+
+func TestPortFieldErrors(t *testing.T) {
+	assert.Len(t, Config{PortMappings: []PortMapping{{ContainerPort: "0"}}}.portFieldErrors(), 1)
+	assert.Len(t, Config{PortMappings: []PortMapping{{HostPort: "http", ContainerPort: "80"}}}.portFieldErrors(), 1)
+	assert.Len(t, Config{PortMappings: []PortMapping{{ContainerPort: "80", Protocol: "icmp"}}}.portFieldErrors(), 1)
+
+	// a host port is bound once per protocol across ports and port mappings
+	duplicate := Config{
+		Ports:        map[string]string{"8080": "80"},
+		PortMappings: []PortMapping{{HostPort: "8080", ContainerPort: "8080", Protocol: TCP}},
+	}
+	assert.Len(t, duplicate.portFieldErrors(), 1)
+}
@@ -0,0 +1,292 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/api/types"
+
+	"github.com/krane/krane/internal/docker"
+)
+
+// DefaultProbeTimeout is the max time to connect to a container when probing its health
+const DefaultProbeTimeout = 5 * time.Second
+
+// DefaultHealthCheckRetries is the number of times a new container is probed again before it is considered unhealthy
+const DefaultHealthCheckRetries = 10
+
+// HealthCheck configures how the containers of a deployment are probed before they are considered healthy
+type HealthCheck struct {
+	Port         string `json:"port"`          // container port that must accept connections over the krane network, not probed if empty
+	Retries      *uint  `json:"retries"`       // attempts after the first failed probe before a new container is unhealthy, 0 probes once (default 10)
+	Interval     uint   `json:"interval"`      // seconds between probes (default 0, which waits 10 seconds more before every attempt)
+	Timeout      uint   `json:"timeout"`       // max time in seconds for a single probe (default 5)
+	InitialDelay uint   `json:"initial_delay"` // seconds after the containers start before they are first probed (default 0)
+
+	HTTP HTTPHealthCheck `json:"http"` // http endpoint of the application that must respond with the expected status
+}
+
+// HTTPHealthCheck configures an http request the application inside a container must answer before it is healthy
+type HTTPHealthCheck struct {
+	Path           string `json:"path"`            // http path requested (ie. /healthz), not probed if empty
+	Port           string `json:"port"`            // container port requested over the krane network, defaults to the health check port then the target port
+	ExpectedStatus int    `json:"expected_status"` // status the endpoint must respond with (default any 2xx status)
+	Interval       uint   `json:"interval"`        // seconds between probes, overrides the health check interval (default the health check interval)
+}
+
+// HTTPProbeError is returned when the http health check endpoint of a container does not respond as expected
+type HTTPProbeError struct {
+	Container string
+	URL       string
+	Reason    string // connection refused, timeout, unexpected status or request failure
+	Err       error
+}
+
+// Error returns a string representation of a HTTPProbeError
+func (e HTTPProbeError) Error() string {
+	return fmt.Sprintf("container %s http health check %s failed, %s: %v", e.Container, e.URL, e.Reason, e.Err)
+}
+
+// HTTP probe failure reasons
+const (
+	HTTPProbeConnectionRefused = "connection refused"
+	HTTPProbeTimeout           = "timeout"
+	HTTPProbeUnexpectedStatus  = "unexpected status"
+	HTTPProbeRequestFailed     = "request failed"
+)
+
+// Enabled returns true if the http endpoint of containers is probed
+func (h HTTPHealthCheck) Enabled() bool {
+	return h.Path != ""
+}
+
+// probed returns whether containers are probed beyond being running
+func (h HealthCheck) probed() bool {
+	return h.Port != "" || h.HTTP.Enabled()
+}
+
+// httpHealthCheckPort returns the container port the http health check requests
+func (config Config) httpHealthCheckPort() string {
+	switch {
+	case config.HealthCheck.HTTP.Port != "":
+		return config.HealthCheck.HTTP.Port
+	case config.HealthCheck.Port != "":
+		return config.HealthCheck.Port
+	default:
+		return config.TargetPort
+	}
+}
+
+// retries returns the number of attempts after the first failed probe before a new container is unhealthy
+func (h HealthCheck) retries() int {
+	if h.Retries == nil {
+		return DefaultHealthCheckRetries
+	}
+	return int(*h.Retries)
+}
+
+// timeout returns the max time for a single probe of a container
+func (h HealthCheck) timeout() time.Duration {
+	if h.Timeout == 0 {
+		return DefaultProbeTimeout
+	}
+	return time.Duration(h.Timeout) * time.Second
+}
+
+// policy returns how many times and how often new containers are probed
+func (h HealthCheck) policy() healthCheckPolicy {
+	return healthCheckPolicy{
+		Retries:  h.retries(),
+		Backoff:  healthCheckBackoff,
+		Interval: time.Duration(h.interval()) * time.Second,
+	}
+}
+
+// interval returns the seconds between probes, the http health check interval when set
+func (h HealthCheck) interval() uint {
+	if h.HTTP.Enabled() && h.HTTP.Interval > 0 {
+		return h.HTTP.Interval
+	}
+	return h.Interval
+}
+
+// ContainerNotAttachedError is returned when a container does not (yet) have an address on a network
+type ContainerNotAttachedError struct {
+	Container string
+	Network   string
+}
+
+// Error returns a string representation of a ContainerNotAttachedError
+func (e ContainerNotAttachedError) Error() string {
+	return fmt.Sprintf("container %s is not attached to network %s yet", e.Container, e.Network)
+}
+
+// healthCheckFieldErrors returns a validation error if the health check port is not a valid port or the
+// http health check is invalid
+func (config Config) healthCheckFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+	if config.HealthCheck.Port != "" && !isValidPort(config.HealthCheck.Port) {
+		errs = append(errs, newFieldError("health_check", "invalid health check port %s", config.HealthCheck.Port))
+	}
+
+	h := config.HealthCheck.HTTP
+	if !h.Enabled() {
+		return errs
+	}
+
+	if !strings.HasPrefix(h.Path, "/") {
+		errs = append(errs, newFieldError("health_check.http", "invalid http health check path %s, expected a path starting with /", h.Path))
+	}
+	if port := config.httpHealthCheckPort(); port == "" {
+		errs = append(errs, newFieldError("health_check.http", "http health check port required when no health check port or target port is set"))
+	} else if !isValidPort(port) {
+		errs = append(errs, newFieldError("health_check.http", "invalid http health check port %s", port))
+	}
+	if h.ExpectedStatus != 0 && (h.ExpectedStatus < 100 || h.ExpectedStatus > 599) {
+		errs = append(errs, newFieldError("health_check.http", "invalid expected status %d", h.ExpectedStatus))
+	}
+	return errs
+}
+
+// healthProbe returns the probe a container must pass to be healthy. Containers must be running and,
+// when a health check port is configured, accept connections on the port over the krane network. When an
+// http health check is configured, the application must also respond to it with the expected status.
+func (config Config) healthProbe() func(KraneContainer, context.Context) (bool, error) {
+	if !config.HealthCheck.probed() {
+		return KraneContainer.Running
+	}
+
+	return func(c KraneContainer, ctx context.Context) (bool, error) {
+		if running, err := c.Running(ctx); err != nil || !running {
+			return running, err
+		}
+
+		if config.HealthCheck.Port != "" {
+			if err := c.probePort(ctx, docker.KraneNetworkName, config.HealthCheck.Port, config.HealthCheck.timeout()); err != nil {
+				return false, err
+			}
+		}
+
+		if config.HealthCheck.HTTP.Enabled() {
+			if err := c.probeHTTP(ctx, docker.KraneNetworkName, config.httpHealthCheckPort(), config.HealthCheck.HTTP, config.HealthCheck.timeout()); err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+}
+
+// probePort returns an error if a container does not accept connections on a port at its address on a network.
+// Probing the container directly does not depend on the container publishing the port or being routed by the proxy.
+func (c KraneContainer) probePort(ctx context.Context, network string, port string, timeout time.Duration) error {
+	container, err := docker.GetClient().GetOneContainer(ctx, c.ID)
+	if err != nil {
+		return err
+	}
+
+	ip, err := containerIP(container, network)
+	if err != nil {
+		return err
+	}
+
+	if err := dialProbe(ctx, net.JoinHostPort(ip, port), timeout); err != nil {
+		return fmt.Errorf("container %s not accepting connections on %s, %v", c.Name, net.JoinHostPort(ip, port), err)
+	}
+	return nil
+}
+
+// probeHTTP returns a HTTPProbeError if the http health check endpoint of a container at its address on a
+// network does not respond with the expected status within the timeout
+func (c KraneContainer) probeHTTP(ctx context.Context, network string, port string, check HTTPHealthCheck, timeout time.Duration) error {
+	container, err := docker.GetClient().GetOneContainer(ctx, c.ID)
+	if err != nil {
+		return err
+	}
+
+	ip, err := containerIP(container, network)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("http://%s%s", net.JoinHostPort(ip, port), check.Path)
+	if err := httpProbe(ctx, url, check.ExpectedStatus, timeout); err != nil {
+		err.Container = c.Name
+		return *err
+	}
+	return nil
+}
+
+// httpProbe returns an error classifying why a GET request to a url did not respond with the expected status
+// (any 2xx status when 0) within the timeout. Redirects are not followed, they are reported as unexpected statuses.
+func httpProbe(ctx context.Context, url string, expectedStatus int, timeout time.Duration) *HTTPProbeError {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return &HTTPProbeError{URL: url, Reason: HTTPProbeRequestFailed, Err: err}
+	}
+
+	client := http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var netErr net.Error
+		switch {
+		case errors.Is(err, syscall.ECONNREFUSED):
+			return &HTTPProbeError{URL: url, Reason: HTTPProbeConnectionRefused, Err: err}
+		case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+			return &HTTPProbeError{URL: url, Reason: HTTPProbeTimeout, Err: fmt.Errorf("no response within %s", timeout)}
+		default:
+			return &HTTPProbeError{URL: url, Reason: HTTPProbeRequestFailed, Err: err}
+		}
+	}
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64*1024))
+	_ = resp.Body.Close()
+
+	expected := resp.StatusCode == expectedStatus
+	if expectedStatus == 0 {
+		expected = resp.StatusCode >= 200 && resp.StatusCode < 300
+	}
+	if !expected {
+		return &HTTPProbeError{URL: url, Reason: HTTPProbeUnexpectedStatus, Err: fmt.Errorf("responded %d", resp.StatusCode)}
+	}
+	return nil
+}
+
+// dialProbe returns an error if a tcp connection to an address cannot be opened within the timeout
+func dialProbe(ctx context.Context, address string, timeout time.Duration) error {
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// containerIP returns the address of a container on a network
+func containerIP(container types.ContainerJSON, network string) (string, error) {
+	name := container.Name
+	if container.Config != nil {
+		name = container.Config.Hostname
+	}
+
+	if container.NetworkSettings == nil {
+		return "", ContainerNotAttachedError{Container: name, Network: network}
+	}
+
+	endpoint, ok := container.NetworkSettings.Networks[network]
+	if !ok || endpoint == nil || endpoint.IPAddress == "" {
+		return "", ContainerNotAttachedError{Container: name, Network: network}
+	}
+	return endpoint.IPAddress, nil
+}
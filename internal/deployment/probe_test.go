@@ -0,0 +1,133 @@
+package deployment
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/docker"
+)
+
+func TestContainerIP(t *testing.T) {
+	c := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{Name: "/app-1"},
+		Config:            &container.Config{Hostname: "app-1"},
+		NetworkSettings: &types.NetworkSettings{Networks: map[string]*network.EndpointSettings{
+			docker.KraneNetworkName: {IPAddress: "172.18.0.5"},
+			"pending":               {IPAddress: ""},
+		}},
+	}
+
+	ip, err := containerIP(c, docker.KraneNetworkName)
+	assert.Nil(t, err)
+	assert.Equal(t, "172.18.0.5", ip)
+
+	_, err = containerIP(c, "pending")
+	assert.Equal(t, ContainerNotAttachedError{Container: "app-1", Network: "pending"}, err)
+
+	_, err = containerIP(c, "other")
+	assert.Error(t, err)
+}
+
+func TestDialProbe(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	address := listener.Addr().String()
+
+	assert.Nil(t, dialProbe(context.Background(), address, time.Second))
+
+	listener.Close()
+	assert.Error(t, dialProbe(context.Background(), address, time.Second))
+}
+
+func TestHealthCheckConfig(t *testing.T) {
+	assert.Empty(t, Config{HealthCheck: HealthCheck{Port: "8080"}}.healthCheckFieldErrors())
+	assert.Len(t, Config{HealthCheck: HealthCheck{Port: "http"}}.healthCheckFieldErrors(), 1)
+	assert.Len(t, Config{HealthCheck: HealthCheck{Port: "70000"}}.healthCheckFieldErrors(), 1)
+}
+
+func TestHTTPHealthCheckConfig(t *testing.T) {
+	assert.Empty(t, Config{HealthCheck: HealthCheck{HTTP: HTTPHealthCheck{Path: "/healthz", Port: "8080"}}}.healthCheckFieldErrors())
+	assert.Empty(t, Config{TargetPort: "80", HealthCheck: HealthCheck{HTTP: HTTPHealthCheck{Path: "/healthz", ExpectedStatus: 204}}}.healthCheckFieldErrors())
+	assert.Len(t, Config{HealthCheck: HealthCheck{HTTP: HTTPHealthCheck{Path: "healthz", Port: "8080"}}}.healthCheckFieldErrors(), 1)
+	assert.Len(t, Config{HealthCheck: HealthCheck{HTTP: HTTPHealthCheck{Path: "/healthz"}}}.healthCheckFieldErrors(), 1)
+	assert.Len(t, Config{HealthCheck: HealthCheck{HTTP: HTTPHealthCheck{Path: "/healthz", Port: "8080", ExpectedStatus: 42}}}.healthCheckFieldErrors(), 1)
+
+	// the http port defaults to the health check port, then the target port
+	assert.Equal(t, "9000", Config{TargetPort: "80", HealthCheck: HealthCheck{Port: "9000", HTTP: HTTPHealthCheck{Path: "/"}}}.httpHealthCheckPort())
+	assert.Equal(t, "80", Config{TargetPort: "80", HealthCheck: HealthCheck{HTTP: HTTPHealthCheck{Path: "/"}}}.httpHealthCheckPort())
+}
+
+func TestHTTPProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz":
+			w.WriteHeader(http.StatusOK)
+		case "/created":
+			w.WriteHeader(http.StatusCreated)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		case "/moved":
+			http.Redirect(w, r, "/healthz", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	assert.Nil(t, httpProbe(context.Background(), server.URL+"/healthz", 0, time.Second))
+	assert.Nil(t, httpProbe(context.Background(), server.URL+"/created", http.StatusCreated, time.Second))
+
+	err := httpProbe(context.Background(), server.URL+"/created", http.StatusOK, time.Second)
+	assert.Equal(t, HTTPProbeUnexpectedStatus, err.Reason)
+
+	err = httpProbe(context.Background(), server.URL+"/broken", 0, time.Second)
+	assert.Equal(t, HTTPProbeUnexpectedStatus, err.Reason)
+	assert.EqualError(t, err.Err, "responded 500")
+
+	err = httpProbe(context.Background(), server.URL+"/moved", 0, time.Second)
+	assert.Equal(t, HTTPProbeUnexpectedStatus, err.Reason)
+
+	err = httpProbe(context.Background(), server.URL+"/slow", 0, 50*time.Millisecond)
+	assert.Equal(t, HTTPProbeTimeout, err.Reason)
+
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	address := listener.Addr().String()
+	listener.Close()
+	err = httpProbe(context.Background(), "http://"+address+"/healthz", 0, time.Second)
+	assert.Equal(t, HTTPProbeConnectionRefused, err.Reason)
+}
+
+func TestHealthCheckPolicy(t *testing.T) {
+	// defaults preserve the linear backoff of 10 retries
+	policy := HealthCheck{}.policy()
+	assert.Equal(t, DefaultHealthCheckRetries, policy.Retries)
+	assert.Equal(t, time.Duration(0), policy.delay(0))
+	assert.Equal(t, 10*time.Second, policy.delay(1))
+	assert.Equal(t, 30*time.Second, policy.delay(3))
+	assert.Equal(t, DefaultProbeTimeout, HealthCheck{}.timeout())
+
+	retries, noRetries := uint(30), uint(0)
+	policy = HealthCheck{Retries: &retries, Interval: 2}.policy()
+	assert.Equal(t, 30, policy.Retries)
+	assert.Equal(t, time.Duration(0), policy.delay(0))
+	assert.Equal(t, 2*time.Second, policy.delay(1))
+	assert.Equal(t, 2*time.Second, policy.delay(20))
+	assert.Equal(t, 3*time.Second, HealthCheck{Timeout: 3}.timeout())
+
+	// an explicit 0 probes new containers once
+	assert.Equal(t, 0, HealthCheck{Retries: &noRetries}.policy().Retries)
+}
+
+func TestHTTPHealthCheckInterval(t *testing.T) {
+	assert.Equal(t, 5*time.Second, HealthCheck{Interval: 2, HTTP: HTTPHealthCheck{Path: "/", Interval: 5}}.policy().delay(1))
+	assert.Equal(t, 2*time.Second, HealthCheck{Interval: 2, HTTP: HTTPHealthCheck{Path: "/"}}.policy().delay(1))
+}
@@ -0,0 +1,260 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/proxy"
+)
+
+// Readiness probe defaults
+const (
+	DefaultReadinessInterval = 10 // seconds between readiness probes of a container by the network proxy
+	DefaultReadinessTimeout  = 5  // max time in seconds for a container to respond to a readiness probe
+)
+
+// DefaultLivenessFailureThreshold is the number of consecutive failed liveness probes before a container is restarted
+const DefaultLivenessFailureThreshold = 3
+
+// ReadinessProbe configures when the containers of a deployment are ready to receive traffic. Readiness is probed
+// by the network proxy, containers failing the probe are removed from the load balancer but keep running.
+type ReadinessProbe struct {
+	Path     string `json:"path"`     // http path probed by the network proxy (ie. /ready), readiness is not probed if empty
+	Port     string `json:"port"`     // container port to probe, defaults to the port traffic is routed to
+	Interval uint   `json:"interval"` // seconds between probes (default 10)
+	Timeout  uint   `json:"timeout"`  // max time in seconds for a container to respond (default 5)
+}
+
+// LivenessProbe configures when the containers of a deployment are alive. Liveness is probed by Krane
+// while monitoring deployments, containers failing the probe too many times in a row are restarted.
+type LivenessProbe struct {
+	Port                   string `json:"port"`                     // container port that must accept connections over the krane network, liveness is not probed if empty
+	FailureThreshold       uint   `json:"failure_threshold"`        // consecutive failed probes before the container is restarted (default 3)
+	InitialDelay           uint   `json:"initial_delay"`            // seconds after a container starts before it is probed (default 0)
+	StableAfter            uint   `json:"stable_after"`             // seconds a container must be up without failing probes to tolerate stable_failure_threshold failures (default 0, which means no age weighting)
+	StableFailureThreshold uint   `json:"stable_failure_threshold"` // consecutive failed probes before a stable container is restarted (default twice the failure_threshold)
+}
+
+// Enabled returns true if the readiness of containers is probed
+func (p ReadinessProbe) Enabled() bool {
+	return p.Path != ""
+}
+
+// Enabled returns true if the liveness of containers is probed
+func (p LivenessProbe) Enabled() bool {
+	return p.Port != ""
+}
+
+// intervalLabel returns the readiness probe interval formatted as a Traefik duration
+func (p ReadinessProbe) intervalLabel() string {
+	if p.Interval == 0 {
+		return fmt.Sprintf("%ds", DefaultReadinessInterval)
+	}
+	return fmt.Sprintf("%ds", p.Interval)
+}
+
+// timeoutLabel returns the readiness probe timeout formatted as a Traefik duration
+func (p ReadinessProbe) timeoutLabel() string {
+	if p.Timeout == 0 {
+		return fmt.Sprintf("%ds", DefaultReadinessTimeout)
+	}
+	return fmt.Sprintf("%ds", p.Timeout)
+}
+
+// failureThreshold returns the number of consecutive failed liveness probes before a container is restarted
+func (p LivenessProbe) failureThreshold() uint {
+	if p.FailureThreshold == 0 {
+		return DefaultLivenessFailureThreshold
+	}
+	return p.FailureThreshold
+}
+
+// stableFailureThreshold returns the number of consecutive failed liveness probes before a stable container is restarted
+func (p LivenessProbe) stableFailureThreshold() uint {
+	if p.StableFailureThreshold == 0 {
+		return 2 * p.failureThreshold()
+	}
+	return p.StableFailureThreshold
+}
+
+// failureThresholdFor returns the consecutive failed probes tolerated for a container. Containers up for stable_after
+// without a failed probe streak in that time are stable and tolerate more failures, so a transient blip of a long-lived
+// container does not restart it while new or flapping containers are restarted as soon as they reach the failure threshold.
+func (p LivenessProbe) failureThresholdFor(uptime time.Duration, sinceRecovered time.Duration) uint {
+	stableAfter := time.Duration(p.StableAfter) * time.Second
+	if p.StableAfter == 0 || uptime < stableAfter || sinceRecovered < stableAfter {
+		return p.failureThreshold()
+	}
+	return p.stableFailureThreshold()
+}
+
+// probesFieldErrors returns a validation error for every readiness or liveness probe setting that is invalid
+func (config Config) probesFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+
+	if config.Readiness.Enabled() {
+		if !strings.HasPrefix(config.Readiness.Path, "/") {
+			errs = append(errs, newFieldError("readiness", "invalid readiness path %s, expected a path starting with /", config.Readiness.Path))
+		}
+		if config.Readiness.Port != "" && !validPort(config.Readiness.Port) {
+			errs = append(errs, newFieldError("readiness", "invalid readiness port %s", config.Readiness.Port))
+		}
+		if config.Readiness.Timeout > 0 && config.Readiness.Interval > 0 && config.Readiness.Timeout > config.Readiness.Interval {
+			errs = append(errs, newFieldError("readiness", "readiness timeout %ds exceeds the interval %ds", config.Readiness.Timeout, config.Readiness.Interval))
+		}
+		if config.TargetPort == "" && len(config.Ports) == 0 {
+			errs = append(errs, newFieldError("readiness", "readiness is probed by the network proxy and requires a target_port or ports"))
+		}
+		if docker.IsIsolatedNetworkMode(config.NetworkMode) {
+			errs = append(errs, newFieldError("readiness", "readiness can't be probed by the network proxy with network_mode %s", config.NetworkMode))
+		}
+	} else if config.Readiness != (ReadinessProbe{}) {
+		errs = append(errs, newFieldError("readiness", "readiness path is required to probe readiness"))
+	}
+
+	if config.Liveness.Enabled() {
+		if !validPort(config.Liveness.Port) {
+			errs = append(errs, newFieldError("liveness", "invalid liveness port %s", config.Liveness.Port))
+		}
+		if docker.IsIsolatedNetworkMode(config.NetworkMode) {
+			errs = append(errs, newFieldError("liveness", "liveness port can't be probed with network_mode %s", config.NetworkMode))
+		}
+		if config.Liveness.StableFailureThreshold > 0 && config.Liveness.StableAfter == 0 {
+			errs = append(errs, newFieldError("liveness", "liveness stable_failure_threshold requires stable_after"))
+		}
+		if config.Liveness.StableFailureThreshold > 0 && config.Liveness.StableFailureThreshold < config.Liveness.failureThreshold() {
+			errs = append(errs, newFieldError("liveness", "liveness stable_failure_threshold %d is lower than the failure_threshold %d",
+				config.Liveness.StableFailureThreshold, config.Liveness.failureThreshold()))
+		}
+	} else if config.Liveness != (LivenessProbe{}) {
+		errs = append(errs, newFieldError("liveness", "liveness port is required to probe liveness"))
+	}
+
+	return errs
+}
+
+// validPort returns true if a port is a number between 1 and 65535
+func validPort(value string) bool {
+	port, err := strconv.Atoi(value)
+	return err == nil && port >= 1 && port <= 65535
+}
+
+// readinessLabels returns the network proxy labels probing the readiness of the deployment containers
+func (config Config) readinessLabels() map[string]string {
+	if !config.Readiness.Enabled() {
+		return map[string]string{}
+	}
+
+	return proxy.TraefikHealthCheckLabels(config.serviceName(), config.Ports, config.TargetPort,
+		config.Readiness.Path, config.Readiness.Port, config.Readiness.intervalLabel(), config.Readiness.timeoutLabel())
+}
+
+var livenessMu sync.Mutex
+
+// livenessState is the recent liveness history of a container
+type livenessState struct {
+	failures    uint      // consecutive failed probes
+	recoveredAt time.Time // when the container last passed a probe after failing some
+}
+
+// livenessStates are the liveness history of each container by deployment
+var livenessStates = make(map[string]map[string]livenessState)
+
+// livenessRestart is a container which reached its liveness failure threshold
+type livenessRestart struct {
+	container KraneContainer
+	failures  uint
+}
+
+// MonitorLiveness probes the liveness of the running containers of a deployment and restarts the containers
+// which failed the probe too many times in a row (weighted by their age). Returns the restarted containers.
+func MonitorLiveness(ctx context.Context, d Deployment) []KraneContainer {
+	if !d.Config.Liveness.Enabled() {
+		return make([]KraneContainer, 0)
+	}
+
+	probe := func(c KraneContainer, ctx context.Context) error {
+		return c.probePort(ctx, docker.KraneNetworkName, d.Config.Liveness.Port, DefaultProbeTimeout)
+	}
+
+	failing := livenessRestarts(ctx, d, time.Now(), probe)
+	restarted := make([]KraneContainer, 0, len(failing))
+	for _, restart := range failing {
+		c := restart.container
+		e := createEventEmitter(d.Config.Name, "")
+		e.Phase = HealthPhase
+
+		logger.Warnf("container %s failed %d liveness probes in a row, restarting it", c.Name, restart.failures)
+		if err := c.Restart(ctx, d.Config.ContainerStopTimeout()); err != nil {
+			logger.Errorf("unable to restart container failing its liveness probe %v", err)
+			e.emit(fmt.Sprintf("Container %s failed its liveness probe but could not be restarted: %v", c.Name, err))
+			continue
+		}
+
+		e.emit(fmt.Sprintf("Container %s failed %d liveness probes in a row and was restarted", c.Name, restart.failures))
+		restarted = append(restarted, c)
+	}
+
+	return restarted
+}
+
+// livenessRestarts probes the running containers of a deployment and returns the containers that reached their liveness
+// failure threshold, their history is reset. Containers still within their initial delay are not probed.
+func livenessRestarts(ctx context.Context, d Deployment, now time.Time, probe func(KraneContainer, context.Context) error) []livenessRestart {
+	liveness := d.Config.Liveness
+	initialDelay := time.Duration(liveness.InitialDelay) * time.Second
+
+	livenessMu.Lock()
+	previous := livenessStates[d.Config.Name]
+	livenessMu.Unlock()
+
+	// history is only kept for the current containers so removed containers don't leak
+	states := make(map[string]livenessState, len(d.Containers))
+	restarts := make([]livenessRestart, 0)
+	for _, c := range d.Containers {
+		if !c.State.Running {
+			continue
+		}
+
+		state := previous[c.ID]
+
+		// containers with an unknown start time are considered new
+		uptime := time.Duration(0)
+		if startedAt, err := time.Parse(time.RFC3339Nano, c.State.StartedAt); err == nil {
+			uptime = now.Sub(startedAt)
+		}
+		if uptime < initialDelay {
+			states[c.ID] = state
+			continue
+		}
+
+		err := probe(c, ctx)
+		if err == nil {
+			if state.failures > 0 {
+				state = livenessState{recoveredAt: now}
+			}
+			states[c.ID] = state
+			continue
+		}
+		logger.Debugf("container %s failed its liveness probe %v", c.Name, err)
+
+		state.failures++
+		if state.failures >= liveness.failureThresholdFor(uptime, now.Sub(state.recoveredAt)) {
+			restarts = append(restarts, livenessRestart{container: c, failures: state.failures})
+			continue
+		}
+		states[c.ID] = state
+	}
+
+	livenessMu.Lock()
+	livenessStates[d.Config.Name] = states
+	livenessMu.Unlock()
+
+	return restarts
+}
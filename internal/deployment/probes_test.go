@@ -0,0 +1,120 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbesConfig(t *testing.T) {
+	routed := Config{TargetPort: "8080"}
+
+	routed.Readiness = ReadinessProbe{Path: "/ready"}
+	assert.Empty(t, routed.probesFieldErrors())
+
+	routed.Readiness = ReadinessProbe{Path: "ready", Port: "http"}
+	assert.Len(t, routed.probesFieldErrors(), 2)
+
+	routed.Readiness = ReadinessProbe{Interval: 5}
+	assert.Len(t, routed.probesFieldErrors(), 1)
+
+	assert.Len(t, Config{Readiness: ReadinessProbe{Path: "/ready"}}.probesFieldErrors(), 1)
+	assert.Len(t, Config{TargetPort: "80", NetworkMode: "host", Readiness: ReadinessProbe{Path: "/ready"}}.probesFieldErrors(), 1)
+
+	assert.Empty(t, Config{Liveness: LivenessProbe{Port: "8080"}}.probesFieldErrors())
+	assert.Len(t, Config{Liveness: LivenessProbe{Port: "70000"}}.probesFieldErrors(), 1)
+	assert.Len(t, Config{Liveness: LivenessProbe{FailureThreshold: 5}}.probesFieldErrors(), 1)
+	assert.Len(t, Config{NetworkMode: "none", Liveness: LivenessProbe{Port: "8080"}}.probesFieldErrors(), 1)
+}
+
+func TestReadinessLabels(t *testing.T) {
+	config := Config{Name: "app", TargetPort: "8080", Readiness: ReadinessProbe{Path: "/ready", Port: "9090"}}
+
+	labels := config.readinessLabels()
+	assert.Equal(t, "/ready", labels["traefik.http.services.app.loadbalancer.healthcheck.path"])
+	assert.Equal(t, "9090", labels["traefik.http.services.app.loadbalancer.healthcheck.port"])
+	assert.Equal(t, "10s", labels["traefik.http.services.app.loadbalancer.healthcheck.interval"])
+	assert.Equal(t, "5s", labels["traefik.http.services.app.loadbalancer.healthcheck.timeout"])
+
+	assert.Empty(t, Config{Name: "app", TargetPort: "8080"}.readinessLabels())
+}
+
+func TestLivenessRestarts(t *testing.T) {
+	now := time.Now()
+	d := Deployment{
+		Config: Config{Name: "liveness", Liveness: LivenessProbe{Port: "8080", FailureThreshold: 2, InitialDelay: 60}},
+		Containers: []KraneContainer{
+			{ID: "dead", Name: "liveness-1", State: ContainerState{Running: true, StartedAt: now.Add(-time.Hour).Format(time.RFC3339Nano)}},
+			{ID: "warming", Name: "liveness-2", State: ContainerState{Running: true, StartedAt: now.Format(time.RFC3339Nano)}},
+			{ID: "stopped", Name: "liveness-3", State: ContainerState{Running: false}},
+		},
+	}
+
+	probed := make([]string, 0)
+	failing := func(c KraneContainer, ctx context.Context) error {
+		probed = append(probed, c.ID)
+		return errors.New("connection refused")
+	}
+
+	assert.Empty(t, livenessRestarts(context.Background(), d, now, failing))
+	assert.Equal(t, []string{"dead"}, probed)
+
+	restarts := livenessRestarts(context.Background(), d, now, failing)
+	assert.Len(t, restarts, 1)
+	assert.Equal(t, "dead", restarts[0].container.ID)
+	assert.Equal(t, uint(2), restarts[0].failures)
+
+	// the failure count is reset once the container is restarted
+	assert.Empty(t, livenessRestarts(context.Background(), d, now, failing))
+
+	// a passing probe resets the failure count
+	assert.Empty(t, livenessRestarts(context.Background(), d, now, func(KraneContainer, context.Context) error { return nil }))
+	assert.Empty(t, livenessRestarts(context.Background(), d, now, failing))
+}
+
+func TestLivenessRestartsWeightedByAge(t *testing.T) {
+	now := time.Now()
+	d := Deployment{
+		Config: Config{Name: "liveness-age", Liveness: LivenessProbe{Port: "8080", FailureThreshold: 1, StableAfter: 600, StableFailureThreshold: 3}},
+		Containers: []KraneContainer{
+			{ID: "stable", Name: "liveness-age-1", State: ContainerState{Running: true, StartedAt: now.Add(-time.Hour).Format(time.RFC3339Nano)}},
+			{ID: "new", Name: "liveness-age-2", State: ContainerState{Running: true, StartedAt: now.Add(-time.Minute).Format(time.RFC3339Nano)}},
+		},
+	}
+
+	failing := func(KraneContainer, context.Context) error { return errors.New("connection refused") }
+	passing := func(KraneContainer, context.Context) error { return nil }
+
+	// a new container is restarted on its first failure while a stable container tolerates a blip
+	restarts := livenessRestarts(context.Background(), d, now, failing)
+	assert.Len(t, restarts, 1)
+	assert.Equal(t, "new", restarts[0].container.ID)
+
+	// the stable container recovers, a recent failure streak makes it flapping and no longer stable
+	assert.Empty(t, livenessRestarts(context.Background(), d, now, passing))
+	restarts = livenessRestarts(context.Background(), d, now.Add(time.Minute), failing)
+	assert.Len(t, restarts, 2)
+
+	// failures long after its recovery are tolerated again
+	d.Containers = d.Containers[:1]
+	assert.Empty(t, livenessRestarts(context.Background(), d, now.Add(20*time.Minute), passing))
+	assert.Empty(t, livenessRestarts(context.Background(), d, now.Add(21*time.Minute), failing))
+	assert.Empty(t, livenessRestarts(context.Background(), d, now.Add(22*time.Minute), failing))
+	assert.Len(t, livenessRestarts(context.Background(), d, now.Add(23*time.Minute), failing), 1)
+}
+
+func TestLivenessFailureThresholdFor(t *testing.T) {
+	probe := LivenessProbe{Port: "8080", StableAfter: 60}
+	assert.Equal(t, uint(3), probe.failureThresholdFor(30*time.Second, time.Hour))
+	assert.Equal(t, uint(6), probe.failureThresholdFor(time.Hour, time.Hour))
+	assert.Equal(t, uint(3), probe.failureThresholdFor(time.Hour, 30*time.Second))
+
+	// age weighting is disabled by default
+	assert.Equal(t, uint(3), LivenessProbe{Port: "8080"}.failureThresholdFor(time.Hour, time.Hour))
+
+	assert.Len(t, Config{Liveness: LivenessProbe{Port: "8080", StableFailureThreshold: 5}}.probesFieldErrors(), 1)
+	assert.Len(t, Config{Liveness: LivenessProbe{Port: "8080", StableAfter: 60, StableFailureThreshold: 2}}.probesFieldErrors(), 1)
+}
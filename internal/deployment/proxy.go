@@ -0,0 +1,160 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/proxy"
+)
+
+// DefaultProxyImageTag is the Traefik version installed when no tag is provided
+const DefaultProxyImageTag = "v2.11"
+
+// proxyAcmeStorage is the path inside the proxy container where Let's Encrypt certificates are stored
+const proxyAcmeStorage = "/letsencrypt"
+
+// ProxyInstallOptions configure the Traefik network proxy installed by Krane
+type ProxyInstallOptions struct {
+	Tag                string `json:"tag"`                 // Traefik image tag (default v2.11)
+	DashboardAlias     string `json:"dashboard_alias"`     // alias for the Traefik dashboard (ie. monitor.example.com)
+	LetsEncryptEmail   string `json:"letsencrypt_email"`   // enables the Let's Encrypt cert resolver for secure deployments
+	LetsEncryptStorage string `json:"letsencrypt_storage"` // host directory persisting Let's Encrypt certificates across deploys
+}
+
+// ProxyStatus is the state of the network proxy
+type ProxyStatus struct {
+	Installed  bool             `json:"installed"`  // whether a proxy deployment is configured in Krane
+	Running    bool             `json:"running"`    // whether a running Traefik proxy was found
+	Proxy      string           `json:"proxy"`      // name of the running proxy container, it may not be managed by Krane
+	Config     *Config          `json:"config"`     // the proxy deployment configuration (if installed)
+	Containers []KraneContainer `json:"containers"` // the proxy deployment containers (if installed)
+}
+
+// proxyInstallConfig returns the deployment configuration of a Traefik network proxy. Traefik is configured
+// through environment variables with the docker provider scoped to the proxy network, the web and web-secure
+// entrypoints and (if an email is provided) the daemon cert resolver referenced by secure deployments.
+func proxyInstallConfig(opts ProxyInstallOptions) Config {
+	tag := opts.Tag
+	if tag == "" {
+		tag = DefaultProxyImageTag
+	}
+
+	env := map[string]string{
+		"TRAEFIK_PROVIDERS_DOCKER":                  "true",
+		"TRAEFIK_PROVIDERS_DOCKER_EXPOSEDBYDEFAULT": "false",
+		"TRAEFIK_PROVIDERS_DOCKER_NETWORK":          docker.ProxyNetworkName(),
+		"TRAEFIK_ENTRYPOINTS_WEB_ADDRESS":           ":80",
+		"TRAEFIK_ENTRYPOINTS_WEB-SECURE_ADDRESS":    ":443",
+		"TRAEFIK_API_DASHBOARD":                     "true",
+		"TRAEFIK_API_INSECURE":                      "true",
+	}
+
+	volumes := map[string]string{
+		"/var/run/docker.sock": "/var/run/docker.sock",
+	}
+
+	secure := opts.LetsEncryptEmail != ""
+	if secure {
+		resolver := "TRAEFIK_CERTIFICATESRESOLVERS_" + strings.ToUpper(defaultCertResolver())
+		env[resolver+"_ACME_EMAIL"] = opts.LetsEncryptEmail
+		env[resolver+"_ACME_STORAGE"] = proxyAcmeStorage + "/acme.json"
+		env[resolver+"_ACME_HTTPCHALLENGE_ENTRYPOINT"] = "web"
+		if opts.LetsEncryptStorage != "" {
+			volumes[opts.LetsEncryptStorage] = proxyAcmeStorage
+		}
+	}
+
+	alias := make([]string, 0)
+	if opts.DashboardAlias != "" {
+		alias = append(alias, opts.DashboardAlias)
+	}
+
+	// tls is set explicitly so the proxy dashboard doesn't inherit the tls of the global defaults
+	dashboardSecure := secure && opts.DashboardAlias != ""
+
+	return Config{
+		Name:       ProxyDeploymentName,
+		Image:      "traefik",
+		Tag:        tag,
+		Secure:     dashboardSecure,
+		TLS:        TLS{Enabled: &dashboardSecure},
+		Alias:      alias,
+		Scale:      1,
+		Internal:   true,
+		Env:        env,
+		Volumes:    volumes,
+		TargetPort: "8080",
+		Ports: map[string]string{
+			"80":  "80",
+			"443": "443",
+			"":    "8080",
+		},
+	}
+}
+
+// InstallProxy saves the deployment configuration of a Traefik network proxy managed by Krane and
+// deploys it. Installing again replaces the existing proxy configuration.
+func InstallProxy(opts ProxyInstallOptions) (Config, error) {
+	config := proxyInstallConfig(opts)
+	if err := SaveConfigWithNote(config, fmt.Sprintf("install traefik:%s network proxy", config.Tag)); err != nil {
+		return Config{}, err
+	}
+
+	if err := Run(config.Name); err != nil {
+		return Config{}, err
+	}
+
+	return GetDeploymentConfig(config.Name)
+}
+
+// proxyVersion returns the Traefik version of the network proxy: PROXY_VERSION when set, otherwise the tag of the
+// Traefik proxy installed through the api, otherwise the default version (Traefik v2)
+func proxyVersion() proxy.Version {
+	if v, ok := proxy.ParseVersion(os.Getenv(constants.EnvProxyVersion)); ok {
+		return v
+	}
+
+	if config, err := GetDeploymentConfig(ProxyDeploymentName); err == nil && config.Image == "traefik" {
+		if v, ok := proxy.ParseVersion(config.Tag); ok {
+			return v
+		}
+	}
+
+	v, _ := proxy.ParseVersion(proxy.DefaultVersion)
+	return v
+}
+
+// GetProxyStatus returns the status of the network proxy
+func GetProxyStatus() (ProxyStatus, error) {
+	status := ProxyStatus{Containers: make([]KraneContainer, 0)}
+
+	proxy, running, err := DetectProxy(context.Background())
+	if err != nil {
+		return status, err
+	}
+	status.Running = running
+	status.Proxy = proxy
+
+	if !Exist(ProxyDeploymentName) {
+		return status, nil
+	}
+
+	config, err := GetDeploymentConfig(ProxyDeploymentName)
+	if err != nil {
+		return status, err
+	}
+	status.Installed = true
+	status.Config = &config
+
+	containers, err := GetContainersByDeployment(ProxyDeploymentName)
+	if err != nil {
+		return status, err
+	}
+	status.Containers = containers
+
+	return status, nil
+}
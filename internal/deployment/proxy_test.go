@@ -0,0 +1,61 @@
+package deployment
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/proxy"
+)
+
+func TestProxyInstallConfig(t *testing.T) {
+	config := proxyInstallConfig(ProxyInstallOptions{})
+	config.applyDefaults()
+
+	assert.Nil(t, config.isValid())
+	assert.Equal(t, ProxyDeploymentName, config.Name)
+	assert.Equal(t, DefaultProxyImageTag, config.Tag)
+	assert.Equal(t, "true", config.Env["TRAEFIK_PROVIDERS_DOCKER"])
+	assert.Equal(t, ":443", config.Env["TRAEFIK_ENTRYPOINTS_WEB-SECURE_ADDRESS"])
+	assert.NotContains(t, config.Env, "TRAEFIK_CERTIFICATESRESOLVERS_LETS-ENCRYPT_ACME_EMAIL")
+	assert.Empty(t, config.Alias)
+	assert.False(t, config.Secure)
+}
+
+func TestProxyInstallConfigLetsEncrypt(t *testing.T) {
+	config := proxyInstallConfig(ProxyInstallOptions{
+		Tag:                "v2.10",
+		DashboardAlias:     "monitor.example.com",
+		LetsEncryptEmail:   "email@example.com",
+		LetsEncryptStorage: "/var/lib/krane/letsencrypt",
+	})
+
+	assert.Equal(t, "v2.10", config.Tag)
+	assert.Equal(t, []string{"monitor.example.com"}, config.Alias)
+	assert.True(t, config.Secure)
+	assert.Equal(t, "email@example.com", config.Env["TRAEFIK_CERTIFICATESRESOLVERS_LETS-ENCRYPT_ACME_EMAIL"])
+	assert.Equal(t, proxyAcmeStorage, config.Volumes["/var/lib/krane/letsencrypt"])
+}
+
+func TestProxyVersion(t *testing.T) {
+	os.Setenv(constants.EnvProxyVersion, "")
+	assert.Equal(t, proxy.Version{Major: 2, Minor: 11}, proxyVersion())
+
+	os.Setenv(constants.EnvProxyVersion, "v3.1.2")
+	defer os.Setenv(constants.EnvProxyVersion, "")
+	assert.Equal(t, proxy.Version{Major: 3, Minor: 1}, proxyVersion())
+}
+
+func TestAccessLogLabelsRequireTraefikV31(t *testing.T) {
+	v2, _ := proxy.ParseVersion("v2.11")
+	assert.Empty(t, proxy.TraefikObservabilityLabels("my-app", true, v2))
+
+	v3, _ := proxy.ParseVersion("3.1-alpine")
+	labels := proxy.TraefikObservabilityLabels("my-app", false, v3)
+	assert.Equal(t, "false", labels["traefik.http.routers.my-app-insecure.observability.accesslogs"])
+
+	_, ok := proxy.ParseVersion("latest")
+	assert.False(t, ok)
+}
@@ -0,0 +1,122 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/go-units"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/utils"
+)
+
+// pruneImagesAfterDeploy removes the dangling images left behind by previous deploys once a deploy succeeded,
+// when PRUNE_IMAGES_AFTER_DEPLOY is enabled. The disk space reclaimed is reported in the deployment events.
+func pruneImagesAfterDeploy(ctx context.Context, e *EventEmitter) {
+	if !utils.BoolEnv(constants.EnvPruneImagesAfterDeploy) {
+		return
+	}
+
+	du, err := docker.GetClient().GetDiskUsage(ctx)
+	if err != nil {
+		logger.Errorf("unable to get docker disk usage %v", err)
+		e.emit(fmt.Sprintf("Unable to prune dangling images: %v", err))
+		return
+	}
+
+	// images pinned by a deployment digest may have been pulled by a job of another deployment still creating its containers
+	configs, err := GetAllDeploymentConfigs()
+	if err != nil {
+		logger.Errorf("unable to get deployments %v", err)
+		e.emit(fmt.Sprintf("Unable to prune dangling images: %v", err))
+		return
+	}
+
+	removed := 0
+	var reclaimed int64
+	for _, image := range unusedDanglingImages(du, pinnedDigests(configs)) {
+		if _, err := docker.GetClient().RemoveUnusedImage(ctx, image.ID); err != nil {
+			// docker refuses to remove an image used by a container started since the disk usage was read
+			logger.Warnf("unable to remove dangling image %s %v", image.ID, err)
+			continue
+		}
+		removed++
+		reclaimed += imageUniqueSize(image)
+	}
+
+	if removed == 0 {
+		e.emit("No dangling images to remove")
+		return
+	}
+
+	logger.Infof("removed %d dangling image(s) reclaiming %s", removed, units.BytesSize(float64(reclaimed)))
+	e.emit(fmt.Sprintf("Removed %d dangling image(s) reclaiming %s of disk space", removed, units.BytesSize(float64(reclaimed))))
+}
+
+// unusedDanglingImages returns the untagged images that no container (running or not) was created from.
+// Images that are the parent of another image are layers of that image and images at one of the pinned
+// digests are never returned.
+func unusedDanglingImages(du types.DiskUsage, pinned []string) []*types.ImageSummary {
+	used := make(map[string]bool)
+	for _, c := range du.Containers {
+		used[c.ImageID] = true
+	}
+	for _, image := range du.Images {
+		if image.ParentID != "" {
+			used[image.ParentID] = true
+		}
+	}
+
+	dangling := make([]*types.ImageSummary, 0)
+	for _, image := range du.Images {
+		if used[image.ID] || image.Containers > 0 || !isDangling(image) || hasAnyImageDigest(image.RepoDigests, pinned) {
+			continue
+		}
+		dangling = append(dangling, image)
+	}
+	return dangling
+}
+
+// pinnedDigests returns the image digests deployments are pinned to
+func pinnedDigests(configs []Config) []string {
+	digests := make([]string, 0)
+	for _, config := range configs {
+		if config.Digest != "" {
+			digests = append(digests, config.Digest)
+		}
+	}
+	return digests
+}
+
+// hasAnyImageDigest returns true if one of the repository digests is at one of the digests
+func hasAnyImageDigest(repoDigests []string, digests []string) bool {
+	for _, digest := range digests {
+		if hasImageDigest(repoDigests, digest) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDangling returns true if an image is not referenced by a tag (listed as <none>:<none>). Like docker, images
+// keeping a repository digest are dangling: an image pulled by tag keeps its digest once the tag moves to a newer
+// image, and images deployed pinned to a digest never have a tag.
+func isDangling(image *types.ImageSummary) bool {
+	for _, tag := range image.RepoTags {
+		if tag != "<none>:<none>" {
+			return false
+		}
+	}
+	return true
+}
+
+// imageUniqueSize returns the disk space of the layers of an image not shared with another image
+func imageUniqueSize(image *types.ImageSummary) int64 {
+	if image.SharedSize < 0 || image.SharedSize > image.Size {
+		return image.Size
+	}
+	return image.Size - image.SharedSize
+}
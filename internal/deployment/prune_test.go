@@ -0,0 +1,39 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnusedDanglingImages(t *testing.T) {
+	du := types.DiskUsage{
+		Images: []*types.ImageSummary{
+			{ID: "sha256:tagged", RepoTags: []string{"nginx:latest"}},
+			{ID: "sha256:pinned", RepoTags: []string{"<none>:<none>"}, RepoDigests: []string{"nginx@sha256:abc"}},
+			{ID: "sha256:dangling", RepoTags: []string{"<none>:<none>"}, RepoDigests: []string{"<none>@<none>"}},
+			{ID: "sha256:deployed", RepoTags: []string{"<none>:<none>"}, RepoDigests: []string{"redis@sha256:def"}},
+			{ID: "sha256:stopped"},
+			{ID: "sha256:running"},
+			{ID: "sha256:parent"},
+			{ID: "sha256:child", RepoTags: []string{"app:latest"}, ParentID: "sha256:parent"},
+		},
+		Containers: []*types.Container{
+			{ID: "a", ImageID: "sha256:running", State: "running"},
+			{ID: "b", ImageID: "sha256:stopped", State: "exited"},
+		},
+	}
+
+	ids := make([]string, 0)
+	pinned := pinnedDigests([]Config{{Name: "cache", Digest: "sha256:def"}, {Name: "web"}})
+	for _, image := range unusedDanglingImages(du, pinned) {
+		ids = append(ids, image.ID)
+	}
+	assert.Equal(t, []string{"sha256:pinned", "sha256:dangling"}, ids)
+}
+
+func TestImageUniqueSize(t *testing.T) {
+	assert.Equal(t, int64(60), imageUniqueSize(&types.ImageSummary{Size: 100, SharedSize: 40}))
+	assert.Equal(t, int64(100), imageUniqueSize(&types.ImageSummary{Size: 100, SharedSize: -1}))
+}
@@ -0,0 +1,149 @@
+package deployment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/krane/krane/internal/job"
+	"github.com/krane/krane/internal/logger"
+)
+
+// DefaultReadinessWebhookTimeout is the max time to wait for a readiness webhook to confirm a deploy
+const DefaultReadinessWebhookTimeout = 60 * time.Second
+
+// maxReadinessBackoff is the max delay between readiness webhook attempts
+const maxReadinessBackoff = 10 * time.Second
+
+// ReadinessWebhook is an external endpoint confirming a deploy is ready once its containers are healthy
+type ReadinessWebhook struct {
+	URL     string `json:"url"`     // url the deploy details are posted to, the deploy is ready once it responds with 200
+	Timeout uint   `json:"timeout"` // max time in seconds to wait for a 200 response including retries (default 60)
+}
+
+// ReadinessRequest is the payload posted to a readiness webhook
+type ReadinessRequest struct {
+	Deployment string   `json:"deployment"`
+	JobID      string   `json:"job_id"`
+	Image      string   `json:"image"`
+	Containers []string `json:"containers"` // names of the containers created by the deploy
+}
+
+// ReadinessRejectedError is returned when a readiness webhook rejects a deploy. Deploying
+// the same configuration again is expected to be rejected so the error is permanent.
+type ReadinessRejectedError struct {
+	URL    string
+	Status int
+}
+
+// Error returns a string representation of a ReadinessRejectedError
+func (e ReadinessRejectedError) Error() string {
+	return fmt.Sprintf("readiness webhook %s rejected the deploy with status %d", e.URL, e.Status)
+}
+
+// Permanent returns true since the webhook rejected the deploy
+func (e ReadinessRejectedError) Permanent() bool { return true }
+
+// ReadinessTimeout returns the max duration to wait for the readiness webhook
+func (w ReadinessWebhook) ReadinessTimeout() time.Duration {
+	if w.Timeout == 0 {
+		return DefaultReadinessWebhookTimeout
+	}
+	return time.Duration(w.Timeout) * time.Second
+}
+
+// readinessWebhookFieldErrors returns a validation error if the readiness webhook url is not a valid http(s) url
+func (config Config) readinessWebhookFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+	if config.ReadinessWebhook.URL == "" {
+		return errs
+	}
+
+	u, err := url.Parse(config.ReadinessWebhook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, newFieldError("readiness_webhook", "invalid readiness webhook url %s, expected an http(s) url", config.ReadinessWebhook.URL))
+	}
+
+	return errs
+}
+
+// confirmReadiness posts the deploy details to the readiness webhook until it responds with 200. Server errors and
+// failed requests are retried with backoff until the webhook timeout elapses, any other response fails the deploy.
+func confirmReadiness(ctx context.Context, config Config, containers []KraneContainer, e *EventEmitter) error {
+	webhook := config.ReadinessWebhook
+	timeout := webhook.ReadinessTimeout()
+
+	names := make([]string, 0, len(containers))
+	for _, c := range containers {
+		names = append(names, c.Name)
+	}
+
+	payload, _ := json.Marshal(ReadinessRequest{
+		Deployment: config.Name,
+		JobID:      job.IDFromContext(ctx),
+		Image:      config.ImageRef(),
+		Containers: names,
+	})
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	e.Phase = ReadinessPhase
+	e.emit(fmt.Sprintf("Waiting for readiness webhook %s", webhook.URL))
+
+	start := time.Now()
+	backoff := time.Second
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		status, err := postWebhook(ctx, webhook.URL, payload)
+		switch {
+		case err == nil && status == http.StatusOK:
+			job.RecordDuration(ctx, "readiness_webhook", time.Since(start))
+			e.emit("Readiness webhook confirmed the deploy")
+			return nil
+		case err == nil && status < http.StatusInternalServerError && status != http.StatusTooManyRequests:
+			return ReadinessRejectedError{URL: webhook.URL, Status: status}
+		case err == nil:
+			lastErr = fmt.Errorf("status %d", status)
+		case ctx.Err() != nil && lastErr != nil:
+			// the request was cut short by the timeout, keep the error from the previous attempt
+		default:
+			lastErr = err
+		}
+
+		logger.Debugf("readiness webhook attempt %d for deployment %s failed, %v", attempt, config.Name, lastErr)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return fmt.Errorf("readiness webhook %s did not respond with 200 within %s, last error: %v", webhook.URL, timeout, lastErr)
+		}
+
+		backoff *= 2
+		if backoff > maxReadinessBackoff {
+			backoff = maxReadinessBackoff
+		}
+	}
+}
+
+// postWebhook posts a json payload to a webhook returning the response status code
+func postWebhook(ctx context.Context, webhookURL string, payload []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
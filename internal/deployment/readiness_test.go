@@ -0,0 +1,70 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadinessWebhookConfig(t *testing.T) {
+	assert.Nil(t, Config{Name: "ready-app", Image: "nginx", ReadinessWebhook: ReadinessWebhook{URL: "https://smoke.example.com/ready"}}.isValid())
+	assert.Error(t, Config{Name: "ready-app", Image: "nginx", ReadinessWebhook: ReadinessWebhook{URL: "smoke.example.com"}}.isValid())
+	assert.Error(t, Config{Name: "ready-app", Image: "nginx", ReadinessWebhook: ReadinessWebhook{URL: "ftp://smoke.example.com"}}.isValid())
+	assert.Equal(t, DefaultReadinessWebhookTimeout, ReadinessWebhook{}.ReadinessTimeout())
+}
+
+func TestConfirmReadinessRetriesUntilOk(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ReadinessRequest
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "ready-app", req.Deployment)
+		assert.Equal(t, []string{"ready-app-1"}, req.Containers)
+
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := Config{Name: "ready-app", Image: "nginx", ReadinessWebhook: ReadinessWebhook{URL: server.URL, Timeout: 5}}
+	containers := []KraneContainer{{Name: "ready-app-1"}}
+
+	err := confirmReadiness(context.Background(), config, containers, createEventEmitter("ready-app", "job"))
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+}
+
+func TestConfirmReadinessRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	config := Config{Name: "ready-app", Image: "nginx", ReadinessWebhook: ReadinessWebhook{URL: server.URL, Timeout: 5}}
+
+	err := confirmReadiness(context.Background(), config, nil, createEventEmitter("ready-app", "job"))
+	assert.Equal(t, ReadinessRejectedError{URL: server.URL, Status: http.StatusBadRequest}, err)
+	assert.True(t, err.(ReadinessRejectedError).Permanent())
+}
+
+func TestConfirmReadinessTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	config := Config{Name: "ready-app", Image: "nginx", ReadinessWebhook: ReadinessWebhook{URL: server.URL, Timeout: 1}}
+
+	err := confirmReadiness(context.Background(), config, nil, createEventEmitter("ready-app", "job"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "did not respond with 200 within 1s")
+	assert.Contains(t, err.Error(), "status 502")
+}
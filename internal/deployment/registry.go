@@ -1,7 +1,171 @@
 package deployment
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/krane/krane/internal/auth"
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/store"
+)
+
 type Registry struct {
 	URL      string `json:"url"`
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Token    string `json:"token"` // bearer token for registries issuing access tokens instead of a username and password
+}
+
+// Redacted returns the registry with its password and token masked, registry credentials are never returned by
+// the api. Credentials referencing a secret (ie. @GITHUB_TOKEN) are kept, the secret value is not part of the config.
+func (r Registry) Redacted() Registry {
+	if r.Password != "" && !strings.HasPrefix(r.Password, "@") {
+		r.Password = redactedValue
+	}
+	if r.Token != "" && !strings.HasPrefix(r.Token, "@") {
+		r.Token = redactedValue
+	}
+	return r
+}
+
+// withStoredCredentials returns the registry with its masked password and token replaced by the stored ones,
+// so a configuration returned by the api can be saved back without resending its registry credentials
+func (r Registry) withStoredCredentials(stored Registry) Registry {
+	if r.Password == redactedValue {
+		r.Password = stored.Password
+	}
+	if r.Token == redactedValue {
+		r.Token = stored.Token
+	}
+	return r
+}
+
+// RegistryCredentials are the credentials used to pull images from a registry host by any deployment
+// without its own registry credentials. Credentials are encrypted at rest with the server private key.
+type RegistryCredentials struct {
+	Host     string `json:"host"`
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"` // never returned by the api
+	Token    string `json:"token,omitempty"`    // bearer token used instead of a username and password, never returned by the api
+}
+
+// SaveRegistryCredentials saves the credentials for a registry host, replacing existing credentials for the host
+func SaveRegistryCredentials(creds RegistryCredentials) error {
+	creds.Host = registryHost(creds.Host)
+	if creds.Host == "" {
+		return errors.New("registry host required")
+	}
+
+	if creds.Token == "" && (creds.Username == "" || creds.Password == "") {
+		return fmt.Errorf("username and password or token required for registry %s", creds.Host)
+	}
+
+	bytes, _ := json.Marshal(creds)
+	encrypted, err := auth.Encrypt(bytes)
+	if err != nil {
+		return err
+	}
+
+	return store.Client().Put(constants.RegistriesCollectionName, creds.Host, encrypted)
+}
+
+// GetRegistryCredentials returns the credentials for a registry host
+func GetRegistryCredentials(host string) (RegistryCredentials, error) {
+	host = registryHost(host)
+	bytes, err := store.Client().Get(constants.RegistriesCollectionName, host)
+	if err != nil {
+		return RegistryCredentials{}, err
+	}
+
+	if bytes == nil {
+		return RegistryCredentials{}, fmt.Errorf("no credentials for registry %s", host)
+	}
+
+	return decryptRegistryCredentials(bytes)
+}
+
+// GetAllRegistryCredentials returns the credentials of every registry host without their passwords
+func GetAllRegistryCredentials() ([]RegistryCredentials, error) {
+	all, err := store.Client().GetAll(constants.RegistriesCollectionName)
+	if err != nil {
+		return make([]RegistryCredentials, 0), err
+	}
+
+	registries := make([]RegistryCredentials, 0, len(all))
+	for _, bytes := range all {
+		creds, err := decryptRegistryCredentials(bytes)
+		if err != nil {
+			return make([]RegistryCredentials, 0), err
+		}
+		creds.Password = ""
+		creds.Token = ""
+		registries = append(registries, creds)
+	}
+
+	sort.Slice(registries, func(i, j int) bool { return registries[i].Host < registries[j].Host })
+	return registries, nil
+}
+
+// DeleteRegistryCredentials removes the credentials for a registry host
+func DeleteRegistryCredentials(host string) error {
+	return store.Client().Remove(constants.RegistriesCollectionName, registryHost(host))
+}
+
+// decryptRegistryCredentials returns registry credentials from their encrypted bytes
+func decryptRegistryCredentials(bytes []byte) (RegistryCredentials, error) {
+	decrypted, err := auth.Decrypt(bytes)
+	if err != nil {
+		return RegistryCredentials{}, err
+	}
+
+	var creds RegistryCredentials
+	err = json.Unmarshal(decrypted, &creds)
+	return creds, err
+}
+
+// registryHost returns the host of a registry url (ie. https://ghcr.io/v2 -> ghcr.io), the docker hub aliases resolve to docker.io
+func registryHost(registryURL string) string {
+	host := strings.ToLower(strings.TrimSpace(registryURL))
+	if strings.Contains(host, "://") {
+		if u, err := url.Parse(host); err == nil {
+			host = u.Host
+		}
+	}
+	host = strings.SplitN(host, "/", 2)[0]
+
+	switch host {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return "docker.io"
+	}
+	return host
+}
+
+// pullCredentials returns the credentials for pulling the deployment image. Registry credentials set on the
+// deployment take precedence over the credentials saved for the registry host.
+func (config Config) pullCredentials() docker.RegistryCredentials {
+	if config.Registry.Username != "" || config.Registry.Password != "" || config.Registry.Token != "" {
+		return docker.RegistryCredentials{
+			URL:      config.Registry.URL,
+			Username: config.Registry.Username,
+			Password: config.Registry.Password,
+			Token:    config.Registry.Token,
+		}
+	}
+
+	creds, err := GetRegistryCredentials(config.Registry.URL)
+	if err != nil {
+		return docker.RegistryCredentials{URL: config.Registry.URL}
+	}
+
+	return docker.RegistryCredentials{
+		URL:      config.Registry.URL,
+		Username: creds.Username,
+		Password: creds.Password,
+		Token:    creds.Token,
+	}
 }
@@ -0,0 +1,84 @@
+package deployment
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/store"
+)
+
+func TestRegistryHost(t *testing.T) {
+	assert.Equal(t, "ghcr.io", registryHost("ghcr.io"))
+	assert.Equal(t, "ghcr.io", registryHost("https://GHCR.io/v2/"))
+	assert.Equal(t, "localhost:5000", registryHost("localhost:5000/my-app"))
+	assert.Equal(t, "docker.io", registryHost("index.docker.io"))
+}
+
+func TestRegistryCredentials(t *testing.T) {
+	os.Setenv(constants.EnvKranePrivateKey, "test-private-key")
+	defer os.Unsetenv(constants.EnvKranePrivateKey)
+
+	assert.Nil(t, SaveRegistryCredentials(RegistryCredentials{Host: "https://ghcr.io", Username: "bot", Password: "s3cret"}))
+	defer DeleteRegistryCredentials("ghcr.io")
+
+	// credentials are encrypted at rest
+	raw, err := store.Client().Get(constants.RegistriesCollectionName, "ghcr.io")
+	assert.Nil(t, err)
+	assert.NotContains(t, string(raw), "s3cret")
+
+	creds, err := GetRegistryCredentials("ghcr.io")
+	assert.Nil(t, err)
+	assert.Equal(t, RegistryCredentials{Host: "ghcr.io", Username: "bot", Password: "s3cret"}, creds)
+
+	all, err := GetAllRegistryCredentials()
+	assert.Nil(t, err)
+	assert.Equal(t, []RegistryCredentials{{Host: "ghcr.io", Username: "bot"}}, all)
+
+	// deployments without credentials use the registry host credentials
+	config := Config{Name: "registry-app", Registry: Registry{URL: "ghcr.io"}}
+	assert.Equal(t, docker.RegistryCredentials{URL: "ghcr.io", Username: "bot", Password: "s3cret"}, config.pullCredentials())
+
+	// deployment credentials take precedence
+	config.Registry.Username = "deployer"
+	config.Registry.Password = "other"
+	assert.Equal(t, docker.RegistryCredentials{URL: "ghcr.io", Username: "deployer", Password: "other"}, config.pullCredentials())
+
+	other := Config{Name: "registry-app", Registry: Registry{URL: "docker.io"}}
+	assert.Equal(t, docker.RegistryCredentials{URL: "docker.io"}, other.pullCredentials())
+
+	assert.Error(t, SaveRegistryCredentials(RegistryCredentials{Host: "quay.io", Username: "bot"}))
+
+	// tokens are used instead of a username and password
+	assert.Nil(t, SaveRegistryCredentials(RegistryCredentials{Host: "registry.example.com", Token: "t0ken"}))
+	defer DeleteRegistryCredentials("registry.example.com")
+
+	tokenConfig := Config{Name: "registry-app", Registry: Registry{URL: "registry.example.com"}}
+	assert.Equal(t, docker.RegistryCredentials{URL: "registry.example.com", Token: "t0ken"}, tokenConfig.pullCredentials())
+
+	all, err = GetAllRegistryCredentials()
+	assert.Nil(t, err)
+	assert.Equal(t, RegistryCredentials{Host: "registry.example.com"}, all[1])
+}
+
+func TestRegistryRedacted(t *testing.T) {
+	registry := Registry{URL: "ghcr.io", Username: "bot", Password: "s3cret", Token: "t0ken"}
+	redacted := registry.Redacted()
+	assert.Equal(t, Registry{URL: "ghcr.io", Username: "bot", Password: redactedValue, Token: redactedValue}, redacted)
+
+	// masked credentials saved back keep the stored ones
+	assert.Equal(t, registry, redacted.withStoredCredentials(registry))
+
+	// new credentials replace the stored ones
+	redacted.Password = "changed"
+	assert.Equal(t, "changed", redacted.withStoredCredentials(registry).Password)
+
+	// secret references are not secret values
+	ref := Registry{URL: "ghcr.io", Username: "bot", Password: "@GITHUB_TOKEN"}
+	assert.Equal(t, ref, ref.Redacted())
+
+	assert.Equal(t, Registry{}, Registry{}.Redacted())
+}
@@ -0,0 +1,183 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/logger"
+)
+
+// ReloadDebounce is how long changes to the secrets or mounted files of a deployment are collected
+// before it is reloaded, so rotating several secrets at once reloads the deployment once
+const ReloadDebounce = 10 * time.Second
+
+// mountedFile is the state of a bind mounted host path the last time it was checked
+type mountedFile struct {
+	modTime time.Time
+	size    int64
+}
+
+var reloadMu sync.Mutex
+var pendingReloads = make(map[string]*pendingReload)
+
+var mountsMu sync.Mutex
+var lastMounts = make(map[string]map[string]mountedFile)
+
+// pendingReload is a reload of a deployment waiting for changes to settle
+type pendingReload struct {
+	timer   *time.Timer
+	reasons []string
+}
+
+// scheduleReload reloads a deployment once no other change is reported for ReloadDebounce
+func scheduleReload(deployment string, reason string) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	pending, ok := pendingReloads[deployment]
+	if ok {
+		pending.timer.Stop()
+	} else {
+		pending = &pendingReload{}
+		pendingReloads[deployment] = pending
+	}
+
+	pending.reasons = append(pending.reasons, reason)
+
+	var timer *time.Timer
+	timer = time.AfterFunc(ReloadDebounce, func() {
+		reloadMu.Lock()
+		// a timer firing while a newer change is scheduled leaves the reload to the newer timer
+		if pending.timer != timer || pendingReloads[deployment] != pending {
+			reloadMu.Unlock()
+			return
+		}
+		reasons := pending.reasons
+		delete(pendingReloads, deployment)
+		reloadMu.Unlock()
+
+		reload(deployment, reasons)
+	})
+	pending.timer = timer
+
+	logger.Debugf("deployment %s reload scheduled in %s, %s", deployment, ReloadDebounce, reason)
+}
+
+// reload runs a deployment to recreate its containers with the current secrets and mounted files. The run replaces
+// the containers once the new ones are healthy, it is an automated run deferred outside the deploy window.
+func reload(deployment string, reasons []string) {
+	if !Exist(deployment) {
+		return
+	}
+
+	logger.Infof("reloading deployment %s, %s", deployment, strings.Join(reasons, ", "))
+	e := createEventEmitter(deployment, "")
+	e.Phase = ReloadPhase
+	e.emit(fmt.Sprintf("reloading deployment, %s", strings.Join(reasons, ", ")))
+
+	if err := RunWithOptions(deployment, RunOptions{Start: true, Automated: true}); err != nil {
+		logger.Warnf("unable to reload deployment %s, %v", deployment, err)
+	}
+}
+
+// reloadOnSecretChange schedules a reload of a deployment with reload_on_change when a secret it references changed
+func reloadOnSecretChange(deployment string, key string) {
+	config, err := GetDeploymentConfig(deployment)
+	if err != nil || !config.ReloadOnChange {
+		return
+	}
+
+	if _, ok := config.Secrets[key]; !ok {
+		return
+	}
+
+	scheduleReload(deployment, fmt.Sprintf("secret %s changed", key))
+}
+
+// MonitorMountedFiles schedules a reload of a deployment with reload_on_change when one of its bind mounted host
+// paths changed since it was last monitored. Paths are compared by modification time and size.
+func MonitorMountedFiles(ctx context.Context, config Config) {
+	if !config.ReloadOnChange {
+		return
+	}
+
+	var current map[string]mountedFile
+	if runningInContainer() {
+		current = statMountedFilesInContainer(ctx, config)
+	} else {
+		current = statMountedFiles(config)
+	}
+	reloadChangedMounts(config.Name, current)
+}
+
+// reloadChangedMounts schedules a reload of a deployment when the state of one of its bind mounted host paths
+// differs from the state it had when last monitored
+func reloadChangedMounts(deployment string, current map[string]mountedFile) {
+	mountsMu.Lock()
+	changed := changedMounts(lastMounts[deployment], current)
+	lastMounts[deployment] = current
+	mountsMu.Unlock()
+
+	for _, hostPath := range changed {
+		scheduleReload(deployment, fmt.Sprintf("mounted file %s changed", hostPath))
+	}
+}
+
+// statMountedFiles returns the state of the bind mounted host paths of a deployment
+func statMountedFiles(config Config) map[string]mountedFile {
+	current := make(map[string]mountedFile)
+	for _, hostPath := range config.bindSources() {
+		info, err := os.Stat(hostPath)
+		if err != nil {
+			continue
+		}
+		current[hostPath] = mountedFile{modTime: info.ModTime(), size: info.Size()}
+	}
+	return current
+}
+
+// statMountedFilesInContainer returns the state of the bind mounted host paths of a deployment read through docker
+// at their target in a running container of the deployment. Host paths are not visible to Krane when it runs in a
+// container. Deployments without a running container report no paths, their paths are compared again once a container runs.
+func statMountedFilesInContainer(ctx context.Context, config Config) map[string]mountedFile {
+	current := make(map[string]mountedFile)
+
+	containers, err := GetContainersByDeployment(config.Name)
+	if err != nil {
+		return current
+	}
+
+	for _, c := range containers {
+		if !c.State.Running {
+			continue
+		}
+
+		for hostPath, target := range config.bindTargets() {
+			stat, err := docker.GetClient().StatContainerPath(ctx, c.ID, target)
+			if err != nil {
+				continue
+			}
+			current[hostPath] = mountedFile{modTime: stat.Mtime, size: stat.Size}
+		}
+		return current
+	}
+	return current
+}
+
+// changedMounts returns the host paths sorted whose state differs from their previous state, paths not seen before are not reported
+func changedMounts(previous map[string]mountedFile, current map[string]mountedFile) []string {
+	changed := make([]string, 0)
+	for hostPath, file := range current {
+		if before, seen := previous[hostPath]; seen && (!before.modTime.Equal(file.modTime) || before.size != file.size) {
+			changed = append(changed, hostPath)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,86 @@
+package deployment
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// cancelReload stops a pending reload of a deployment, returning whether one was scheduled
+func cancelReload(deployment string) bool {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	pending, ok := pendingReloads[deployment]
+	if ok {
+		pending.timer.Stop()
+		delete(pendingReloads, deployment)
+	}
+	return ok
+}
+
+func TestChangedMounts(t *testing.T) {
+	now := time.Now()
+	previous := map[string]mountedFile{
+		"/etc/app/config.yml": {modTime: now, size: 10},
+		"/etc/app/other.yml":  {modTime: now, size: 10},
+	}
+	current := map[string]mountedFile{
+		"/etc/app/config.yml": {modTime: now.Add(time.Second), size: 10},
+		"/etc/app/other.yml":  {modTime: now, size: 10},
+		"/etc/app/new.yml":    {modTime: now, size: 10},
+	}
+	assert.Equal(t, []string{"/etc/app/config.yml"}, changedMounts(previous, current))
+	assert.Empty(t, changedMounts(nil, current))
+}
+
+func TestChangedMountedFilesScheduleReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "krane-reload")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "config.yml")
+	assert.Nil(t, ioutil.WriteFile(file, []byte("a: 1"), 0644))
+
+	config := Config{Name: "reload-mounts", ReloadOnChange: true, Volumes: map[string]string{file: "/etc/app/config.yml"}}
+	reloadChangedMounts(config.Name, statMountedFiles(config))
+	assert.False(t, cancelReload(config.Name))
+
+	assert.Nil(t, ioutil.WriteFile(file, []byte("a: 12"), 0644))
+	reloadChangedMounts(config.Name, statMountedFiles(config))
+	assert.True(t, cancelReload(config.Name))
+
+	// deployments without reload_on_change are not watched
+	config.ReloadOnChange = false
+	assert.Nil(t, ioutil.WriteFile(file, []byte("a: 123"), 0644))
+	MonitorMountedFiles(context.Background(), config)
+	assert.False(t, cancelReload(config.Name))
+}
+
+func TestSecretChangeSchedulesReload(t *testing.T) {
+	config := Config{Name: "reload-secrets", Image: "nginx", ReloadOnChange: true, Secrets: map[string]string{"DB_PASSWORD": "@DB_PASSWORD"}}
+	assert.Nil(t, SaveConfig(config))
+	defer func() { _ = DeleteConfig(config.Name) }()
+
+	_, err := AddSecret(config.Name, "DB_PASSWORD", "hunter2")
+	assert.Nil(t, err)
+	assert.True(t, cancelReload(config.Name))
+
+	// unchanged values and secrets not referenced by the deployment do not reload it
+	_, err = AddSecret(config.Name, "DB_PASSWORD", "hunter2")
+	assert.Nil(t, err)
+	_, err = AddSecret(config.Name, "UNUSED", "value")
+	assert.Nil(t, err)
+	assert.False(t, cancelReload(config.Name))
+
+	assert.Nil(t, DeleteSecret(config.Name, "DB_PASSWORD"))
+	assert.True(t, cancelReload(config.Name))
+
+	// deleting a secret that no longer exists does not reload the deployment
+	assert.Nil(t, DeleteSecret(config.Name, "DB_PASSWORD"))
+	assert.False(t, cancelReload(config.Name))
+}
@@ -0,0 +1,236 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/krane/krane/internal/job"
+	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/store"
+)
+
+// Rename renames a deployment keeping its secrets, jobs and history. The configuration and collections are
+// migrated to the new name, then the deployment is run under the new name and the containers created with
+// the previous name are removed once the run succeeds. A deployment cannot be renamed while it has jobs in progress.
+// Rename waits for the run, if it fails the rename is rolled back so the previous containers keep their deployment.
+func Rename(deployment, newName string) (Config, error) {
+	if !Exist(deployment) {
+		return Config{}, fmt.Errorf("deployment %s does not exist", deployment)
+	}
+
+	if Exist(newName) {
+		return Config{}, fmt.Errorf("deployment %s already exists", newName)
+	}
+
+	if !(Config{Name: newName}).isValidName() {
+		return Config{}, fmt.Errorf("invalid deployment name %s", newName)
+	}
+
+	if job.IsBusy(deployment) {
+		return Config{}, fmt.Errorf("deployment %s has jobs in progress, retry once they complete", deployment)
+	}
+
+	config, err := GetDeploymentConfigFromStore(deployment)
+	if err != nil {
+		return Config{}, err
+	}
+
+	// the renamed deployment is run right away, it is not renamed at all outside its deploy window
+	if open, reason := config.DeployWindow.Open(time.Now()); !open {
+		return Config{}, DeployWindowClosedError{Deployment: deployment, Reason: reason}
+	}
+
+	containers, err := GetContainersByDeployment(deployment)
+	if err != nil {
+		return Config{}, fmt.Errorf("unable to get containers of deployment %s, %w", deployment, err)
+	}
+
+	if err := migrateDeploymentCollections(deployment, newName); err != nil {
+		deleteDeploymentCollections(newName)
+		return Config{}, err
+	}
+
+	config.Name = newName
+	if err := saveConfig(config, fmt.Sprintf("renamed from %s", deployment), deployment); err != nil {
+		deleteDeploymentCollections(newName)
+		return Config{}, err
+	}
+
+	if err := DeleteConfig(deployment); err != nil {
+		logger.Warnf("unable to remove configuration of renamed deployment %s, %v", deployment, err)
+	}
+	deleteDeploymentCollections(deployment)
+	if err := deleteDeferredRun(deployment); err != nil {
+		logger.Warnf("unable to remove deferred run of renamed deployment %s, %v", deployment, err)
+	}
+	// the staged containers (if any) are replaced along with the other containers of the deployment
+	if err := deleteStagedRun(deployment); err != nil {
+		logger.Warnf("unable to remove staged run of renamed deployment %s, %v", deployment, err)
+	}
+	if err := renameSchedule(deployment, newName); err != nil {
+		logger.Warnf("unable to move the schedule of renamed deployment %s, %v", deployment, err)
+	}
+	if err := renameWebhookToken(deployment, newName); err != nil {
+		logger.Warnf("unable to move the webhook token of renamed deployment %s, %v", deployment, err)
+	}
+
+	logger.Infof("deployment %s renamed to %s, replacing %d container(s)", deployment, newName, len(containers))
+	completed := make(chan job.Job, 1)
+	done := func(j job.Job) {
+		select {
+		case completed <- j:
+		default:
+		}
+	}
+	if err := RunWithOptions(newName, RunOptions{Start: true, Replace: containers, done: done}); err != nil {
+		return config, rollbackRename(config, deployment, containers, err)
+	}
+
+	if j := <-completed; !j.Succeeded() {
+		return config, rollbackRename(config, deployment, containers, runError(j))
+	}
+
+	return config, nil
+}
+
+// rollbackRename moves a renamed deployment whose run failed back to its previous name, the previous containers
+// keep serving it. If the rename cannot be rolled back the previous containers are removed, since no deployment
+// owns them anymore. The returned error describes the failed run and which of the two happened.
+func rollbackRename(config Config, deployment string, containers []KraneContainer, runErr error) error {
+	newName := config.Name
+	err := restoreRenamedDeployment(config, deployment)
+	if err == nil {
+		logger.Warnf("run of renamed deployment %s failed, rename rolled back to %s", newName, deployment)
+		return fmt.Errorf("unable to run deployment %s, %v. The rename was rolled back, deployment %s keeps its previous containers", newName, runErr, deployment)
+	}
+
+	logger.Errorf("unable to roll back the rename of deployment %v", err)
+	if rmErr := removeContainers(context.Background(), containers, config.ContainerStopTimeout()); rmErr != nil {
+		return fmt.Errorf("unable to run deployment %s, %v. The rename could not be rolled back (%v) and the containers of %s could not be removed, %v", newName, runErr, err, deployment, rmErr)
+	}
+	return fmt.Errorf("unable to run deployment %s, %v. The rename could not be rolled back (%v), the containers of %s were removed", newName, runErr, err, deployment)
+}
+
+// restoreRenamedDeployment migrates the configuration and collections of a renamed deployment back to its previous name
+func restoreRenamedDeployment(config Config, deployment string) error {
+	newName := config.Name
+	if err := migrateDeploymentCollections(newName, deployment); err != nil {
+		deleteDeploymentCollections(deployment)
+		return err
+	}
+
+	config.Name = deployment
+	if err := saveConfig(config, fmt.Sprintf("rename to %s rolled back", newName), newName); err != nil {
+		deleteDeploymentCollections(deployment)
+		return err
+	}
+
+	if err := DeleteConfig(newName); err != nil {
+		logger.Warnf("unable to remove configuration of deployment %s, %v", newName, err)
+	}
+	deleteDeploymentCollections(newName)
+	if err := deleteStagedRun(newName); err != nil {
+		logger.Warnf("unable to remove staged run of deployment %s, %v", newName, err)
+	}
+	if err := renameSchedule(newName, deployment); err != nil {
+		logger.Warnf("unable to move back the schedule of deployment %s, %v", deployment, err)
+	}
+	if err := renameWebhookToken(newName, deployment); err != nil {
+		logger.Warnf("unable to move back the webhook token of deployment %s, %v", deployment, err)
+	}
+	return nil
+}
+
+// runError returns the error of a run job which did not succeed
+func runError(j job.Job) error {
+	if n := len(j.Status.Failures); n > 0 {
+		return errors.New(j.Status.Failures[n-1].Message)
+	}
+	if j.Cancelled {
+		return errors.New("the run was cancelled")
+	}
+	return errors.New("the run did not complete")
+}
+
+// migrateDeploymentCollections copies the secrets, jobs and history of a deployment to the collections of a new name
+func migrateDeploymentCollections(deployment, newName string) error {
+	err := migrateCollection(getSecretsCollectionName(deployment), getSecretsCollectionName(newName), func(bytes []byte) (interface{}, error) {
+		var secret Secret
+		err := json.Unmarshal(bytes, &secret)
+		secret.Deployment = newName
+		return secret, err
+	})
+	if err != nil {
+		return fmt.Errorf("unable to migrate secrets of deployment %s, %w", deployment, err)
+	}
+
+	err = migrateCollection(job.GetJobsCollectionName(deployment), job.GetJobsCollectionName(newName), func(bytes []byte) (interface{}, error) {
+		var j job.Job
+		err := json.Unmarshal(bytes, &j)
+		j.Deployment = newName
+		return j, err
+	})
+	if err != nil {
+		return fmt.Errorf("unable to migrate jobs of deployment %s, %w", deployment, err)
+	}
+
+	// revisions are renamed too so a rollback to a previous revision keeps the new name
+	err = migrateCollection(getHistoryCollectionName(deployment), getHistoryCollectionName(newName), func(bytes []byte) (interface{}, error) {
+		var entry HistoryEntry
+		err := json.Unmarshal(bytes, &entry)
+		entry.Deployment = newName
+		entry.Config.Name = newName
+		return entry, err
+	})
+	if err != nil {
+		return fmt.Errorf("unable to migrate history of deployment %s, %w", deployment, err)
+	}
+
+	return nil
+}
+
+// migrateCollection copies every key/value pair of a collection to another collection, rewriting the values
+func migrateCollection(from, to string, rewrite func([]byte) (interface{}, error)) error {
+	entries, err := store.Client().GetAllWithKeys(from)
+	if err != nil {
+		return err
+	}
+
+	if err := store.Client().CreateCollection(to); err != nil {
+		return err
+	}
+
+	for key, value := range entries {
+		rewritten, err := rewrite(value)
+		if err != nil {
+			return err
+		}
+
+		bytes, err := json.Marshal(rewritten)
+		if err != nil {
+			return err
+		}
+
+		if err := store.Client().Put(to, key, bytes); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteDeploymentCollections deletes the secrets, jobs and history collections of a deployment, logging errors
+func deleteDeploymentCollections(deployment string) {
+	if err := DeleteSecretsCollection(deployment); err != nil {
+		logger.Warnf("unable to remove secrets collection of deployment %s, %v", deployment, err)
+	}
+	if err := DeleteJobsCollection(deployment); err != nil {
+		logger.Warnf("unable to remove jobs collection of deployment %s, %v", deployment, err)
+	}
+	if err := DeleteHistoryCollection(deployment); err != nil {
+		logger.Warnf("unable to remove history collection of deployment %s, %v", deployment, err)
+	}
+}
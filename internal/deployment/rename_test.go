@@ -0,0 +1,99 @@
+package deployment
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/job"
+	"github.com/krane/krane/internal/store"
+)
+
+func TestRenameValidation(t *testing.T) {
+	assert.Nil(t, SaveConfig(Config{Name: "rename-from", Image: "nginx"}))
+	assert.Nil(t, SaveConfig(Config{Name: "rename-taken", Image: "nginx"}))
+	defer DeleteConfig("rename-from")
+	defer DeleteConfig("rename-taken")
+	defer DeleteHistoryCollection("rename-from")
+	defer DeleteHistoryCollection("rename-taken")
+
+	_, err := Rename("rename-missing", "rename-to")
+	assert.EqualError(t, err, "deployment rename-missing does not exist")
+
+	_, err = Rename("rename-from", "rename-taken")
+	assert.EqualError(t, err, "deployment rename-taken already exists")
+
+	_, err = Rename("rename-from", "Rename To")
+	assert.EqualError(t, err, "invalid deployment name Rename To")
+}
+
+func TestMigrateDeploymentCollections(t *testing.T) {
+	assert.Nil(t, SaveConfigWithNote(Config{Name: "migrate-from", Image: "nginx"}, "initial"))
+	defer DeleteConfig("migrate-from")
+	_, err := AddSecret("migrate-from", "token", "value")
+	assert.Nil(t, err)
+
+	j := job.Job{ID: "migrate-job", Deployment: "migrate-from"}
+	bytes, _ := j.Serialize()
+	assert.Nil(t, store.Client().Put(job.GetJobsCollectionName("migrate-from"), "2020-01-01T00:00:00Z", bytes))
+
+	assert.Nil(t, migrateDeploymentCollections("migrate-from", "migrate-to"))
+	deleteDeploymentCollections("migrate-from")
+	defer deleteDeploymentCollections("migrate-to")
+
+	secret, err := GetSecret("migrate-to", "token")
+	assert.Nil(t, err)
+	assert.Equal(t, "migrate-to", secret.Deployment)
+	assert.Equal(t, "value", secret.Value)
+
+	history, err := GetHistory("migrate-to")
+	assert.Nil(t, err)
+	assert.Len(t, history, 1)
+	assert.Equal(t, "migrate-to", history[0].Config.Name)
+	assert.Equal(t, "initial", history[0].Note)
+
+	// job keys are kept so jobs can still be looked up by time range
+	jobs, err := store.Client().GetAllWithKeys(job.GetJobsCollectionName("migrate-to"))
+	assert.Nil(t, err)
+	var migrated job.Job
+	assert.Nil(t, store.Deserialize(jobs["2020-01-01T00:00:00Z"], &migrated))
+	assert.Equal(t, "migrate-to", migrated.Deployment)
+
+	// the previous collections are removed
+	old, _ := GetAllSecrets("migrate-from")
+	assert.Empty(t, old)
+}
+
+func TestRestoreRenamedDeployment(t *testing.T) {
+	assert.Nil(t, SaveConfigWithNote(Config{Name: "restore-to", Image: "nginx"}, "renamed from restore-from"))
+	_, err := AddSecret("restore-to", "token", "value")
+	assert.Nil(t, err)
+
+	config, err := GetDeploymentConfigFromStore("restore-to")
+	assert.Nil(t, err)
+	assert.Nil(t, restoreRenamedDeployment(config, "restore-from"))
+	defer DeleteConfig("restore-from")
+	defer deleteDeploymentCollections("restore-from")
+
+	assert.False(t, Exist("restore-to"))
+	restored, err := GetDeploymentConfigFromStore("restore-from")
+	assert.Nil(t, err)
+	assert.Equal(t, "restore-from", restored.Name)
+
+	secret, err := GetSecret("restore-from", "token")
+	assert.Nil(t, err)
+	assert.Equal(t, "restore-from", secret.Deployment)
+
+	history, err := GetHistory("restore-from")
+	assert.Nil(t, err)
+	assert.Len(t, history, 2)
+}
+
+func TestRunError(t *testing.T) {
+	failed := job.Job{}
+	failed.WithError(errors.New("health check failed"))
+	assert.EqualError(t, runError(failed), "health check failed")
+	assert.EqualError(t, runError(job.Job{Cancelled: true}), "the run was cancelled")
+	assert.EqualError(t, runError(job.Job{}), "the run did not complete")
+}
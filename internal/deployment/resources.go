@@ -0,0 +1,254 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/docker/go-units"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/job"
+	"github.com/krane/krane/internal/logger"
+)
+
+// DefaultResourceOvercommitFactor is the factor of the host resources the limits of all deployments can add up to
+const DefaultResourceOvercommitFactor = 1.0
+
+// Resources are the cpu and memory limits of each deployment container. Limits are either absolute
+// (ie. cpus 1.5 or memory 512mb) or a percentage of the docker host (ie. 25%) resolved at deploy time.
+type Resources struct {
+	CPUs       string `json:"cpus"`        // number of cpus (ie. 0.5) or percentage of the host cpus (ie. 25%)
+	Memory     string `json:"memory"`      // memory size (ie. 512mb) or percentage of the host memory (ie. 25%)
+	MemorySwap string `json:"memory_swap"` // total memory and swap size (ie. 1gb), -1 for unlimited swap, requires memory (default twice the memory)
+	CPUShares  int64  `json:"cpu_shares"`  // cpu weight relative to other containers when cpus are contended (default 1024)
+}
+
+// ResolvedResources are the absolute resource limits of each deployment container
+type ResolvedResources struct {
+	NanoCPUs   int64 `json:"nano_cpus"`   // cpu limit in units of 1e-9 cpus, 0 means no limit
+	Memory     int64 `json:"memory"`      // memory limit in bytes, 0 means no limit
+	MemorySwap int64 `json:"memory_swap"` // memory and swap limit in bytes, -1 means unlimited swap, 0 uses the docker default
+	CPUShares  int64 `json:"cpu_shares"`  // relative cpu weight, 0 uses the docker default
+}
+
+// minCPUShares and maxCPUShares are the cpu shares accepted by the docker daemon
+const (
+	minCPUShares = 2
+	maxCPUShares = 262144
+)
+
+// IsSet returns true if a cpu or memory limit is set
+func (r Resources) IsSet() bool {
+	return r.CPUs != "" || r.Memory != "" || r.MemorySwap != "" || r.CPUShares != 0
+}
+
+// resolve returns the absolute resource limits for a host with the provided cpus and memory
+func (r Resources) resolve(hostCPUs int, hostMemory int64) (ResolvedResources, error) {
+	var resolved ResolvedResources
+
+	if r.CPUs != "" {
+		value, percent, err := parseResourceValue(r.CPUs, parseCPUs)
+		if err != nil {
+			return resolved, fmt.Errorf("invalid cpus %s, expected a number of cpus like 0.5 or a percentage like 25%%", r.CPUs)
+		}
+		if percent {
+			value = value / 100 * float64(hostCPUs)
+		}
+		resolved.NanoCPUs = int64(value * 1e9)
+	}
+
+	if r.Memory != "" {
+		value, percent, err := parseResourceValue(r.Memory, parseMemory)
+		if err != nil {
+			return resolved, fmt.Errorf("invalid memory %s, expected a size like 512mb or a percentage like 25%%", r.Memory)
+		}
+		if percent {
+			value = value / 100 * float64(hostMemory)
+		}
+		resolved.Memory = int64(value)
+	}
+
+	if r.MemorySwap != "" {
+		if r.Memory == "" {
+			return resolved, fmt.Errorf("memory_swap %s requires a memory limit", r.MemorySwap)
+		}
+
+		if strings.TrimSpace(r.MemorySwap) == "-1" {
+			resolved.MemorySwap = -1
+		} else {
+			value, err := parseMemory(strings.TrimSpace(r.MemorySwap))
+			if err != nil || value <= 0 {
+				return resolved, fmt.Errorf("invalid memory_swap %s, expected a size like 1gb or -1 for unlimited swap", r.MemorySwap)
+			}
+			if int64(value) < resolved.Memory {
+				return resolved, fmt.Errorf("memory_swap %s is less than the memory limit %s, it includes the memory", r.MemorySwap, r.Memory)
+			}
+			resolved.MemorySwap = int64(value)
+		}
+	}
+
+	if r.CPUShares != 0 {
+		if r.CPUShares < minCPUShares || r.CPUShares > maxCPUShares {
+			return resolved, fmt.Errorf("invalid cpu_shares %d, expected a weight between %d and %d", r.CPUShares, minCPUShares, maxCPUShares)
+		}
+		resolved.CPUShares = r.CPUShares
+	}
+
+	return resolved, nil
+}
+
+// parseResourceValue parses a resource limit returning its value and whether it is a percentage of the host
+func parseResourceValue(value string, parseAbsolute func(string) (float64, error)) (float64, bool, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasSuffix(value, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil || percent <= 0 || percent > 100 {
+			return 0, true, fmt.Errorf("invalid percentage %s", value)
+		}
+		return percent, true, nil
+	}
+
+	absolute, err := parseAbsolute(value)
+	if err != nil || absolute <= 0 {
+		return 0, false, fmt.Errorf("invalid value %s", value)
+	}
+	return absolute, false, nil
+}
+
+// parseCPUs parses an absolute number of cpus
+func parseCPUs(value string) (float64, error) {
+	return strconv.ParseFloat(value, 64)
+}
+
+// parseMemory parses an absolute memory size in bytes
+func parseMemory(value string) (float64, error) {
+	size, err := units.RAMInBytes(value)
+	return float64(size), err
+}
+
+// withResolvedResources returns the deployment config with its resource limits resolved against the docker
+// host. The resolved limits are recorded with the job the context belongs to.
+func (config Config) withResolvedResources(ctx context.Context) (Config, error) {
+	if !config.Resources.IsSet() {
+		return config, nil
+	}
+
+	hostCPUs, hostMemory, err := docker.GetClient().HostResources(ctx)
+	if err != nil {
+		return config, fmt.Errorf("unable to get docker host resources, %v", err)
+	}
+
+	resolved, err := config.Resources.resolve(hostCPUs, hostMemory)
+	if err != nil {
+		return config, err
+	}
+
+	if resolved.NanoCPUs > 0 {
+		job.RecordDetail(ctx, "resources.nano_cpus", strconv.FormatInt(resolved.NanoCPUs, 10))
+	}
+	if resolved.Memory > 0 {
+		job.RecordDetail(ctx, "resources.memory", strconv.FormatInt(resolved.Memory, 10))
+	}
+	if resolved.MemorySwap != 0 {
+		job.RecordDetail(ctx, "resources.memory_swap", strconv.FormatInt(resolved.MemorySwap, 10))
+	}
+	if resolved.CPUShares > 0 {
+		job.RecordDetail(ctx, "resources.cpu_shares", strconv.FormatInt(resolved.CPUShares, 10))
+	}
+	logger.Debugf("resolved resources for deployment %s to %d nano cpus and %d bytes of memory", config.Name, resolved.NanoCPUs, resolved.Memory)
+
+	config.ResolvedResources = resolved
+	return config, nil
+}
+
+// resourcesFieldErrors returns a validation error if the resource limits cannot be parsed, or would overcommit
+// the docker host when added to the limits of every other deployment
+func (config Config) resourcesFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+	if !config.Resources.IsSet() {
+		return errs
+	}
+
+	if _, err := config.Resources.resolve(1, 1); err != nil {
+		return append(errs, newFieldError("resources", "%v", err))
+	}
+
+	// the host resources are only checked when connected to docker
+	if docker.GetClient() == nil {
+		return errs
+	}
+
+	hostCPUs, hostMemory, err := docker.GetClient().HostResources(context.Background())
+	if err != nil {
+		logger.Warnf("unable to get docker host resources to validate resources %v", err)
+		return errs
+	}
+
+	// docker rejects containers limited to more cpus than the host has, whatever the overcommit factor
+	if resolved, err := config.Resources.resolve(hostCPUs, hostMemory); err == nil && resolved.NanoCPUs > int64(hostCPUs)*1e9 {
+		errs = append(errs, newFieldError("resources", "cpus %s exceeds the %d cpus of the docker host", config.Resources.CPUs, hostCPUs))
+	}
+
+	others, err := GetAllDeploymentConfigs()
+	if err != nil {
+		logger.Warnf("unable to get deployments to validate resources %v", err)
+		return errs
+	}
+
+	return append(errs, resourcesOvercommitFieldErrors(config, others, hostCPUs, hostMemory, resourceOvercommitFactor())...)
+}
+
+// resourcesOvercommitFieldErrors returns a validation error for every resource whose total limits across deployments
+// (including the deployment being validated) exceeds the host resources multiplied by the overcommit factor
+func resourcesOvercommitFieldErrors(config Config, others []Config, hostCPUs int, hostMemory int64, factor float64) []FieldError {
+	errs := make([]FieldError, 0)
+
+	var totalNanoCPUs, totalMemory int64
+	for _, c := range others {
+		// the stored config of the deployment being validated is replaced by the new config
+		if c.Name == config.Name {
+			continue
+		}
+		resolved, err := c.Resources.resolve(hostCPUs, hostMemory)
+		if err != nil {
+			continue
+		}
+		totalNanoCPUs += resolved.NanoCPUs * int64(c.Scale)
+		totalMemory += resolved.Memory * int64(c.Scale)
+	}
+
+	resolved, _ := config.Resources.resolve(hostCPUs, hostMemory)
+	totalNanoCPUs += resolved.NanoCPUs * int64(config.Scale)
+	totalMemory += resolved.Memory * int64(config.Scale)
+
+	if maxNanoCPUs := factor * float64(hostCPUs) * 1e9; resolved.NanoCPUs > 0 && float64(totalNanoCPUs) > maxNanoCPUs {
+		errs = append(errs, newFieldError("resources", "cpu limits of all deployments add up to %.2f cpus, exceeding %.2f times the %d host cpus",
+			float64(totalNanoCPUs)/1e9, factor, hostCPUs))
+	}
+
+	if maxMemory := factor * float64(hostMemory); resolved.Memory > 0 && float64(totalMemory) > maxMemory {
+		errs = append(errs, newFieldError("resources", "memory limits of all deployments add up to %s, exceeding %.2f times the host memory of %s",
+			units.BytesSize(float64(totalMemory)), factor, units.BytesSize(float64(hostMemory))))
+	}
+
+	return errs
+}
+
+// resourceOvercommitFactor returns the factor of the host resources the limits of all deployments can add up to
+func resourceOvercommitFactor() float64 {
+	value := os.Getenv(constants.EnvResourceOvercommitFactor)
+	if value == "" {
+		return DefaultResourceOvercommitFactor
+	}
+
+	factor, err := strconv.ParseFloat(value, 64)
+	if err != nil || factor <= 0 {
+		logger.Warnf("invalid %s %s, using %.1f", constants.EnvResourceOvercommitFactor, value, DefaultResourceOvercommitFactor)
+		return DefaultResourceOvercommitFactor
+	}
+	return factor
+}
@@ -0,0 +1,80 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const gb = 1024 * 1024 * 1024
+
+func TestResolveResources(t *testing.T) {
+	resolved, err := Resources{CPUs: "25%", Memory: "50%"}.resolve(8, 16*gb)
+	assert.Nil(t, err)
+	assert.Equal(t, ResolvedResources{NanoCPUs: 2e9, Memory: 8 * gb}, resolved)
+
+	resolved, err = Resources{CPUs: "1.5", Memory: "512mb"}.resolve(8, 16*gb)
+	assert.Nil(t, err)
+	assert.Equal(t, ResolvedResources{NanoCPUs: 15e8, Memory: 512 * 1024 * 1024}, resolved)
+
+	resolved, err = Resources{}.resolve(8, 16*gb)
+	assert.Nil(t, err)
+	assert.Equal(t, ResolvedResources{}, resolved)
+}
+
+func TestResolveMemorySwapAndCPUShares(t *testing.T) {
+	resolved, err := Resources{Memory: "512mb", MemorySwap: "1gb", CPUShares: 512}.resolve(8, 16*gb)
+	assert.Nil(t, err)
+	assert.Equal(t, ResolvedResources{Memory: 512 * 1024 * 1024, MemorySwap: gb, CPUShares: 512}, resolved)
+
+	resolved, err = Resources{Memory: "25%", MemorySwap: "-1"}.resolve(8, 16*gb)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-1), resolved.MemorySwap)
+
+	// the swap limit includes the memory limit once resolved against the host
+	_, err = Resources{Memory: "25%", MemorySwap: "2gb"}.resolve(8, 16*gb)
+	assert.Error(t, err)
+}
+
+func TestResourcesFieldErrors(t *testing.T) {
+	for _, resources := range []Resources{
+		{CPUs: "0"},
+		{CPUs: "-1"},
+		{CPUs: "lots"},
+		{CPUs: "150%"},
+		{Memory: "0%"},
+		{Memory: "big"},
+		{MemorySwap: "1gb"},
+		{Memory: "1gb", MemorySwap: "512mb"},
+		{Memory: "1gb", MemorySwap: "lots"},
+		{CPUShares: 1},
+		{CPUShares: -1024},
+	} {
+		config := Config{Name: "resources-app", Image: "nginx", Resources: resources}
+		assert.Len(t, config.resourcesFieldErrors(), 1, resources)
+	}
+
+	assert.Empty(t, Config{Name: "resources-app", Image: "nginx", Resources: Resources{CPUs: "25%", Memory: "1g"}}.resourcesFieldErrors())
+}
+
+func TestResourcesOvercommit(t *testing.T) {
+	others := []Config{
+		{Name: "api", Scale: 2, Resources: Resources{CPUs: "25%", Memory: "4g"}},
+		{Name: "worker", Scale: 1, Resources: Resources{CPUs: "2"}},
+		{Name: "web", Scale: 1, Resources: Resources{CPUs: "4"}},
+	}
+
+	// 2 x 2 + 2 cpus of others and 2 x 1 cpus for web
+	web := Config{Name: "web", Scale: 2, Resources: Resources{CPUs: "1"}}
+	assert.Empty(t, resourcesOvercommitFieldErrors(web, others, 8, 16*gb, 1))
+
+	web.Resources.CPUs = "2"
+	assert.Len(t, resourcesOvercommitFieldErrors(web, others, 8, 16*gb, 1), 1)
+	assert.Empty(t, resourcesOvercommitFieldErrors(web, others, 8, 16*gb, 1.5))
+
+	// memory is only checked when the deployment sets a memory limit
+	web.Resources = Resources{Memory: "50%"}
+	assert.Len(t, resourcesOvercommitFieldErrors(web, others, 8, 16*gb, 1), 1)
+	web.Resources = Resources{Memory: "25%"}
+	assert.Empty(t, resourcesOvercommitFieldErrors(web, others, 8, 16*gb, 1))
+}
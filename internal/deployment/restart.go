@@ -0,0 +1,69 @@
+package deployment
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// restartPolicies are the restart policies supported by docker
+var restartPolicies = map[string]bool{
+	"no":             true,
+	"always":         true,
+	"unless-stopped": true,
+	"on-failure":     true,
+}
+
+// DockerRestartPolicy returns the docker restart policy of the containers, the no policy when the restart policy is not set
+func (config Config) DockerRestartPolicy() container.RestartPolicy {
+	policy, _ := parseRestartPolicy(config.RestartPolicy)
+	return policy
+}
+
+// parseRestartPolicy parses a restart policy in the docker --restart syntax (ie. on-failure:5)
+func parseRestartPolicy(value string) (container.RestartPolicy, error) {
+	if value == "" {
+		return container.RestartPolicy{Name: "no"}, nil
+	}
+
+	parts := strings.SplitN(value, ":", 2)
+	policy := container.RestartPolicy{Name: parts[0]}
+	if !restartPolicies[policy.Name] {
+		return container.RestartPolicy{Name: "no"}, fmt.Errorf("unknown restart policy %s, expected no, always, unless-stopped or on-failure", policy.Name)
+	}
+
+	if len(parts) == 2 {
+		if policy.Name != "on-failure" {
+			return container.RestartPolicy{Name: "no"}, fmt.Errorf("max retries are only supported by the on-failure restart policy, not %s", policy.Name)
+		}
+
+		retries, err := strconv.Atoi(parts[1])
+		if err != nil || retries < 0 {
+			return container.RestartPolicy{Name: "no"}, fmt.Errorf("invalid max retries %s, expected a number of retries (0 retries forever)", parts[1])
+		}
+		policy.MaximumRetryCount = retries
+	}
+
+	return policy, nil
+}
+
+// formatRestartPolicy returns a docker restart policy in the docker --restart syntax, empty for the no policy
+func formatRestartPolicy(policy container.RestartPolicy) string {
+	if policy.IsNone() {
+		return ""
+	}
+	if policy.IsOnFailure() && policy.MaximumRetryCount > 0 {
+		return fmt.Sprintf("%s:%d", policy.Name, policy.MaximumRetryCount)
+	}
+	return policy.Name
+}
+
+// restartPolicyFieldErrors returns a validation error if the restart policy is unknown or has invalid max retries
+func (config Config) restartPolicyFieldErrors() []FieldError {
+	if _, err := parseRestartPolicy(config.RestartPolicy); err != nil {
+		return []FieldError{newFieldError("restart_policy", "%v", err)}
+	}
+	return make([]FieldError, 0)
+}
@@ -0,0 +1,34 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDockerRestartPolicy(t *testing.T) {
+	assert.Equal(t, container.RestartPolicy{Name: "no"}, Config{}.DockerRestartPolicy())
+	assert.Equal(t, container.RestartPolicy{Name: "unless-stopped"}, Config{RestartPolicy: "unless-stopped"}.DockerRestartPolicy())
+	assert.Equal(t, container.RestartPolicy{Name: "on-failure", MaximumRetryCount: 5}, Config{RestartPolicy: "on-failure:5"}.DockerRestartPolicy())
+
+	// invalid restart policies are rejected by validation, containers are never restarted with them
+	assert.Equal(t, container.RestartPolicy{Name: "no"}, Config{RestartPolicy: "sometimes"}.DockerRestartPolicy())
+}
+
+func TestRestartPolicyFieldErrors(t *testing.T) {
+	for _, policy := range []string{"", "no", "always", "unless-stopped", "on-failure", "on-failure:0", "on-failure:10"} {
+		assert.Empty(t, Config{RestartPolicy: policy}.restartPolicyFieldErrors(), policy)
+	}
+
+	for _, policy := range []string{"sometimes", "always:3", "on-failure:-1", "on-failure:many"} {
+		assert.Len(t, Config{RestartPolicy: policy}.restartPolicyFieldErrors(), 1, policy)
+	}
+}
+
+func TestFormatRestartPolicy(t *testing.T) {
+	assert.Equal(t, "", formatRestartPolicy(container.RestartPolicy{}))
+	assert.Equal(t, "", formatRestartPolicy(container.RestartPolicy{Name: "no"}))
+	assert.Equal(t, "always", formatRestartPolicy(container.RestartPolicy{Name: "always"}))
+	assert.Equal(t, "on-failure:3", formatRestartPolicy(container.RestartPolicy{Name: "on-failure", MaximumRetryCount: 3}))
+}
@@ -0,0 +1,71 @@
+package deployment
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Rollback restores the configuration of the nth previous successfully deployed revision of a deployment
+// (1 being the revision deployed before the current one) and runs the deployment with it. The restored
+// configuration is saved as a new revision so a rollback can itself be rolled back.
+func Rollback(deployment string, n int, overrideWindow bool) (HistoryEntry, error) {
+	if n < 1 {
+		return HistoryEntry{}, fmt.Errorf("invalid rollback of %d revision(s), expected at least 1", n)
+	}
+
+	if !Exist(deployment) {
+		return HistoryEntry{}, fmt.Errorf("deployment %s does not exist", deployment)
+	}
+
+	config, err := GetDeploymentConfigFromStore(deployment)
+	if err != nil {
+		return HistoryEntry{}, err
+	}
+
+	// a rollback is a manual run, it is rejected outside the deploy window unless overridden by an admin
+	if open, reason := config.DeployWindow.Open(time.Now()); !open && !overrideWindow {
+		return HistoryEntry{}, DeployWindowClosedError{Deployment: deployment, Reason: reason}
+	}
+
+	history, err := GetHistory(deployment)
+	if err != nil {
+		return HistoryEntry{}, fmt.Errorf("unable to get history of deployment %s, %w", deployment, err)
+	}
+
+	target, err := rollbackTarget(history, n)
+	if err != nil {
+		return HistoryEntry{}, fmt.Errorf("unable to rollback deployment %s, %w", deployment, err)
+	}
+
+	restored := target.Config
+	restored.Name = deployment
+	if err := SaveConfigWithNote(restored, fmt.Sprintf("rolled back to revision %s", target.ID)); err != nil {
+		return HistoryEntry{}, err
+	}
+
+	if err := RunWithOptions(deployment, RunOptions{Start: true, OverrideWindow: overrideWindow}); err != nil {
+		return target, err
+	}
+
+	return target, nil
+}
+
+// rollbackTarget returns the nth revision deployed before the current one from a history sorted from newest to
+// oldest. Revisions are ordered by when they last deployed successfully, revisions never deployed are skipped.
+func rollbackTarget(history []HistoryEntry, n int) (HistoryEntry, error) {
+	deployed := make([]HistoryEntry, 0)
+	for _, entry := range history {
+		if entry.DeployedAt > 0 {
+			deployed = append(deployed, entry)
+		}
+	}
+
+	sort.SliceStable(deployed, func(i, j int) bool { return deployed[i].DeployedAt > deployed[j].DeployedAt })
+
+	// the most recently deployed revision is the one currently running
+	if len(deployed) <= n {
+		return HistoryEntry{}, fmt.Errorf("no successful deploy %d revision(s) before the current one", n)
+	}
+	return deployed[n], nil
+}
@@ -0,0 +1,61 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollbackTarget(t *testing.T) {
+	history := []HistoryEntry{
+		{ID: "5"},                 // saved, never deployed
+		{ID: "4", DeployedAt: 40}, // current
+		{ID: "3"},                 // failed deploy
+		{ID: "2", DeployedAt: 20},
+		{ID: "1", DeployedAt: 50}, // rolled back to, most recent deploy
+	}
+
+	target, err := rollbackTarget(history, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "4", target.ID)
+
+	target, err = rollbackTarget(history, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "2", target.ID)
+
+	_, err = rollbackTarget(history, 3)
+	assert.EqualError(t, err, "no successful deploy 3 revision(s) before the current one")
+
+	_, err = rollbackTarget([]HistoryEntry{{ID: "1", DeployedAt: 10}}, 1)
+	assert.NotNil(t, err)
+}
+
+func TestRollbackValidation(t *testing.T) {
+	_, err := Rollback("rollback-missing", 1, false)
+	assert.EqualError(t, err, "deployment rollback-missing does not exist")
+
+	_, err = Rollback("rollback-missing", 0, false)
+	assert.EqualError(t, err, "invalid rollback of 0 revision(s), expected at least 1")
+
+	assert.Nil(t, SaveConfigWithNote(Config{Name: "rollback", Image: "nginx", Tag: "1.18"}, "initial"))
+	defer DeleteConfig("rollback")
+	defer DeleteHistoryCollection("rollback")
+
+	_, err = Rollback("rollback", 1, false)
+	assert.EqualError(t, err, "unable to rollback deployment rollback, no successful deploy 1 revision(s) before the current one")
+}
+
+func TestPrunedRevisions(t *testing.T) {
+	history := []HistoryEntry{
+		{ID: "6"}, // latest, always kept
+		{ID: "5", DeployedAt: 50},
+		{ID: "4"}, // newer than a kept revision
+		{ID: "3", DeployedAt: 30},
+		{ID: "2", DeployedAt: 20},
+		{ID: "1"},
+	}
+
+	assert.Equal(t, []string{"2", "1"}, prunedRevisions(history, 2))
+	assert.Empty(t, prunedRevisions(history, 3))
+	assert.Equal(t, []string{"4", "3", "2", "1"}, prunedRevisions(history, 1))
+}
@@ -0,0 +1,167 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/logger"
+)
+
+// ProxyDeploymentName is the name of the network proxy deployment created by Krane
+const ProxyDeploymentName = "krane-proxy"
+
+// NoProxyWarning is reported for routed deployments when no running Traefik proxy is found
+const NoProxyWarning = "routing configured but no Traefik found, aliases will not be reachable until a Traefik proxy is running"
+
+// RoutingStatus is the state of the routing of a deployment through the network proxy
+type RoutingStatus struct {
+	ProxyDetected bool   `json:"proxy_detected"`
+	Proxy         string `json:"proxy,omitempty"`   // name of the running proxy container
+	Warning       string `json:"warning,omitempty"` // set when the deployment is routed but no proxy is running
+}
+
+// DetectProxy returns the name of a running Traefik proxy container, either the network proxy
+// created by Krane or a Traefik container managed outside of Krane
+func DetectProxy(ctx context.Context) (string, bool, error) {
+	if docker.GetClient() == nil {
+		return "", false, fmt.Errorf("docker client not connected")
+	}
+
+	containers, err := docker.GetClient().ListContainers(ctx, docker.ListContainersOptions{All: false})
+	if err != nil {
+		return "", false, err
+	}
+
+	name, ok := proxyContainer(containers)
+	return name, ok, nil
+}
+
+// proxyContainer returns the name of the first running proxy container
+func proxyContainer(containers []types.ContainerJSON) (string, bool) {
+	for _, c := range containers {
+		if c.State == nil || !c.State.Running || c.Config == nil {
+			continue
+		}
+
+		if c.Config.Labels[docker.ContainerDeploymentLabel] == ProxyDeploymentName ||
+			strings.Contains(strings.ToLower(c.Config.Image), "traefik") {
+			return strings.TrimPrefix(c.Name, "/"), true
+		}
+	}
+	return "", false
+}
+
+// GetRoutingStatus returns the routing status of a deployment, nil if the deployment is not routed
+func GetRoutingStatus(ctx context.Context, config Config) *RoutingStatus {
+	if !config.Routed() || config.Internal {
+		return nil
+	}
+
+	proxy, ok, err := DetectProxy(ctx)
+	if err != nil {
+		logger.Warnf("unable to detect the network proxy for deployment %s: %v", config.Name, err)
+		return nil
+	}
+
+	return routingStatus(config, proxy, ok)
+}
+
+// routingStatus returns the routing status of a deployment given the detected proxy, nil if the deployment is not routed
+func routingStatus(config Config, proxy string, detected bool) *RoutingStatus {
+	if !config.Routed() || config.Internal {
+		return nil
+	}
+
+	status := RoutingStatus{ProxyDetected: detected, Proxy: proxy}
+	if !detected {
+		status.Warning = NoProxyWarning
+	}
+	return &status
+}
+
+// warnIfProxyMissing emits a warning event when a routed deployment is deployed but no proxy is running
+func warnIfProxyMissing(ctx context.Context, config Config, e *EventEmitter) {
+	status := GetRoutingStatus(ctx, config)
+	if status == nil || status.ProxyDetected {
+		return
+	}
+
+	logger.Warnf("deployment %s: %s", config.Name, status.Warning)
+	e.emit(fmt.Sprintf("Warning: %s", status.Warning))
+}
+
+// warnIfUnroutable emits a warning event when a routed deployment is deployed with a network mode the proxy can't reach
+func warnIfUnroutable(config Config, e *EventEmitter) {
+	if !config.Routed() || config.Internal || !docker.IsIsolatedNetworkMode(config.NetworkMode) {
+		return
+	}
+
+	logger.Warnf("deployment %s uses network_mode %s, its aliases are not routed by Traefik", config.Name, config.NetworkMode)
+	e.emit(fmt.Sprintf("Warning: network_mode %s is not on the krane network, aliases are not routed by Traefik unless the proxy is configured manually", config.NetworkMode))
+}
+
+var poolMu sync.Mutex
+var lastPool = make(map[string]map[string]bool)
+
+// routable returns whether the proxy routes requests to a container. The Traefik docker provider ignores
+// containers with a docker health check that are not healthy (starting or unhealthy), they are pulled from
+// the load balancer until their health check passes again without the container being relabeled or recreated.
+func (c KraneContainer) routable() bool {
+	if !c.State.Running {
+		return false
+	}
+	return c.State.Health == nil || c.State.Health.Status == "healthy"
+}
+
+// MonitorRoutingPool emits a routing event to the deployment event subscribers for every container of a
+// routed deployment pulled from or added back to the proxy load balancer since it was last monitored
+func MonitorRoutingPool(d Deployment) {
+	if !d.Config.Routed() {
+		return
+	}
+
+	removed, restored := poolChanges(d)
+	for _, c := range removed {
+		logger.Warnf("container %s of deployment %s is not healthy, removed from the proxy load balancer", c.Name, d.Config.Name)
+		e := createEventEmitter(d.Config.Name, "")
+		e.Phase = RoutingPhase
+		e.emit(fmt.Sprintf("container %s is not healthy, removed from the proxy load balancer", c.Name))
+	}
+	for _, c := range restored {
+		logger.Infof("container %s of deployment %s is healthy, added back to the proxy load balancer", c.Name, d.Config.Name)
+		e := createEventEmitter(d.Config.Name, "")
+		e.Phase = RoutingPhase
+		e.emit(fmt.Sprintf("container %s is healthy, added back to the proxy load balancer", c.Name))
+	}
+}
+
+// poolChanges records the routable containers of a deployment and returns the containers that stopped
+// or started being routable since the previous call. New containers are recorded without being reported.
+func poolChanges(d Deployment) (removed []KraneContainer, restored []KraneContainer) {
+	poolMu.Lock()
+	defer poolMu.Unlock()
+
+	previous := lastPool[d.Config.Name]
+	current := make(map[string]bool, len(d.Containers))
+	for _, c := range d.Containers {
+		routable := c.routable()
+		current[c.ID] = routable
+
+		wasRoutable, seen := previous[c.ID]
+		switch {
+		case !seen || wasRoutable == routable:
+		case routable:
+			restored = append(restored, c)
+		default:
+			removed = append(removed, c)
+		}
+	}
+	lastPool[d.Config.Name] = current
+
+	return removed, restored
+}
@@ -0,0 +1,78 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/docker"
+)
+
+func testContainerJSON(name string, image string, labels map[string]string, running bool) types.ContainerJSON {
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{Name: "/" + name, State: &types.ContainerState{Running: running}},
+		Config:            &container.Config{Image: image, Labels: labels},
+	}
+}
+
+func TestProxyContainer(t *testing.T) {
+	app := testContainerJSON("app-1", "nginx", map[string]string{docker.ContainerDeploymentLabel: "app"}, true)
+	stoppedProxy := testContainerJSON("krane-proxy-1", "biensupernice/proxy", map[string]string{docker.ContainerDeploymentLabel: ProxyDeploymentName}, false)
+	kraneProxy := testContainerJSON("krane-proxy-2", "biensupernice/proxy", map[string]string{docker.ContainerDeploymentLabel: ProxyDeploymentName}, true)
+	traefik := testContainerJSON("traefik", "traefik:v2.4", nil, true)
+
+	_, ok := proxyContainer([]types.ContainerJSON{app, stoppedProxy})
+	assert.False(t, ok)
+
+	name, ok := proxyContainer([]types.ContainerJSON{app, stoppedProxy, kraneProxy})
+	assert.True(t, ok)
+	assert.Equal(t, "krane-proxy-2", name)
+
+	name, ok = proxyContainer([]types.ContainerJSON{app, traefik})
+	assert.True(t, ok)
+	assert.Equal(t, "traefik", name)
+}
+
+func TestRoutingStatus(t *testing.T) {
+	routed := Config{Name: "app", Alias: []string{"app.example.com"}}
+
+	assert.Nil(t, routingStatus(Config{Name: "app"}, "", false))
+	assert.Nil(t, routingStatus(Config{Name: ProxyDeploymentName, Internal: true}, "", false))
+	assert.Equal(t, &RoutingStatus{ProxyDetected: true, Proxy: "traefik"}, routingStatus(routed, "traefik", true))
+	assert.Equal(t, &RoutingStatus{Warning: NoProxyWarning}, routingStatus(routed, "", false))
+}
+
+func TestPoolChanges(t *testing.T) {
+	healthy := KraneContainer{ID: "1", Name: "app-1", State: ContainerState{Running: true, Health: &types.Health{Status: "healthy"}}}
+	noHealthCheck := KraneContainer{ID: "2", Name: "app-2", State: ContainerState{Running: true}}
+	d := Deployment{Config: Config{Name: "pool", Alias: []string{"app.example.com"}}, Containers: []KraneContainer{healthy, noHealthCheck}}
+
+	// containers seen for the first time are not reported
+	removed, restored := poolChanges(d)
+	assert.Empty(t, removed)
+	assert.Empty(t, restored)
+
+	unhealthy := healthy
+	unhealthy.State.Health = &types.Health{Status: "unhealthy"}
+	d.Containers = []KraneContainer{unhealthy, noHealthCheck}
+	removed, restored = poolChanges(d)
+	assert.Equal(t, []KraneContainer{unhealthy}, removed)
+	assert.Empty(t, restored)
+
+	removed, restored = poolChanges(d)
+	assert.Empty(t, removed)
+	assert.Empty(t, restored)
+
+	d.Containers = []KraneContainer{healthy, noHealthCheck}
+	removed, restored = poolChanges(d)
+	assert.Empty(t, removed)
+	assert.Equal(t, []KraneContainer{healthy}, restored)
+}
+
+func TestContainerRoutable(t *testing.T) {
+	assert.True(t, KraneContainer{State: ContainerState{Running: true}}.routable())
+	assert.False(t, KraneContainer{State: ContainerState{Running: false}}.routable())
+	assert.False(t, KraneContainer{State: ContainerState{Running: true, Health: &types.Health{Status: "starting"}}}.routable())
+}
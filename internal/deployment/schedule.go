@@ -0,0 +1,77 @@
+package deployment
+
+import (
+	"fmt"
+
+	"github.com/krane/krane/internal/job"
+)
+
+// Actions run on a deployment by its schedule
+const (
+	ScheduledRun     = "run"     // runs the deployment, deferred outside the deploy window like other automated runs
+	ScheduledRestart = "restart" // recreates the containers of the deployment from its current configuration
+)
+
+// Schedule registers a cron schedule running an action on a deployment, replacing its current schedule (if any)
+func Schedule(deployment string, cron string, action string, timezone string) (job.RecurringJob, error) {
+	if !Exist(deployment) {
+		return job.RecurringJob{}, fmt.Errorf("deployment %s does not exist", deployment)
+	}
+
+	if action == "" {
+		action = ScheduledRun
+	}
+	if action != ScheduledRun && action != ScheduledRestart {
+		return job.RecurringJob{}, fmt.Errorf("unknown scheduled action %s, expected %s or %s", action, ScheduledRun, ScheduledRestart)
+	}
+
+	return job.SaveRecurringJob(job.RecurringJob{
+		Deployment: deployment,
+		Action:     action,
+		Cron:       cron,
+		Timezone:   timezone,
+	})
+}
+
+// GetSchedule returns the schedule of a deployment, nil if it is not scheduled
+func GetSchedule(deployment string) (*job.RecurringJob, error) {
+	return job.GetRecurringJob(deployment)
+}
+
+// Unschedule removes the schedule of a deployment
+func Unschedule(deployment string) error {
+	return job.DeleteRecurringJob(deployment)
+}
+
+// RunScheduled runs the action of a due deployment schedule, schedules of removed deployments are dropped
+func RunScheduled(r job.RecurringJob) error {
+	if !Exist(r.Deployment) {
+		if err := Unschedule(r.Deployment); err != nil {
+			return err
+		}
+		return fmt.Errorf("deployment %s does not exist, schedule removed", r.Deployment)
+	}
+
+	switch r.Action {
+	case ScheduledRun:
+		return RunWithOptions(r.Deployment, RunOptions{Start: true, Automated: true})
+	case ScheduledRestart:
+		_, err := RestartContainers(r.Deployment)
+		return err
+	default:
+		return fmt.Errorf("unknown scheduled action %s", r.Action)
+	}
+}
+
+// renameSchedule moves the schedule of a deployment to its new name
+func renameSchedule(deployment string, newName string) error {
+	schedule, err := GetSchedule(deployment)
+	if err != nil || schedule == nil {
+		return err
+	}
+
+	if _, err := Schedule(newName, schedule.Cron, schedule.Action, schedule.Timezone); err != nil {
+		return err
+	}
+	return Unschedule(deployment)
+}
@@ -0,0 +1,45 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/job"
+)
+
+func TestSchedule(t *testing.T) {
+	_, err := Schedule("schedule-missing", "@daily", ScheduledRun, "")
+	assert.Error(t, err)
+
+	assert.Nil(t, SaveConfig(Config{Name: "schedule-app", Image: "nginx"}))
+	defer DeleteConfig("schedule-app")
+	defer DeleteHistoryCollection("schedule-app")
+	defer Unschedule("schedule-app")
+
+	_, err = Schedule("schedule-app", "@daily", "rebuild", "")
+	assert.Error(t, err)
+
+	schedule, err := Schedule("schedule-app", "0 3 * * *", "", "Europe/Paris")
+	assert.Nil(t, err)
+	assert.Equal(t, ScheduledRun, schedule.Action)
+
+	stored, err := GetSchedule("schedule-app")
+	assert.Nil(t, err)
+	assert.Equal(t, schedule, *stored)
+
+	assert.Nil(t, Unschedule("schedule-app"))
+	stored, err = GetSchedule("schedule-app")
+	assert.Nil(t, err)
+	assert.Nil(t, stored)
+}
+
+func TestRunScheduledRemovedDeployment(t *testing.T) {
+	_, err := job.SaveRecurringJob(job.RecurringJob{Deployment: "schedule-removed", Action: ScheduledRun, Cron: "@hourly"})
+	assert.Nil(t, err)
+
+	assert.Error(t, RunScheduled(job.RecurringJob{Deployment: "schedule-removed", Action: ScheduledRun}))
+	stored, err := GetSchedule("schedule-removed")
+	assert.Nil(t, err)
+	assert.Nil(t, stored)
+}
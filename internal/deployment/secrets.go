@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/krane/krane/internal/constants"
@@ -32,6 +33,8 @@ func AddSecret(deployment, key, value string) (*Secret, error) {
 		Alias:      formatSecretAlias(key),
 	}
 
+	previous, _ := GetSecret(deployment, key)
+
 	collection := getSecretsCollectionName(deployment)
 	bytes, _ := secret.SerializeSecret()
 	err := store.Client().Put(collection, secret.Key, bytes)
@@ -39,13 +42,91 @@ func AddSecret(deployment, key, value string) (*Secret, error) {
 		return nil, err
 	}
 
+	if previous == nil || previous.Value != value {
+		reloadOnSecretChange(deployment, key)
+	}
+
 	return secret, nil
 }
 
-// DeleteSecret deletes a deployment secret
+// SecretImportSummary summarizes a bulk import of secrets
+type SecretImportSummary struct {
+	Created int                 `json:"created"` // number of secrets added
+	Updated int                 `json:"updated"` // number of existing secrets saved with a new value
+	Skipped []SecretImportError `json:"skipped"` // entries not imported
+}
+
+// ImportSecrets adds or updates the secrets of a deployment in a single transaction. Entries with an invalid
+// key are skipped and reported in the summary along with their line, the other entries are still imported.
+// When a key is repeated, the last entry wins.
+func ImportSecrets(deployment string, entries []SecretEntry) (SecretImportSummary, error) {
+	summary := SecretImportSummary{Skipped: make([]SecretImportError, 0)}
+
+	collection := getSecretsCollectionName(deployment)
+	existing, err := store.Client().GetAllWithKeys(collection)
+	if err != nil {
+		return summary, err
+	}
+
+	secrets := make(map[string]*Secret)
+	for _, entry := range entries {
+		if !isValidSecretKey(entry.Key) {
+			summary.Skipped = append(summary.Skipped, SecretImportError{Line: entry.Line, Key: entry.Key, Error: fmt.Sprintf("invalid secret name %s", entry.Key)})
+			continue
+		}
+
+		secrets[entry.Key] = &Secret{
+			Deployment: deployment,
+			Key:        entry.Key,
+			Value:      entry.Value,
+			Alias:      formatSecretAlias(entry.Key),
+		}
+	}
+
+	values := make(map[string][]byte, len(secrets))
+	changed := make([]string, 0)
+	for key, secret := range secrets {
+		values[key], _ = secret.SerializeSecret()
+
+		previousBytes, ok := existing[key]
+		if !ok {
+			summary.Created++
+			changed = append(changed, key)
+			continue
+		}
+
+		var previous Secret
+		_ = json.Unmarshal(previousBytes, &previous)
+		if previous.Value != secret.Value {
+			summary.Updated++
+			changed = append(changed, key)
+		}
+	}
+
+	if err := store.Client().PutAll(collection, values); err != nil {
+		return SecretImportSummary{Skipped: summary.Skipped}, err
+	}
+
+	for _, key := range changed {
+		reloadOnSecretChange(deployment, key)
+	}
+
+	return summary, nil
+}
+
+// DeleteSecret deletes a deployment secret, deleting a secret that does not exist is a no-op
 func DeleteSecret(deployment, key string) error {
+	previous, _ := GetSecret(deployment, key)
+
 	collection := getSecretsCollectionName(deployment)
-	return store.Client().Remove(collection, key)
+	if err := store.Client().Remove(collection, key); err != nil {
+		return err
+	}
+
+	if previous != nil {
+		reloadOnSecretChange(deployment, key)
+	}
+	return nil
 }
 
 // CreateSecretsCollection creates secrets collection for a deployment
@@ -81,6 +162,22 @@ func GetAllSecrets(deployment string) ([]*Secret, error) {
 	return secrets, nil
 }
 
+// GetSecretKeys returns the sorted keys of the secrets of a deployment, without their values
+func GetSecretKeys(deployment string) ([]string, error) {
+	secrets, err := GetAllSecrets(deployment)
+	if err != nil {
+		return make([]string, 0), err
+	}
+
+	keys := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		keys = append(keys, secret.Key)
+	}
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
 // GetAllSecretsRedacted returns all deployment secrets with <redacted> a their value
 func GetAllSecretsRedacted(deployment string) []Secret {
 	plainSecrets, _ := GetAllSecrets(deployment)
@@ -180,3 +180,56 @@ func TestDeleteSecret(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Equal(t, fmt.Sprintf("secret with key %s not found for deployment %s", secretKey, testDeployment), err.Error())
 }
+
+func TestDeleteSecretKeepsOtherKeys(t *testing.T) {
+	deployment := "krane-test-delete-secret"
+	assert.Nil(t, CreateSecretsCollection(deployment))
+	defer func() { _ = DeleteSecretsCollection(deployment) }()
+
+	_, err := AddSecret(deployment, "DB_PASSWORD", "hunter2")
+	assert.Nil(t, err)
+	_, err = AddSecret(deployment, "API_TOKEN", "abc")
+	assert.Nil(t, err)
+
+	assert.Nil(t, DeleteSecret(deployment, "DB_PASSWORD"))
+	keys, err := GetSecretKeys(deployment)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"API_TOKEN"}, keys)
+
+	// deleting a secret that does not exist is a no-op
+	assert.Nil(t, DeleteSecret(deployment, "DB_PASSWORD"))
+	keys, err = GetSecretKeys(deployment)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"API_TOKEN"}, keys)
+}
+
+func TestImportSecrets(t *testing.T) {
+	deployment := "krane-test-import-secrets"
+	assert.Nil(t, CreateSecretsCollection(deployment))
+	defer func() { _ = DeleteSecretsCollection(deployment) }()
+
+	_, err := AddSecret(deployment, "DB_PASSWORD", "hunter2")
+	assert.Nil(t, err)
+	_, err = AddSecret(deployment, "API_TOKEN", "abc")
+	assert.Nil(t, err)
+
+	summary, err := ImportSecrets(deployment, []SecretEntry{
+		{Line: 1, Key: "DB_PASSWORD", Value: "hunter3"},
+		{Line: 2, Key: "API_TOKEN", Value: "abc"},
+		{Line: 3, Key: "DB_HOST", Value: "db.internal"},
+		{Line: 4, Key: "not valid!", Value: "value"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, summary.Created)
+	assert.Equal(t, 1, summary.Updated)
+	assert.Equal(t, []SecretImportError{{Line: 4, Key: "not valid!", Error: "invalid secret name not valid!"}}, summary.Skipped)
+
+	s, err := GetSecret(deployment, "DB_PASSWORD")
+	assert.Nil(t, err)
+	assert.Equal(t, "hunter3", s.Value)
+	assert.Equal(t, "@DB_PASSWORD", s.Alias)
+
+	keys, err := GetSecretKeys(deployment)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"API_TOKEN", "DB_HOST", "DB_PASSWORD"}, keys)
+}
@@ -0,0 +1,175 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/job"
+	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/store"
+)
+
+// StagedRun is a run whose containers were created without being started (?start=false). The staged containers
+// are only started once the deployment is started, the previous containers keep serving the deployment meanwhile.
+type StagedRun struct {
+	Deployment string   `json:"deployment"`
+	JobID      string   `json:"job_id"`     // id of the run job which created the staged containers
+	Containers []string `json:"containers"` // ids of the staged containers
+	StagedAt   int64    `json:"staged_at"`
+}
+
+// stageRun records the containers created by a run without starting them. The containers of a run staged
+// earlier and never started are removed, only the latest staged run can be started.
+func stageRun(ctx context.Context, config Config, containers []KraneContainer, e *EventEmitter) error {
+	previous, err := GetStagedRun(config.Name)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(containers))
+	for _, c := range containers {
+		ids = append(ids, c.ID)
+	}
+
+	bytes, _ := json.Marshal(StagedRun{
+		Deployment: config.Name,
+		JobID:      job.IDFromContext(ctx),
+		Containers: ids,
+		StagedAt:   time.Now().Unix(),
+	})
+	if err := store.Client().Put(constants.StagedRunsCollectionName, config.Name, bytes); err != nil {
+		return err
+	}
+
+	if previous != nil {
+		current, err := GetContainersByDeployment(config.Name)
+		if err != nil {
+			return err
+		}
+
+		replaced, _ := splitStagedContainers(current, previous.Containers)
+		if err := removeContainers(ctx, replaced, config.ContainerStopTimeout()); err != nil {
+			return err
+		}
+	}
+
+	e.emit(fmt.Sprintf("%d container(s) staged, start the deployment to replace the current containers", len(containers)))
+	return nil
+}
+
+// GetStagedRun returns the run of a deployment whose containers were created without being started, nil if none
+func GetStagedRun(deployment string) (*StagedRun, error) {
+	bytes, err := store.Client().Get(constants.StagedRunsCollectionName, deployment)
+	if err != nil || bytes == nil {
+		return nil, err
+	}
+
+	var run StagedRun
+	if err := json.Unmarshal(bytes, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// deleteStagedRun removes the staged run of a deployment, its containers are left as is
+func deleteStagedRun(deployment string) error {
+	return store.Client().Remove(constants.StagedRunsCollectionName, deployment)
+}
+
+// splitStagedContainers splits the containers of a deployment into the staged containers (by id) and the others
+func splitStagedContainers(containers []KraneContainer, staged []string) (matching []KraneContainer, others []KraneContainer) {
+	ids := make(map[string]bool, len(staged))
+	for _, id := range staged {
+		ids[id] = true
+	}
+
+	matching = make([]KraneContainer, 0)
+	others = make([]KraneContainer, 0)
+	for _, c := range containers {
+		if ids[c.ID] {
+			matching = append(matching, c)
+		} else {
+			others = append(others, c)
+		}
+	}
+	return matching, others
+}
+
+// activateStagedRun starts and health checks the staged containers of a deployment, then removes the previous
+// containers. If the staged containers fail to start or to pass the health check they are stopped, the previous
+// containers keep serving the deployment and the run stays staged.
+func activateStagedRun(ctx context.Context, config Config, run StagedRun, e *EventEmitter) error {
+	containers, err := GetContainersByDeployment(config.Name)
+	if err != nil {
+		return err
+	}
+
+	staged, previous := splitStagedContainers(containers, run.Containers)
+	if len(staged) == 0 {
+		if err := deleteStagedRun(config.Name); err != nil {
+			logger.Warnf("unable to remove the staged run of deployment %s, %v", config.Name, err)
+		}
+		return fmt.Errorf("the staged containers of deployment %s no longer exist, run the deployment again", config.Name)
+	}
+
+	// previous containers bound to the same fixed host ports are stopped so the staged containers can bind them
+	handoff := newPortHandoff(config, previous)
+	if err := handoff.stop(ctx, e); err != nil {
+		return err
+	}
+
+	err = startStagedContainers(ctx, config, staged)
+	if err != nil {
+		logger.Errorf("unable to start staged containers %v", err)
+		for _, c := range staged {
+			if stopErr := c.Stop(context.Background(), config.ContainerStopTimeout()); stopErr != nil {
+				logger.Errorf("unable to stop staged container %v", stopErr)
+			}
+		}
+		handoff.restore(e)
+		return err
+	}
+
+	job.RecordStep(ctx, "remove_previous_containers")
+	if err := removeContainers(ctx, previous, config.ContainerStopTimeout()); err != nil {
+		return err
+	}
+
+	if err := deleteStagedRun(config.Name); err != nil {
+		logger.Warnf("unable to remove the staged run of deployment %s, %v", config.Name, err)
+	}
+
+	// the revision deployed by the staged run can now be rolled back to
+	markRevisionDeployed(config.Name, run.JobID)
+	e.emit(fmt.Sprintf("Started %d staged container(s), removed %d previous container(s)", len(staged), len(previous)))
+	return nil
+}
+
+// startStagedContainers starts the staged containers of a deployment and waits for them to pass the health check
+func startStagedContainers(ctx context.Context, config Config, staged []KraneContainer) error {
+	job.RecordStep(ctx, "start_containers")
+	for _, c := range staged {
+		logger.Debugf("Starting staged container %s", c.Name)
+		if err := c.Start(ctx); err != nil {
+			return err
+		}
+	}
+
+	job.RecordStep(ctx, "health_check")
+	if delay := time.Duration(config.HealthCheck.InitialDelay) * time.Second; delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return fmt.Errorf("health check aborted %v", ctx.Err())
+		}
+	}
+
+	minHealthy := config.MinHealthyContainers()
+	if minHealthy > len(staged) {
+		minHealthy = len(staged)
+	}
+	return RetriableContainersHealthCheck(ctx, config, staged, minHealthy, config.HealthCheck.retries())
+}
@@ -0,0 +1,25 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitStagedContainers(t *testing.T) {
+	containers := []KraneContainer{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+
+	staged, others := splitStagedContainers(containers, []string{"b", "removed"})
+	assert.Equal(t, []KraneContainer{{ID: "b"}}, staged)
+	assert.Equal(t, []KraneContainer{{ID: "a"}, {ID: "c"}}, others)
+
+	staged, others = splitStagedContainers(containers, nil)
+	assert.Empty(t, staged)
+	assert.Len(t, others, 3)
+}
+
+func TestGetStagedRun(t *testing.T) {
+	run, err := GetStagedRun("unstaged-app")
+	assert.Nil(t, err)
+	assert.Nil(t, run)
+}
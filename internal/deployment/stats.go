@@ -0,0 +1,68 @@
+package deployment
+
+import (
+	"math"
+	"sort"
+
+	"github.com/krane/krane/internal/job"
+)
+
+// DeployStats summarizes the outcome and duration of the run jobs for a deployment
+type DeployStats struct {
+	Deployment    string  `json:"deployment"`
+	Total         int     `json:"total"`
+	Succeeded     int     `json:"succeeded"`
+	Failed        int     `json:"failed"`
+	SuccessRate   float64 `json:"success_rate"`    // ratio of succeeded deploys between 0 and 1
+	P50DurationMs int64   `json:"p50_duration_ms"` // median deploy duration
+	P95DurationMs int64   `json:"p95_duration_ms"`
+}
+
+// GetDeployStats returns deploy statistics for a deployment computed from its run jobs within a time range
+func GetDeployStats(deployment string, daysAgo uint) (DeployStats, error) {
+	jobs, err := GetJobsByDeployment(deployment, daysAgo)
+	if err != nil {
+		return DeployStats{}, err
+	}
+	return deployStats(deployment, jobs), nil
+}
+
+func deployStats(deployment string, jobs []job.Job) DeployStats {
+	stats := DeployStats{Deployment: deployment}
+
+	durations := make([]int64, 0)
+	for _, j := range jobs {
+		if j.Type != string(RunDeploymentJobType) || j.State != job.Completed {
+			continue
+		}
+
+		stats.Total++
+		if j.Succeeded() {
+			stats.Succeeded++
+		} else {
+			stats.Failed++
+		}
+
+		// job start and end times are recorded in seconds
+		durations = append(durations, (j.EndTime-j.StartTime)*1000)
+	}
+
+	if stats.Total == 0 {
+		return stats
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	stats.SuccessRate = float64(stats.Succeeded) / float64(stats.Total)
+	stats.P50DurationMs = percentile(durations, 50)
+	stats.P95DurationMs = percentile(durations, 95)
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []int64, p float64) int64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
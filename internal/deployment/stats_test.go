@@ -0,0 +1,49 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/job"
+)
+
+func runJob(seconds int64, failed bool) job.Job {
+	j := job.Job{
+		Type:      string(RunDeploymentJobType),
+		State:     job.Completed,
+		StartTime: 100,
+		EndTime:   100 + seconds,
+		Status:    job.Status{ExecutionCount: 1},
+	}
+	if failed {
+		j.Status.FailureCount = 1
+	}
+	return j
+}
+
+func TestDeployStats(t *testing.T) {
+	jobs := []job.Job{
+		runJob(1, false),
+		runJob(2, false),
+		runJob(3, true),
+		runJob(10, false),
+		{Type: string(StopContainersJobType), State: job.Completed, Status: job.Status{ExecutionCount: 1}},
+		{Type: string(RunDeploymentJobType), State: job.Started},
+	}
+
+	stats := deployStats("app", jobs)
+	assert.Equal(t, "app", stats.Deployment)
+	assert.Equal(t, 4, stats.Total)
+	assert.Equal(t, 3, stats.Succeeded)
+	assert.Equal(t, 1, stats.Failed)
+	assert.Equal(t, 0.75, stats.SuccessRate)
+	assert.Equal(t, int64(2000), stats.P50DurationMs)
+	assert.Equal(t, int64(10000), stats.P95DurationMs)
+}
+
+func TestDeployStatsNoJobs(t *testing.T) {
+	stats := deployStats("app", []job.Job{})
+	assert.Equal(t, 0, stats.Total)
+	assert.Equal(t, float64(0), stats.SuccessRate)
+}
@@ -0,0 +1,104 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/logger"
+)
+
+// NoHealthCheck is the health of a container whose image has no docker health check
+const NoHealthCheck = "none"
+
+// DeploymentStatus is the runtime status of a deployment assembled from its docker containers
+type DeploymentStatus struct {
+	Deployment string            `json:"deployment"`
+	Orphaned   bool              `json:"orphaned"`         // the containers exist but the deployment configuration was deleted
+	Health     *Health           `json:"health,omitempty"` // not set for orphaned deployments, the expected scale is unknown
+	Containers []ContainerReport `json:"containers"`
+}
+
+// ContainerReport is the runtime status of a single deployment container
+type ContainerReport struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	State         string `json:"state"`  // docker status (ie. running, exited)
+	Health        string `json:"health"` // docker health check status (ie. healthy, unhealthy, starting) or none
+	UptimeSeconds int64  `json:"uptime_seconds"`
+	Restarts      int    `json:"restarts"`
+	Image         string `json:"image"`
+	ImageDigest   string `json:"image_digest"` // repository digest of the running image, empty for images not pulled from a registry
+}
+
+// GetStatus returns the runtime status of a deployment and its containers. Containers left
+// behind by a deleted deployment are reported as orphaned, an error is returned if a deployment
+// has neither a configuration nor containers.
+func GetStatus(deployment string) (DeploymentStatus, error) {
+	containers, err := GetContainersByDeployment(deployment)
+	if err != nil {
+		return DeploymentStatus{}, fmt.Errorf("unable to get containers of deployment %s, %w", deployment, err)
+	}
+
+	config, err := GetDeploymentConfig(deployment)
+	exists := err == nil && !config.Empty()
+	if !exists && len(containers) == 0 {
+		return DeploymentStatus{}, fmt.Errorf("deployment %s does not exist", deployment)
+	}
+
+	status := deploymentStatus(deployment, containers, time.Now())
+	if exists {
+		health := Deployment{Config: config, Containers: containers}.GetHealth()
+		status.Health = &health
+	} else {
+		status.Orphaned = true
+	}
+
+	// containers of a deployment usually share an image, it is only inspected once
+	ctx := context.Background()
+	digests := make(map[string]string)
+	for i, c := range containers {
+		digest, ok := digests[c.ImageID]
+		if !ok {
+			repoDigests, err := docker.GetClient().GetImageDigests(ctx, c.ImageID)
+			if err != nil {
+				logger.Warnf("unable to get the digest of image %s, %v", c.Image, err)
+			}
+			digest = findImageDigest(repoDigests)
+			digests[c.ImageID] = digest
+		}
+		status.Containers[i].ImageDigest = digest
+	}
+
+	return status, nil
+}
+
+// deploymentStatus returns the status of deployment containers at a point in time
+func deploymentStatus(deployment string, containers []KraneContainer, now time.Time) DeploymentStatus {
+	reports := make([]ContainerReport, 0, len(containers))
+	for _, c := range containers {
+		health := NoHealthCheck
+		if c.State.Health != nil {
+			health = c.State.Health.Status
+		}
+
+		// stopped containers have no uptime
+		uptime := int64(0)
+		if startedAt, err := time.Parse(time.RFC3339Nano, c.State.StartedAt); err == nil && c.State.Running {
+			uptime = int64(now.Sub(startedAt).Seconds())
+		}
+
+		reports = append(reports, ContainerReport{
+			ID:            c.ID,
+			Name:          c.Name,
+			State:         c.State.Status,
+			Health:        health,
+			UptimeSeconds: uptime,
+			Restarts:      c.Restarts,
+			Image:         c.Image,
+		})
+	}
+
+	return DeploymentStatus{Deployment: deployment, Containers: reports}
+}
@@ -0,0 +1,44 @@
+package deployment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeploymentStatus(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	running := KraneContainer{
+		ID:       "a",
+		Name:     "app-a",
+		Image:    "nginx:1.19",
+		Restarts: 2,
+		State: ContainerState{
+			Status:    "running",
+			Running:   true,
+			StartedAt: "2020-06-01T11:30:00.123456789Z",
+			Health:    &types.Health{Status: "healthy"},
+		},
+	}
+	exited := KraneContainer{
+		ID:    "b",
+		Name:  "app-b",
+		Image: "nginx:1.19",
+		State: ContainerState{Status: "exited", StartedAt: "2020-06-01T11:00:00Z", ExitCode: 1},
+	}
+
+	status := deploymentStatus("app", []KraneContainer{running, exited}, now)
+	assert.Equal(t, "app", status.Deployment)
+	assert.Len(t, status.Containers, 2)
+
+	assert.Equal(t, "running", status.Containers[0].State)
+	assert.Equal(t, "healthy", status.Containers[0].Health)
+	assert.Equal(t, int64(1799), status.Containers[0].UptimeSeconds)
+	assert.Equal(t, 2, status.Containers[0].Restarts)
+
+	assert.Equal(t, "exited", status.Containers[1].State)
+	assert.Equal(t, NoHealthCheck, status.Containers[1].Health)
+	assert.Equal(t, int64(0), status.Containers[1].UptimeSeconds)
+}
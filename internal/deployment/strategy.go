@@ -0,0 +1,196 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/logger"
+)
+
+// Deploy strategies replacing the containers of a deployment
+const (
+	RecreateStrategy  = "recreate"   // new containers join the proxy load balancer as soon as they start, the previous containers are removed once the new ones are healthy
+	BlueGreenStrategy = "blue-green" // new containers only receive traffic once they are all healthy and the previous containers are removed
+)
+
+// blueGreenPriorityBase is the router priority of the first blue-green deploy, every later deploy gets a lower priority
+const blueGreenPriorityBase = 1 << 30
+
+// blueGreenPriorityEpoch is the unix time blue-green router priorities are counted down from
+const blueGreenPriorityEpoch = 1600000000
+
+// ContainerColorLabel is the label identifying the color (blue or green) of the containers created by a blue-green deploy
+const ContainerColorLabel = "krane.deployment.color"
+
+// Colors alternated between blue-green deploys
+const (
+	Blue  = "blue"
+	Green = "green"
+)
+
+// strategyFieldErrors returns a validation error if the deploy strategy is unknown or cannot be used by the deployment
+func (config Config) strategyFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+
+	switch config.Strategy {
+	case "", RecreateStrategy:
+		return errs
+	case BlueGreenStrategy:
+	default:
+		return append(errs, newFieldError("strategy", "unknown strategy %s, expected %s or %s", config.Strategy, RecreateStrategy, BlueGreenStrategy))
+	}
+
+	// the blue and green containers run side by side, they cannot bind the same host port
+	for _, p := range config.portMappings() {
+		if p.HostPort != "" {
+			errs = append(errs, newFieldError("strategy", "the %s strategy cannot bind the fixed host port %s, blue and green containers run side by side", BlueGreenStrategy, p.HostPort))
+		}
+	}
+
+	return errs
+}
+
+// withBlueGreenStage returns a copy of a blue-green deployment config labeled with the color opposite to the
+// current containers. Deployments using another strategy are returned unchanged.
+func (config Config) withBlueGreenStage(current []KraneContainer) Config {
+	if config.Strategy != BlueGreenStrategy {
+		return config
+	}
+
+	// labels are copied so the color doesn't leak into the saved configuration
+	labels := make(map[string]string, len(config.Labels)+1)
+	for k, v := range config.Labels {
+		labels[k] = v
+	}
+	labels[ContainerColorLabel] = nextColor(current)
+
+	config.Labels = labels
+	return config
+}
+
+// color returns the color of the containers created by a blue-green deploy, empty outside blue-green deploys
+func (config Config) color() string {
+	return config.Labels[ContainerColorLabel]
+}
+
+// nextColor returns the color of the next blue-green deploy, the color opposite to the current containers
+func nextColor(current []KraneContainer) string {
+	for _, c := range current {
+		if c.Labels[ContainerColorLabel] == Blue {
+			return Green
+		}
+		if c.Labels[ContainerColorLabel] == Green {
+			return Blue
+		}
+	}
+	return Blue
+}
+
+// routerName returns the name of the proxy routers and services of the deployment containers. Blue-green
+// containers use routers of their color so they are not merged with the routers of the previous containers.
+func (config Config) routerName() string {
+	if config.color() == "" {
+		return config.Name
+	}
+	return fmt.Sprintf("%s-%s", config.Name, config.color())
+}
+
+// blueGreenLabels returns the router priority labels of blue-green containers. The priority decreases with the
+// deploy time so the routers of the previous containers sharing the same host rules take precedence over the routers
+// of the new containers: production traffic stays on the previous containers until they are removed, once every new
+// container is healthy, and then moves to the new containers at once.
+func (config Config) blueGreenLabels() map[string]string {
+	labels := make(map[string]string, 0)
+	if config.color() == "" {
+		return labels
+	}
+
+	deployedAt, err := time.Parse(time.RFC3339, config.Labels[ContainerDeployedAtLabel])
+	if err != nil {
+		deployedAt = time.Now()
+	}
+
+	priority := strconv.FormatInt(blueGreenPriority(deployedAt), 10)
+	labels[fmt.Sprintf("traefik.http.routers.%s-insecure.priority", config.routerName())] = priority
+	if config.TLSEnabled() {
+		labels[fmt.Sprintf("traefik.http.routers.%s-secure.priority", config.routerName())] = priority
+	}
+	return labels
+}
+
+// blueGreenPriority returns the router priority of blue-green containers deployed at a time, lower for later deploys
+func blueGreenPriority(deployedAt time.Time) int64 {
+	priority := int64(blueGreenPriorityBase) - (deployedAt.Unix() - blueGreenPriorityEpoch)
+	if priority < 1 {
+		return 1
+	}
+	return priority
+}
+
+// verifyBlueGreenImage returns an error if the image of a routed blue-green deployment does not define a docker
+// health check. The traffic switch waits for the docker health check of every new container to pass.
+func verifyBlueGreenImage(ctx context.Context, config Config) error {
+	if config.color() == "" || !config.Routed() {
+		return nil
+	}
+
+	image, err := docker.GetClient().GetImageConfig(ctx, config.ImageRef())
+	if err != nil {
+		return err
+	}
+
+	if !hasDockerHealthCheck(image) {
+		return fmt.Errorf("image %s does not define a docker HEALTHCHECK, required by the %s strategy to keep traffic off containers until they are healthy", config.ImageRef(), BlueGreenStrategy)
+	}
+	return nil
+}
+
+// hasDockerHealthCheck returns whether an image configuration defines an enabled docker health check
+func hasDockerHealthCheck(image *container.Config) bool {
+	if image == nil || image.Healthcheck == nil || len(image.Healthcheck.Test) == 0 {
+		return false
+	}
+	return image.Healthcheck.Test[0] != "NONE"
+}
+
+// switchTraffic waits for every blue-green container to pass its docker health check. The routers of the previous
+// containers take precedence over the routers of the blue-green containers, so production traffic stays on the
+// previous containers meanwhile. Once they are all healthy the previous containers can be removed, the proxy then
+// routes every request to the blue-green containers at once.
+func switchTraffic(ctx context.Context, config Config, containers []KraneContainer, e *EventEmitter) error {
+	if config.color() == "" || !config.Routed() {
+		return nil
+	}
+
+	e.emit(fmt.Sprintf("Switching traffic to the %d %s container(s)", len(containers), config.color()))
+	unhealthy, err := checkContainersHealth(ctx, containers, config.HealthCheck.policy(), KraneContainer.dockerHealthy)
+	if err != nil {
+		return err
+	}
+
+	if len(unhealthy) > 0 {
+		return fmt.Errorf("traffic not switched to %s, %s", config.color(), joinErrors(unhealthy))
+	}
+	logger.Debugf("Deployment %s traffic switched to %s", config.Name, config.color())
+
+	return nil
+}
+
+// dockerHealthy returns whether a container passes its docker health check
+func (c KraneContainer) dockerHealthy(ctx context.Context) (bool, error) {
+	resp, err := docker.GetClient().GetOneContainer(ctx, c.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if resp.State.Health != nil && resp.State.Health.Status == "healthy" {
+		return true, nil
+	}
+
+	return false, fmt.Errorf("container %s is not healthy yet", c.ID)
+}
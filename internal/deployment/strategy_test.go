@@ -0,0 +1,63 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrategyFieldErrors(t *testing.T) {
+	for _, strategy := range []string{"", RecreateStrategy, BlueGreenStrategy} {
+		assert.Empty(t, Config{Strategy: strategy}.strategyFieldErrors(), strategy)
+	}
+	assert.Len(t, Config{Strategy: "canary"}.strategyFieldErrors(), 1)
+
+	// blue and green containers cannot bind the same fixed host port
+	assert.Empty(t, Config{Strategy: BlueGreenStrategy, Ports: map[string]string{"": "80"}}.strategyFieldErrors())
+	assert.Len(t, Config{Strategy: BlueGreenStrategy, Ports: map[string]string{"8080": "80"}}.strategyFieldErrors(), 1)
+	assert.Empty(t, Config{Ports: map[string]string{"8080": "80"}}.strategyFieldErrors())
+}
+
+func TestNextColor(t *testing.T) {
+	assert.Equal(t, Blue, nextColor(nil))
+	assert.Equal(t, Blue, nextColor([]KraneContainer{{Labels: map[string]string{}}}))
+	assert.Equal(t, Green, nextColor([]KraneContainer{{Labels: map[string]string{ContainerColorLabel: Blue}}}))
+	assert.Equal(t, Blue, nextColor([]KraneContainer{{Labels: map[string]string{ContainerColorLabel: Green}}}))
+}
+
+func TestWithBlueGreenStage(t *testing.T) {
+	current := []KraneContainer{{Labels: map[string]string{ContainerColorLabel: Blue}}}
+
+	recreate := Config{Name: "app"}.withBlueGreenStage(current)
+	assert.Equal(t, "", recreate.color())
+	assert.Equal(t, "app", recreate.routerName())
+
+	saved := Config{Name: "app", Strategy: BlueGreenStrategy, Alias: []string{"app.example.com"}, Labels: map[string]string{}}
+	config := saved.withBlueGreenStage(current)
+	assert.Equal(t, Green, config.color())
+	assert.Equal(t, "app-green", config.routerName())
+	assert.Empty(t, saved.Labels)
+
+	config = config.withDeployLabels(context.Background(), time.Unix(1700000000, 0))
+
+	labels := config.DockerLabels()
+	assert.Equal(t, "Host(`app.example.com`)", labels["traefik.http.routers.app-green-insecure.rule"])
+	assert.Equal(t, "973741824", labels["traefik.http.routers.app-green-insecure.priority"])
+	assert.NotContains(t, labels, "traefik.http.routers.app-insecure.rule")
+}
+
+func TestHasDockerHealthCheck(t *testing.T) {
+	assert.False(t, hasDockerHealthCheck(nil))
+	assert.False(t, hasDockerHealthCheck(&container.Config{}))
+	assert.False(t, hasDockerHealthCheck(&container.Config{Healthcheck: &container.HealthConfig{Test: []string{"NONE"}}}))
+	assert.True(t, hasDockerHealthCheck(&container.Config{Healthcheck: &container.HealthConfig{Test: []string{"CMD", "curl", "-f", "http://localhost"}}}))
+}
+
+func TestBlueGreenPriorityDecreases(t *testing.T) {
+	deployedAt := time.Unix(1700000000, 0)
+	assert.Greater(t, blueGreenPriority(deployedAt), blueGreenPriority(deployedAt.Add(time.Second)))
+	assert.Equal(t, int64(1), blueGreenPriority(time.Unix(1<<40, 0)))
+}
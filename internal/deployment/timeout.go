@@ -0,0 +1,36 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/krane/krane/internal/job"
+)
+
+// DeployTimeoutError is returned when a deployment run exceeds the deploy timeout of the deployment
+type DeployTimeoutError struct {
+	Deployment string
+	Step       string // workflow step in progress when the timeout elapsed, empty if unknown
+	Timeout    time.Duration
+}
+
+// Error returns a string representation of a DeployTimeoutError
+func (e DeployTimeoutError) Error() string {
+	if e.Step == "" {
+		return fmt.Sprintf("deployment %s exceeded the deploy timeout of %s", e.Deployment, e.Timeout)
+	}
+	return fmt.Sprintf("deployment %s exceeded the deploy timeout of %s during the %s step", e.Deployment, e.Timeout, e.Step)
+}
+
+// Permanent returns true since the timeout covers every retry of the run
+func (e DeployTimeoutError) Permanent() bool { return true }
+
+// deployTimeoutError returns the error of a deployment run whose deploy timeout elapsed, naming the step in progress
+func deployTimeoutError(ctx context.Context, config Config) DeployTimeoutError {
+	return DeployTimeoutError{
+		Deployment: config.Name,
+		Step:       job.CurrentStep(ctx),
+		Timeout:    time.Duration(config.DeployTimeout) * time.Second,
+	}
+}
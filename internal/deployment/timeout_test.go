@@ -0,0 +1,28 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/job"
+)
+
+func TestDeployTimeoutError(t *testing.T) {
+	err := DeployTimeoutError{Deployment: "app", Step: "pull_image", Timeout: 5 * time.Minute}
+	assert.Equal(t, "deployment app exceeded the deploy timeout of 5m0s during the pull_image step", err.Error())
+	assert.True(t, err.Permanent())
+
+	err.Step = ""
+	assert.Equal(t, "deployment app exceeded the deploy timeout of 5m0s", err.Error())
+}
+
+func TestDeployTimeoutErrorOutsideJob(t *testing.T) {
+	ctx := context.Background()
+	job.RecordStep(ctx, "health_check")
+
+	err := deployTimeoutError(ctx, Config{Name: "app", DeployTimeout: 30})
+	assert.Equal(t, DeployTimeoutError{Deployment: "app", Timeout: 30 * time.Second}, err)
+}
@@ -0,0 +1,58 @@
+package deployment
+
+import (
+	"os"
+	"regexp"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/proxy"
+)
+
+var certResolverRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// TLS configures how the network proxy serves a deployment over https. It is typically set once in the
+// global defaults (ie. enabled with a host-wide cert resolver) and overridden by the deployments that opt out.
+type TLS struct {
+	Enabled  *bool  `json:"enabled,omitempty"` // serve the deployment over https, when unset the secure property applies
+	Resolver string `json:"resolver"`          // Traefik cert resolver generating the certificates (default CERT_RESOLVER)
+}
+
+// TLSEnabled returns true if the deployment is served over https. An explicit tls.enabled (set on
+// the deployment or inherited from the global defaults) takes precedence over the secure property.
+func (config Config) TLSEnabled() bool {
+	if config.TLS.Enabled != nil {
+		return *config.TLS.Enabled
+	}
+	return config.Secure
+}
+
+// CertResolver returns the Traefik cert resolver generating the certificates of the deployment
+func (config Config) CertResolver() string {
+	if config.TLS.Resolver == "" {
+		return defaultCertResolver()
+	}
+	return config.TLS.Resolver
+}
+
+// defaultCertResolver returns the cert resolver configured for the daemon with CERT_RESOLVER, defaults to lets-encrypt
+func defaultCertResolver() string {
+	if resolver := os.Getenv(constants.EnvCertResolver); certResolverRegex.MatchString(resolver) {
+		return resolver
+	}
+	return proxy.DefaultCertResolver
+}
+
+// tlsFieldErrors returns a validation error if the cert resolver is not a valid Traefik resolver name
+// or if a secure deployment has no alias, certificates are issued for the deployment aliases.
+func (config Config) tlsFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+	if config.TLS.Resolver != "" && !certResolverRegex.MatchString(config.TLS.Resolver) {
+		errs = append(errs, newFieldError("tls", "invalid cert resolver %s, expected letters, numbers, - or _", config.TLS.Resolver))
+	}
+
+	// internal deployments (ie. the network proxy) can be secured before their alias is configured
+	if config.Secure && config.TLSEnabled() && !config.Internal && !config.hasAlias() {
+		errs = append(errs, newFieldError("secure", "secure requires at least one alias to issue a certificate for"))
+	}
+	return errs
+}
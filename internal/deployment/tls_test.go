@@ -0,0 +1,75 @@
+package deployment
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/store"
+)
+
+func TestTLSInheritsGlobalDefaults(t *testing.T) {
+	defer store.Client().Remove(constants.SettingsCollectionName, defaultsKey)
+
+	enabled := true
+	assert.Nil(t, SaveDefaults(Config{TLS: TLS{Enabled: &enabled, Resolver: "host-resolver"}}))
+
+	public := Config{Name: "tls-public", Image: "nginx", Alias: []string{"app.example.com"}, Labels: map[string]string{}}
+	public.applyDefaults()
+	assert.True(t, public.TLSEnabled())
+	assert.Equal(t, "host-resolver", public.CertResolver())
+
+	labels := public.DockerLabels()
+	assert.Equal(t, "host-resolver", labels["traefik.http.routers.tls-public-secure.tls.certresolver"])
+	assert.Contains(t, labels["traefik.http.routers.tls-public-insecure.middlewares"], "redirect-to-https")
+
+	disabled := false
+	internal := Config{Name: "tls-internal", Image: "nginx", TLS: TLS{Enabled: &disabled}, Labels: map[string]string{}}
+	internal.applyDefaults()
+	assert.False(t, internal.TLSEnabled())
+	assert.NotContains(t, internal.DockerLabels(), "traefik.http.routers.tls-internal-secure.tls")
+
+	custom := Config{Name: "tls-custom", Image: "nginx", TLS: TLS{Resolver: "custom"}}
+	custom.applyDefaults()
+	assert.True(t, custom.TLSEnabled())
+	assert.Equal(t, "custom", custom.CertResolver())
+}
+
+func TestTLSFallsBackToSecure(t *testing.T) {
+	assert.False(t, Config{}.TLSEnabled())
+	assert.True(t, Config{Secure: true}.TLSEnabled())
+	assert.Equal(t, "lets-encrypt", Config{Secure: true}.CertResolver())
+
+	disabled := false
+	assert.False(t, Config{Secure: true, TLS: TLS{Enabled: &disabled}}.TLSEnabled())
+}
+
+func TestTLSConfig(t *testing.T) {
+	assert.Empty(t, Config{TLS: TLS{Resolver: "lets-encrypt"}}.tlsFieldErrors())
+	assert.Len(t, Config{TLS: TLS{Resolver: "lets encrypt"}}.tlsFieldErrors(), 1)
+}
+
+func TestSecureRequiresAlias(t *testing.T) {
+	assert.Empty(t, Config{Secure: true, Alias: []string{"app.example.com"}}.tlsFieldErrors())
+	assert.Len(t, Config{Secure: true}.tlsFieldErrors(), 1)
+	assert.Len(t, Config{Secure: true, Alias: []string{""}}.tlsFieldErrors(), 1)
+	assert.Empty(t, Config{Secure: true, Internal: true}.tlsFieldErrors())
+
+	disabled := false
+	assert.Empty(t, Config{Secure: true, TLS: TLS{Enabled: &disabled}}.tlsFieldErrors())
+}
+
+func TestDaemonCertResolver(t *testing.T) {
+	defer os.Unsetenv(constants.EnvCertResolver)
+	os.Setenv(constants.EnvCertResolver, "acme-dns")
+
+	secure := Config{Name: "tls-daemon", Secure: true, Alias: []string{"app.example.com"}, Labels: map[string]string{}}
+	assert.Equal(t, "acme-dns", secure.CertResolver())
+	assert.Equal(t, "acme-dns", secure.DockerLabels()["traefik.http.routers.tls-daemon-secure.tls.certresolver"])
+	assert.Equal(t, "custom", Config{TLS: TLS{Resolver: "custom"}}.CertResolver())
+
+	config := proxyInstallConfig(ProxyInstallOptions{LetsEncryptEmail: "email@example.com"})
+	assert.Equal(t, "email@example.com", config.Env["TRAEFIK_CERTIFICATESRESOLVERS_ACME-DNS_ACME_EMAIL"])
+}
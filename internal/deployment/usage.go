@@ -0,0 +1,198 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/logger"
+)
+
+// UsageStreamInterval is the time between two samples pushed to a client streaming the resource usage of a deployment
+const UsageStreamInterval = 5 * time.Second
+
+// ResourceUsage is the cpu, memory and network usage of a deployment summed across its running containers
+type ResourceUsage struct {
+	Deployment       string           `json:"deployment"`
+	CPUPercent       float64          `json:"cpu_percent"` // 100 is one full cpu, it may exceed 100 on hosts with several cpus
+	MemoryUsageBytes uint64           `json:"memory_usage_bytes"`
+	MemoryLimitBytes uint64           `json:"memory_limit_bytes"`
+	MemoryPercent    float64          `json:"memory_percent"`
+	NetworkRxBytes   uint64           `json:"network_rx_bytes"` // bytes received since the containers started
+	NetworkTxBytes   uint64           `json:"network_tx_bytes"` // bytes sent since the containers started
+	Containers       []ContainerUsage `json:"containers"`
+}
+
+// ContainerUsage is the cpu, memory and network usage of a single container
+type ContainerUsage struct {
+	ID               string  `json:"id"`
+	Name             string  `json:"name"`
+	CPUPercent       float64 `json:"cpu_percent"`
+	MemoryUsageBytes uint64  `json:"memory_usage_bytes"` // memory used without the page cache
+	MemoryLimitBytes uint64  `json:"memory_limit_bytes"`
+	NetworkRxBytes   uint64  `json:"network_rx_bytes"`
+	NetworkTxBytes   uint64  `json:"network_tx_bytes"`
+}
+
+// GetResourceUsage returns the resource usage of a deployment from a single stats sample of each of its running containers.
+// Containers removed while being sampled are left out.
+func GetResourceUsage(ctx context.Context, deployment string) (ResourceUsage, error) {
+	containers, err := listContainers(ctx, map[string]string{docker.ContainerDeploymentLabel: deployment})
+	if err != nil {
+		return ResourceUsage{}, fmt.Errorf("unable to get containers of deployment %s, %w", deployment, err)
+	}
+
+	running := make([]KraneContainer, 0)
+	for _, c := range containers {
+		if c.State.Running {
+			running = append(running, c)
+		}
+	}
+
+	// docker waits for two cpu cycles before returning a sample, containers are sampled at the same time
+	samples := make([]*ContainerUsage, len(running))
+	var wg sync.WaitGroup
+	for i, c := range running {
+		wg.Add(1)
+		go func(i int, c KraneContainer) {
+			defer wg.Done()
+			usage, err := sampleContainerUsage(ctx, c)
+			if err != nil {
+				logger.Warnf("unable to get the stats of container %s, %v", c.Name, err)
+				return
+			}
+			samples[i] = &usage
+		}(i, c)
+	}
+	wg.Wait()
+
+	usages := make([]ContainerUsage, 0, len(samples))
+	for _, sample := range samples {
+		if sample != nil {
+			usages = append(usages, *sample)
+		}
+	}
+
+	return resourceUsage(deployment, usages), nil
+}
+
+// StreamResourceUsage sends the resource usage of a deployment to a client every interval until the context is done
+// or the client fails to receive it. Stats readers opened with docker are closed once the context is done.
+func StreamResourceUsage(ctx context.Context, deployment string, interval time.Duration, send func(ResourceUsage) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		usage, err := GetResourceUsage(ctx, deployment)
+		if err != nil {
+			return err
+		}
+
+		// a sample taken while the client was leaving is not sent
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err := send(usage); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// sampleContainerUsage reads a single stats sample of a container
+func sampleContainerUsage(ctx context.Context, c KraneContainer) (ContainerUsage, error) {
+	stats, err := docker.GetClient().GetContainerStatus(ctx, c.ID, false)
+	if err != nil {
+		return ContainerUsage{}, err
+	}
+	defer stats.Body.Close()
+
+	body, err := ioutil.ReadAll(stats.Body)
+	if err != nil {
+		return ContainerUsage{}, err
+	}
+
+	var sample types.StatsJSON
+	if err := json.Unmarshal(body, &sample); err != nil {
+		return ContainerUsage{}, err
+	}
+
+	// the docker sdk does not decode online_cpus, it is read separately
+	var online onlineCPUsSample
+	_ = json.Unmarshal(body, &online)
+
+	return containerUsage(c, sample, online.CPUStats.OnlineCPUs), nil
+}
+
+// onlineCPUsSample is the number of cpus available to a container in a docker stats sample
+type onlineCPUsSample struct {
+	CPUStats struct {
+		OnlineCPUs uint32 `json:"online_cpus"`
+	} `json:"cpu_stats"`
+}
+
+// containerUsage computes the usage of a container from a docker stats sample, the cpu usage is the share of the
+// host cpu time used by the container between the sample and the previous one. Docker does not report the per cpu
+// usage on cgroup v2 hosts, the cpu count is the online cpus and falls back to the per cpu usage with cgroup v1.
+func containerUsage(c KraneContainer, sample types.StatsJSON, onlineCPUs uint32) ContainerUsage {
+	usage := ContainerUsage{
+		ID:               c.ID,
+		Name:             c.Name,
+		MemoryLimitBytes: sample.MemoryStats.Limit,
+	}
+
+	cpuDelta := float64(sample.CPUStats.CPUUsage.TotalUsage) - float64(sample.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(sample.CPUStats.SystemUsage) - float64(sample.PreCPUStats.SystemUsage)
+	cpus := float64(onlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(sample.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 && cpus > 0 {
+		usage.CPUPercent = cpuDelta / systemDelta * cpus * 100
+	}
+
+	// the page cache is reclaimable, it is reported as cache with cgroup v1 and inactive_file with cgroup v2
+	cache, ok := sample.MemoryStats.Stats["cache"]
+	if !ok {
+		cache = sample.MemoryStats.Stats["inactive_file"]
+	}
+	if sample.MemoryStats.Usage > cache {
+		usage.MemoryUsageBytes = sample.MemoryStats.Usage - cache
+	}
+
+	for _, network := range sample.Networks {
+		usage.NetworkRxBytes += network.RxBytes
+		usage.NetworkTxBytes += network.TxBytes
+	}
+
+	return usage
+}
+
+// resourceUsage sums the usage of the containers of a deployment
+func resourceUsage(deployment string, containers []ContainerUsage) ResourceUsage {
+	usage := ResourceUsage{Deployment: deployment, Containers: containers}
+	for _, c := range containers {
+		usage.CPUPercent += c.CPUPercent
+		usage.MemoryUsageBytes += c.MemoryUsageBytes
+		usage.MemoryLimitBytes += c.MemoryLimitBytes
+		usage.NetworkRxBytes += c.NetworkRxBytes
+		usage.NetworkTxBytes += c.NetworkTxBytes
+	}
+
+	if usage.MemoryLimitBytes > 0 {
+		usage.MemoryPercent = float64(usage.MemoryUsageBytes) / float64(usage.MemoryLimitBytes) * 100
+	}
+	return usage
+}
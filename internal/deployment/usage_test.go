@@ -0,0 +1,58 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerUsage(t *testing.T) {
+	var sample types.StatsJSON
+	sample.CPUStats.CPUUsage.TotalUsage = 3000
+	sample.CPUStats.CPUUsage.PercpuUsage = []uint64{1500, 1500}
+	sample.CPUStats.SystemUsage = 20000
+	sample.PreCPUStats.CPUUsage.TotalUsage = 1000
+	sample.PreCPUStats.SystemUsage = 10000
+	sample.MemoryStats.Usage = 300
+	sample.MemoryStats.Limit = 1000
+	sample.MemoryStats.Stats = map[string]uint64{"cache": 100}
+	sample.Networks = map[string]types.NetworkStats{
+		"eth0": {RxBytes: 10, TxBytes: 20},
+		"eth1": {RxBytes: 1, TxBytes: 2},
+	}
+
+	usage := containerUsage(KraneContainer{ID: "a", Name: "app-a"}, sample, 0)
+	assert.Equal(t, "app-a", usage.Name)
+	assert.InDelta(t, 40.0, usage.CPUPercent, 0.001)
+	assert.Equal(t, uint64(200), usage.MemoryUsageBytes)
+	assert.Equal(t, uint64(1000), usage.MemoryLimitBytes)
+	assert.Equal(t, uint64(11), usage.NetworkRxBytes)
+	assert.Equal(t, uint64(22), usage.NetworkTxBytes)
+
+	// a first sample has no previous cpu usage to compare with
+	var first types.StatsJSON
+	first.CPUStats.CPUUsage.TotalUsage = 3000
+	assert.Equal(t, 0.0, containerUsage(KraneContainer{}, first, 0).CPUPercent)
+
+	// docker leaves the per cpu usage empty on cgroup v2 hosts, the online cpus are used instead
+	sample.CPUStats.CPUUsage.PercpuUsage = nil
+	assert.Equal(t, 0.0, containerUsage(KraneContainer{}, sample, 0).CPUPercent)
+	assert.InDelta(t, 80.0, containerUsage(KraneContainer{}, sample, 4).CPUPercent, 0.001)
+}
+
+func TestResourceUsage(t *testing.T) {
+	usage := resourceUsage("app", []ContainerUsage{
+		{CPUPercent: 40, MemoryUsageBytes: 200, MemoryLimitBytes: 1000, NetworkRxBytes: 1, NetworkTxBytes: 2},
+		{CPUPercent: 10.5, MemoryUsageBytes: 300, MemoryLimitBytes: 1000, NetworkRxBytes: 3, NetworkTxBytes: 4},
+	})
+
+	assert.Equal(t, 50.5, usage.CPUPercent)
+	assert.Equal(t, uint64(500), usage.MemoryUsageBytes)
+	assert.Equal(t, uint64(2000), usage.MemoryLimitBytes)
+	assert.Equal(t, 25.0, usage.MemoryPercent)
+	assert.Equal(t, uint64(4), usage.NetworkRxBytes)
+	assert.Equal(t, uint64(6), usage.NetworkTxBytes)
+
+	assert.Equal(t, 0.0, resourceUsage("idle", []ContainerUsage{}).MemoryPercent)
+}
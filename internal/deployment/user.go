@@ -0,0 +1,44 @@
+package deployment
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// groupNameRegex matches valid unix user and group names
+var groupNameRegex = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+
+// userFieldErrors returns a validation error if the user or a supplementary group is not a valid name or id
+func (config Config) userFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+
+	if config.User != "" {
+		parts := strings.Split(config.User, ":")
+		if len(parts) > 2 || !validUserOrGroup(parts[0]) || (len(parts) == 2 && !validUserOrGroup(parts[1])) {
+			errs = append(errs, newFieldError("user", "invalid user %s, expected a name or uid optionally followed by :group or :gid", config.User))
+		}
+	}
+
+	seen := make(map[string]bool, len(config.GroupAdd))
+	for _, group := range config.GroupAdd {
+		if !validUserOrGroup(group) {
+			errs = append(errs, newFieldError("group_add", "invalid group %s, expected a group name or gid", group))
+			continue
+		}
+		if seen[group] {
+			errs = append(errs, newFieldError("group_add", "group %s is added more than once", group))
+		}
+		seen[group] = true
+	}
+
+	return errs
+}
+
+// validUserOrGroup returns true if a value is a unix user or group name, or a numeric id
+func validUserOrGroup(value string) bool {
+	if id, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return id < 1<<32-1 // the max id is reserved as an invalid id
+	}
+	return groupNameRegex.MatchString(value)
+}
@@ -0,0 +1,27 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserConfig(t *testing.T) {
+	assert.Empty(t, Config{}.userFieldErrors())
+	assert.Empty(t, Config{User: "1000"}.userFieldErrors())
+	assert.Empty(t, Config{User: "1000:1000"}.userFieldErrors())
+	assert.Empty(t, Config{User: "app:www-data"}.userFieldErrors())
+
+	assert.Len(t, Config{User: "1000:1000:1000"}.userFieldErrors(), 1)
+	assert.Len(t, Config{User: ":1000"}.userFieldErrors(), 1)
+	assert.Len(t, Config{User: "App User"}.userFieldErrors(), 1)
+}
+
+func TestGroupAddConfig(t *testing.T) {
+	assert.Empty(t, Config{GroupAdd: []string{"1001", "docker", "_shared"}}.userFieldErrors())
+
+	assert.Len(t, Config{GroupAdd: []string{"-1"}}.userFieldErrors(), 1)
+	assert.Len(t, Config{GroupAdd: []string{"4294967295"}}.userFieldErrors(), 1)
+	assert.Len(t, Config{GroupAdd: []string{"Shared Group"}}.userFieldErrors(), 1)
+	assert.Len(t, Config{GroupAdd: []string{"1001", "1001"}}.userFieldErrors(), 1)
+}
@@ -0,0 +1,111 @@
+package deployment
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError is a validation error for a field in a deployment config
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error returns the validation error message
+func (e FieldError) Error() string { return e.Message }
+
+func newFieldError(field string, format string, args ...interface{}) FieldError {
+	return FieldError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// ValidationReport is the result of validating a deployment config
+type ValidationReport struct {
+	Valid    bool         `json:"valid"`
+	Errors   []FieldError `json:"errors"`
+	Warnings []string     `json:"warnings"`
+	Lint     []LintResult `json:"lint"` // best practice warnings, they don't affect whether the config is valid
+}
+
+// Validate runs every validation for a deployment config without saving or running it.
+// Besides the config itself, host ports and aliases are checked against other deployments and
+// referenced secrets are checked to exist. The report also includes the lint warnings for the config.
+func Validate(config Config) ValidationReport {
+	config.applyDefaults()
+
+	errs := config.fieldErrors()
+	errs = append(errs, config.portConflicts()...)
+	errs = append(errs, config.aliasConflicts("")...)
+	errs = append(errs, config.missingSecrets()...)
+
+	warnings := make([]string, 0)
+	if Exist(config.Name) {
+		warnings = append(warnings, fmt.Sprintf("deployment %s already exists and will be updated", config.Name))
+	}
+
+	return ValidationReport{
+		Valid:    len(errs) == 0,
+		Errors:   errs,
+		Warnings: warnings,
+		Lint:     config.lint(imageUser),
+	}
+}
+
+// portConflicts returns an error for every host port already bound by another deployment
+func (config Config) portConflicts() []FieldError {
+	errs := make([]FieldError, 0)
+
+	configs, err := GetAllDeploymentConfigs()
+	if err != nil {
+		return errs
+	}
+
+	for _, other := range configs {
+		if other.Name == config.Name {
+			continue
+		}
+
+		bound := make(map[string]bool)
+		for _, p := range other.portMappings() {
+			if p.HostPort != "" {
+				bound[p.hostBinding()] = true
+			}
+		}
+
+		for _, p := range config.portMappings() {
+			if p.HostPort != "" && bound[p.hostBinding()] {
+				errs = append(errs, newFieldError("ports", "host port %s is already bound by deployment %s", p.hostBinding(), other.Name))
+			}
+		}
+	}
+
+	return errs
+}
+
+// missingSecrets returns an error for every secret referenced by a config that does not exist
+func (config Config) missingSecrets() []FieldError {
+	errs := make([]FieldError, 0)
+
+	for key := range config.Secrets {
+		if _, err := GetSecret(config.Name, key); err != nil {
+			errs = append(errs, newFieldError(fmt.Sprintf("secrets.%s", key), "secret %s not found for deployment %s", key, config.Name))
+		}
+	}
+
+	registry := map[string]string{
+		"registry.url":      config.Registry.URL,
+		"registry.username": config.Registry.Username,
+		"registry.password": config.Registry.Password,
+		"registry.token":    config.Registry.Token,
+	}
+	for field, value := range registry {
+		if !strings.HasPrefix(value, "@") {
+			continue
+		}
+
+		if _, err := GetSecret(config.Name, strings.Trim(value, "@")); err != nil {
+			errs = append(errs, newFieldError(field, "secret %s not found for deployment %s", value, config.Name))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,35 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateReportsFieldErrors(t *testing.T) {
+	report := Validate(Config{Name: "$invalid"})
+	assert.False(t, report.Valid)
+	assert.Equal(t, "name", report.Errors[0].Field)
+	assert.Equal(t, "image", report.Errors[1].Field)
+}
+
+func TestValidateReportsPortConflicts(t *testing.T) {
+	assert.Nil(t, SaveConfig(Config{Name: "validate-ports", Image: "nginx", Ports: map[string]string{"8080": "80"}}))
+	defer DeleteConfig("validate-ports")
+
+	report := Validate(Config{Name: "validate-conflict", Image: "nginx", Ports: map[string]string{"8080": "80"}})
+	assert.False(t, report.Valid)
+	assert.Equal(t, "ports", report.Errors[0].Field)
+}
+
+func TestValidateReportsMissingSecrets(t *testing.T) {
+	report := Validate(Config{Name: "validate-secrets", Image: "nginx", Secrets: map[string]string{"TOKEN": "@TOKEN"}})
+	assert.False(t, report.Valid)
+	assert.Equal(t, "secrets.TOKEN", report.Errors[0].Field)
+}
+
+func TestValidateDoesNotPersist(t *testing.T) {
+	report := Validate(Config{Name: "validate-only", Image: "nginx"})
+	assert.True(t, report.Valid)
+	assert.False(t, Exist("validate-only"))
+}
@@ -0,0 +1,236 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/proxy"
+)
+
+// ContainerVariantLabel is the label identifying the variant a container was created for
+const ContainerVariantLabel = "krane.deployment.variant"
+
+// Variant is a named image running under a deployment. Traffic is split between variants based on their
+// weight, which determines the share of the deployment's containers created for each variant. Every variant
+// has its own proxy service, the deployment routers send requests to a weighted service splitting the
+// traffic between the variant services by weight.
+type Variant struct {
+	Name   string `json:"name" binding:"required"`  // variant name
+	Image  string `json:"image" binding:"required"` // container image for the variant
+	Tag    string `json:"tag"`                      // container image tag (default latest)
+	Weight uint   `json:"weight"`                   // relative share of traffic for the variant
+}
+
+var variantNameRegex = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// variantFieldErrors returns a validation error for every invalid variant
+func (config Config) variantFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+	if len(config.Variants) == 0 {
+		return errs
+	}
+
+	names := make(map[string]bool)
+	var totalWeight uint
+	weighted := 0
+	for i, v := range config.Variants {
+		field := fmt.Sprintf("variants[%d]", i)
+		if !variantNameRegex.MatchString(v.Name) {
+			errs = append(errs, newFieldError(field, "invalid variant name %s", v.Name))
+		}
+		if names[v.Name] {
+			errs = append(errs, newFieldError(field, "duplicate variant name %s", v.Name))
+		}
+		if v.Image == "" {
+			errs = append(errs, newFieldError(field, "image required for variant %s", v.Name))
+		}
+		names[v.Name] = true
+		totalWeight += v.Weight
+		if v.Weight > 0 {
+			weighted++
+		}
+	}
+
+	if totalWeight == 0 {
+		errs = append(errs, newFieldError("variants", "at least one variant must have a weight greater than 0"))
+	}
+
+	// every variant with a weight runs at least one container
+	if config.Scale < weighted {
+		errs = append(errs, newFieldError("variants", "scale %d is lower than the %d variants with a weight, every variant needs a container", config.Scale, weighted))
+	}
+
+	return errs
+}
+
+// variantConfigs returns a deployment config for every variant with a weight, except the excluded variants (ie. variants
+// that failed their health check). Each config runs the variant image with a scale proportional to the variant weight
+// and carries the weights of the variants the proxy splits the traffic between.
+func (config Config) variantConfigs(excluded map[string]bool) []Config {
+	replicas := variantReplicas(config.Scale, config.Variants)
+
+	weights := make([]proxy.WeightedService, 0)
+	for i, v := range config.Variants {
+		if replicas[i] > 0 && !excluded[v.Name] {
+			weights = append(weights, proxy.WeightedService{Name: config.variantServiceName(v.Name), Weight: v.Weight})
+		}
+	}
+
+	configs := make([]Config, 0)
+	for i, v := range config.Variants {
+		if replicas[i] == 0 || excluded[v.Name] {
+			continue
+		}
+
+		vc := config
+		vc.Image = v.Image
+		vc.Tag = v.Tag
+		vc.Digest = ""
+		if vc.Tag == "" {
+			vc.Tag = "latest"
+		}
+		vc.Scale = replicas[i]
+		vc.Variants = nil
+		vc.variantWeights = weights
+
+		// labels are copied so variant labels don't leak between variants
+		vc.Labels = make(map[string]string, len(config.Labels)+1)
+		for k, val := range config.Labels {
+			vc.Labels[k] = val
+		}
+		vc.Labels[ContainerVariantLabel] = v.Name
+
+		configs = append(configs, vc)
+	}
+
+	return configs
+}
+
+// serviceName returns the name of the proxy service of the deployment containers, the containers of a variant
+// have a service of their own (ie. my-app-canary) the routers reach through a weighted service
+func (config Config) serviceName() string {
+	if variant := config.Labels[ContainerVariantLabel]; variant != "" {
+		return config.variantServiceName(variant)
+	}
+	return config.routerName()
+}
+
+// variantServiceName returns the name of the proxy service of a variant
+func (config Config) variantServiceName(variant string) string {
+	return fmt.Sprintf("%s-%s", config.routerName(), variant)
+}
+
+// variantReplicas splits a scale between variants proportionally to their weight using the largest remainder
+// method, the total number of containers is always the scale. Every variant with a weight gets at least one
+// container, taken from the variant with the most containers over its share, when the scale allows it.
+func variantReplicas(scale int, variants []Variant) []int {
+	replicas := make([]int, len(variants))
+
+	var totalWeight uint
+	for _, v := range variants {
+		totalWeight += v.Weight
+	}
+	if totalWeight == 0 {
+		return replicas
+	}
+
+	assigned := 0
+	shares := make([]float64, len(variants))
+	remainders := make([]float64, len(variants))
+	for i, v := range variants {
+		shares[i] = float64(scale) * float64(v.Weight) / float64(totalWeight)
+		replicas[i] = int(shares[i])
+		remainders[i] = shares[i] - float64(replicas[i])
+		assigned += replicas[i]
+	}
+
+	for ; assigned < scale; assigned++ {
+		largest := 0
+		for i := range remainders {
+			if remainders[i] > remainders[largest] {
+				largest = i
+			}
+		}
+		replicas[largest]++
+		remainders[largest] = -1
+	}
+
+	for i, v := range variants {
+		if v.Weight == 0 || replicas[i] > 0 {
+			continue
+		}
+
+		donor := -1
+		for j := range variants {
+			if replicas[j] > 1 && (donor == -1 || float64(replicas[j])-shares[j] > float64(replicas[donor])-shares[donor]) {
+				donor = j
+			}
+		}
+		// the scale is lower than the number of variants with a weight, rejected by the validation
+		if donor == -1 {
+			break
+		}
+		replicas[donor]--
+		replicas[i]++
+	}
+
+	return replicas
+}
+
+// deployVariants creates the containers for every variant of a deployment. A variant failing its health check has
+// its containers removed and its weight set to 0 instead of failing the deployment, the deployment only fails if no
+// variant is healthy. The containers of the healthy variants route to the failed variant through the weighted service,
+// so they are created again without the failed variant.
+func deployVariants(ctx context.Context, config Config, opts RunOptions, e *EventEmitter) ([]KraneContainer, error) {
+	excluded := make(map[string]bool)
+	for {
+		containers, failed, err := deployVariantConfigs(ctx, config, config.variantConfigs(excluded), opts, e)
+		if err != nil || len(failed) == 0 {
+			return containers, err
+		}
+
+		for _, variant := range failed {
+			excluded[variant] = true
+		}
+		e.emit(fmt.Sprintf("Variant(s) %s failed, creating the healthy variants again with a weight of 0 for them", strings.Join(failed, ", ")))
+		if err := removeContainers(context.Background(), containers, config.ContainerStopTimeout()); err != nil {
+			logger.Errorf("unable to remove variant containers %v", err)
+		}
+	}
+}
+
+// deployVariantConfigs creates the containers for the variant configs of a deployment, returning the containers
+// of the healthy variants and the names of the failed variants
+func deployVariantConfigs(ctx context.Context, config Config, configs []Config, opts RunOptions, e *EventEmitter) ([]KraneContainer, []string, error) {
+	containers := make([]KraneContainer, 0)
+	failed := make([]string, 0)
+
+	for _, vc := range configs {
+		variant := vc.Labels[ContainerVariantLabel]
+
+		created, err := deployReplicas(ctx, vc, vc.Scale, opts, e)
+		if err != nil {
+			if ctx.Err() != nil {
+				return append(containers, created...), nil, err
+			}
+
+			logger.Warnf("variant %s for deployment %s failed, setting its weight to 0: %v", variant, config.Name, err)
+			if err := removeContainers(context.Background(), created, config.ContainerStopTimeout()); err != nil {
+				logger.Errorf("unable to remove variant containers %v", err)
+			}
+			failed = append(failed, variant)
+			continue
+		}
+
+		containers = append(containers, created...)
+	}
+
+	if len(containers) == 0 {
+		return containers, nil, fmt.Errorf("no variant for deployment %s passed the health check", config.Name)
+	}
+
+	return containers, failed, nil
+}
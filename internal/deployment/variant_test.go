@@ -0,0 +1,98 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/proxy"
+)
+
+func TestVariantReplicas(t *testing.T) {
+	variants := []Variant{{Name: "a", Weight: 3}, {Name: "b", Weight: 1}}
+	assert.Equal(t, []int{3, 1}, variantReplicas(4, variants))
+
+	variants = []Variant{{Name: "a", Weight: 1}, {Name: "b", Weight: 1}, {Name: "c", Weight: 1}}
+	assert.Equal(t, []int{1, 1, 1}, variantReplicas(3, variants))
+
+	// every weighted variant gets at least one container without exceeding the scale
+	variants = []Variant{{Name: "a", Weight: 98}, {Name: "b", Weight: 1}, {Name: "c", Weight: 1}}
+	assert.Equal(t, []int{1, 1, 1}, variantReplicas(3, variants))
+	assert.Equal(t, []int{8, 1, 1}, variantReplicas(10, variants))
+
+	variants = []Variant{{Name: "a", Weight: 99}, {Name: "b", Weight: 1}, {Name: "c", Weight: 0}}
+	assert.Equal(t, []int{9, 1, 0}, variantReplicas(10, variants))
+
+	// the scale is never exceeded, configs with a scale lower than the weighted variants are rejected
+	assert.Equal(t, []int{1, 0, 0}, variantReplicas(1, variants))
+}
+
+func TestVariantConfigs(t *testing.T) {
+	config := Config{
+		Name:   "variants",
+		Image:  "nginx",
+		Scale:  2,
+		Labels: map[string]string{"app": "test"},
+		Variants: []Variant{
+			{Name: "stable", Image: "nginx", Tag: "1.19", Weight: 1},
+			{Name: "canary", Image: "nginx", Weight: 1},
+		},
+	}
+
+	configs := config.variantConfigs(nil)
+	assert.Len(t, configs, 2)
+	assert.Equal(t, "1.19", configs[0].Tag)
+	assert.Equal(t, "latest", configs[1].Tag)
+	assert.Equal(t, "stable", configs[0].Labels[ContainerVariantLabel])
+	assert.Equal(t, "canary", configs[1].Labels[ContainerVariantLabel])
+	assert.Equal(t, "test", configs[1].Labels["app"])
+	assert.Empty(t, config.Labels[ContainerVariantLabel])
+
+	// every variant has its own service, the routers reach them through a weighted service
+	weights := []proxy.WeightedService{{Name: "variants-stable", Weight: 1}, {Name: "variants-canary", Weight: 1}}
+	assert.Equal(t, weights, configs[0].variantWeights)
+	assert.Equal(t, "variants-canary", configs[1].serviceName())
+
+	// excluded variants get no containers and are dropped from the weighted service
+	configs = config.variantConfigs(map[string]bool{"canary": true})
+	assert.Len(t, configs, 1)
+	assert.Equal(t, []proxy.WeightedService{{Name: "variants-stable", Weight: 1}}, configs[0].variantWeights)
+}
+
+func TestVariantProxyLabels(t *testing.T) {
+	config := Config{
+		Name:       "variants",
+		Image:      "nginx",
+		Scale:      2,
+		TargetPort: "80",
+		Labels:     map[string]string{},
+		Variants: []Variant{
+			{Name: "stable", Image: "nginx", Weight: 9},
+			{Name: "canary", Image: "nginx", Weight: 1},
+		},
+	}
+
+	labels := config.variantConfigs(nil)[1].DockerLabels()
+	assert.Equal(t, "80", labels["traefik.http.services.variants-canary.loadbalancer.server.port"])
+	assert.Equal(t, "variants-stable", labels["traefik.http.services.variants.weighted.services[0].name"])
+	assert.Equal(t, "9", labels["traefik.http.services.variants.weighted.services[0].weight"])
+	assert.Equal(t, "variants-canary", labels["traefik.http.services.variants.weighted.services[1].name"])
+	assert.Equal(t, "1", labels["traefik.http.services.variants.weighted.services[1].weight"])
+	assert.Equal(t, "variants", labels["traefik.http.routers.variants-insecure.service"])
+	assert.Empty(t, labels["traefik.http.services.variants.loadbalancer.server.port"])
+}
+
+func TestVariantFieldErrors(t *testing.T) {
+	config := Config{Name: "variants", Image: "nginx", Variants: []Variant{{Name: "a", Image: "nginx"}, {Name: "a"}}}
+	errs := config.variantFieldErrors()
+	assert.Len(t, errs, 3)
+	assert.Equal(t, "variants", errs[2].Field)
+
+	config = Config{Name: "variants", Image: "nginx", Scale: 1, Variants: []Variant{{Name: "a", Image: "nginx", Weight: 1}, {Name: "b", Image: "nginx", Weight: 1}}}
+	errs = config.variantFieldErrors()
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "variants", errs[0].Field)
+
+	config.Scale = 2
+	assert.Empty(t, config.variantFieldErrors())
+}
@@ -1,12 +1,54 @@
 package deployment
 
-import "github.com/docker/docker/api/types"
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
+
+	"github.com/krane/krane/internal/docker"
+	"github.com/krane/krane/internal/logger"
+)
 
 type Volume struct {
 	HostVolume      string `json:"host_volume"`
 	ContainerVolume string `json:"container_volume"`
 }
 
+// VolumeMount mounts a named volume or a host path (bind mount) into the containers of a deployment
+type VolumeMount struct {
+	Source   string `json:"source"`    // name of a volume, created if it does not exist, or absolute host path to bind mount
+	Target   string `json:"target"`    // absolute path in the container
+	ReadOnly bool   `json:"read_only"` // mount the source read-only (default false)
+}
+
+var volumeNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]+$`)
+
+// Named returns whether the mount source is a named volume rather than a host path
+func (m VolumeMount) Named() bool {
+	return !strings.HasPrefix(m.Source, "/")
+}
+
+// dockerMount returns the mount as a Docker volume or bind mount
+func (m VolumeMount) dockerMount() mount.Mount {
+	mountType := mount.TypeBind
+	if m.Named() {
+		mountType = mount.TypeVolume
+	}
+	return mount.Mount{
+		Type:     mountType,
+		Source:   m.Source,
+		Target:   m.Target,
+		ReadOnly: m.ReadOnly,
+	}
+}
+
 // fromMountPointToVolumeList converts a list of volume MountPoints into a list of formatted Krane Volumes
 func fromMountPointToVolumeList(mounts []types.MountPoint) []Volume {
 	volumes := make([]Volume, 0)
@@ -18,3 +60,125 @@ func fromMountPointToVolumeList(mounts []types.MountPoint) []Volume {
 	}
 	return volumes
 }
+
+// mountFieldErrors returns a validation error for every mount with an invalid source or target, and for
+// every container path mounted more than once by the volumes and mounts of the deployment
+func (config Config) mountFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+
+	targets := make(map[string]bool, len(config.Volumes)+len(config.Mounts))
+	for _, target := range config.Volumes {
+		targets[path.Clean(target)] = true
+	}
+
+	for _, m := range config.Mounts {
+		switch {
+		case m.Source == "":
+			errs = append(errs, newFieldError("mounts", "mount of %s has no source, expected a volume name or an absolute host path", m.Target))
+		case m.Named() && !volumeNameRegex.MatchString(m.Source):
+			errs = append(errs, newFieldError("mounts", "invalid volume name %s, expected letters, digits, _, . or - (host paths must be absolute)", m.Source))
+		}
+
+		if !strings.HasPrefix(m.Target, "/") {
+			errs = append(errs, newFieldError("mounts", "invalid target %s for %s, expected an absolute container path", m.Target, m.Source))
+			continue
+		}
+
+		target := path.Clean(m.Target)
+		if targets[target] {
+			errs = append(errs, newFieldError("mounts", "%s is mounted more than once", target))
+		}
+		targets[target] = true
+
+		if target == localtimePath && config.MountLocaltime {
+			errs = append(errs, newFieldError("mount_localtime", "mount_localtime conflicts with the %s mount", localtimePath))
+		}
+	}
+
+	return errs
+}
+
+// bindSources returns the host paths bind mounted by the volumes and mounts of a deployment, sorted
+func (config Config) bindSources() []string {
+	sources := make([]string, 0, len(config.Volumes)+len(config.Mounts))
+	for hostPath := range config.Volumes {
+		sources = append(sources, hostPath)
+	}
+	for _, m := range config.Mounts {
+		if !m.Named() {
+			sources = append(sources, m.Source)
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// bindTargets returns the container path of each bind mounted host path of a deployment keyed by host path
+func (config Config) bindTargets() map[string]string {
+	targets := make(map[string]string, len(config.Volumes)+len(config.Mounts))
+	for hostPath, target := range config.Volumes {
+		targets[hostPath] = target
+	}
+	for _, m := range config.Mounts {
+		if !m.Named() {
+			targets[m.Source] = m.Target
+		}
+	}
+	return targets
+}
+
+// prepareMounts creates the named volumes of a deployment that do not exist yet and verifies its bind mounted
+// host paths exist. Host paths are only verified when Krane runs on the docker host, inside a container they are
+// not visible to Krane and docker reports missing paths when the containers are created.
+func prepareMounts(ctx context.Context, config Config, e *EventEmitter) error {
+	for _, m := range config.Mounts {
+		if !m.Named() {
+			continue
+		}
+
+		created, err := docker.GetClient().EnsureVolume(ctx, m.Source, config.Name)
+		if err != nil {
+			return fmt.Errorf("unable to create volume %s, %w", m.Source, err)
+		}
+
+		if created {
+			logger.Debugf("volume %s created for deployment %s", m.Source, config.Name)
+			e.emit(fmt.Sprintf("Volume %s created", m.Source))
+		}
+	}
+
+	if runningInContainer() {
+		return nil
+	}
+
+	for _, hostPath := range config.bindSources() {
+		if _, err := os.Stat(hostPath); os.IsNotExist(err) {
+			return fmt.Errorf("bind mount source %s does not exist on the docker host", hostPath)
+		}
+	}
+
+	return nil
+}
+
+// removeVolumes removes the named volumes created for a deployment
+func removeVolumes(ctx context.Context, deployment string) error {
+	volumes, err := docker.GetClient().GetVolumesByDeployment(ctx, deployment)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range volumes {
+		if err := docker.GetClient().RemoveVolume(ctx, v.Name); err != nil {
+			return fmt.Errorf("unable to remove volume %s, %w", v.Name, err)
+		}
+		logger.Debugf("volume %s of deployment %s removed", v.Name, deployment)
+	}
+
+	return nil
+}
+
+// runningInContainer returns whether Krane runs in a docker container
+func runningInContainer() bool {
+	_, err := os.Stat("/.dockerenv")
+	return err == nil
+}
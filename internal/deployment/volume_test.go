@@ -0,0 +1,54 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVolumeMountDockerMount(t *testing.T) {
+	named := VolumeMount{Source: "pgdata", Target: "/var/lib/postgresql/data"}.dockerMount()
+	assert.Equal(t, mount.Mount{Type: mount.TypeVolume, Source: "pgdata", Target: "/var/lib/postgresql/data"}, named)
+
+	bind := VolumeMount{Source: "/etc/app", Target: "/config", ReadOnly: true}.dockerMount()
+	assert.Equal(t, mount.Mount{Type: mount.TypeBind, Source: "/etc/app", Target: "/config", ReadOnly: true}, bind)
+}
+
+func TestMountFieldErrors(t *testing.T) {
+	valid := Config{
+		Volumes: map[string]string{"/srv/data": "/data"},
+		Mounts: []VolumeMount{
+			{Source: "pgdata", Target: "/var/lib/postgresql/data"},
+			{Source: "/etc/app", Target: "/config", ReadOnly: true},
+		},
+	}
+	assert.Empty(t, valid.mountFieldErrors())
+
+	assert.Len(t, Config{Mounts: []VolumeMount{{Target: "/data"}}}.mountFieldErrors(), 1)
+	assert.Len(t, Config{Mounts: []VolumeMount{{Source: "./data", Target: "/data"}}}.mountFieldErrors(), 1)
+	assert.Len(t, Config{Mounts: []VolumeMount{{Source: "pgdata", Target: "data"}}}.mountFieldErrors(), 1)
+
+	// a container path is only mounted once across volumes and mounts
+	duplicate := Config{
+		Volumes: map[string]string{"/srv/data": "/data"},
+		Mounts:  []VolumeMount{{Source: "pgdata", Target: "/data/"}},
+	}
+	assert.Len(t, duplicate.mountFieldErrors(), 1)
+
+	localtime := Config{MountLocaltime: true, Mounts: []VolumeMount{{Source: "/etc/localtime", Target: "/etc/localtime"}}}
+	assert.Len(t, localtime.mountFieldErrors(), 1)
+}
+
+func TestBindSources(t *testing.T) {
+	config := Config{
+		Volumes: map[string]string{"/srv/data": "/data"},
+		Mounts: []VolumeMount{
+			{Source: "pgdata", Target: "/var/lib/postgresql/data"},
+			{Source: "/etc/app", Target: "/config"},
+		},
+	}
+	assert.Equal(t, []string{"/etc/app", "/srv/data"}, config.bindSources())
+	assert.Len(t, config.DockerVolumeMount(), 3)
+	assert.Len(t, config.DockerVolumeSet(), 3)
+}
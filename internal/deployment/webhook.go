@@ -0,0 +1,200 @@
+package deployment
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/store"
+)
+
+// WebhookRateLimit is the max number of runs a deployment webhook triggers within WebhookRateWindow
+const WebhookRateLimit = 5
+
+// WebhookRateWindow is the window the webhook runs of a deployment are rate limited over
+const WebhookRateWindow = time.Minute
+
+// webhookTokenBytes is the number of random bytes of a webhook token
+const webhookTokenBytes = 32
+
+var imageTagRegex = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127}$`)
+
+// WebhookTrigger is the optional payload of a deployment webhook call
+type WebhookTrigger struct {
+	Tag    string `json:"tag"`    // image tag deployed instead of the configured tag for this run only
+	Digest string `json:"digest"` // image digest deployed instead of the configured image for this run only
+	Source string `json:"source"` // who or what called the webhook (ie. a CI pipeline url), recorded with the run job
+}
+
+// WebhookUnauthorizedError is returned when a webhook is called without the token of the deployment
+type WebhookUnauthorizedError struct{ Deployment string }
+
+// Error returns a string representation of a WebhookUnauthorizedError
+func (e WebhookUnauthorizedError) Error() string {
+	return fmt.Sprintf("invalid webhook token for deployment %s", e.Deployment)
+}
+
+// WebhookRateLimitedError is returned when a deployment webhook triggered too many runs within the rate window
+type WebhookRateLimitedError struct {
+	Deployment string
+	RetryAfter time.Duration
+}
+
+// Error returns a string representation of a WebhookRateLimitedError
+func (e WebhookRateLimitedError) Error() string {
+	return fmt.Sprintf("webhook of deployment %s triggered %d runs within %s, retry in %s",
+		e.Deployment, WebhookRateLimit, WebhookRateWindow, e.RetryAfter.Round(time.Second))
+}
+
+// GenerateWebhookToken generates a new webhook token for a deployment replacing its previous token. Only a hash
+// of the token is stored, the token is returned once.
+func GenerateWebhookToken(deployment string) (string, error) {
+	if !Exist(deployment) {
+		return "", fmt.Errorf("deployment %s does not exist", deployment)
+	}
+
+	bytes := make([]byte, webhookTokenBytes)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	token := hex.EncodeToString(bytes)
+	if err := store.Client().Put(constants.WebhookTokensCollectionName, deployment, []byte(hashWebhookToken(token))); err != nil {
+		return "", err
+	}
+
+	logger.Infof("webhook token generated for deployment %s", deployment)
+	return token, nil
+}
+
+// RevokeWebhookToken removes the webhook token of a deployment, the webhook can't be called until a new token is generated
+func RevokeWebhookToken(deployment string) error {
+	return store.Client().Remove(constants.WebhookTokensCollectionName, deployment)
+}
+
+// renameWebhookToken moves the webhook token of a deployment to a new name
+func renameWebhookToken(deployment string, newName string) error {
+	hash, err := store.Client().Get(constants.WebhookTokensCollectionName, deployment)
+	if err != nil || hash == nil {
+		return err
+	}
+
+	if err := store.Client().Put(constants.WebhookTokensCollectionName, newName, hash); err != nil {
+		return err
+	}
+	return RevokeWebhookToken(deployment)
+}
+
+// validWebhookToken returns true if a token is the webhook token of a deployment
+func validWebhookToken(deployment string, token string) bool {
+	if token == "" {
+		return false
+	}
+
+	hash, err := store.Client().Get(constants.WebhookTokensCollectionName, deployment)
+	if err != nil || hash == nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(hash, []byte(hashWebhookToken(token))) == 1
+}
+
+func hashWebhookToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// TriggerWebhook runs a deployment from its webhook. The token must be the webhook token of the deployment and the
+// webhook runs of a deployment are rate limited. Webhook runs are automated, they are deferred outside the deploy window.
+func TriggerWebhook(deployment string, token string, trigger WebhookTrigger, from string) error {
+	if !validWebhookToken(deployment, token) {
+		return WebhookUnauthorizedError{Deployment: deployment}
+	}
+
+	if err := trigger.validate(); err != nil {
+		return err
+	}
+
+	if retryAfter, ok := webhookRuns.allow(deployment, time.Now()); !ok {
+		return WebhookRateLimitedError{Deployment: deployment, RetryAfter: retryAfter}
+	}
+
+	triggeredBy := fmt.Sprintf("webhook from %s", from)
+	if trigger.Source != "" {
+		triggeredBy = fmt.Sprintf("webhook from %s (%s)", from, trigger.Source)
+	}
+
+	logger.Infof("deployment %s triggered by %s", deployment, triggeredBy)
+	return RunWithOptions(deployment, RunOptions{
+		Start:       true,
+		Automated:   true,
+		Tag:         trigger.Tag,
+		Digest:      trigger.Digest,
+		TriggeredBy: triggeredBy,
+	})
+}
+
+// validate returns an error if the image overrides of a webhook call are not a valid tag or digest
+func (t WebhookTrigger) validate() error {
+	if t.Tag != "" && !imageTagRegex.MatchString(t.Tag) {
+		return fmt.Errorf("invalid image tag %s", t.Tag)
+	}
+
+	if t.Digest != "" && !digestRegex.MatchString(t.Digest) {
+		return fmt.Errorf("invalid image digest %s", t.Digest)
+	}
+
+	return nil
+}
+
+// withImageOverride returns the config deploying an image tag or digest instead of the configured image. A tag
+// override unpins the configured digest so the tag is deployed.
+func (config Config) withImageOverride(tag string, digest string) Config {
+	if tag != "" {
+		config.Tag = tag
+		config.Digest = ""
+	}
+
+	if digest != "" {
+		config.Digest = digest
+	}
+
+	return config
+}
+
+// webhookRateLimiter limits the number of runs triggered by the webhook of each deployment within a window
+type webhookRateLimiter struct {
+	mu    sync.Mutex
+	limit int
+	runs  map[string][]time.Time
+}
+
+var webhookRuns = &webhookRateLimiter{limit: WebhookRateLimit, runs: make(map[string][]time.Time)}
+
+// allow records a webhook run of a deployment if the rate limit allows it, otherwise returns the time until it does
+func (l *webhookRateLimiter) allow(deployment string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	recent := make([]time.Time, 0, l.limit)
+	for _, t := range l.runs[deployment] {
+		if now.Sub(t) < WebhookRateWindow {
+			recent = append(recent, t)
+		}
+	}
+
+	if len(recent) >= l.limit {
+		l.runs[deployment] = recent
+		return WebhookRateWindow - now.Sub(recent[0]), false
+	}
+
+	l.runs[deployment] = append(recent, now)
+	return 0, true
+}
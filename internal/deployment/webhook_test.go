@@ -0,0 +1,71 @@
+package deployment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookToken(t *testing.T) {
+	assert.Nil(t, SaveConfig(Config{Name: "webhook-app", Image: "nginx"}))
+	defer DeleteConfig("webhook-app")
+
+	_, err := GenerateWebhookToken("webhook-missing")
+	assert.Error(t, err)
+
+	token, err := GenerateWebhookToken("webhook-app")
+	assert.Nil(t, err)
+	assert.Len(t, token, 2*webhookTokenBytes)
+	assert.True(t, validWebhookToken("webhook-app", token))
+	assert.False(t, validWebhookToken("webhook-app", ""))
+	assert.False(t, validWebhookToken("webhook-app", "not-the-token"))
+
+	// a new token replaces the previous token
+	rotated, err := GenerateWebhookToken("webhook-app")
+	assert.Nil(t, err)
+	assert.False(t, validWebhookToken("webhook-app", token))
+	assert.True(t, validWebhookToken("webhook-app", rotated))
+
+	assert.Nil(t, renameWebhookToken("webhook-app", "webhook-renamed"))
+	assert.False(t, validWebhookToken("webhook-app", rotated))
+	assert.True(t, validWebhookToken("webhook-renamed", rotated))
+
+	assert.Nil(t, RevokeWebhookToken("webhook-renamed"))
+	assert.False(t, validWebhookToken("webhook-renamed", rotated))
+
+	err = TriggerWebhook("webhook-renamed", rotated, WebhookTrigger{}, "127.0.0.1")
+	assert.Equal(t, WebhookUnauthorizedError{Deployment: "webhook-renamed"}, err)
+}
+
+func TestWebhookTrigger(t *testing.T) {
+	assert.Nil(t, WebhookTrigger{Tag: "1.2.3-alpine"}.validate())
+	assert.Nil(t, WebhookTrigger{Digest: "sha256:" + "a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90"}.validate())
+	assert.Error(t, WebhookTrigger{Tag: "-latest"}.validate())
+	assert.Error(t, WebhookTrigger{Digest: "sha256:abc"}.validate())
+
+	pinned := Config{Tag: "1.0", Digest: "sha256:abc"}
+	assert.Equal(t, Config{Tag: "1.1"}, pinned.withImageOverride("1.1", ""))
+	assert.Equal(t, Config{Tag: "1.0", Digest: "sha256:def"}, pinned.withImageOverride("", "sha256:def"))
+	assert.Equal(t, pinned, pinned.withImageOverride("", ""))
+}
+
+func TestWebhookRateLimit(t *testing.T) {
+	limiter := &webhookRateLimiter{limit: 2, runs: make(map[string][]time.Time)}
+	now := time.Now()
+
+	_, ok := limiter.allow("app", now)
+	assert.True(t, ok)
+	_, ok = limiter.allow("app", now.Add(10*time.Second))
+	assert.True(t, ok)
+
+	retryAfter, ok := limiter.allow("app", now.Add(20*time.Second))
+	assert.False(t, ok)
+	assert.Equal(t, 40*time.Second, retryAfter)
+
+	// other deployments are limited separately and runs leave the window
+	_, ok = limiter.allow("other", now.Add(20*time.Second))
+	assert.True(t, ok)
+	_, ok = limiter.allow("app", now.Add(WebhookRateWindow))
+	assert.True(t, ok)
+}
@@ -0,0 +1,302 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/store"
+)
+
+// DeferredRunsInterval is how often deferred runs are checked for an open deploy window
+const DeferredRunsInterval = 30 * time.Second
+
+// DeployWindow restricts when automated runs (webhooks, scheduled runs) deploy a deployment. Automated runs
+// outside the window are deferred until it opens, manual runs are rejected unless an admin overrides the window.
+type DeployWindow struct {
+	Timezone string         `json:"timezone"` // tz database timezone the allowed ranges are in (default UTC)
+	Allow    []TimeRange    `json:"allow"`    // weekly time ranges deploys are allowed in, deploys are allowed anytime when empty
+	Freeze   []FreezePeriod `json:"freeze"`   // periods deploys are not allowed in, even within an allowed range
+}
+
+// TimeRange is a daily time range (ie. 18:00 to 08:00) on some days of the week
+type TimeRange struct {
+	Days  []string `json:"days"`  // days of the week the range starts on (mon, tue, wed, thu, fri, sat, sun), every day when empty
+	Start string   `json:"start"` // start time (HH:MM) of the range
+	End   string   `json:"end"`   // end time (HH:MM) of the range, a range ending before it starts ends the next day
+}
+
+// FreezePeriod is an explicit period deploys are frozen in (ie. a release freeze)
+type FreezePeriod struct {
+	Start  string `json:"start"`  // start of the freeze (RFC3339)
+	End    string `json:"end"`    // end of the freeze (RFC3339)
+	Reason string `json:"reason"` // reason reported for deploys rejected or deferred by the freeze
+}
+
+// DeferredRun is an automated run deferred until the deploy window of its deployment opens
+type DeferredRun struct {
+	Deployment  string `json:"deployment"`
+	Start       bool   `json:"start"`                  // start option of the deferred run
+	Tag         string `json:"tag,omitempty"`          // image tag override of the deferred run
+	Digest      string `json:"digest,omitempty"`       // image digest override of the deferred run
+	TriggeredBy string `json:"triggered_by,omitempty"` // who or what triggered the deferred run
+	Reason      string `json:"reason"`                 // why the run was deferred
+	DeferredAt  int64  `json:"deferred_at"`            // unix time of the last deferred run
+}
+
+// DeployWindowClosedError is returned when a manual run is requested outside the deploy window of a deployment
+type DeployWindowClosedError struct {
+	Deployment string
+	Reason     string
+}
+
+// Error returns a string representation of a DeployWindowClosedError
+func (e DeployWindowClosedError) Error() string {
+	return fmt.Sprintf("deployment %s cannot be deployed now, %s", e.Deployment, e.Reason)
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// configured returns whether the deploy window restricts when the deployment is deployed
+func (w DeployWindow) configured() bool {
+	return len(w.Allow) > 0 || len(w.Freeze) > 0
+}
+
+// Open returns whether a deployment can be deployed at a time, with the reason when it cannot. A window whose
+// timezone cannot be loaded is closed.
+func (w DeployWindow) Open(now time.Time) (bool, string) {
+	for _, freeze := range w.Freeze {
+		start, errStart := time.Parse(time.RFC3339, freeze.Start)
+		end, errEnd := time.Parse(time.RFC3339, freeze.End)
+		if errStart != nil || errEnd != nil || now.Before(start) || !now.Before(end) {
+			continue
+		}
+
+		reason := fmt.Sprintf("deploys are frozen until %s", freeze.End)
+		if freeze.Reason != "" {
+			reason = fmt.Sprintf("%s (%s)", reason, freeze.Reason)
+		}
+		return false, reason
+	}
+
+	if len(w.Allow) == 0 {
+		return true, ""
+	}
+
+	// the allowed ranges are never evaluated in another timezone, the window stays closed until the timezone can be loaded
+	location, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		logger.Errorf("unable to load deploy window timezone %v", err)
+		return false, fmt.Sprintf("unable to load the deploy window timezone %s", w.Timezone)
+	}
+
+	local := now.In(location)
+	for _, r := range w.Allow {
+		if r.contains(local) {
+			return true, ""
+		}
+	}
+	return false, "outside of the allowed deploy window"
+}
+
+// contains returns whether a time is within the range, ranges ending before they start end the next day
+func (r TimeRange) contains(t time.Time) bool {
+	start, errStart := parseClock(r.Start)
+	end, errEnd := parseClock(r.End)
+	if errStart != nil || errEnd != nil {
+		return false
+	}
+
+	minutes := t.Hour()*60 + t.Minute()
+	if start < end {
+		return r.onDay(t.Weekday()) && minutes >= start && minutes < end
+	}
+
+	// overnight range, t is either after the start on a day of the range or before the end the next day
+	yesterday := (t.Weekday() + 6) % 7
+	return (r.onDay(t.Weekday()) && minutes >= start) || (r.onDay(yesterday) && minutes < end)
+}
+
+// onDay returns whether the range starts on a day of the week
+func (r TimeRange) onDay(day time.Weekday) bool {
+	if len(r.Days) == 0 {
+		return true
+	}
+	for _, d := range r.Days {
+		if weekdays[strings.ToLower(d)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock returns the minutes since midnight of a HH:MM time
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// deployWindowFieldErrors returns a validation error for every invalid timezone, time range or freeze period of the deploy window
+func (config Config) deployWindowFieldErrors() []FieldError {
+	errs := make([]FieldError, 0)
+	w := config.DeployWindow
+
+	if w.Timezone != "" {
+		if _, err := time.LoadLocation(w.Timezone); err != nil || w.Timezone == "Local" {
+			errs = append(errs, newFieldError("deploy_window", "unknown timezone %s, expected a tz database name (ie. America/New_York)", w.Timezone))
+		}
+	}
+
+	for _, r := range w.Allow {
+		start, errStart := parseClock(r.Start)
+		end, errEnd := parseClock(r.End)
+		if errStart != nil || errEnd != nil {
+			errs = append(errs, newFieldError("deploy_window", "invalid time range %s-%s, expected HH:MM times", r.Start, r.End))
+		} else if start == end {
+			errs = append(errs, newFieldError("deploy_window", "empty time range %s-%s", r.Start, r.End))
+		}
+
+		for _, d := range r.Days {
+			if _, ok := weekdays[strings.ToLower(d)]; !ok {
+				errs = append(errs, newFieldError("deploy_window", "invalid day %s, expected one of mon, tue, wed, thu, fri, sat, sun", d))
+			}
+		}
+	}
+
+	for _, freeze := range w.Freeze {
+		start, errStart := time.Parse(time.RFC3339, freeze.Start)
+		end, errEnd := time.Parse(time.RFC3339, freeze.End)
+		if errStart != nil || errEnd != nil {
+			errs = append(errs, newFieldError("deploy_window", "invalid freeze period %s to %s, expected RFC3339 times", freeze.Start, freeze.End))
+		} else if !end.After(start) {
+			errs = append(errs, newFieldError("deploy_window", "freeze period %s ends before it starts", freeze.Start))
+		}
+	}
+
+	return errs
+}
+
+// deferRun records an automated run to deploy once the deploy window opens, replacing a run already deferred
+func deferRun(config Config, opts RunOptions, reason string) error {
+	bytes, _ := json.Marshal(DeferredRun{
+		Deployment:  config.Name,
+		Start:       opts.Start,
+		Tag:         opts.Tag,
+		Digest:      opts.Digest,
+		TriggeredBy: opts.TriggeredBy,
+		Reason:      reason,
+		DeferredAt:  time.Now().Unix(),
+	})
+
+	if err := store.Client().Put(constants.DeferredRunsCollectionName, config.Name, bytes); err != nil {
+		return err
+	}
+
+	logger.Infof("run of deployment %s deferred, %s", config.Name, reason)
+	e := createEventEmitter(config.Name, "")
+	e.Phase = DeferredPhase
+	e.emit(fmt.Sprintf("automated run deferred until the deploy window opens, %s", reason))
+	return nil
+}
+
+// GetDeferredRun returns the run of a deployment deferred until its deploy window opens, nil if none
+func GetDeferredRun(deployment string) (*DeferredRun, error) {
+	bytes, err := store.Client().Get(constants.DeferredRunsCollectionName, deployment)
+	if err != nil || bytes == nil {
+		return nil, err
+	}
+
+	var run DeferredRun
+	if err := json.Unmarshal(bytes, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// RunDeferredRuns runs the deferred runs once the deploy window of their deployment opens until ctx is done
+func RunDeferredRuns(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runDeferredRuns()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// runDeferredRuns runs the deferred runs whose deploy window is open, runs of removed deployments are dropped
+func runDeferredRuns() {
+	all, err := store.Client().GetAll(constants.DeferredRunsCollectionName)
+	if err != nil {
+		logger.Warnf("unable to get deferred runs %v", err)
+		return
+	}
+
+	for _, bytes := range all {
+		var run DeferredRun
+		if err := json.Unmarshal(bytes, &run); err != nil {
+			logger.Warnf("unable to read deferred run %v", err)
+			continue
+		}
+
+		config, err := GetDeploymentConfig(run.Deployment)
+		if err != nil {
+			logger.Warnf("deployment %s not found, dropping its deferred run, %v", run.Deployment, err)
+			_ = deleteDeferredRun(run.Deployment)
+			continue
+		}
+
+		RunDeferred(config)
+	}
+}
+
+// RunDeferred runs the deferred run of a deployment once its deploy window is open
+func RunDeferred(config Config) {
+	run, err := GetDeferredRun(config.Name)
+	if err != nil {
+		logger.Warnf("unable to get the deferred run of deployment %s, %v", config.Name, err)
+		return
+	}
+
+	if run == nil {
+		return
+	}
+
+	if open, _ := config.DeployWindow.Open(time.Now()); !open {
+		return
+	}
+
+	if err := deleteDeferredRun(config.Name); err != nil {
+		logger.Warnf("unable to remove the deferred run of deployment %s, %v", config.Name, err)
+		return
+	}
+
+	logger.Infof("deploy window of deployment %s open, running the run deferred at %s", config.Name, time.Unix(run.DeferredAt, 0).UTC().Format(time.RFC3339))
+	if err := RunWithOptions(config.Name, RunOptions{Start: run.Start, Automated: true, Tag: run.Tag, Digest: run.Digest, TriggeredBy: run.TriggeredBy}); err != nil {
+		logger.Warnf("unable to run the deferred run of deployment %s, %v", config.Name, err)
+	}
+}
+
+// deleteDeferredRun removes the deferred run of a deployment
+func deleteDeferredRun(deployment string) error {
+	return store.Client().Remove(constants.DeferredRunsCollectionName, deployment)
+}
@@ -0,0 +1,109 @@
+package deployment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeployWindowOpen(t *testing.T) {
+	w := DeployWindow{
+		Timezone: "America/New_York",
+		Allow: []TimeRange{
+			{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "18:00", End: "08:00"},
+			{Days: []string{"sat", "sun"}, Start: "00:00", End: "23:59"},
+		},
+	}
+	ny, _ := time.LoadLocation("America/New_York")
+
+	// wednesday during business hours
+	open, reason := w.Open(time.Date(2021, 3, 3, 12, 0, 0, 0, ny))
+	assert.False(t, open)
+	assert.Equal(t, "outside of the allowed deploy window", reason)
+
+	// wednesday evening and early thursday belong to the wednesday range
+	open, _ = w.Open(time.Date(2021, 3, 3, 19, 0, 0, 0, ny))
+	assert.True(t, open)
+	open, _ = w.Open(time.Date(2021, 3, 4, 7, 59, 0, 0, ny))
+	assert.True(t, open)
+
+	// the range is in the window timezone
+	open, _ = w.Open(time.Date(2021, 3, 3, 17, 0, 0, 0, time.UTC))
+	assert.False(t, open)
+
+	// early monday belongs to neither the sunday nor the monday range
+	open, _ = w.Open(time.Date(2021, 3, 8, 0, 30, 0, 0, ny))
+	assert.False(t, open)
+
+	open, _ = w.Open(time.Date(2021, 3, 6, 12, 0, 0, 0, ny))
+	assert.True(t, open)
+
+	// a window whose timezone cannot be loaded stays closed instead of opening at the hours of another timezone
+	w.Timezone = "Mars/Olympus_Mons"
+	open, reason = w.Open(time.Date(2021, 3, 6, 12, 0, 0, 0, ny))
+	assert.False(t, open)
+	assert.Equal(t, "unable to load the deploy window timezone Mars/Olympus_Mons", reason)
+}
+
+func TestDeployWindowFreeze(t *testing.T) {
+	w := DeployWindow{Freeze: []FreezePeriod{{Start: "2021-12-20T00:00:00Z", End: "2022-01-03T00:00:00Z", Reason: "holidays"}}}
+
+	open, reason := w.Open(time.Date(2021, 12, 24, 0, 0, 0, 0, time.UTC))
+	assert.False(t, open)
+	assert.Equal(t, "deploys are frozen until 2022-01-03T00:00:00Z (holidays)", reason)
+
+	open, _ = w.Open(time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC))
+	assert.True(t, open)
+
+	// without allowed ranges deploys are allowed anytime
+	open, _ = DeployWindow{}.Open(time.Now())
+	assert.True(t, open)
+}
+
+func TestDeployWindowFieldErrors(t *testing.T) {
+	config := Config{DeployWindow: DeployWindow{
+		Timezone: "Mars/Olympus",
+		Allow:    []TimeRange{{Days: []string{"monday"}, Start: "8am", End: "17:00"}, {Start: "10:00", End: "10:00"}},
+		Freeze:   []FreezePeriod{{Start: "2022-01-03T00:00:00Z", End: "2021-12-20T00:00:00Z"}, {Start: "tomorrow", End: "2021-12-20T00:00:00Z"}},
+	}}
+	assert.Len(t, config.deployWindowFieldErrors(), 6)
+
+	config.DeployWindow = DeployWindow{Allow: []TimeRange{{Days: []string{"Sat"}, Start: "22:00", End: "06:00"}}}
+	assert.Empty(t, config.deployWindowFieldErrors())
+}
+
+func TestRunWithOptionsOutsideDeployWindow(t *testing.T) {
+	config := Config{
+		Name:         "frozen-app",
+		Image:        "nginx",
+		DeployWindow: DeployWindow{Freeze: []FreezePeriod{{Start: "2000-01-01T00:00:00Z", End: "2100-01-01T00:00:00Z"}}},
+	}
+	assert.Nil(t, SaveConfig(config))
+	defer func() { _ = DeleteConfig(config.Name) }()
+	defer func() { _ = deleteDeferredRun(config.Name) }()
+
+	err := RunWithOptions(config.Name, RunOptions{Start: true})
+	assert.IsType(t, DeployWindowClosedError{}, err)
+
+	assert.Nil(t, RunWithOptions(config.Name, RunOptions{Start: true, Automated: true}))
+	run, err := GetDeferredRun(config.Name)
+	assert.Nil(t, err)
+	assert.NotNil(t, run)
+	assert.True(t, run.Start)
+
+	// the window is still closed, the run stays deferred
+	RunDeferred(config)
+	runDeferredRuns()
+	run, _ = GetDeferredRun(config.Name)
+	assert.NotNil(t, run)
+}
+
+func TestRunDeferredRunsDropsRemovedDeployments(t *testing.T) {
+	assert.Nil(t, deferRun(Config{Name: "removed-app"}, RunOptions{Start: true, Automated: true}, "frozen"))
+
+	runDeferredRuns()
+	run, err := GetDeferredRun("removed-app")
+	assert.Nil(t, err)
+	assert.Nil(t, run)
+}
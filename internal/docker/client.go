@@ -54,3 +54,21 @@ func Ping() bool {
 
 	return true
 }
+
+// HostMemory returns the total memory in bytes of the docker host
+func (c *Client) HostMemory(ctx context.Context) (int64, error) {
+	info, err := c.Info(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return info.MemTotal, nil
+}
+
+// HostResources returns the number of CPUs and the total memory in bytes of the docker host
+func (c *Client) HostResources(ctx context.Context) (int, int64, error) {
+	info, err := c.Info(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	return info.NCPU, info.MemTotal, nil
+}
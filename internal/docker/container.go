@@ -2,14 +2,20 @@ package docker
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"fmt"
+	"io"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
 )
 
@@ -18,8 +24,10 @@ const ContainerDeploymentLabel = "krane.deployment"
 // DockerConfig properties required to create a docker container
 type DockerConfig struct {
 	ContainerName string
+	Hostname      string // hostname of the container, the container name when empty
 	Image         string
 	NetworkID     string
+	ExtraNetworks []string // ids of additional networks to attach the container to (ie. the proxy network)
 	Labels        map[string]string
 	Ports         nat.PortMap
 	PortSet       nat.PortSet
@@ -29,13 +37,51 @@ type DockerConfig struct {
 	Env           []string // Comma separated, formatted NODE_ENV=dev
 	Command       []string
 	Entrypoint    []string
+	ShmSize       int64                   // size of /dev/shm in bytes, 0 uses the docker default
+	AutoRemove    bool                    // remove the container once it exits, only for ephemeral containers
+	Init          bool                    // run an init process (tini) as PID 1 to forward signals and reap zombie processes
+	User          string                  // user[:group] the container runs as, empty uses the image user
+	GroupAdd      []string                // supplementary groups of the container user
+	Memory        int64                   // memory limit in bytes, 0 means no limit
+	NanoCPUs      int64                   // cpu limit in units of 1e-9 CPUs, 0 means no limit
+	MemorySwap    int64                   // memory and swap limit in bytes, -1 means unlimited swap, 0 uses the docker default
+	CPUShares     int64                   // cpu weight relative to other containers, 0 uses the docker default
+	NetworkMode   string                  // host or none to skip attaching the container to the krane network, empty uses the krane network
+	RestartPolicy container.RestartPolicy // restart policy applied by the docker daemon when the container exits
+}
+
+const (
+	NetworkModeHost = "host" // the container shares the network stack of the host
+	NetworkModeNone = "none" // the container has no networking
+)
+
+// IsIsolatedNetworkMode returns true if a network mode keeps a container off the krane network
+func IsIsolatedNetworkMode(mode string) bool {
+	return mode == NetworkModeHost || mode == NetworkModeNone
 }
 
 // CreateContainer creates a docker container from a docker config
 func (c *Client) CreateContainer(ctx context.Context, config DockerConfig) (container.ContainerCreateCreatedBody, error) {
 	networkingConfig := createNetworkingConfig(config.NetworkID, config.Aliases)
-	hostConfig := createHostConfig(config.Ports, config.VolumeMounts)
-	containerConfig := createContainerConfig(config.ContainerName,
+	hostname := config.Hostname
+	if hostname == "" {
+		hostname = config.ContainerName
+	}
+	if IsIsolatedNetworkMode(config.NetworkMode) {
+		// host and none network modes can't be combined with the krane network,
+		// and docker rejects a hostname for containers sharing the host network
+		networkingConfig = network.NetworkingConfig{}
+		if config.NetworkMode == NetworkModeHost {
+			hostname = ""
+		}
+	}
+
+	hostConfig := createHostConfig(config.Ports, config.VolumeMounts, config.ShmSize, config.AutoRemove, config.Init,
+		container.Resources{Memory: config.Memory, MemorySwap: config.MemorySwap, NanoCPUs: config.NanoCPUs, CPUShares: config.CPUShares})
+	hostConfig.NetworkMode = container.NetworkMode(config.NetworkMode)
+	hostConfig.GroupAdd = config.GroupAdd
+	hostConfig.RestartPolicy = config.RestartPolicy
+	containerConfig := createContainerConfig(hostname,
 		config.Image,
 		config.Env,
 		config.Labels,
@@ -43,14 +89,32 @@ func (c *Client) CreateContainer(ctx context.Context, config DockerConfig) (cont
 		config.Entrypoint,
 		config.VolumeSet,
 		config.PortSet)
+	containerConfig.User = config.User
 
-	return c.ContainerCreate(
+	body, err := c.ContainerCreate(
 		ctx,
 		&containerConfig,
 		&hostConfig,
 		&networkingConfig,
 		config.ContainerName,
 	)
+	if err != nil {
+		return body, containerCreateError(config.ContainerName, config.Image, err)
+	}
+
+	// a container can only be created with a single network, other networks are connected after creation
+	for _, networkID := range config.ExtraNetworks {
+		if networkID == config.NetworkID {
+			continue
+		}
+
+		if err := c.NetworkConnect(ctx, networkID, body.ID, &network.EndpointSettings{NetworkID: networkID}); err != nil {
+			_ = c.RemoveContainer(context.Background(), body.ID, true)
+			return container.ContainerCreateCreatedBody{}, fmt.Errorf("unable to connect container %s to network %s, %v", config.ContainerName, networkID, err)
+		}
+	}
+
+	return body, nil
 }
 
 // StartContainer starts a docker container
@@ -59,12 +123,42 @@ func (c *Client) StartContainer(ctx context.Context, containerID string) error {
 	return c.ContainerStart(ctx, containerID, options)
 }
 
-// StopContainer : stop docker container
-func (c *Client) StopContainer(ctx context.Context, containerID string) error {
-	timeout := 60 * time.Second
+// StopContainer stops a docker container, the container is killed if it did not exit
+// within the timeout after receiving the stop signal. A timeout of 0 kills it immediately.
+func (c *Client) StopContainer(ctx context.Context, containerID string, timeout time.Duration) error {
 	return c.ContainerStop(ctx, containerID, &timeout)
 }
 
+// WaitContainer blocks until a docker container exits and returns its exit code
+func (c *Client) WaitContainer(ctx context.Context, containerID string) (int64, error) {
+	return c.ContainerWait(ctx, containerID)
+}
+
+// ContainerOutput returns the last lines of the stdout and stderr of a docker container, interleaved
+func (c *Client) ContainerOutput(ctx context.Context, containerID string, lines int) (string, error) {
+	reader, err := c.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Tail:       strconv.Itoa(lines),
+	})
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	// the output of containers without a tty is multiplexed
+	var output bytes.Buffer
+	if _, err := stdcopy.StdCopy(&output, &output, reader); err != nil {
+		return "", err
+	}
+	return output.String(), nil
+}
+
+// RestartContainer restarts a docker container, the container is killed if it does not stop within the timeout
+func (c *Client) RestartContainer(ctx context.Context, containerID string, timeout time.Duration) error {
+	return c.ContainerRestart(ctx, containerID, &timeout)
+}
+
 // RemoveContainer removes a docker container
 func (c *Client) RemoveContainer(ctx context.Context, containerID string, force bool) error {
 	options := types.ContainerRemoveOptions{
@@ -79,21 +173,43 @@ func (c *Client) GetOneContainer(ctx context.Context, containerId string) (types
 	return c.ContainerInspect(ctx, containerId)
 }
 
+// ListContainersOptions filters the containers returned when listing containers
+type ListContainersOptions struct {
+	All    bool              // include stopped containers
+	Labels map[string]string // only return containers with all of these labels, an empty value matches any label value
+}
+
 // GetKraneContainers : gets all containers on the host machine
 func (c *Client) GetAllContainers(ctx *context.Context) ([]types.ContainerJSON, error) {
+	return c.ListContainers(*ctx, ListContainersOptions{All: true})
+}
+
+// ListContainers returns the containers on the host machine matching the list options.
+// Filtering by label is done by the docker daemon.
+func (c *Client) ListContainers(ctx context.Context, opts ListContainersOptions) ([]types.ContainerJSON, error) {
+	args := filters.NewArgs()
+	for key, value := range opts.Labels {
+		if value == "" {
+			args.Add("label", key)
+			continue
+		}
+		args.Add("label", fmt.Sprintf("%s=%s", key, value))
+	}
+
 	options := types.ContainerListOptions{
-		All:   true,
-		Quiet: false,
+		All:     opts.All,
+		Quiet:   false,
+		Filters: args,
 	}
 
-	containers, err := c.ContainerList(*ctx, options)
+	containers, err := c.ContainerList(ctx, options)
 	if err != nil {
 		return make([]types.ContainerJSON, 0), err
 	}
 
 	toJsonContainers := make([]types.ContainerJSON, 0)
 	for _, cc := range containers {
-		containerJson, err := c.GetOneContainer(*ctx, cc.ID)
+		containerJson, err := c.GetOneContainer(ctx, cc.ID)
 		if err != nil {
 			return make([]types.ContainerJSON, 0), err
 		}
@@ -103,16 +219,35 @@ func (c *Client) GetAllContainers(ctx *context.Context) ([]types.ContainerJSON,
 	return toJsonContainers, nil
 }
 
+// GetContainerChanges returns the filesystem changes of a docker container relative to its image
+func (c *Client) GetContainerChanges(ctx context.Context, containerID string) ([]types.ContainerChange, error) {
+	return c.ContainerDiff(ctx, containerID)
+}
+
+// CopyToContainer extracts a tar archive into a path inside a docker container
+func (c *Client) CopyToContainer(ctx context.Context, containerID string, path string, content io.Reader) error {
+	options := types.CopyToContainerOptions{AllowOverwriteDirWithFile: false}
+	return c.Client.CopyToContainer(ctx, containerID, path, content, options)
+}
+
+// CopyFromContainer returns a tar archive of a path inside a docker container
+func (c *Client) CopyFromContainer(ctx context.Context, containerID string, path string) (io.ReadCloser, types.ContainerPathStat, error) {
+	return c.Client.CopyFromContainer(ctx, containerID, path)
+}
+
+// StatContainerPath returns the stat of a path inside a docker container
+func (c *Client) StatContainerPath(ctx context.Context, containerID string, path string) (types.ContainerPathStat, error) {
+	return c.Client.ContainerStatPath(ctx, containerID, path)
+}
+
 // GetContainerStatus returns the status of a docker container if it exists
 func (c *Client) GetContainerStatus(ctx context.Context, containerID string, stream bool) (stats types.ContainerStats, err error) {
 	return c.ContainerStats(ctx, containerID, stream)
 }
 
-// StreamContainerLogs reads the logs for a container outputting the data into a unbuffered channel
-func (c *Client) StreamContainerLogs(containerID string, out chan []byte, done chan bool) error {
-	ctx := context.Background()
-	defer ctx.Done()
-
+// StreamContainerLogs reads the logs for a container outputting the data into a unbuffered channel.
+// Streaming stops once the context is cancelled.
+func (c *Client) StreamContainerLogs(ctx context.Context, containerID string, out chan []byte, done chan bool) error {
 	stream, err := c.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{
 		ShowStdout: true,
 		ShowStderr: true,
@@ -143,7 +278,10 @@ func (c *Client) StreamContainerLogs(containerID string, out chan []byte, done c
 				if err := stream.Close(); err != nil {
 					return
 				}
-				done <- true
+				select {
+				case done <- true:
+				case <-ctx.Done():
+				}
 				return
 			}
 
@@ -152,11 +290,19 @@ func (c *Client) StreamContainerLogs(containerID string, out chan []byte, done c
 				if err := stream.Close(); err != nil {
 					return
 				}
-				done <- true
+				select {
+				case done <- true:
+				case <-ctx.Done():
+				}
 				return
 			}
 
-			out <- bytes
+			select {
+			case out <- bytes:
+			case <-ctx.Done():
+				_ = stream.Close()
+				return
+			}
 
 			mu.Unlock()
 		}
@@ -202,10 +348,19 @@ func createContainerConfig(
 }
 
 // createHostConfig returns the host config for a Docker container
-func createHostConfig(ports nat.PortMap, volumes []mount.Mount) container.HostConfig {
-	return container.HostConfig{
+func createHostConfig(ports nat.PortMap, volumes []mount.Mount, shmSize int64, autoRemove bool, init bool, resources container.Resources) container.HostConfig {
+	config := container.HostConfig{
 		PortBindings: ports,
-		AutoRemove:   false,
+		AutoRemove:   autoRemove,
 		Mounts:       volumes,
+		ShmSize:      shmSize,
+		Resources:    resources,
+	}
+
+	// only set when enabled so the daemon's default init setting is kept otherwise
+	if init {
+		config.Init = &init
 	}
+
+	return config
 }
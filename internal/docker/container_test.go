@@ -0,0 +1,22 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateHostConfigInit(t *testing.T) {
+	assert.Nil(t, createHostConfig(nil, nil, 0, false, false, container.Resources{}).Init)
+
+	config := createHostConfig(nil, nil, 0, false, true, container.Resources{})
+	assert.NotNil(t, config.Init)
+	assert.True(t, *config.Init)
+}
+
+func TestCreateHostConfigResources(t *testing.T) {
+	config := createHostConfig(nil, nil, 0, false, false, container.Resources{Memory: 512 * 1024 * 1024, NanoCPUs: 1500000000})
+	assert.Equal(t, int64(512*1024*1024), config.Memory)
+	assert.Equal(t, int64(1500000000), config.NanoCPUs)
+}
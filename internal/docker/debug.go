@@ -0,0 +1,52 @@
+package docker
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+)
+
+// DebugContainerLabel labels debug containers with the id of the container they debug
+const DebugContainerLabel = "krane.debug.target"
+
+// DebugShell is the command started in debug containers
+var DebugShell = []string{"sh"}
+
+// CreateDebugContainer creates a container running an interactive shell in the network and pid namespaces
+// of a target container, the target processes and ports are visible from the debug container
+func (c *Client) CreateDebugContainer(ctx context.Context, name string, image string, target string) (string, error) {
+	containerConfig := &container.Config{
+		Image:        image,
+		Cmd:          DebugShell,
+		Labels:       map[string]string{DebugContainerLabel: target},
+		Tty:          true,
+		OpenStdin:    true,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	}
+
+	hostConfig := &container.HostConfig{
+		NetworkMode: container.NetworkMode("container:" + target),
+		PidMode:     container.PidMode("container:" + target),
+		AutoRemove:  true,
+	}
+
+	body, err := c.ContainerCreate(ctx, containerConfig, hostConfig, &network.NetworkingConfig{}, name)
+	if err != nil {
+		return "", containerCreateError(name, image, err)
+	}
+	return body.ID, nil
+}
+
+// AttachContainer attaches to the stdin, stdout and stderr of a container. With a tty the output is not multiplexed.
+func (c *Client) AttachContainer(ctx context.Context, containerID string) (types.HijackedResponse, error) {
+	return c.ContainerAttach(ctx, containerID, types.ContainerAttachOptions{
+		Stream: true,
+		Stdin:  true,
+		Stdout: true,
+		Stderr: true,
+	})
+}
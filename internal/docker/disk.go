@@ -0,0 +1,12 @@
+package docker
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types"
+)
+
+// GetDiskUsage returns the disk space used by images, containers and volumes on the docker host
+func (c *Client) GetDiskUsage(ctx context.Context) (types.DiskUsage, error) {
+	return c.DiskUsage(ctx)
+}
@@ -0,0 +1,209 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/client"
+)
+
+// DefaultRateLimitRetryAfter is the delay before retrying an image pull that was
+// rate limited when the registry does not provide a retry after duration
+const DefaultRateLimitRetryAfter = 1 * time.Minute
+
+// daemonErrorPrefix prefixes the message of every error response the docker client receives from the daemon
+const daemonErrorPrefix = "Error response from daemon: "
+
+var retryAfterRegex = regexp.MustCompile(`(?i)retry[- ]after[:= ]*(\d+)`)
+
+// RateLimitError is returned when a registry rejects an image pull with 429 Too Many Requests
+type RateLimitError struct {
+	Ref        string
+	Message    string
+	retryAfter time.Duration
+}
+
+// Error returns a string representation of a RateLimitError
+func (e RateLimitError) Error() string {
+	return fmt.Sprintf("registry rate limit hit pulling %s, retry after %s: configure registry credentials "+
+		"for the deployment since authenticated pulls have higher rate limits (%s)", e.Ref, e.retryAfter, e.Message)
+}
+
+// RetryAfter returns the duration to wait before pulling the image again
+func (e RateLimitError) RetryAfter() time.Duration { return e.retryAfter }
+
+// ImageNotFoundError is returned when a registry does not have the image being pulled.
+// Retrying the pull will not succeed so the error is permanent.
+type ImageNotFoundError struct {
+	Ref     string
+	Message string
+}
+
+// Error returns a string representation of an ImageNotFoundError
+func (e ImageNotFoundError) Error() string {
+	return fmt.Sprintf("image not found: %s (%s)", e.Ref, e.Message)
+}
+
+// Permanent returns true since pulling a missing image will never succeed
+func (e ImageNotFoundError) Permanent() bool { return true }
+
+// RegistryAuthError is returned when a registry rejects the credentials of an image pull, or requires credentials
+// for an anonymous pull. Retrying with the same credentials will not succeed so the error is permanent.
+type RegistryAuthError struct {
+	Ref     string
+	Message string
+}
+
+// Error returns a string representation of a RegistryAuthError
+func (e RegistryAuthError) Error() string {
+	return fmt.Sprintf("registry authentication failed pulling %s: check the registry credentials of the deployment (%s)", e.Ref, e.Message)
+}
+
+// Permanent returns true since pulling with the same credentials will never succeed
+func (e RegistryAuthError) Permanent() bool { return true }
+
+// DiskFullError is returned when the docker host runs out of disk space pulling an image or creating a
+// container. Retrying fills the same disk again so the error is permanent until space is reclaimed.
+type DiskFullError struct {
+	Op      string // the operation that ran out of space (ie. pull)
+	Ref     string // the image being pulled or the container being created
+	Message string
+}
+
+// Error returns a string representation of a DiskFullError
+func (e DiskFullError) Error() string {
+	return fmt.Sprintf("disk full on the docker host, unable to %s %s: free up disk space (ie. remove unused images) and deploy again (%s)", e.Op, e.Ref, e.Message)
+}
+
+// Permanent returns true since retrying won't succeed until disk space is reclaimed
+func (e DiskFullError) Permanent() bool { return true }
+
+// isDiskFull returns true if an error message reports the host is out of disk space
+func isDiskFull(msg string) bool {
+	return strings.Contains(strings.ToLower(msg), "no space left on device")
+}
+
+// ContainerCreateError is returned when the docker daemon fails to create a container. It keeps
+// the message the daemon responded with (ie. an invalid mount or label) and the underlying error.
+type ContainerCreateError struct {
+	Container  string
+	Image      string
+	StatusCode int // http status of the daemon response, 0 when unknown
+	Message    string
+	Cause      error
+}
+
+// Error returns a string representation of a ContainerCreateError
+func (e ContainerCreateError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("unable to create container %s from image %s, daemon responded with %d %s: %s",
+			e.Container, e.Image, e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+	}
+	return fmt.Sprintf("unable to create container %s from image %s: %s", e.Container, e.Image, e.Message)
+}
+
+// Unwrap returns the error returned by the docker client
+func (e ContainerCreateError) Unwrap() error { return e.Cause }
+
+// containerCreateError converts a docker client error from creating a container into a ContainerCreateError.
+// Context errors are returned as is so callers can still tell a cancelled or timed out create apart.
+func containerCreateError(container string, image string, err error) error {
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return err
+	}
+
+	msg := strings.TrimSpace(err.Error())
+	fromDaemon := strings.HasPrefix(msg, daemonErrorPrefix)
+	msg = strings.TrimPrefix(msg, daemonErrorPrefix)
+
+	// the disk full error is kept as the cause so the failure is not retried
+	if isDiskFull(msg) {
+		err = DiskFullError{Op: "create", Ref: container, Message: msg}
+	}
+
+	// the docker client does not expose the status of daemon responses,
+	// it is recovered for the errors the daemon reports in a known format
+	status := 0
+	switch {
+	case client.IsErrImageNotFound(err):
+		status = http.StatusNotFound
+	case fromDaemon && strings.HasPrefix(msg, "Conflict."):
+		status = http.StatusConflict
+	}
+
+	return ContainerCreateError{Container: container, Image: image, StatusCode: status, Message: msg, Cause: err}
+}
+
+// streamMessage is a json message part of a docker stream (ie. image pull progress)
+type streamMessage struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+}
+
+// StreamMessageError returns an error if a line from a docker json stream reports an error
+func StreamMessageError(ref string, line []byte) error {
+	var msg streamMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		return nil
+	}
+
+	if msg.Error == "" {
+		return nil
+	}
+
+	return pullImageError(ref, errors.New(msg.Error))
+}
+
+// pullImageError converts an image pull error into a typed error when the cause is known
+func pullImageError(ref string, err error) error {
+	msg := err.Error()
+	lower := strings.ToLower(msg)
+
+	if isDiskFull(lower) {
+		return DiskFullError{Op: "pull", Ref: ref, Message: msg}
+	}
+
+	if strings.Contains(lower, "toomanyrequests") || strings.Contains(lower, "too many requests") {
+		retryAfter := DefaultRateLimitRetryAfter
+		if match := retryAfterRegex.FindStringSubmatch(msg); len(match) == 2 {
+			if seconds, err := strconv.Atoi(match[1]); err == nil {
+				retryAfter = time.Duration(seconds) * time.Second
+			}
+		}
+		return RateLimitError{Ref: ref, Message: msg, retryAfter: retryAfter}
+	}
+
+	if isImageNotFound(lower) {
+		return ImageNotFoundError{Ref: ref, Message: msg}
+	}
+
+	// docker hub reports missing and private repositories the same way, those are reported as not found above
+	if isRegistryAuthError(lower) {
+		return RegistryAuthError{Ref: ref, Message: msg}
+	}
+
+	return err
+}
+
+// isImageNotFound returns true if a (lower cased) pull error message reports a missing image or tag
+func isImageNotFound(msg string) bool {
+	return strings.Contains(msg, "manifest unknown") ||
+		strings.Contains(msg, "repository does not exist") ||
+		(strings.Contains(msg, "manifest for") && strings.Contains(msg, "not found"))
+}
+
+// isRegistryAuthError returns true if a (lower cased) pull error message reports missing or rejected credentials
+func isRegistryAuthError(msg string) bool {
+	return strings.Contains(msg, "unauthorized") ||
+		strings.Contains(msg, "authentication required") ||
+		strings.Contains(msg, "no basic auth credentials") ||
+		strings.Contains(msg, "incorrect username or password") ||
+		strings.Contains(msg, "denied: requested access to the resource is denied")
+}
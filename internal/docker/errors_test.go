@@ -0,0 +1,113 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitPullError(t *testing.T) {
+	err := pullImageError("docker.io/library/nginx:latest", errors.New("toomanyrequests: You have reached your pull rate limit"))
+
+	rateLimitErr, ok := err.(RateLimitError)
+	assert.True(t, ok)
+	assert.Equal(t, DefaultRateLimitRetryAfter, rateLimitErr.RetryAfter())
+}
+
+func TestRateLimitPullErrorWithRetryAfter(t *testing.T) {
+	err := pullImageError("docker.io/library/nginx:latest", errors.New("429 Too Many Requests, Retry-After: 120"))
+
+	rateLimitErr, ok := err.(RateLimitError)
+	assert.True(t, ok)
+	assert.Equal(t, 120*time.Second, rateLimitErr.RetryAfter())
+}
+
+func TestStreamMessageError(t *testing.T) {
+	assert.Nil(t, StreamMessageError("nginx", []byte(`{"status":"Downloading"}`)))
+	assert.Nil(t, StreamMessageError("nginx", []byte(`not json`)))
+	assert.EqualError(t, StreamMessageError("nginx", []byte(`{"error":"unexpected EOF"}`)), "unexpected EOF")
+
+	_, ok := StreamMessageError("nginx", []byte(`{"error":"toomanyrequests: rate limit exceeded"}`)).(RateLimitError)
+	assert.True(t, ok)
+}
+
+func TestImageNotFoundPullError(t *testing.T) {
+	ref := "docker.io/library/ngnix:latest"
+	for _, msg := range []string{
+		"manifest for ngnix:latest not found: manifest unknown: manifest unknown",
+		"pull access denied for ngnix, repository does not exist or may require 'docker login'",
+	} {
+		err := pullImageError(ref, errors.New(msg))
+
+		notFoundErr, ok := err.(ImageNotFoundError)
+		assert.True(t, ok)
+		assert.True(t, notFoundErr.Permanent())
+		assert.Contains(t, err.Error(), "image not found: "+ref)
+	}
+
+	_, ok := pullImageError(ref, errors.New("connection reset by peer")).(ImageNotFoundError)
+	assert.False(t, ok)
+}
+
+func TestRegistryAuthPullError(t *testing.T) {
+	ref := "ghcr.io/org/private:latest"
+	for _, msg := range []string{
+		"Head https://ghcr.io/v2/org/private/manifests/latest: unauthorized",
+		"Get https://registry.example.com/v2/: no basic auth credentials",
+		"unauthorized: incorrect username or password",
+	} {
+		err := pullImageError(ref, errors.New(msg))
+
+		authErr, ok := err.(RegistryAuthError)
+		assert.True(t, ok)
+		assert.True(t, authErr.Permanent())
+		assert.Contains(t, err.Error(), "registry authentication failed pulling "+ref)
+	}
+
+	// missing images are not reported as authentication failures
+	_, ok := pullImageError(ref, errors.New("manifest unknown")).(RegistryAuthError)
+	assert.False(t, ok)
+}
+
+func TestContainerCreateError(t *testing.T) {
+	cause := errors.New("Error response from daemon: invalid mount config for type \"bind\": bind source path does not exist: /data")
+	err := containerCreateError("app-1", "nginx:latest", cause)
+
+	createErr, ok := err.(ContainerCreateError)
+	assert.True(t, ok)
+	assert.Equal(t, 0, createErr.StatusCode)
+	assert.True(t, errors.Is(err, cause))
+	assert.EqualError(t, err, "unable to create container app-1 from image nginx:latest: invalid mount config for type \"bind\": bind source path does not exist: /data")
+}
+
+func TestContainerCreateErrorConflict(t *testing.T) {
+	err := containerCreateError("app-1", "nginx:latest", errors.New("Error response from daemon: Conflict. The container name \"/app-1\" is already in use"))
+
+	assert.Equal(t, 409, err.(ContainerCreateError).StatusCode)
+	assert.EqualError(t, err, "unable to create container app-1 from image nginx:latest, daemon responded with 409 Conflict: Conflict. The container name \"/app-1\" is already in use")
+}
+
+func TestContainerCreateErrorContext(t *testing.T) {
+	assert.Equal(t, context.DeadlineExceeded, containerCreateError("app-1", "nginx:latest", context.DeadlineExceeded))
+}
+
+func TestDiskFullPullError(t *testing.T) {
+	err := StreamMessageError("nginx:latest", []byte(`{"error":"failed to register layer: write /usr/lib/libc.so: no space left on device"}`))
+
+	diskFullErr, ok := err.(DiskFullError)
+	assert.True(t, ok)
+	assert.Equal(t, "pull", diskFullErr.Op)
+	assert.True(t, diskFullErr.Permanent())
+}
+
+func TestDiskFullContainerCreateError(t *testing.T) {
+	err := containerCreateError("app-1", "nginx:latest", errors.New("Error response from daemon: mkdir /var/lib/docker/overlay2/abc: no space left on device"))
+
+	var diskFullErr DiskFullError
+	assert.True(t, errors.As(err, &diskFullErr))
+	assert.Equal(t, "create", diskFullErr.Op)
+	assert.Equal(t, "app-1", diskFullErr.Ref)
+}
@@ -6,18 +6,20 @@ import (
 	"io"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 )
 
 // PullImage pulls a container image from a registry onto the host machine
-func (c *Client) PullImage(image string, tag string, registry RegistryCredentials) (io.Reader, error) {
-	ctx := context.Background()
-	defer ctx.Done()
-
-	ref := createImageRef(registry.URL, image, tag)
-	return c.ImagePull(ctx, ref, types.ImagePullOptions{
+func (c *Client) PullImage(ctx context.Context, ref string, registry RegistryCredentials) (io.ReadCloser, error) {
+	reader, err := c.ImagePull(ctx, ref, types.ImagePullOptions{
 		All:          false,
-		RegistryAuth: Base64RegistryCredentials(registry.Username, registry.Password),
+		RegistryAuth: Base64RegistryCredentials(registry),
 	})
+	if err != nil {
+		return nil, pullImageError(ref, err)
+	}
+	return reader, nil
 }
 
 // RemoveImage removes a docker image from the host machine
@@ -29,8 +31,64 @@ func (c *Client) RemoveImage(ctx *context.Context, imageID string) ([]types.Imag
 	return c.ImageRemove(*ctx, imageID, options)
 }
 
-// createImageRef returns a formatted docker image url
-func createImageRef(registry, image, tag string) string {
+// RemoveUnusedImage removes a docker image from the host machine unless a container uses it, docker refuses
+// to remove an image used by a container instead of removing it from under the container
+func (c *Client) RemoveUnusedImage(ctx context.Context, imageID string) ([]types.ImageDelete, error) {
+	options := types.ImageRemoveOptions{
+		Force:         false,
+		PruneChildren: true,
+	}
+	return c.ImageRemove(ctx, imageID, options)
+}
+
+// PruneDanglingImages removes the untagged images not used by any container, returning the disk space reclaimed
+func (c *Client) PruneDanglingImages(ctx context.Context) (uint64, error) {
+	args := filters.NewArgs()
+	args.Add("dangling", "true")
+	report, err := c.ImagesPrune(ctx, args)
+	if err != nil {
+		return 0, err
+	}
+	return report.SpaceReclaimed, nil
+}
+
+// GetImageDigests returns the repository digests (ie. nginx@sha256:...) of a docker image
+func (c *Client) GetImageDigests(ctx context.Context, imageID string) ([]string, error) {
+	image, _, err := c.ImageInspectWithRaw(ctx, imageID)
+	if err != nil {
+		return nil, err
+	}
+	return image.RepoDigests, nil
+}
+
+// GetImageUser returns the user a docker image runs as, empty when the image does not set a user (root)
+func (c *Client) GetImageUser(ctx context.Context, imageID string) (string, error) {
+	image, _, err := c.ImageInspectWithRaw(ctx, imageID)
+	if err != nil {
+		return "", err
+	}
+	if image.Config == nil {
+		return "", nil
+	}
+	return image.Config.User, nil
+}
+
+// GetImageConfig returns the container configuration a docker image defines (env, labels, cmd...), nil when the image does not define one
+func (c *Client) GetImageConfig(ctx context.Context, imageID string) (*container.Config, error) {
+	image, _, err := c.ImageInspectWithRaw(ctx, imageID)
+	if err != nil {
+		return nil, err
+	}
+	return image.Config, nil
+}
+
+// ImageDigestRef returns a formatted docker image url pinned to a digest
+func ImageDigestRef(registry, image, digest string) string {
+	return fmt.Sprintf("%s/%s@%s", registry, image, digest)
+}
+
+// ImageRef returns a formatted docker image url
+func ImageRef(registry, image, tag string) string {
 	if tag == "" {
 		tag = "latest"
 	}
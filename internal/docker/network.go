@@ -3,17 +3,28 @@ package docker
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/network"
 
+	"github.com/krane/krane/internal/constants"
 	"github.com/krane/krane/internal/logger"
 )
 
 // KraneNetworkName is the network used for Krane managed containers
 const KraneNetworkName = "krane"
 
-// EnsureKraneDockerNetwork ensure the Krane docker network is created
+// ProxyNetworkName returns the network shared by the network proxy and routed containers.
+// Defaults to the Krane network.
+func ProxyNetworkName() string {
+	if name := os.Getenv(constants.EnvProxyNetwork); name != "" {
+		return name
+	}
+	return KraneNetworkName
+}
+
+// EnsureKraneDockerNetwork ensure the Krane and proxy docker networks are created
 func EnsureKraneDockerNetwork() {
 	ctx := context.Background()
 	defer ctx.Done()
@@ -22,6 +33,15 @@ func EnsureKraneDockerNetwork() {
 	if err != nil {
 		logger.Fatalf("Unable to create Krane network, %v", err)
 	}
+
+	if ProxyNetworkName() == KraneNetworkName {
+		return
+	}
+
+	_, err = instance.CreateBridgeNetwork(&ctx, ProxyNetworkName())
+	if err != nil {
+		logger.Fatalf("Unable to create proxy network %s, %v", ProxyNetworkName(), err)
+	}
 }
 
 // CreateBridgeNetwork creates a docker bridge network
@@ -57,6 +77,16 @@ func (c *Client) GetNetworkByName(name string) (types.NetworkResource, error) {
 	return types.NetworkResource{}, fmt.Errorf("network %s not found", name)
 }
 
+// InspectNetwork returns a docker network including the containers attached to it
+func (c *Client) InspectNetwork(ctx context.Context, networkID string) (types.NetworkResource, error) {
+	return c.NetworkInspect(ctx, networkID)
+}
+
+// DisconnectNetwork disconnects a container from a docker network
+func (c *Client) DisconnectNetwork(ctx context.Context, networkName string, containerID string) error {
+	return c.NetworkDisconnect(ctx, networkName, containerID, false)
+}
+
 // createNetworkingConfig create the container network config
 func createNetworkingConfig(networkID string, aliases []string) network.NetworkingConfig {
 	return network.NetworkingConfig{
@@ -0,0 +1,64 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// architectures maps the kernel architecture names reported by the docker host to OCI architecture names
+var architectures = map[string]string{
+	"x86_64":  "amd64",
+	"aarch64": "arm64",
+	"armv7l":  "arm",
+	"armv6l":  "arm",
+	"i386":    "386",
+	"i686":    "386",
+}
+
+// PlatformMismatchError is returned when a pulled image was not built for the requested platform.
+// Pulling the image again will not change its platform so the error is permanent.
+type PlatformMismatchError struct {
+	Ref      string
+	Expected string
+	Actual   string
+}
+
+// Error returns a string representation of a PlatformMismatchError
+func (e PlatformMismatchError) Error() string {
+	return fmt.Sprintf("image %s is built for platform %s, expected %s", e.Ref, e.Actual, e.Expected)
+}
+
+// Permanent returns true since retrying the pull returns an image for the same platform
+func (e PlatformMismatchError) Permanent() bool { return true }
+
+// NormalizeArchitecture returns the OCI architecture name (ie. amd64) for an architecture
+func NormalizeArchitecture(arch string) string {
+	if normalized, ok := architectures[arch]; ok {
+		return normalized
+	}
+	return arch
+}
+
+// Platform returns a platform string (ie. linux/arm64) for an os and architecture
+func Platform(os, arch string) string {
+	return fmt.Sprintf("%s/%s", strings.ToLower(os), NormalizeArchitecture(strings.ToLower(arch)))
+}
+
+// HostPlatform returns the native platform (ie. linux/amd64) of the docker host
+func (c *Client) HostPlatform(ctx context.Context) (string, error) {
+	info, err := c.Info(ctx)
+	if err != nil {
+		return "", err
+	}
+	return Platform(info.OSType, info.Architecture), nil
+}
+
+// ImagePlatform returns the platform (ie. linux/amd64) a docker image was built for
+func (c *Client) ImagePlatform(ctx context.Context, imageID string) (string, error) {
+	image, _, err := c.ImageInspectWithRaw(ctx, imageID)
+	if err != nil {
+		return "", err
+	}
+	return Platform(image.Os, image.Architecture), nil
+}
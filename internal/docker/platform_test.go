@@ -0,0 +1,20 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlatform(t *testing.T) {
+	assert.Equal(t, "linux/amd64", Platform("linux", "x86_64"))
+	assert.Equal(t, "linux/arm64", Platform("linux", "aarch64"))
+	assert.Equal(t, "linux/arm64", Platform("Linux", "arm64"))
+	assert.Equal(t, "windows/amd64", Platform("windows", "amd64"))
+}
+
+func TestPlatformMismatchErrorIsPermanent(t *testing.T) {
+	err := PlatformMismatchError{Ref: "docker.io/nginx:latest", Expected: "linux/arm64", Actual: "linux/amd64"}
+	assert.True(t, err.Permanent())
+	assert.Contains(t, err.Error(), "linux/arm64")
+}
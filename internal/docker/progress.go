@@ -0,0 +1,50 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// PullProgress tracks the progress of an image pull from the messages of a pull stream
+type PullProgress struct {
+	Ref    string
+	layers map[string]bool // layer id -> whether the layer is complete
+}
+
+// NewPullProgress returns a PullProgress for an image reference
+func NewPullProgress(ref string) *PullProgress {
+	return &PullProgress{Ref: ref, layers: make(map[string]bool)}
+}
+
+// Update updates the pull progress from a pull stream message.
+// Returns false if the message is not about an image layer.
+func (p *PullProgress) Update(line []byte) bool {
+	var msg struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(line, &msg); err != nil || msg.ID == "" {
+		return false
+	}
+
+	switch msg.Status {
+	case "Pull complete", "Already exists":
+		p.layers[msg.ID] = true
+	default:
+		if _, ok := p.layers[msg.ID]; !ok {
+			p.layers[msg.ID] = false
+		}
+	}
+	return true
+}
+
+// String returns a summary of the pull progress
+func (p *PullProgress) String() string {
+	complete := 0
+	for _, done := range p.layers {
+		if done {
+			complete++
+		}
+	}
+	return fmt.Sprintf("pulling %s: %d/%d layer(s) complete", p.Ref, complete, len(p.layers))
+}
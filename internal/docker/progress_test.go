@@ -0,0 +1,20 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPullProgress(t *testing.T) {
+	p := NewPullProgress("docker.io/library/nginx:latest")
+
+	assert.False(t, p.Update([]byte(`{"status":"Pulling from library/nginx","id":""}`)))
+	assert.True(t, p.Update([]byte(`{"status":"Pulling fs layer","id":"a"}`)))
+	assert.True(t, p.Update([]byte(`{"status":"Already exists","id":"b"}`)))
+	assert.True(t, p.Update([]byte(`{"status":"Downloading","id":"a"}`)))
+	assert.Equal(t, "pulling docker.io/library/nginx:latest: 1/2 layer(s) complete", p.String())
+
+	p.Update([]byte(`{"status":"Pull complete","id":"a"}`))
+	assert.Equal(t, "pulling docker.io/library/nginx:latest: 2/2 layer(s) complete", p.String())
+}
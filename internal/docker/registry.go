@@ -3,19 +3,34 @@ package docker
 import (
 	"encoding/base64"
 	"encoding/json"
+
+	"github.com/docker/docker/api/types"
 )
 
 type RegistryCredentials struct {
 	URL      string `json:"url"`
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Token    string `json:"token"` // bearer token sent to the registry instead of a username and password
+}
+
+// Authenticated returns true if the credentials authenticate pulls, pulls are anonymous otherwise
+func (r RegistryCredentials) Authenticated() bool {
+	return r.Username != "" || r.Password != "" || r.Token != ""
 }
 
-// Base64RegistryCredentials returns base64 container registry credentials
-func Base64RegistryCredentials(username string, password string) string {
-	bytes, _ := json.Marshal(RegistryCredentials{
-		Username: username,
-		Password: password,
+// Base64RegistryCredentials returns base64 container registry credentials,
+// empty for anonymous pulls so the docker client sends no credentials at all
+func Base64RegistryCredentials(registry RegistryCredentials) string {
+	if !registry.Authenticated() {
+		return ""
+	}
+
+	bytes, _ := json.Marshal(types.AuthConfig{
+		Username:      registry.Username,
+		Password:      registry.Password,
+		ServerAddress: registry.URL,
+		RegistryToken: registry.Token,
 	})
-	return base64.StdEncoding.EncodeToString(bytes)
+	return base64.URLEncoding.EncodeToString(bytes)
 }
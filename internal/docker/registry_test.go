@@ -0,0 +1,26 @@
+package docker
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBase64RegistryCredentials(t *testing.T) {
+	assert.Equal(t, "", Base64RegistryCredentials(RegistryCredentials{URL: "docker.io"}))
+
+	encoded := Base64RegistryCredentials(RegistryCredentials{URL: "ghcr.io", Username: "bot", Password: "s3cret?>"})
+	decoded, err := base64.URLEncoding.DecodeString(encoded)
+	assert.Nil(t, err)
+
+	var auth types.AuthConfig
+	assert.Nil(t, json.Unmarshal(decoded, &auth))
+	assert.Equal(t, types.AuthConfig{Username: "bot", Password: "s3cret?>", ServerAddress: "ghcr.io"}, auth)
+
+	encoded = Base64RegistryCredentials(RegistryCredentials{URL: "registry.example.com", Token: "t0ken"})
+	decoded, _ = base64.URLEncoding.DecodeString(encoded)
+	assert.Contains(t, string(decoded), `"registrytoken":"t0ken"`)
+}
@@ -0,0 +1,48 @@
+package docker
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+)
+
+// EnsureVolume creates a named volume labeled with the deployment using it unless it already exists.
+// Returns true if the volume was created.
+func (c *Client) EnsureVolume(ctx context.Context, name string, deployment string) (bool, error) {
+	_, err := c.VolumeInspect(ctx, name)
+	if err == nil {
+		return false, nil
+	}
+
+	if !client.IsErrVolumeNotFound(err) {
+		return false, err
+	}
+
+	_, err = c.VolumeCreate(ctx, volume.VolumesCreateBody{
+		Name:       name,
+		Driver:     "local",
+		DriverOpts: map[string]string{},
+		Labels:     map[string]string{ContainerDeploymentLabel: deployment},
+	})
+	return err == nil, err
+}
+
+// GetVolumesByDeployment returns the named volumes created by Krane for a deployment
+func (c *Client) GetVolumesByDeployment(ctx context.Context, deployment string) ([]*types.Volume, error) {
+	args := filters.NewArgs()
+	args.Add("label", ContainerDeploymentLabel+"="+deployment)
+
+	body, err := c.VolumeList(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return body.Volumes, nil
+}
+
+// RemoveVolume removes a named volume, volumes used by a container are not removed
+func (c *Client) RemoveVolume(ctx context.Context, name string) error {
+	return c.Client.VolumeRemove(ctx, name, false)
+}
@@ -0,0 +1,69 @@
+package job
+
+import (
+	"time"
+)
+
+// maxAttemptLogs is the max number of log lines kept for a single attempt of a job
+const maxAttemptLogs = 500
+
+// Attempt is a single execution of a job, a job is executed once and once more for every retry
+type Attempt struct {
+	Execution uint           `json:"execution"`        // execution count of the attempt, starting at 1
+	StartTime int64          `json:"start_time_epoch"` // attempt start time - epoch in seconds since 1970
+	EndTime   int64          `json:"end_time_epoch"`   // attempt end time - epoch in seconds since 1970
+	Durations []StepDuration `json:"durations"`        // time spent in job steps during the attempt
+	Logs      []string       `json:"logs"`             // messages logged by the job steps during the attempt
+	Error     string         `json:"error,omitempty"`  // error the attempt failed with, empty if the attempt succeeded
+}
+
+// RecordLog records a message logged while executing a job (ie. a deployment event).
+// Logs are stored with the attempt of the job they were recorded in once it ends.
+func RecordLog(jobID string, message string) {
+	if jobID == "" {
+		return
+	}
+
+	contextsMu.Lock()
+	defer contextsMu.Unlock()
+
+	jc, ok := contexts[jobID]
+	if !ok || len(jc.logs)-jc.attemptLogs >= maxAttemptLogs {
+		return
+	}
+	jc.logs = append(jc.logs, message)
+	contexts[jobID] = jc
+}
+
+// startAttempt marks the start of a new attempt of a job, durations and logs recorded from then on belong to the attempt
+func startAttempt(jobID string) {
+	contextsMu.Lock()
+	defer contextsMu.Unlock()
+
+	jc, ok := contexts[jobID]
+	if !ok {
+		return
+	}
+	jc.attemptDurations = len(jc.durations)
+	jc.attemptLogs = len(jc.logs)
+	contexts[jobID] = jc
+}
+
+// recordedAttempt returns an attempt with the durations and logs recorded since the attempt started
+func recordedAttempt(jobID string, execution uint, start time.Time, err error) Attempt {
+	contextsMu.RLock()
+	defer contextsMu.RUnlock()
+
+	jc := contexts[jobID]
+	attempt := Attempt{
+		Execution: execution,
+		StartTime: start.Unix(),
+		EndTime:   time.Now().Unix(),
+		Durations: append(make([]StepDuration, 0), jc.durations[jc.attemptDurations:]...),
+		Logs:      append(make([]string, 0), jc.logs[jc.attemptLogs:]...),
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	return attempt
+}
@@ -0,0 +1,148 @@
+package job
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/krane/krane/internal/logger"
+)
+
+// Errors returned when cancelling a job
+var (
+	ErrJobNotFound   = errors.New("job is not queued or running")
+	ErrJobCompleting = errors.New("job is completing and can no longer be cancelled")
+	ErrJobCancelled  = errors.New("job cancelled")
+)
+
+var cancelMu sync.Mutex
+var cancelledJobs = make(map[string]bool) // queued jobs cancelled before reaching a worker
+
+// cancelSignal wakes up the dispatcher to remove the cancelled jobs from the queue
+var cancelSignal = make(chan struct{}, 1)
+
+// Cancel cancels a queued or running job of a deployment. A queued job is removed from the queue without executing.
+// A running job has its context cancelled, aborting the step in progress, and is not retried. Its Finally handler
+// does not run, the job can no longer be cancelled once its Run handler succeeded. Jobs of another deployment are not found.
+func Cancel(deployment string, jobID string) error {
+	found, err := cancelRunning(deployment, jobID)
+	if err != nil {
+		return err
+	}
+	if found {
+		logger.Infof("Cancelling running job %s", jobID)
+		return nil
+	}
+
+	if !isPending(deployment, jobID) {
+		return ErrJobNotFound
+	}
+
+	logger.Infof("Cancelling queued job %s", jobID)
+	cancelMu.Lock()
+	cancelledJobs[jobID] = true
+	cancelMu.Unlock()
+
+	select {
+	case cancelSignal <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// cancelRunning cancels the context of a running job of a deployment, returns whether the job is running
+func cancelRunning(deployment string, jobID string) (bool, error) {
+	contextsMu.Lock()
+	defer contextsMu.Unlock()
+
+	jc, ok := contexts[jobID]
+	if !ok || jc.deployment != deployment {
+		return false, nil
+	}
+	if jc.finalizing {
+		return true, ErrJobCompleting
+	}
+
+	jc.cancelled = true
+	contexts[jobID] = jc
+	jc.cancel()
+	return true, nil
+}
+
+// wasCancelled returns whether a running job was cancelled
+func wasCancelled(jobID string) bool {
+	contextsMu.RLock()
+	defer contextsMu.RUnlock()
+	return contexts[jobID].cancelled
+}
+
+// beginFinally marks a running job whose Run handler succeeded as completing so it can no longer be cancelled.
+// A cancel that reached the job after the last step of its Run handler is too late: the job still completes,
+// with a new context since the cancelled one would abort its Finally handler.
+func beginFinally(jobID string) {
+	contextsMu.Lock()
+	defer contextsMu.Unlock()
+
+	jc, ok := contexts[jobID]
+	if !ok {
+		return
+	}
+	if jc.cancelled {
+		logger.Infof("Job %s was cancelled once its Run handler succeeded, completing the job", jobID)
+		jc.cancel()
+		jc.ctx, jc.cancel = context.WithCancel(context.WithValue(context.Background(), jobIDKey{}, jobID))
+		jc.cancelled = false
+	}
+	jc.finalizing = true
+	contexts[jobID] = jc
+}
+
+// isPending returns whether a job of a deployment is waiting in the queue
+func isPending(deployment string, jobID string) bool {
+	for _, pending := range GetQueueStatus().Pending {
+		if pending.ID == jobID && pending.Deployment == deployment {
+			return true
+		}
+	}
+	return false
+}
+
+// takeCancelled returns whether a queued job was cancelled, forgetting the cancellation
+func takeCancelled(jobID string) bool {
+	cancelMu.Lock()
+	defer cancelMu.Unlock()
+
+	cancelled := cancelledJobs[jobID]
+	delete(cancelledJobs, jobID)
+	return cancelled
+}
+
+// removeCancelled removes the cancelled jobs from the queue
+func (q *priorityQueue) removeCancelled() {
+	for i := 0; i < q.Len(); {
+		j := (*q)[i].job
+		if !takeCancelled(j.ID) {
+			i++
+			continue
+		}
+		heap.Remove(q, i)
+		recordCancelled(j)
+		i = 0 // removing an item reorders the heap
+	}
+}
+
+// recordCancelled saves a queued job cancelled before it executed
+func recordCancelled(j Job) {
+	logger.Infof("Job %s for deployment %s cancelled while queued", j.ID, j.Deployment)
+
+	now := time.Now().Unix()
+	j.StartTime = now
+	j.EndTime = now
+	j.State = Completed
+	j.Cancelled = true
+	j.WithError(ErrJobCancelled)
+	j.save()
+	j.notifyComplete()
+}
@@ -0,0 +1,151 @@
+package job
+
+import (
+	"container/heap"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// waitForContext blocks until a job is running
+func waitForContext(jobID string) {
+	for {
+		contextsMu.RLock()
+		_, ok := contexts[jobID]
+		contextsMu.RUnlock()
+		if ok {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCancelRunningJob(t *testing.T) {
+	w := newWorker(context.Background(), nil, nil)
+	runs, finally := 0, 0
+	job := Job{ID: "cancel-running", Deployment: "test", RetryPolicy: 3}
+	job.Run = func(args interface{}) error {
+		runs++
+		<-Context(job.ID).Done()
+		return Context(job.ID).Err()
+	}
+	job.Finally = func(args interface{}) error {
+		finally++
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		w.execute(&job)
+		close(done)
+	}()
+
+	waitForContext(job.ID)
+	assert.Equal(t, ErrJobNotFound, Cancel("other", job.ID))
+	assert.Nil(t, Cancel("test", job.ID))
+	<-done
+
+	assert.Equal(t, 1, runs)
+	assert.Equal(t, 0, finally)
+	assert.True(t, job.Cancelled)
+	assert.False(t, job.Succeeded())
+	assert.Equal(t, ErrJobCancelled.Error(), job.Status.Failures[len(job.Status.Failures)-1].Message)
+}
+
+func TestCancelCompletingJob(t *testing.T) {
+	w := newWorker(context.Background(), nil, nil)
+	release := make(chan struct{})
+	job := Job{ID: "cancel-completing", Deployment: "test", RetryPolicy: 1}
+	job.Run = func(args interface{}) error { return nil }
+	job.Finally = func(args interface{}) error {
+		<-release
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		w.execute(&job)
+		close(done)
+	}()
+
+	waitForContext(job.ID)
+	for !func() bool {
+		contextsMu.RLock()
+		defer contextsMu.RUnlock()
+		return contexts[job.ID].finalizing
+	}() {
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.Equal(t, ErrJobCompleting, Cancel("test", job.ID))
+	close(release)
+	<-done
+	assert.True(t, job.Succeeded())
+}
+
+func TestCancelQueuedJob(t *testing.T) {
+	assert.Equal(t, ErrJobNotFound, Cancel("test", "cancel-unknown"))
+
+	q := &priorityQueue{}
+	heap.Push(q, priorityItem{job: Job{ID: "cancel-queued-1", Deployment: "test"}, seq: 1})
+	heap.Push(q, priorityItem{job: Job{ID: "cancel-queued-2", Deployment: "test"}, seq: 2})
+	heap.Push(q, priorityItem{job: Job{ID: "cancel-queued-3", Deployment: "test"}, seq: 3})
+	updateQueueStatus(*q)
+	defer updateQueueStatus(priorityQueue{})
+
+	// jobs of another deployment are not found
+	assert.Equal(t, ErrJobNotFound, Cancel("other", "cancel-queued-2"))
+	assert.Nil(t, Cancel("test", "cancel-queued-2"))
+	q.removeCancelled()
+
+	assert.Equal(t, 2, q.Len())
+	assert.Equal(t, "cancel-queued-1", heap.Pop(q).(priorityItem).job.ID)
+	assert.Equal(t, "cancel-queued-3", heap.Pop(q).(priorityItem).job.ID)
+}
+
+func TestWorkerSkipsCancelledJob(t *testing.T) {
+	w := newWorker(context.Background(), nil, nil)
+	runs := 0
+	var completed *Job
+	job := Job{ID: "cancel-handed-over", Deployment: "test", RetryPolicy: 1, Run: func(args interface{}) error {
+		runs++
+		return nil
+	}}
+	job.OnComplete = func(j Job) { completed = &j }
+
+	cancelMu.Lock()
+	cancelledJobs[job.ID] = true
+	cancelMu.Unlock()
+
+	w.execute(&job)
+	assert.Equal(t, 0, runs)
+	assert.False(t, takeCancelled(job.ID))
+
+	// a job cancelled before executing still notifies its completion
+	assert.NotNil(t, completed)
+	assert.True(t, completed.Cancelled)
+}
+
+func TestCancelAfterRunSucceeded(t *testing.T) {
+	w := newWorker(context.Background(), nil, nil)
+	finally := 0
+	job := Job{ID: "cancel-after-run", Deployment: "test", RetryPolicy: 1}
+	job.Run = func(args interface{}) error {
+		// the cancel lands once the last step of the Run handler completed
+		assert.Nil(t, Cancel("test", job.ID))
+		return nil
+	}
+	job.Finally = func(args interface{}) error {
+		finally++
+		assert.Nil(t, Context(job.ID).Err())
+		return nil
+	}
+
+	w.execute(&job)
+
+	assert.Equal(t, 1, finally)
+	assert.False(t, job.Cancelled)
+	assert.True(t, job.Succeeded())
+}
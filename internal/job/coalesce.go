@@ -0,0 +1,102 @@
+package job
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/krane/krane/internal/logger"
+)
+
+// QueueStatus is the state of the jobs waiting for a worker
+type QueueStatus struct {
+	Pending   []PendingJob `json:"pending"`   // queued jobs in the order they will be processed
+	Coalesced uint         `json:"coalesced"` // number of queued jobs replaced by a newer job since Krane started
+}
+
+// PendingJob is a queued job waiting for a worker
+type PendingJob struct {
+	ID         string   `json:"id"`
+	Deployment string   `json:"deployment"`
+	Type       string   `json:"type"`
+	Priority   int      `json:"priority"`
+	Coalesced  []string `json:"coalesced"` // ids of the queued jobs this job replaced
+}
+
+var queueMu sync.Mutex
+var queueStatus = QueueStatus{Pending: make([]PendingJob, 0)}
+
+// GetQueueStatus returns the jobs waiting for a worker
+func GetQueueStatus() QueueStatus {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+
+	status := queueStatus
+	status.Pending = append([]PendingJob{}, queueStatus.Pending...)
+	return status
+}
+
+// coalesce replaces a queued job that has not started with a newer job of the same deployment and type
+// when both can be coalesced, so a burst of runs only deploys the latest configuration once. The newer
+// job keeps the place of the job it replaces in the queue. Returns the replaced job, if any.
+func (q *priorityQueue) coalesce(j Job) (Job, bool) {
+	if !j.Coalesce {
+		return Job{}, false
+	}
+
+	for i, item := range *q {
+		queued := item.job
+		if !queued.Coalesce || queued.Deployment != j.Deployment || queued.Type != j.Type {
+			continue
+		}
+
+		j.Coalesced = append(append(append([]string{}, queued.Coalesced...), queued.ID), j.Coalesced...)
+		(*q)[i].job = j
+		heap.Fix(q, i)
+		return queued, true
+	}
+	return Job{}, false
+}
+
+// recordCoalesced saves a queued job replaced by a newer job, the job completes without executing
+func recordCoalesced(replaced Job, by Job) {
+	logger.Infof("Job %s for deployment %s coalesced into job %s", replaced.ID, replaced.Deployment, by.ID)
+
+	now := time.Now().Unix()
+	replaced.StartTime = now
+	replaced.EndTime = now
+	replaced.State = Completed
+	replaced.CoalescedInto = by.ID
+	replaced.save()
+	replaced.notifyComplete()
+
+	queueMu.Lock()
+	queueStatus.Coalesced++
+	queueMu.Unlock()
+}
+
+// updateQueueStatus snapshots the queued jobs in the order they will be processed
+func updateQueueStatus(q priorityQueue) {
+	items := append(priorityQueue{}, q...)
+	sort.Slice(items, func(i, j int) bool { return items.Less(i, j) })
+
+	pending := make([]PendingJob, 0, len(items))
+	for _, item := range items {
+		coalesced := item.job.Coalesced
+		if coalesced == nil {
+			coalesced = make([]string, 0)
+		}
+		pending = append(pending, PendingJob{
+			ID:         item.job.ID,
+			Deployment: item.job.Deployment,
+			Type:       item.job.Type,
+			Priority:   item.job.Priority,
+			Coalesced:  coalesced,
+		})
+	}
+
+	queueMu.Lock()
+	queueStatus.Pending = pending
+	queueMu.Unlock()
+}
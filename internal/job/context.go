@@ -0,0 +1,174 @@
+package job
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type jobContext struct {
+	ctx              context.Context
+	cancel           context.CancelFunc
+	deployment       string // deployment the job belongs to
+	durations        []StepDuration
+	details          map[string]string
+	logs             []string
+	attemptDurations int    // index of the first duration recorded in the current attempt
+	attemptLogs      int    // index of the first log recorded in the current attempt
+	cancelled        bool   // whether the job was cancelled
+	step             string // workflow step the job is executing
+	finalizing       bool   // whether the Run handler of the job succeeded, the job can no longer be cancelled
+}
+
+type jobIDKey struct{}
+
+var contextsMu sync.RWMutex
+var contexts = make(map[string]jobContext)
+
+// Context returns the context for a running job. The context is cancelled when the job
+// completes or the worker executing the job is stopped. If the job is not running a
+// background context is returned.
+func Context(jobID string) context.Context {
+	contextsMu.RLock()
+	defer contextsMu.RUnlock()
+
+	jc, ok := contexts[jobID]
+	if !ok {
+		return context.Background()
+	}
+	return jc.ctx
+}
+
+// IDFromContext returns the id of the job a context was created for, empty if the context is not a job context
+func IDFromContext(ctx context.Context) string {
+	jobID, _ := ctx.Value(jobIDKey{}).(string)
+	return jobID
+}
+
+// withContext creates a cancellable context for a job derived from a parent context.
+// When a timeout is provided the context is cancelled once the timeout elapses.
+func withContext(parent context.Context, jobID string, deployment string, timeout time.Duration) context.Context {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	parent = context.WithValue(parent, jobIDKey{}, jobID)
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(parent, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(parent)
+	}
+
+	contextsMu.Lock()
+	contexts[jobID] = jobContext{ctx: ctx, cancel: cancel, deployment: deployment}
+	contextsMu.Unlock()
+
+	return ctx
+}
+
+// releaseContext cancels and removes the context for a job
+func releaseContext(jobID string) {
+	contextsMu.Lock()
+	defer contextsMu.Unlock()
+
+	jc, ok := contexts[jobID]
+	if !ok {
+		return
+	}
+	jc.cancel()
+	delete(contexts, jobID)
+}
+
+// RecordDuration records the time spent in a step of the job a context belongs to.
+// Recorded durations are stored with the job once it completes and observed by the step duration metric.
+func RecordDuration(ctx context.Context, step string, d time.Duration) {
+	jobID, ok := ctx.Value(jobIDKey{}).(string)
+	if !ok {
+		return
+	}
+
+	contextsMu.Lock()
+	defer contextsMu.Unlock()
+
+	jc, ok := contexts[jobID]
+	if !ok {
+		return
+	}
+	jc.durations = append(jc.durations, StepDuration{Step: step, DurationMs: d.Milliseconds()})
+	contexts[jobID] = jc
+	recordStepMetrics(step, d)
+}
+
+// RecordDetail records a value resolved while executing the job a context belongs to.
+// Recorded details are stored with the job once it completes.
+func RecordDetail(ctx context.Context, key string, value string) {
+	jobID, ok := ctx.Value(jobIDKey{}).(string)
+	if !ok {
+		return
+	}
+
+	contextsMu.Lock()
+	defer contextsMu.Unlock()
+
+	jc, ok := contexts[jobID]
+	if !ok {
+		return
+	}
+	if jc.details == nil {
+		jc.details = make(map[string]string)
+	}
+	jc.details[key] = value
+	contexts[jobID] = jc
+}
+
+// RecordStep records the workflow step the job a context belongs to is executing, replacing the previous step
+func RecordStep(ctx context.Context, step string) {
+	jobID, ok := ctx.Value(jobIDKey{}).(string)
+	if !ok {
+		return
+	}
+
+	contextsMu.Lock()
+	defer contextsMu.Unlock()
+
+	jc, ok := contexts[jobID]
+	if !ok {
+		return
+	}
+	jc.step = step
+	contexts[jobID] = jc
+}
+
+// CurrentStep returns the workflow step last recorded for the job a context belongs to, empty if none
+func CurrentStep(ctx context.Context) string {
+	jobID, ok := ctx.Value(jobIDKey{}).(string)
+	if !ok {
+		return ""
+	}
+
+	contextsMu.RLock()
+	defer contextsMu.RUnlock()
+	return contexts[jobID].step
+}
+
+// recordedDetails returns the details recorded for a job
+func recordedDetails(jobID string) map[string]string {
+	contextsMu.RLock()
+	defer contextsMu.RUnlock()
+
+	details := contexts[jobID].details
+	if details == nil {
+		return make(map[string]string)
+	}
+	return details
+}
+
+// recordedDurations returns the step durations recorded for a job
+func recordedDurations(jobID string) []StepDuration {
+	contextsMu.RLock()
+	defer contextsMu.RUnlock()
+
+	durations := contexts[jobID].durations
+	if durations == nil {
+		return make([]StepDuration, 0)
+	}
+	return durations
+}
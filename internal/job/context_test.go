@@ -0,0 +1,83 @@
+package job
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextForJobNotRunning(t *testing.T) {
+	ctx := Context("not-running")
+	assert.NotNil(t, ctx)
+	assert.Nil(t, ctx.Err())
+}
+
+func TestContextCancelledOnRelease(t *testing.T) {
+	ctx := withContext(context.Background(), "job-1", "test", 0)
+	assert.Equal(t, ctx, Context("job-1"))
+
+	releaseContext("job-1")
+	assert.Error(t, ctx.Err())
+	assert.Nil(t, Context("job-1").Err())
+}
+
+func TestContextCancelledWithParent(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	ctx := withContext(parent, "job-2", "test", 0)
+
+	cancel()
+	assert.Error(t, ctx.Err())
+
+	releaseContext("job-2")
+}
+
+func TestContextWithTimeout(t *testing.T) {
+	ctx := withContext(context.Background(), "job-3", "test", time.Millisecond)
+	<-ctx.Done()
+	assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+
+	releaseContext("job-3")
+}
+
+func TestRecordDuration(t *testing.T) {
+	ctx := withContext(context.Background(), "job-4", "test", 0)
+	RecordDuration(ctx, "create", 1500*time.Millisecond)
+	RecordDuration(context.Background(), "ignored", time.Second)
+
+	durations := recordedDurations("job-4")
+	assert.Len(t, durations, 1)
+	assert.Equal(t, "create", durations[0].Step)
+	assert.Equal(t, int64(1500), durations[0].DurationMs)
+
+	releaseContext("job-4")
+	assert.Empty(t, recordedDurations("job-4"))
+}
+
+func TestRecordDetail(t *testing.T) {
+	ctx := withContext(context.Background(), "job-5", "test", 0)
+	RecordDetail(ctx, "memory", "512")
+	RecordDetail(ctx, "memory", "1024")
+	RecordDetail(context.Background(), "ignored", "1")
+
+	assert.Equal(t, map[string]string{"memory": "1024"}, recordedDetails("job-5"))
+
+	releaseContext("job-5")
+	assert.Empty(t, recordedDetails("job-5"))
+}
+
+func TestRecordStep(t *testing.T) {
+	ctx := withContext(context.Background(), "job-6", "test", 0)
+	assert.Empty(t, CurrentStep(ctx))
+
+	RecordStep(ctx, "pull_image")
+	RecordStep(ctx, "health_check")
+	RecordStep(context.Background(), "ignored")
+
+	assert.Equal(t, "health_check", CurrentStep(ctx))
+	assert.Empty(t, CurrentStep(context.Background()))
+
+	releaseContext("job-6")
+	assert.Empty(t, CurrentStep(ctx))
+}
@@ -0,0 +1,189 @@
+package job
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed cron expression with the standard 5 fields: minute, hour, day of month,
+// month and day of week. Fields accept *, values, ranges (1-5), steps (*/15, 0-30/10) and comma
+// separated lists, months and days of week also accept their 3 letter names (jan, mon).
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64 // bit set of the values allowed by each field
+	domAny, dowAny                bool   // whether the day of month and day of week fields are *
+}
+
+// cronField is the range of values of a cron expression field
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	cronMinute = cronField{name: "minute", min: 0, max: 59}
+	cronHour   = cronField{name: "hour", min: 0, max: 23}
+	cronDom    = cronField{name: "day of month", min: 1, max: 31}
+	cronMonth  = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	cronDow = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// cronMacros are the shorthands for common cron expressions
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// maxCronSearch bounds the search for the next time of a schedule, schedules never due (ie. feb 30) return no time
+const maxCronSearch = 5 * 366 * 24 * time.Hour
+
+// ParseCron parses a cron expression (ie. 0 3 * * mon-fri) or macro (ie. @daily)
+func ParseCron(spec string) (CronSchedule, error) {
+	expr := strings.TrimSpace(strings.ToLower(spec))
+	if macro, ok := cronMacros[expr]; ok {
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return CronSchedule{}, fmt.Errorf("invalid cron expression %s, expected 5 fields (minute hour day-of-month month day-of-week)", spec)
+	}
+
+	var s CronSchedule
+	var err error
+	if s.minute, err = cronMinute.parse(fields[0]); err != nil {
+		return CronSchedule{}, err
+	}
+	if s.hour, err = cronHour.parse(fields[1]); err != nil {
+		return CronSchedule{}, err
+	}
+	if s.dom, err = cronDom.parse(fields[2]); err != nil {
+		return CronSchedule{}, err
+	}
+	if s.month, err = cronMonth.parse(fields[3]); err != nil {
+		return CronSchedule{}, err
+	}
+	if s.dow, err = cronDow.parse(fields[4]); err != nil {
+		return CronSchedule{}, err
+	}
+
+	// 7 is an alias of sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1 << 0
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+
+	return s, nil
+}
+
+// parse returns the bit set of the values allowed by a field
+func (f cronField) parse(field string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangeExpr, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			rangeExpr = part[:i]
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s < 1 {
+				return 0, fmt.Errorf("invalid %s step in %s", f.name, part)
+			}
+			step = s
+		}
+
+		start, end := f.min, f.max
+		switch {
+		case rangeExpr == "*":
+		case strings.Contains(rangeExpr, "-"):
+			bounds := strings.SplitN(rangeExpr, "-", 2)
+			var err error
+			if start, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if end, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid %s range %s, the range ends before it starts", f.name, rangeExpr)
+			}
+		default:
+			var err error
+			if start, err = f.value(rangeExpr); err != nil {
+				return 0, err
+			}
+			// a single value with a step (ie. 5/15) runs from the value to the end of the range
+			if step == 1 {
+				end = start
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// value returns a value of a field, by number or name
+func (f cronField) value(value string) (int, error) {
+	if v, ok := f.names[value]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(value)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s %s, expected a value between %d and %d", f.name, value, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next returns the first time after t the schedule is due, in the location of t. The zero time is returned
+// when the schedule is never due.
+func (s CronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxCronSearch)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches returns whether the day of t is allowed by the schedule. As with cron, when both the day of month and
+// day of week are restricted the day matches either of them.
+func (s CronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,51 @@
+package job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCron(t *testing.T) {
+	for _, spec := range []string{"* * * * *", "0 3 * * *", "*/15 0-6 1,15 * mon-fri", "30 2 * jan-mar 7", "5/10 * * * *", "@daily", "@Hourly"} {
+		_, err := ParseCron(spec)
+		assert.Nil(t, err, spec)
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "* * * * funday", "@often"} {
+		_, err := ParseCron(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, _ := time.Parse(time.RFC3339, value)
+		return parsed
+	}
+	next := func(spec string, after string) string {
+		s, err := ParseCron(spec)
+		assert.Nil(t, err)
+		return s.Next(at(after)).Format(time.RFC3339)
+	}
+
+	assert.Equal(t, "2020-10-01T12:01:00Z", next("* * * * *", "2020-10-01T12:00:30Z"))
+	assert.Equal(t, "2020-10-02T03:00:00Z", next("0 3 * * *", "2020-10-01T03:00:00Z"))
+	assert.Equal(t, "2020-10-01T12:15:00Z", next("*/15 * * * *", "2020-10-01T12:00:00Z"))
+	assert.Equal(t, "2020-10-04T00:00:00Z", next("@weekly", "2020-10-01T12:00:00Z")) // sunday, the 1st is a thursday
+	assert.Equal(t, "2021-01-01T00:00:00Z", next("@yearly", "2020-10-01T12:00:00Z"))
+	assert.Equal(t, "2021-02-28T09:30:00Z", next("30 9 28 feb *", "2020-10-01T12:00:00Z"))
+
+	// a restricted day of month and day of week match either
+	assert.Equal(t, "2020-10-03T00:00:00Z", next("0 0 15 * sat", "2020-10-01T12:00:00Z"))
+
+	// times are in the location of the time after which the schedule is due
+	ny, _ := time.LoadLocation("America/New_York")
+	s, _ := ParseCron("0 3 * * *")
+	assert.Equal(t, "2020-10-02T07:00:00Z", s.Next(at("2020-10-01T12:00:00Z").In(ny)).UTC().Format(time.RFC3339))
+
+	// never due
+	s, _ = ParseCron("0 0 30 feb *")
+	assert.True(t, s.Next(at("2020-10-01T12:00:00Z")).IsZero())
+}
@@ -20,5 +20,6 @@ func (e *Enqueuer) Enqueue(job Job) (Job, error) {
 	logger.Debugf("Queueing new job %s", job.ID)
 	e.queue <- job // Blocks here until space opens up in the queue
 	logger.Debugf("Job %s Queued", job.ID)
+	job.recordEnqueuedMetrics()
 	return job, nil
 }
@@ -2,6 +2,7 @@ package job
 
 import (
 	"os"
+	"strconv"
 	"testing"
 	"time"
 
@@ -46,7 +47,7 @@ func TestEnqueueNewJobs(t *testing.T) {
 	go func(handler *int) {
 		for i := 0; i < jobCount; i++ {
 			job := Job{
-				ID:         string(i),
+				ID:         strconv.Itoa(i),
 				Deployment: namespace,
 				Type:       "test",
 				Args:       map[string]string{"name": "test"},
@@ -68,7 +69,7 @@ func TestEnqueueNewJobs(t *testing.T) {
 		j := <-jobQueue
 		j.Run(j.Args)
 		assert.NotNil(t, j)
-		assert.Equal(t, j.ID, string(i))
+		assert.Equal(t, j.ID, strconv.Itoa(i))
 		assert.Equal(t, j.Deployment, namespace)
 		assert.Equal(t, j.Args.(map[string]string)["name"], "test")
 	}
@@ -1,5 +1,11 @@
 package job
 
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
 type Error struct {
 	Execution uint   `json:"execution"`
 	Message   string `json:"message"`
@@ -9,3 +15,21 @@ type Error struct {
 func (j *Job) WithError(err error) {
 	j.Status.Failures = append(j.Status.Failures, Error{j.Status.ExecutionCount, err.Error()})
 }
+
+// RetryAfterError is implemented by errors that require a delay before a job is retried
+type RetryAfterError interface {
+	error
+	RetryAfter() time.Duration
+}
+
+// PermanentError is implemented by errors that cannot be resolved by retrying a job
+type PermanentError interface {
+	error
+	Permanent() bool
+}
+
+// isPermanent returns true if an error (or any error it wraps) is a permanent error
+func isPermanent(err error) bool {
+	var permanentErr PermanentError
+	return errors.As(err, &permanentErr) && permanentErr.Permanent()
+}
@@ -14,18 +14,27 @@ import (
 )
 
 type Job struct {
-	ID          string         `json:"id"`               // Unique job ID
-	Deployment  string         `json:"deployment"`       // Deployment used for scoping jobs.
-	Type        string         `json:"type"`             // The type of job
-	Status      Status         `json:"status"`           // The response of the current job with details for execution counts etc..
-	State       State          `json:"state"`            // Current state of a job (running | complete)
-	StartTime   int64          `json:"start_time_epoch"` // Job Start time - epoch in seconds since 1970
-	EndTime     int64          `json:"end_time_epoch"`   // Job end time - epoch in seconds since 1970
-	RetryPolicy uint           `json:"retry_policy"`     // Job retry policy
-	Args        interface{}    `json:"-"`                // Arguments passed down to job handlers
-	Setup       GenericHandler `json:"-"`                // Setup is the initial execution fn for a job typically to setup arguments
-	Run         GenericHandler `json:"-"`                // Run is the main executor fn for a job
-	Finally     GenericHandler `json:"-"`                // Final fn is the final execution fn for a job
+	ID            string         `json:"id"`                       // Unique job ID
+	Deployment    string         `json:"deployment"`               // Deployment used for scoping jobs.
+	Type          string         `json:"type"`                     // The type of job
+	Status        Status         `json:"status"`                   // The response of the current job with details for execution counts etc..
+	State         State          `json:"state"`                    // Current state of a job (running | complete)
+	StartTime     int64          `json:"start_time_epoch"`         // Job Start time - epoch in seconds since 1970
+	EndTime       int64          `json:"end_time_epoch"`           // Job end time - epoch in seconds since 1970
+	RetryPolicy   uint           `json:"retry_policy"`             // Job retry policy
+	Priority      int            `json:"priority"`                 // Jobs with a higher priority are processed first when queued at once
+	Note          string         `json:"note"`                     // Optional note describing the change the job applies
+	TriggeredBy   string         `json:"triggered_by,omitempty"`   // Who or what triggered the job when it was not requested by a user (ie. a webhook)
+	Coalesce      bool           `json:"-"`                        // Whether a queued job can be replaced by a newer job of the same deployment and type
+	Coalesced     []string       `json:"coalesced,omitempty"`      // Ids of the queued jobs replaced by this job
+	CoalescedInto string         `json:"coalesced_into,omitempty"` // Id of the job that replaced this job while it was queued, the job did not execute
+	Cancelled     bool           `json:"cancelled,omitempty"`      // Whether the job was cancelled while queued or running
+	Timeout       time.Duration  `json:"-"`                        // Max duration of a job including retries, 0 means no timeout
+	Args          interface{}    `json:"-"`                        // Arguments passed down to job handlers
+	Setup         GenericHandler `json:"-"`                        // Setup is the initial execution fn for a job typically to setup arguments
+	Run           GenericHandler `json:"-"`                        // Run is the main executor fn for a job
+	Finally       GenericHandler `json:"-"`                        // Final fn is the final execution fn for a job
+	OnComplete    func(j Job)    `json:"-"`                        // Called once the job completed, whether it succeeded or failed
 }
 
 // GenericHandler is a generic job handler that takes in job arguments
@@ -50,7 +59,16 @@ func (j *Job) end() {
 	}
 	j.EndTime = time.Now().Unix()
 	j.State = Completed
+	j.recordMetrics()
 	j.save()
+	j.notifyComplete()
+}
+
+// notifyComplete calls the completion handler of a job, jobs cancelled or coalesced while queued also notify
+func (j *Job) notifyComplete() {
+	if j.OnComplete != nil {
+		j.OnComplete(*j)
+	}
 }
 
 // save : store the job
@@ -163,3 +181,8 @@ func (njobs NJobs) MergeAndSort(overlap SortOrder) []Job {
 func GetJobsCollectionName(deployment string) string {
 	return strings.ToLower(fmt.Sprintf("%s-%s", deployment, constants.JobsCollectionName))
 }
+
+// Succeeded returns true if the last execution of a completed job did not fail
+func (j *Job) Succeeded() bool {
+	return j.State == Completed && j.Status.ExecutionCount > j.Status.FailureCount
+}
@@ -0,0 +1,43 @@
+package job
+
+import "sync"
+
+var locksMu sync.Mutex
+
+// executing is the number of jobs executing for each deployment
+var executing = make(map[string]int)
+
+// markExecuting records a job executing for a deployment, the returned func is called once the job completes.
+// Jobs of the same deployment are handed to workers one at a time by the dispatcher (see dispatch), so a worker
+// never waits on another job of the deployment.
+func markExecuting(deployment string) func() {
+	locksMu.Lock()
+	executing[deployment]++
+	locksMu.Unlock()
+
+	return func() {
+		locksMu.Lock()
+		executing[deployment]--
+		if executing[deployment] == 0 {
+			delete(executing, deployment)
+		}
+		locksMu.Unlock()
+	}
+}
+
+// IsBusy returns true if a job of a deployment is queued or executing
+func IsBusy(deployment string) bool {
+	locksMu.Lock()
+	running := executing[deployment] > 0
+	locksMu.Unlock()
+	if running {
+		return true
+	}
+
+	for _, pending := range GetQueueStatus().Pending {
+		if pending.Deployment == deployment {
+			return true
+		}
+	}
+	return false
+}
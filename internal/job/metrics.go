@@ -0,0 +1,36 @@
+package job
+
+import (
+	"time"
+
+	"github.com/krane/krane/internal/metrics"
+)
+
+// Job metrics
+const (
+	JobsMetric         = "krane_jobs_total"                // completed jobs by deployment, type and result
+	JobsEnqueuedMetric = "krane_jobs_enqueued_total"       // jobs queued by deployment and type
+	JobDurationMetric  = "krane_job_duration_seconds"      // time to complete jobs by deployment and type
+	StepDurationMetric = "krane_job_step_duration_seconds" // histogram of the time to complete the steps of jobs (ie. pull_image) by step
+)
+
+// recordMetrics counts a completed job by its result and records its duration
+func (j *Job) recordMetrics() {
+	result := "succeeded"
+	if !j.Succeeded() {
+		result = "failed"
+	}
+
+	metrics.IncCounter(JobsMetric, metrics.Labels{"deployment": j.Deployment, "type": j.Type, "result": result}, 1)
+	metrics.Observe(JobDurationMetric, metrics.Labels{"deployment": j.Deployment, "type": j.Type}, float64(j.EndTime-j.StartTime))
+}
+
+// recordEnqueuedMetrics counts a queued job
+func (j *Job) recordEnqueuedMetrics() {
+	metrics.IncCounter(JobsEnqueuedMetric, metrics.Labels{"deployment": j.Deployment, "type": j.Type}, 1)
+}
+
+// recordStepMetrics records the duration of a job step into the step duration histogram
+func recordStepMetrics(step string, d time.Duration) {
+	metrics.ObserveHistogram(StepDurationMetric, metrics.Labels{"step": step}, d.Seconds(), metrics.DefaultDurationBuckets)
+}
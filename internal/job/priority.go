@@ -0,0 +1,100 @@
+package job
+
+import (
+	"container/heap"
+	"context"
+)
+
+// priorityItem is a queued job waiting for a worker
+type priorityItem struct {
+	job Job
+	seq uint64 // insertion order, used to keep jobs with the same priority FIFO
+}
+
+// priorityQueue orders jobs by priority (highest first), jobs with the same priority are processed in FIFO order
+type priorityQueue []priorityItem
+
+func (q priorityQueue) Len() int { return len(q) }
+func (q priorityQueue) Less(i, j int) bool {
+	if q[i].job.Priority == q[j].job.Priority {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].job.Priority > q[j].job.Priority
+}
+func (q priorityQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *priorityQueue) Push(x interface{}) { *q = append(*q, x.(priorityItem)) }
+
+func (q *priorityQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	x := old[n-1]
+	*q = old[0 : n-1]
+	return x
+}
+
+// dispatch moves jobs from the queue to workers in priority order. Jobs are only
+// handed to a worker once one is available, so jobs queued while every worker is busy
+// are ordered by priority, and queued jobs that can be coalesced are replaced by newer jobs for the same deployment.
+// At most maxPending jobs are held, after which the queue applies backpressure.
+//
+// Jobs of a deployment with a job executing stay queued until completed receives the deployment, so jobs of the same
+// deployment never execute concurrently, workers stay free for the jobs of other deployments and jobs waiting on
+// a deployment can still be coalesced.
+func dispatch(ctx context.Context, queue <-chan Job, workers chan<- Job, completed <-chan string, maxPending int) {
+	pending := &priorityQueue{}
+	running := make(map[string]bool)
+	var seq uint64
+
+	for {
+		in := queue
+		if pending.Len() >= maxPending {
+			in = nil
+		}
+
+		var out chan<- Job
+		var next Job
+		i := pending.next(running)
+		if i >= 0 {
+			out = workers
+			next = (*pending)[i].job
+		}
+
+		select {
+		case j := <-in:
+			if replaced, ok := pending.coalesce(j); ok {
+				recordCoalesced(replaced, j)
+			} else {
+				seq++
+				heap.Push(pending, priorityItem{job: j, seq: seq})
+			}
+		case out <- next:
+			heap.Remove(pending, i)
+			if next.Deployment != "" {
+				running[next.Deployment] = true
+			}
+		case deployment := <-completed:
+			delete(running, deployment)
+		case <-cancelSignal:
+			pending.removeCancelled()
+		case <-ctx.Done():
+			return
+		}
+		updateQueueStatus(*pending)
+	}
+}
+
+// next returns the index of the highest priority job of a deployment without a running job, -1 if there is none.
+// Jobs without a deployment are never held back.
+func (q priorityQueue) next(running map[string]bool) int {
+	next := -1
+	for i, item := range q {
+		if item.job.Deployment != "" && running[item.job.Deployment] {
+			continue
+		}
+		if next < 0 || q.Less(i, next) {
+			next = i
+		}
+	}
+	return next
+}
@@ -0,0 +1,114 @@
+package job
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatchByPriority(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	queue := make(chan Job, 4)
+	workers := make(chan Job)
+
+	queue <- Job{ID: "worker-a", Priority: 0}
+	queue <- Job{ID: "frontend", Priority: 10}
+	queue <- Job{ID: "worker-b", Priority: 0}
+	queue <- Job{ID: "api", Priority: 5}
+
+	go dispatch(ctx, queue, workers, nil, 4)
+
+	// wait for every queued job to be picked up before a worker is available
+	for len(queue) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	order := make([]string, 0)
+	for i := 0; i < 4; i++ {
+		order = append(order, (<-workers).ID)
+	}
+
+	assert.Equal(t, []string{"frontend", "api", "worker-a", "worker-b"}, order)
+}
+
+func TestDispatchCoalescesQueuedRuns(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	queue := make(chan Job, 4)
+	workers := make(chan Job)
+
+	queue <- Job{ID: "run-1", Deployment: "coalesce-app", Type: "RUN", Coalesce: true}
+	queue <- Job{ID: "other", Deployment: "coalesce-other", Type: "RUN", Coalesce: true}
+	queue <- Job{ID: "run-2", Deployment: "coalesce-app", Type: "RUN", Coalesce: true}
+	queue <- Job{ID: "run-3", Deployment: "coalesce-app", Type: "RUN", Coalesce: true}
+
+	go dispatch(ctx, queue, workers, nil, 4)
+
+	// wait for every queued job to be picked up before a worker is available
+	for len(queue) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	status := GetQueueStatus()
+	assert.Len(t, status.Pending, 2)
+	assert.Equal(t, "run-3", status.Pending[0].ID)
+	assert.Equal(t, []string{"run-1", "run-2"}, status.Pending[0].Coalesced)
+	assert.Equal(t, "other", status.Pending[1].ID)
+
+	// the latest run keeps the place of the first run in the queue
+	first := <-workers
+	assert.Equal(t, "run-3", first.ID)
+	assert.Equal(t, []string{"run-1", "run-2"}, first.Coalesced)
+	assert.Equal(t, "other", (<-workers).ID)
+	assert.Equal(t, uint(2), GetQueueStatus().Coalesced)
+}
+
+func TestCoalesceRequiresCoalescingJobs(t *testing.T) {
+	q := &priorityQueue{{job: Job{ID: "delete", Deployment: "app", Type: "DELETE"}, seq: 1}}
+
+	_, ok := q.coalesce(Job{ID: "delete-2", Deployment: "app", Type: "DELETE"})
+	assert.False(t, ok)
+
+	_, ok = q.coalesce(Job{ID: "run", Deployment: "app", Type: "RUN", Coalesce: true})
+	assert.False(t, ok)
+}
+
+func TestDispatchHoldsJobsOfRunningDeployment(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	queue := make(chan Job, 4)
+	workers := make(chan Job)
+	completed := make(chan string)
+
+	queue <- Job{ID: "restart", Deployment: "held-app", Type: "RESTART", Priority: 10}
+	queue <- Job{ID: "stop", Deployment: "held-app", Type: "STOP", Priority: 10}
+	queue <- Job{ID: "other", Deployment: "held-other", Type: "RUN"}
+
+	go dispatch(ctx, queue, workers, completed, 4)
+
+	for len(queue) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	// the second job of held-app waits for the first one while the job of another deployment is handed to a worker
+	assert.Equal(t, "restart", (<-workers).ID)
+	assert.Equal(t, "other", (<-workers).ID)
+
+	select {
+	case j := <-workers:
+		t.Fatalf("job %s handed to a worker while its deployment has a job executing", j.ID)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	completed <- "held-app"
+	assert.Equal(t, "stop", (<-workers).ID)
+}
@@ -0,0 +1,193 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/logger"
+	"github.com/krane/krane/internal/store"
+)
+
+// RecurringJobsInterval is how often recurring jobs are checked, cron expressions have a precision of a minute
+const RecurringJobsInterval = 30 * time.Second
+
+// RecurringJob is an action on a deployment run on a cron schedule, persisted so it survives restarts
+type RecurringJob struct {
+	Deployment string `json:"deployment"`
+	Action     string `json:"action"`     // action run on the deployment when the job is due (ie. run, restart)
+	Cron       string `json:"cron"`       // cron expression (ie. 0 3 * * *) or macro (ie. @daily)
+	Timezone   string `json:"timezone"`   // tz database timezone the cron expression is in (default UTC)
+	NextRun    int64  `json:"next_run"`   // unix time the job is next due
+	LastRun    int64  `json:"last_run"`   // unix time the job last ran, 0 if it never ran
+	CreatedAt  int64  `json:"created_at"` // unix time the job was scheduled
+}
+
+// RecurringJobHandler runs the action of a due recurring job
+type RecurringJobHandler func(RecurringJob) error
+
+// schedule returns the parsed cron expression of a recurring job and the location it is in
+func (r RecurringJob) schedule() (CronSchedule, *time.Location, error) {
+	loc := time.UTC
+	if r.Timezone != "" {
+		// Local is the timezone of the Krane host, not a tz database name
+		if r.Timezone == "Local" {
+			return CronSchedule{}, nil, fmt.Errorf("unknown timezone %s, expected a tz database name (ie. America/New_York)", r.Timezone)
+		}
+		// the tz database is read from the Krane host (or image), a missing tz database is reported as is
+		l, err := time.LoadLocation(r.Timezone)
+		if err != nil {
+			return CronSchedule{}, nil, fmt.Errorf("unable to load timezone %s, expected a tz database name (ie. America/New_York), %v", r.Timezone, err)
+		}
+		loc = l
+	}
+
+	s, err := ParseCron(r.Cron)
+	if err != nil {
+		return CronSchedule{}, nil, err
+	}
+	return s, loc, nil
+}
+
+// nextRun returns the unix time a recurring job is due after a time, an error if the job is never due
+func (r RecurringJob) nextRun(after time.Time) (int64, error) {
+	s, loc, err := r.schedule()
+	if err != nil {
+		return 0, err
+	}
+
+	next := s.Next(after.In(loc))
+	if next.IsZero() {
+		return 0, fmt.Errorf("cron expression %s is never due", r.Cron)
+	}
+	return next.Unix(), nil
+}
+
+// SaveRecurringJob validates and saves a recurring job, replacing the recurring job of the deployment (if any)
+func SaveRecurringJob(r RecurringJob) (RecurringJob, error) {
+	now := time.Now()
+	next, err := r.nextRun(now)
+	if err != nil {
+		return RecurringJob{}, err
+	}
+
+	r.NextRun = next
+	r.CreatedAt = now.Unix()
+	if err := r.save(); err != nil {
+		return RecurringJob{}, err
+	}
+
+	logger.Infof("deployment %s %s scheduled with %s, next run at %s", r.Deployment, r.Action, r.Cron, time.Unix(r.NextRun, 0).UTC().Format(time.RFC3339))
+	return r, nil
+}
+
+// save stores a recurring job
+func (r RecurringJob) save() error {
+	bytes, _ := json.Marshal(r)
+	return store.Client().Put(constants.RecurringJobsCollectionName, r.Deployment, bytes)
+}
+
+// GetRecurringJob returns the recurring job of a deployment, nil if none
+func GetRecurringJob(deployment string) (*RecurringJob, error) {
+	bytes, err := store.Client().Get(constants.RecurringJobsCollectionName, deployment)
+	if err != nil || bytes == nil {
+		return nil, err
+	}
+
+	var r RecurringJob
+	if err := json.Unmarshal(bytes, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// GetRecurringJobs returns the recurring jobs of every deployment sorted by deployment
+func GetRecurringJobs() ([]RecurringJob, error) {
+	all, err := store.Client().GetAll(constants.RecurringJobsCollectionName)
+	if err != nil {
+		return make([]RecurringJob, 0), err
+	}
+
+	jobs := make([]RecurringJob, 0, len(all))
+	for _, bytes := range all {
+		var r RecurringJob
+		if err := json.Unmarshal(bytes, &r); err != nil {
+			logger.Warnf("unable to read recurring job %v", err)
+			continue
+		}
+		jobs = append(jobs, r)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Deployment < jobs[j].Deployment })
+	return jobs, nil
+}
+
+// DeleteRecurringJob removes the recurring job of a deployment
+func DeleteRecurringJob(deployment string) error {
+	return store.Client().Remove(constants.RecurringJobsCollectionName, deployment)
+}
+
+// RunRecurringJobs runs the recurring jobs when they are due until ctx is done. Runs missed while Krane was
+// down are skipped, jobs are rescheduled to their next run from now instead of catching up.
+func RunRecurringJobs(ctx context.Context, interval time.Duration, handler RecurringJobHandler) {
+	// a run due during the first interval was missed by less than a check and still runs
+	runRecurringJobs(time.Now(), interval, handler)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runRecurringJobs(time.Now(), 0, handler)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// runRecurringJobs runs the recurring jobs due at a time and schedules their next run. Jobs due more than
+// missedAfter before now are not run, only rescheduled. A missedAfter of 0 runs every due job.
+func runRecurringJobs(now time.Time, missedAfter time.Duration, handler RecurringJobHandler) {
+	jobs, err := GetRecurringJobs()
+	if err != nil {
+		logger.Warnf("unable to get recurring jobs %v", err)
+		return
+	}
+
+	for _, r := range jobs {
+		if r.NextRun > now.Unix() {
+			continue
+		}
+
+		due := time.Unix(r.NextRun, 0)
+		if missedAfter > 0 && now.Sub(due) > missedAfter {
+			logger.Infof("skipping missed %s of deployment %s due at %s", r.Action, r.Deployment, due.UTC().Format(time.RFC3339))
+		} else {
+			logger.Debugf("running recurring %s of deployment %s due at %s", r.Action, r.Deployment, due.UTC().Format(time.RFC3339))
+			if err := handler(r); err != nil {
+				logger.Warnf("unable to run recurring %s of deployment %s, %v", r.Action, r.Deployment, err)
+			}
+			r.LastRun = now.Unix()
+		}
+
+		// the next run follows now rather than the missed run so runs missed during a downtime are not caught up
+		next, err := r.nextRun(now)
+		if err != nil {
+			logger.Warnf("unable to schedule the next %s of deployment %s, %v", r.Action, r.Deployment, err)
+			continue
+		}
+		r.NextRun = next
+
+		// the job may have been replaced or removed (ie. the deployment was deleted) while it ran
+		if current, _ := GetRecurringJob(r.Deployment); current == nil || current.CreatedAt != r.CreatedAt {
+			continue
+		}
+		if err := r.save(); err != nil {
+			logger.Warnf("unable to save recurring job of deployment %s, %v", r.Deployment, err)
+		}
+	}
+}
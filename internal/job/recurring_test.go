@@ -0,0 +1,78 @@
+package job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveRecurringJob(t *testing.T) {
+	defer DeleteRecurringJob("recurring-app")
+
+	_, err := SaveRecurringJob(RecurringJob{Deployment: "recurring-app", Action: "run", Cron: "not a cron"})
+	assert.Error(t, err)
+
+	_, err = SaveRecurringJob(RecurringJob{Deployment: "recurring-app", Action: "run", Cron: "@daily", Timezone: "Mars/Olympus"})
+	assert.Error(t, err)
+
+	_, err = SaveRecurringJob(RecurringJob{Deployment: "recurring-app", Action: "run", Cron: "@daily", Timezone: "Local"})
+	assert.Error(t, err)
+
+	zoned, err := SaveRecurringJob(RecurringJob{Deployment: "recurring-app", Action: "run", Cron: "0 3 * * *", Timezone: "America/New_York"})
+	assert.Nil(t, err)
+	ny, _ := time.LoadLocation("America/New_York")
+	assert.Equal(t, 3, time.Unix(zoned.NextRun, 0).In(ny).Hour())
+
+	saved, err := SaveRecurringJob(RecurringJob{Deployment: "recurring-app", Action: "run", Cron: "@daily"})
+	assert.Nil(t, err)
+	assert.True(t, saved.NextRun > time.Now().Unix())
+
+	stored, err := GetRecurringJob("recurring-app")
+	assert.Nil(t, err)
+	assert.Equal(t, saved, *stored)
+
+	assert.Nil(t, DeleteRecurringJob("recurring-app"))
+	stored, err = GetRecurringJob("recurring-app")
+	assert.Nil(t, err)
+	assert.Nil(t, stored)
+}
+
+func TestRunRecurringJobs(t *testing.T) {
+	defer DeleteRecurringJob("recurring-due")
+	defer DeleteRecurringJob("recurring-missed")
+	defer DeleteRecurringJob("recurring-later")
+
+	now := time.Now()
+	due := RecurringJob{Deployment: "recurring-due", Action: "run", Cron: "* * * * *", NextRun: now.Add(-10 * time.Second).Unix(), CreatedAt: 1}
+	missed := RecurringJob{Deployment: "recurring-missed", Action: "run", Cron: "* * * * *", NextRun: now.Add(-time.Hour).Unix(), CreatedAt: 1}
+	later := RecurringJob{Deployment: "recurring-later", Action: "run", Cron: "* * * * *", NextRun: now.Add(time.Hour).Unix(), CreatedAt: 1}
+	for _, r := range []RecurringJob{due, missed, later} {
+		assert.Nil(t, r.save())
+	}
+
+	ran := make([]string, 0)
+	handler := func(r RecurringJob) error {
+		ran = append(ran, r.Deployment)
+		return nil
+	}
+
+	// runs missed while krane was down are skipped on startup
+	runRecurringJobs(now, time.Minute, handler)
+	assert.Equal(t, []string{"recurring-due"}, ran)
+
+	for _, name := range []string{"recurring-due", "recurring-missed"} {
+		r, _ := GetRecurringJob(name)
+		assert.True(t, r.NextRun > now.Unix(), name)
+	}
+	r, _ := GetRecurringJob("recurring-due")
+	assert.Equal(t, now.Unix(), r.LastRun)
+	r, _ = GetRecurringJob("recurring-missed")
+	assert.Equal(t, int64(0), r.LastRun)
+	r, _ = GetRecurringJob("recurring-later")
+	assert.Equal(t, later.NextRun, r.NextRun)
+
+	// rescheduled jobs are not run twice
+	runRecurringJobs(now, 0, handler)
+	assert.Equal(t, []string{"recurring-due"}, ran)
+}
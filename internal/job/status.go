@@ -1,7 +1,16 @@
 package job
 
 type Status struct {
-	ExecutionCount uint    `json:"execution_count"`
-	FailureCount   uint    `json:"failure_count"`
-	Failures       []Error `json:"failures"`
+	ExecutionCount uint              `json:"execution_count"`
+	FailureCount   uint              `json:"failure_count"`
+	Failures       []Error           `json:"failures"`
+	Durations      []StepDuration    `json:"durations"` // time spent in job steps recorded during execution
+	Details        map[string]string `json:"details"`   // values resolved during execution (ie. resource limits)
+	Attempts       []Attempt         `json:"attempts"`  // steps, durations and error of every execution of the job
+}
+
+// StepDuration is the time spent executing a step of a job
+type StepDuration struct {
+	Step       string `json:"step"`
+	DurationMs int64  `json:"duration_ms"`
 }
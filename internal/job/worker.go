@@ -1,7 +1,9 @@
 package job
 
 import (
+	"context"
 	"os"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -9,15 +11,20 @@ import (
 )
 
 type worker struct {
+	ctx        context.Context
 	workerPool chan chan Job
 	channel    chan Job
 	quit       chan bool
+
+	// completed notifies the dispatcher of the deployment of each job the worker completed
+	completed chan<- string
 }
 
 // newWorker is a helper for creating new workers; a worker runs in its
-// own routine waiting to process work from a job queue
-func newWorker(workerPool chan chan Job, jobChannel chan Job) *worker {
-	return &worker{workerPool, jobChannel, make(chan bool)}
+// own routine waiting to process work from a job queue. Jobs executed by the worker
+// derive their context from ctx, cancelling ctx aborts any in-flight job.
+func newWorker(ctx context.Context, workerPool chan chan Job, jobChannel chan Job) *worker {
+	return &worker{ctx: ctx, workerPool: workerPool, channel: jobChannel, quit: make(chan bool)}
 }
 
 // Start starts a worker
@@ -39,48 +46,126 @@ func (w *worker) loop() {
 	for {
 		select {
 		case job := <-w.channel:
-			job.start()
-
-			for i := 0; i < int(job.RetryPolicy); i++ {
-				job.Status.ExecutionCount++
-
-				if job.Setup != nil {
-					logger.Debugf("Setting up job %s", job.ID)
-					if err := job.Setup(job.Args); err != nil {
-						job.WithError(err)
-						job.Status.FailureCount++
-						continue
-					}
-				}
-
-				if job.Run == nil {
-					job.WithError(errors.New("job must have a Run implementation"))
-					job.Status.FailureCount++
-					return
-				}
-
-				if err := job.Run(job.Args); err != nil {
-					job.WithError(err)
-					job.Status.FailureCount++
-					continue
-				}
-
-				if job.Finally != nil {
-					logger.Debugf("Tearing down job %s", job.ID)
-					if err := job.Finally(job.Args); err != nil {
-						job.WithError(err)
-						job.Status.FailureCount++
-						continue
-					}
-				}
-
-				logger.Debugf("Completed job %s", job.ID)
-			}
-
-			job.end()
+			w.execute(&job)
+			w.complete(job.Deployment)
 		case <-w.quit:
 			logger.Debug("Quitting worker")
 			return
 		}
 	}
 }
+
+// complete notifies the dispatcher that a job of a deployment completed, so the next job of the deployment can be handed to a worker
+func (w *worker) complete(deployment string) {
+	if w.completed == nil {
+		return
+	}
+
+	select {
+	case w.completed <- deployment:
+	case <-w.ctx.Done():
+	}
+}
+
+// execute runs a job until it succeeds, its retry policy is exhausted or it fails with a permanent error.
+func (w *worker) execute(job *Job) {
+	done := markExecuting(job.Deployment)
+	defer done()
+
+	// a job cancelled while it was handed to the worker does not execute
+	if takeCancelled(job.ID) {
+		recordCancelled(*job)
+		return
+	}
+
+	job.start()
+	ctx := withContext(w.ctx, job.ID, job.Deployment, job.Timeout)
+
+	for i := 0; i < int(job.RetryPolicy); i++ {
+		if wasCancelled(job.ID) {
+			job.Cancelled = true
+			job.WithError(ErrJobCancelled)
+			break
+		}
+		if ctx.Err() != nil {
+			job.WithError(errors.Wrap(ctx.Err(), "job aborted"))
+			break
+		}
+
+		job.Status.ExecutionCount++
+		attemptStart := time.Now()
+		startAttempt(job.ID)
+		endAttempt := func(err error) {
+			job.Status.Attempts = append(job.Status.Attempts, recordedAttempt(job.ID, job.Status.ExecutionCount, attemptStart, err))
+		}
+
+		if job.Setup != nil {
+			logger.Debugf("Setting up job %s", job.ID)
+			if err := job.Setup(job.Args); err != nil {
+				job.WithError(err)
+				job.Status.FailureCount++
+				endAttempt(err)
+				continue
+			}
+		}
+
+		if job.Run == nil {
+			err := errors.New("job must have a Run implementation")
+			job.WithError(err)
+			job.Status.FailureCount++
+			endAttempt(err)
+			break
+		}
+
+		if err := job.Run(job.Args); err != nil {
+			job.WithError(err)
+			job.Status.FailureCount++
+			endAttempt(err)
+			if isPermanent(err) {
+				logger.Debugf("Job %s failed with a permanent error, not retrying", job.ID)
+				break
+			}
+			if i < int(job.RetryPolicy)-1 {
+				waitBeforeRetry(ctx, err)
+			}
+			continue
+		}
+
+		// the resources of a successful Run handler are live (ie. started containers), the job must now complete
+		beginFinally(job.ID)
+
+		if job.Finally != nil {
+			logger.Debugf("Tearing down job %s", job.ID)
+			if err := job.Finally(job.Args); err != nil {
+				job.WithError(err)
+				job.Status.FailureCount++
+				endAttempt(err)
+				continue
+			}
+		}
+
+		endAttempt(nil)
+		logger.Debugf("Completed job %s", job.ID)
+		break
+	}
+
+	job.Status.Durations = recordedDurations(job.ID)
+	job.Status.Details = recordedDetails(job.ID)
+	releaseContext(job.ID)
+	job.end()
+}
+
+// waitBeforeRetry blocks until a job can be retried if the error
+// from the previous execution requested a delay before retrying
+func waitBeforeRetry(ctx context.Context, err error) {
+	var retryErr RetryAfterError
+	if !errors.As(err, &retryErr) {
+		return
+	}
+
+	logger.Debugf("Waiting %s before retrying job", retryErr.RetryAfter())
+	select {
+	case <-time.After(retryErr.RetryAfter()):
+	case <-ctx.Done():
+	}
+}
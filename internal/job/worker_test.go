@@ -0,0 +1,100 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type permanentErr struct{}
+
+func (permanentErr) Error() string   { return "permanent" }
+func (permanentErr) Permanent() bool { return true }
+
+func TestWorkerStopsAfterSuccess(t *testing.T) {
+	w := newWorker(context.Background(), nil, nil)
+	runs := 0
+	job := Job{ID: "worker-success", Deployment: "test", RetryPolicy: 3, Run: func(args interface{}) error {
+		runs++
+		return nil
+	}}
+
+	w.execute(&job)
+	assert.Equal(t, 1, runs)
+	assert.Equal(t, uint(1), job.Status.ExecutionCount)
+}
+
+func TestWorkerRetriesTransientErrors(t *testing.T) {
+	w := newWorker(context.Background(), nil, nil)
+	job := Job{ID: "worker-transient", Deployment: "test", RetryPolicy: 3, Run: func(args interface{}) error {
+		return errors.New("connection reset")
+	}}
+
+	w.execute(&job)
+	assert.Equal(t, uint(3), job.Status.ExecutionCount)
+	assert.Equal(t, uint(3), job.Status.FailureCount)
+}
+
+func TestWorkerDoesNotRetryPermanentErrors(t *testing.T) {
+	w := newWorker(context.Background(), nil, nil)
+	job := Job{ID: "worker-permanent", Deployment: "test", RetryPolicy: 3, Run: func(args interface{}) error {
+		return permanentErr{}
+	}}
+
+	w.execute(&job)
+	assert.Equal(t, uint(1), job.Status.ExecutionCount)
+	assert.Equal(t, "permanent", job.Status.Failures[0].Message)
+}
+
+func TestWorkerRecordsAttempts(t *testing.T) {
+	w := newWorker(context.Background(), nil, nil)
+	runs := 0
+	job := Job{ID: "worker-attempts", Deployment: "test", RetryPolicy: 3}
+	job.Run = func(args interface{}) error {
+		runs++
+		RecordLog(job.ID, fmt.Sprintf("attempt %d", runs))
+		RecordDuration(Context(job.ID), "create", time.Millisecond)
+		if runs == 1 {
+			return errors.New("connection reset")
+		}
+		return nil
+	}
+
+	w.execute(&job)
+	assert.Len(t, job.Status.Attempts, 2)
+
+	first, second := job.Status.Attempts[0], job.Status.Attempts[1]
+	assert.Equal(t, uint(1), first.Execution)
+	assert.Equal(t, []string{"attempt 1"}, first.Logs)
+	assert.Len(t, first.Durations, 1)
+	assert.Equal(t, "connection reset", first.Error)
+
+	assert.Equal(t, uint(2), second.Execution)
+	assert.Equal(t, []string{"attempt 2"}, second.Logs)
+	assert.Len(t, second.Durations, 1)
+	assert.Empty(t, second.Error)
+
+	// durations of every attempt are still aggregated on the job status
+	assert.Len(t, job.Status.Durations, 2)
+}
+
+func TestWorkerCallsOnComplete(t *testing.T) {
+	w := newWorker(context.Background(), nil, nil)
+
+	var completed []Job
+	onComplete := func(j Job) { completed = append(completed, j) }
+
+	succeeded := Job{ID: "worker-complete", Deployment: "test", RetryPolicy: 1, OnComplete: onComplete, Run: func(args interface{}) error { return nil }}
+	failed := Job{ID: "worker-complete-failed", Deployment: "test", RetryPolicy: 2, OnComplete: onComplete, Run: func(args interface{}) error { return errors.New("failed") }}
+	w.execute(&succeeded)
+	w.execute(&failed)
+
+	assert.Len(t, completed, 2)
+	assert.True(t, completed[0].Succeeded())
+	assert.False(t, completed[1].Succeeded())
+	assert.Equal(t, uint(2), completed[1].Status.ExecutionCount)
+}
@@ -1,6 +1,7 @@
 package job
 
 import (
+	"context"
 	"os"
 	"sync"
 
@@ -18,26 +19,43 @@ type WorkerPool struct {
 
 	store store.Store
 
+	// ctx is shared by every worker in the pool and cancelled when the pool is stopped
+	ctx    context.Context
+	cancel context.CancelFunc
+
 	workers    []*worker
 	workerPool chan chan Job
 	jobChannel chan Job
+
+	// dispatchChannel hands jobs from the job channel to workers in priority order
+	dispatchChannel chan Job
+
+	// completedChannel receives the deployment of each job completed by a worker
+	completedChannel chan string
 }
 
 // NewWorkerPool : create a concurrent pool of workers to process Jobs from the queue
 func NewWorkerPool(concurrency uint, jobChannel chan Job, store store.Store) WorkerPool {
 	logger.Debugf("Creating new worker pool with %d worker(s)", concurrency)
 	wpID := utils.ShortID()
+	ctx, cancel := context.WithCancel(context.Background())
 	wp := WorkerPool{
 		workerPoolID: wpID,
 		concurrency:  concurrency,
 		store:        store,
+		ctx:          ctx,
+		cancel:       cancel,
 		workerPool:   make(chan chan Job, concurrency),
 		jobChannel:   jobChannel,
+
+		dispatchChannel:  make(chan Job),
+		completedChannel: make(chan string),
 	}
 
 	for i := uint(0); i < wp.concurrency; i++ {
 		logger.Debugf("Appending new worker to worker pool %s", wp.workerPoolID)
-		w := newWorker(wp.workerPool, wp.jobChannel)
+		w := newWorker(wp.ctx, wp.workerPool, wp.dispatchChannel)
+		w.completed = wp.completedChannel
 		wp.workers = append(wp.workers, w)
 	}
 
@@ -55,6 +73,12 @@ func (wp *WorkerPool) Start() {
 
 	wp.started = true
 
+	maxPending := cap(wp.jobChannel)
+	if maxPending < 1 {
+		maxPending = 1
+	}
+	go dispatch(wp.ctx, wp.jobChannel, wp.dispatchChannel, wp.completedChannel, maxPending)
+
 	var workersStarted int
 	for _, w := range wp.workers {
 		logger.Debug("Starting new worker")
@@ -79,6 +103,9 @@ func (wp *WorkerPool) Stop() {
 
 	logger.Debugf("Stopping worker pool %s", wp.workerPoolID)
 
+	// abort any in-flight jobs so workers can quit
+	wp.cancel()
+
 	stopped := 0
 	var wg sync.WaitGroup
 	for _, w := range wp.workers {
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/krane/krane/internal/constants"
+	"github.com/krane/krane/internal/logger"
+)
+
+// Push exporters
+const (
+	StatsDExporter = "statsd" // push metrics over udp in the StatsD line format (with DogStatsD tags)
+	OTLPExporter   = "otlp"   // push metrics to an OpenTelemetry collector over OTLP/HTTP (json)
+)
+
+// DefaultPushInterval is the interval metrics are pushed at when none is configured
+const DefaultPushInterval = 30 * time.Second
+
+// Exporter pushes a snapshot of the metrics to an external system
+type Exporter interface {
+	Name() string
+	Export(ctx context.Context, metrics []Metric) error
+}
+
+// ConfiguredExporters returns the push exporters enabled with the METRICS_EXPORTERS environment variable
+func ConfiguredExporters() ([]Exporter, error) {
+	exporters := make([]Exporter, 0)
+	for _, name := range strings.Split(os.Getenv(constants.EnvMetricsExporters), ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "":
+			continue
+		case StatsDExporter:
+			exporters = append(exporters, NewStatsD(os.Getenv(constants.EnvMetricsStatsDAddress)))
+		case OTLPExporter:
+			exporters = append(exporters, NewOTLP(os.Getenv(constants.EnvMetricsOTLPEndpoint)))
+		default:
+			return nil, fmt.Errorf("unknown metrics exporter %s, expected %s or %s", name, StatsDExporter, OTLPExporter)
+		}
+	}
+	return exporters, nil
+}
+
+// PushInterval returns the interval metrics are pushed at
+func PushInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv(constants.EnvMetricsPushIntervalMs) + "ms")
+	if err != nil || interval <= 0 {
+		return DefaultPushInterval
+	}
+	return interval
+}
+
+// Push exports a snapshot of the registry to every exporter at an interval until ctx is done.
+// Exporters are pushed the same snapshot so collectors only run once per interval.
+func (r *Registry) Push(ctx context.Context, exporters []Exporter, interval time.Duration) {
+	if len(exporters) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.pushOnce(ctx, exporters, interval)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// pushOnce exports a snapshot of the registry to every exporter, an export must complete within the interval
+func (r *Registry) pushOnce(ctx context.Context, exporters []Exporter, interval time.Duration) {
+	snapshot := r.Snapshot()
+
+	ctx, cancel := context.WithTimeout(ctx, interval)
+	defer cancel()
+
+	for _, exporter := range exporters {
+		if err := exporter.Export(ctx, snapshot); err != nil {
+			logger.Warnf("unable to export metrics with the %s exporter, %v", exporter.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Kind is the type of a metric
+type Kind string
+
+const (
+	CounterKind   Kind = "counter"   // monotonically increasing value
+	GaugeKind     Kind = "gauge"     // value that can go up and down
+	SummaryKind   Kind = "summary"   // count and sum of observed values (ie. durations)
+	HistogramKind Kind = "histogram" // count and sum of observed values with the number of observations per bucket
+)
+
+// DefaultDurationBuckets are the upper bounds in seconds of the buckets of duration histograms
+var DefaultDurationBuckets = []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// Labels are the dimensions of a metric
+type Labels map[string]string
+
+// Metric is the value of a metric series at the time of a snapshot
+type Metric struct {
+	Name    string
+	Kind    Kind
+	Labels  Labels
+	Value   float64  // value of a counter or gauge
+	Count   uint64   // number of observations of a summary or histogram
+	Sum     float64  // sum of the observations of a summary or histogram
+	Buckets []Bucket // cumulative number of observations of a histogram per bucket, sorted by upper bound
+}
+
+// Bucket is the number of observations of a histogram less than or equal to an upper bound
+type Bucket struct {
+	UpperBound float64
+	Count      uint64
+}
+
+// Registry holds the current value of every metric series
+type Registry struct {
+	mu         sync.Mutex
+	series     map[string]*Metric
+	collectors []func()
+}
+
+// NewRegistry returns an empty metrics registry
+func NewRegistry() *Registry {
+	return &Registry{series: make(map[string]*Metric)}
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default returns the registry metrics are recorded to by the package level functions
+func Default() *Registry { return defaultRegistry }
+
+// IncCounter increments a counter of the default registry
+func IncCounter(name string, labels Labels, delta float64) {
+	defaultRegistry.IncCounter(name, labels, delta)
+}
+
+// SetGauge sets a gauge of the default registry
+func SetGauge(name string, labels Labels, value float64) {
+	defaultRegistry.SetGauge(name, labels, value)
+}
+
+// Observe records an observation of a summary of the default registry
+func Observe(name string, labels Labels, value float64) {
+	defaultRegistry.Observe(name, labels, value)
+}
+
+// ObserveHistogram records an observation of a histogram of the default registry
+func ObserveHistogram(name string, labels Labels, value float64, bounds []float64) {
+	defaultRegistry.ObserveHistogram(name, labels, value, bounds)
+}
+
+// RegisterCollector registers a function with the default registry, called before every snapshot to update gauges
+func RegisterCollector(collect func()) {
+	defaultRegistry.RegisterCollector(collect)
+}
+
+// IncCounter increments a counter by delta
+func (r *Registry) IncCounter(name string, labels Labels, delta float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.get(name, CounterKind, labels).Value += delta
+}
+
+// SetGauge sets the value of a gauge
+func (r *Registry) SetGauge(name string, labels Labels, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.get(name, GaugeKind, labels).Value = value
+}
+
+// ResetGauges removes every series of a gauge, so series no longer set by a collector are not exported
+func (r *Registry) ResetGauges(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, m := range r.series {
+		if m.Name == name && m.Kind == GaugeKind {
+			delete(r.series, key)
+		}
+	}
+}
+
+// Observe records an observation of a summary
+func (r *Registry) Observe(name string, labels Labels, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.get(name, SummaryKind, labels)
+	m.Count++
+	m.Sum += value
+}
+
+// ObserveHistogram records an observation of a histogram, the bucket upper bounds are set by the first observation
+func (r *Registry) ObserveHistogram(name string, labels Labels, value float64, bounds []float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.get(name, HistogramKind, labels)
+	if m.Buckets == nil {
+		m.Buckets = make([]Bucket, 0, len(bounds))
+		for _, bound := range bounds {
+			m.Buckets = append(m.Buckets, Bucket{UpperBound: bound})
+		}
+	}
+
+	m.Count++
+	m.Sum += value
+	for i := range m.Buckets {
+		if value <= m.Buckets[i].UpperBound {
+			m.Buckets[i].Count++
+		}
+	}
+}
+
+// RegisterCollector registers a function called before every snapshot
+func (r *Registry) RegisterCollector(collect func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, collect)
+}
+
+// Snapshot runs the collectors and returns a copy of every metric series sorted by name and labels
+func (r *Registry) Snapshot() []Metric {
+	r.mu.Lock()
+	collectors := append([]func(){}, r.collectors...)
+	r.mu.Unlock()
+
+	for _, collect := range collectors {
+		collect()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := make([]string, 0, len(r.series))
+	for key := range r.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	snapshot := make([]Metric, 0, len(keys))
+	for _, key := range keys {
+		m := *r.series[key]
+		m.Labels = copyLabels(m.Labels)
+		if m.Buckets != nil {
+			m.Buckets = append([]Bucket{}, m.Buckets...)
+		}
+		snapshot = append(snapshot, m)
+	}
+	return snapshot
+}
+
+// get returns the series of a metric creating it if it doesn't exist, it must be called with the lock held
+func (r *Registry) get(name string, kind Kind, labels Labels) *Metric {
+	key := seriesKey(name, labels)
+	m, ok := r.series[key]
+	if !ok {
+		m = &Metric{Name: name, Kind: kind, Labels: copyLabels(labels)}
+		r.series[key] = m
+	}
+	return m
+}
+
+// seriesKey returns a key unique to a metric name and set of labels
+func seriesKey(name string, labels Labels) string {
+	var key strings.Builder
+	key.WriteString(name)
+	for _, k := range labels.keys() {
+		key.WriteString("|" + k + "=" + labels[k])
+	}
+	return key.String()
+}
+
+// keys returns the label names sorted
+func (l Labels) keys() []string {
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func copyLabels(labels Labels) Labels {
+	c := make(Labels, len(labels))
+	for k, v := range labels {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krane/krane/internal/constants"
+)
+
+func TestRegistrySnapshot(t *testing.T) {
+	r := NewRegistry()
+	r.IncCounter("jobs", Labels{"result": "failed"}, 1)
+	r.IncCounter("jobs", Labels{"result": "succeeded"}, 1)
+	r.IncCounter("jobs", Labels{"result": "succeeded"}, 2)
+	r.Observe("duration", Labels{}, 1.5)
+	r.Observe("duration", Labels{}, 0.5)
+
+	collected := 0
+	r.RegisterCollector(func() {
+		collected++
+		r.ResetGauges("health")
+		r.SetGauge("health", Labels{"deployment": "app"}, float64(collected))
+	})
+
+	snapshot := r.Snapshot()
+	assert.Len(t, snapshot, 4)
+	assert.Equal(t, Metric{Name: "duration", Kind: SummaryKind, Labels: Labels{}, Count: 2, Sum: 2}, snapshot[0])
+	assert.Equal(t, Metric{Name: "health", Kind: GaugeKind, Labels: Labels{"deployment": "app"}, Value: 1}, snapshot[1])
+	assert.Equal(t, 1.0, snapshot[2].Value)
+	assert.Equal(t, 3.0, snapshot[3].Value)
+
+	snapshot = r.Snapshot()
+	assert.Len(t, snapshot, 4)
+	assert.Equal(t, 2.0, snapshot[1].Value)
+}
+
+func TestRegistryHistogram(t *testing.T) {
+	r := NewRegistry()
+	r.ObserveHistogram("step", Labels{"step": "pull_image"}, 0.3, []float64{0.5, 1})
+	r.ObserveHistogram("step", Labels{"step": "pull_image"}, 0.7, []float64{0.5, 1})
+	r.ObserveHistogram("step", Labels{"step": "pull_image"}, 4, []float64{0.5, 1})
+
+	snapshot := r.Snapshot()
+	assert.Len(t, snapshot, 1)
+	assert.Equal(t, HistogramKind, snapshot[0].Kind)
+	assert.Equal(t, uint64(3), snapshot[0].Count)
+	assert.Equal(t, 5.0, snapshot[0].Sum)
+	assert.Equal(t, []Bucket{{UpperBound: 0.5, Count: 1}, {UpperBound: 1, Count: 2}}, snapshot[0].Buckets)
+
+	// snapshots are copies
+	snapshot[0].Buckets[0].Count = 10
+	assert.Equal(t, uint64(1), r.Snapshot()[0].Buckets[0].Count)
+}
+
+func TestConfiguredExporters(t *testing.T) {
+	defer os.Unsetenv(constants.EnvMetricsExporters)
+
+	os.Setenv(constants.EnvMetricsExporters, "statsd, otlp")
+	exporters, err := ConfiguredExporters()
+	assert.Nil(t, err)
+	assert.Len(t, exporters, 2)
+
+	os.Setenv(constants.EnvMetricsExporters, "")
+	exporters, err = ConfiguredExporters()
+	assert.Nil(t, err)
+	assert.Empty(t, exporters)
+
+	os.Setenv(constants.EnvMetricsExporters, "graphite")
+	_, err = ConfiguredExporters()
+	assert.Error(t, err)
+}
@@ -0,0 +1,192 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// otlpMetricsPath is the OTLP/HTTP path metrics are posted to
+const otlpMetricsPath = "/v1/metrics"
+
+// aggregationTemporalityCumulative reports counters as their total since the exporter started
+const aggregationTemporalityCumulative = 2
+
+// OTLP pushes metrics to an OpenTelemetry collector over OTLP/HTTP using the json encoding, histograms are sent as summaries
+type OTLP struct {
+	endpoint  string
+	client    *http.Client
+	startTime time.Time
+}
+
+// NewOTLP returns an OTLP exporter pushing to a collector endpoint (ie. http://otel-collector:4318)
+func NewOTLP(endpoint string) *OTLP {
+	return &OTLP{
+		endpoint:  strings.TrimSuffix(endpoint, "/") + otlpMetricsPath,
+		client:    &http.Client{},
+		startTime: time.Now(),
+	}
+}
+
+// Name returns the name of the exporter
+func (o *OTLP) Name() string { return OTLPExporter }
+
+// Export posts the metrics to the collector
+func (o *OTLP) Export(ctx context.Context, metrics []Metric) error {
+	body, err := json.Marshal(o.request(metrics, time.Now()))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, o.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("collector %s responded with status %d", o.endpoint, resp.StatusCode)
+	}
+	return nil
+}
+
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpMetric struct {
+	Name    string       `json:"name"`
+	Sum     *otlpSum     `json:"sum,omitempty"`
+	Gauge   *otlpGauge   `json:"gauge,omitempty"`
+	Summary *otlpSummary `json:"summary,omitempty"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpDataPoint `json:"dataPoints"`
+	AggregationTemporality int             `json:"aggregationTemporality"`
+	IsMonotonic            bool            `json:"isMonotonic"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpSummary struct {
+	DataPoints []otlpSummaryDataPoint `json:"dataPoints"`
+}
+
+type otlpDataPoint struct {
+	Attributes        []otlpAttribute `json:"attributes"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	AsDouble          float64         `json:"asDouble"`
+}
+
+type otlpSummaryDataPoint struct {
+	Attributes        []otlpAttribute `json:"attributes"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	Count             string          `json:"count"`
+	Sum               float64         `json:"sum"`
+}
+
+type otlpAttribute struct {
+	Key   string         `json:"key"`
+	Value otlpAttrString `json:"value"`
+}
+
+type otlpAttrString struct {
+	StringValue string `json:"stringValue"`
+}
+
+// request returns the OTLP export request for the metrics, series of the same metric are data points of one metric
+func (o *OTLP) request(metrics []Metric, now time.Time) otlpRequest {
+	start := strconv.FormatInt(o.startTime.UnixNano(), 10)
+	timestamp := strconv.FormatInt(now.UnixNano(), 10)
+
+	byName := make(map[string]*otlpMetric)
+	names := make([]string, 0)
+	for _, m := range metrics {
+		metric, ok := byName[m.Name]
+		if !ok {
+			metric = &otlpMetric{Name: m.Name}
+			byName[m.Name] = metric
+			names = append(names, m.Name)
+		}
+
+		attributes := otlpAttributes(m.Labels)
+		point := otlpDataPoint{Attributes: attributes, StartTimeUnixNano: start, TimeUnixNano: timestamp, AsDouble: m.Value}
+		switch m.Kind {
+		case CounterKind:
+			if metric.Sum == nil {
+				metric.Sum = &otlpSum{AggregationTemporality: aggregationTemporalityCumulative, IsMonotonic: true}
+			}
+			metric.Sum.DataPoints = append(metric.Sum.DataPoints, point)
+		case GaugeKind:
+			if metric.Gauge == nil {
+				metric.Gauge = &otlpGauge{}
+			}
+			metric.Gauge.DataPoints = append(metric.Gauge.DataPoints, point)
+		case SummaryKind, HistogramKind:
+			if metric.Summary == nil {
+				metric.Summary = &otlpSummary{}
+			}
+			metric.Summary.DataPoints = append(metric.Summary.DataPoints, otlpSummaryDataPoint{
+				Attributes:        attributes,
+				StartTimeUnixNano: start,
+				TimeUnixNano:      timestamp,
+				Count:             strconv.FormatUint(m.Count, 10),
+				Sum:               m.Sum,
+			})
+		}
+	}
+
+	otlpMetrics := make([]otlpMetric, 0, len(names))
+	for _, name := range names {
+		otlpMetrics = append(otlpMetrics, *byName[name])
+	}
+
+	return otlpRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource:     otlpResource{Attributes: otlpAttributes(Labels{"service.name": "krane"})},
+		ScopeMetrics: []otlpScopeMetrics{{Scope: otlpScope{Name: "github.com/krane/krane"}, Metrics: otlpMetrics}},
+	}}}
+}
+
+// otlpAttributes returns the labels as OTLP string attributes sorted by key
+func otlpAttributes(labels Labels) []otlpAttribute {
+	attributes := make([]otlpAttribute, 0, len(labels))
+	for _, k := range labels.keys() {
+		attributes = append(attributes, otlpAttribute{Key: k, Value: otlpAttrString{StringValue: labels[k]}})
+	}
+	return attributes
+}
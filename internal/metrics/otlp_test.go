@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOTLPRequest(t *testing.T) {
+	o := NewOTLP("http://collector:4318/")
+	assert.Equal(t, "http://collector:4318/v1/metrics", o.endpoint)
+
+	req := o.request([]Metric{
+		{Name: "jobs", Kind: CounterKind, Labels: Labels{"result": "failed"}, Value: 1},
+		{Name: "jobs", Kind: CounterKind, Labels: Labels{"result": "succeeded"}, Value: 2},
+		{Name: "healthy", Kind: GaugeKind, Value: 3},
+		{Name: "duration", Kind: SummaryKind, Count: 2, Sum: 1.5},
+	}, time.Unix(10, 0))
+
+	metrics := req.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	assert.Len(t, metrics, 3)
+	assert.Len(t, metrics[0].Sum.DataPoints, 2)
+	assert.True(t, metrics[0].Sum.IsMonotonic)
+	assert.Equal(t, "result", metrics[0].Sum.DataPoints[0].Attributes[0].Key)
+	assert.Equal(t, "10000000000", metrics[0].Sum.DataPoints[0].TimeUnixNano)
+	assert.Equal(t, 3.0, metrics[1].Gauge.DataPoints[0].AsDouble)
+	assert.Equal(t, "2", metrics[2].Summary.DataPoints[0].Count)
+}
+
+func TestOTLPExport(t *testing.T) {
+	var received otlpRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, otlpMetricsPath, r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	assert.Nil(t, NewOTLP(server.URL).Export(context.Background(), []Metric{{Name: "healthy", Kind: GaugeKind, Value: 1}}))
+	assert.Equal(t, "healthy", received.ResourceMetrics[0].ScopeMetrics[0].Metrics[0].Name)
+
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer rejecting.Close()
+	assert.Error(t, NewOTLP(rejecting.URL).Export(context.Background(), nil))
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// PrometheusContentType is the content type of the prometheus text exposition format
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheus writes a snapshot of the metrics in the prometheus text exposition format. Summaries are
+// written without quantiles, as a <name>_count and <name>_sum series.
+func WritePrometheus(w io.Writer, metrics []Metric) error {
+	b := bufio.NewWriter(w)
+
+	previous := ""
+	for _, m := range metrics {
+		// series of a metric are sorted next to each other in a snapshot
+		if m.Name != previous {
+			fmt.Fprintf(b, "# TYPE %s %s\n", m.Name, m.Kind)
+			previous = m.Name
+		}
+
+		switch m.Kind {
+		case CounterKind, GaugeKind:
+			fmt.Fprintf(b, "%s%s %s\n", m.Name, prometheusLabels(m.Labels, "", 0), formatFloat(m.Value))
+		case HistogramKind:
+			for _, bucket := range m.Buckets {
+				fmt.Fprintf(b, "%s_bucket%s %d\n", m.Name, prometheusLabels(m.Labels, "le", bucket.UpperBound), bucket.Count)
+			}
+			fmt.Fprintf(b, "%s_bucket%s %d\n", m.Name, prometheusLabels(m.Labels, "le", math.Inf(1)), m.Count)
+			fallthrough
+		case SummaryKind:
+			fmt.Fprintf(b, "%s_sum%s %s\n", m.Name, prometheusLabels(m.Labels, "", 0), formatFloat(m.Sum))
+			fmt.Fprintf(b, "%s_count%s %d\n", m.Name, prometheusLabels(m.Labels, "", 0), m.Count)
+		}
+	}
+
+	return b.Flush()
+}
+
+// prometheusLabels returns the labels of a series sorted by name (ie. {deployment="app",type="deploy"}),
+// with the bucket upper bound label of a histogram series when bucketLabel is set
+func prometheusLabels(labels Labels, bucketLabel string, upperBound float64) string {
+	pairs := make([]string, 0, len(labels)+1)
+	for _, k := range labels.keys() {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, k, prometheusLabelEscaper.Replace(labels[k])))
+	}
+	if bucketLabel != "" {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, bucketLabel, formatUpperBound(upperBound)))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatUpperBound returns a bucket upper bound as a prometheus le label value
+func formatUpperBound(bound float64) string {
+	if math.IsInf(bound, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(bound, 'f', -1, 64)
+}
@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	r := NewRegistry()
+	r.IncCounter("krane_jobs_total", Labels{"type": "RUN_DEPLOYMENT", "result": "succeeded"}, 2)
+	r.IncCounter("krane_jobs_total", Labels{"type": "RUN_DEPLOYMENT", "result": "failed"}, 1)
+	r.SetGauge("krane_deployment_running_containers", Labels{"deployment": `my "app"`}, 3)
+	r.Observe("krane_job_duration_seconds", Labels{}, 1.5)
+	r.ObserveHistogram("krane_job_step_duration_seconds", Labels{"step": "pull_image"}, 0.7, []float64{0.5, 1})
+
+	var b bytes.Buffer
+	assert.Nil(t, WritePrometheus(&b, r.Snapshot()))
+	assert.Equal(t, `# TYPE krane_deployment_running_containers gauge
+krane_deployment_running_containers{deployment="my \"app\""} 3
+# TYPE krane_job_duration_seconds summary
+krane_job_duration_seconds_sum 1.5
+krane_job_duration_seconds_count 1
+# TYPE krane_job_step_duration_seconds histogram
+krane_job_step_duration_seconds_bucket{step="pull_image",le="0.5"} 0
+krane_job_step_duration_seconds_bucket{step="pull_image",le="1"} 1
+krane_job_step_duration_seconds_bucket{step="pull_image",le="+Inf"} 1
+krane_job_step_duration_seconds_sum{step="pull_image"} 0.7
+krane_job_step_duration_seconds_count{step="pull_image"} 1
+# TYPE krane_jobs_total counter
+krane_jobs_total{result="failed",type="RUN_DEPLOYMENT"} 1
+krane_jobs_total{result="succeeded",type="RUN_DEPLOYMENT"} 2
+`, b.String())
+}
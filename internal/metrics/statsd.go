@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxStatsDPacketSize keeps StatsD packets under the typical network MTU
+const maxStatsDPacketSize = 1432
+
+// StatsD pushes metrics over udp in the StatsD line format. Labels are sent as DogStatsD tags which
+// are supported by the OpenTelemetry collector statsd receiver. Counters and summaries are sent as the
+// increase since the previous export, summaries and histograms as a <name>.count and <name>.sum counter.
+type StatsD struct {
+	address string
+
+	mu       sync.Mutex
+	previous map[string]Metric
+}
+
+// NewStatsD returns a StatsD exporter pushing to a host:port address
+func NewStatsD(address string) *StatsD {
+	return &StatsD{address: address, previous: make(map[string]Metric)}
+}
+
+// Name returns the name of the exporter
+func (s *StatsD) Name() string { return StatsDExporter }
+
+// Export sends the metrics to the StatsD server
+func (s *StatsD) Export(ctx context.Context, metrics []Metric) error {
+	packets := s.packets(metrics)
+	if len(packets) == 0 {
+		return nil
+	}
+
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "udp", s.address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, packet := range packets {
+		if _, err := conn.Write(packet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// packets returns the StatsD lines for the metrics batched into packets
+func (s *StatsD) packets(metrics []Metric) [][]byte {
+	packets := make([][]byte, 0)
+	var packet bytes.Buffer
+	for _, line := range s.lines(metrics) {
+		if packet.Len() > 0 && packet.Len()+len(line)+1 > maxStatsDPacketSize {
+			packets = append(packets, append([]byte{}, packet.Bytes()...))
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	if packet.Len() > 0 {
+		packets = append(packets, packet.Bytes())
+	}
+	return packets
+}
+
+// lines returns the StatsD lines for the metrics, counters unchanged since the previous export are skipped
+func (s *StatsD) lines(metrics []Metric) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lines := make([]string, 0, len(metrics))
+	for _, m := range metrics {
+		key := seriesKey(m.Name, m.Labels)
+		previous := s.previous[key]
+		s.previous[key] = m
+
+		tags := statsDTags(m.Labels)
+		switch m.Kind {
+		case GaugeKind:
+			lines = append(lines, fmt.Sprintf("%s:%s|g%s", m.Name, formatFloat(m.Value), tags))
+		case CounterKind:
+			if delta := m.Value - previous.Value; delta > 0 {
+				lines = append(lines, fmt.Sprintf("%s:%s|c%s", m.Name, formatFloat(delta), tags))
+			}
+		case SummaryKind, HistogramKind:
+			if delta := m.Count - previous.Count; delta > 0 {
+				lines = append(lines, fmt.Sprintf("%s.count:%d|c%s", m.Name, delta, tags))
+				lines = append(lines, fmt.Sprintf("%s.sum:%s|c%s", m.Name, formatFloat(m.Sum-previous.Sum), tags))
+			}
+		}
+	}
+	return lines
+}
+
+// statsDTags returns the labels formatted as DogStatsD tags (ie. |#deployment:app,type:deploy)
+func statsDTags(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	tags := make([]string, 0, len(labels))
+	for _, k := range labels.keys() {
+		tags = append(tags, k+":"+labels[k])
+	}
+	return "|#" + strings.Join(tags, ",")
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsDLines(t *testing.T) {
+	s := NewStatsD("127.0.0.1:8125")
+
+	metrics := []Metric{
+		{Name: "jobs", Kind: CounterKind, Labels: Labels{"type": "deploy", "deployment": "app"}, Value: 3},
+		{Name: "healthy", Kind: GaugeKind, Labels: Labels{}, Value: 2},
+		{Name: "duration", Kind: SummaryKind, Labels: Labels{"deployment": "app"}, Count: 2, Sum: 1.5},
+	}
+	assert.Equal(t, []string{
+		"jobs:3|c|#deployment:app,type:deploy",
+		"healthy:2|g",
+		"duration.count:2|c|#deployment:app",
+		"duration.sum:1.5|c|#deployment:app",
+	}, s.lines(metrics))
+
+	// counters are sent as the increase since the previous export, unchanged counters are skipped
+	metrics[0].Value = 5
+	assert.Equal(t, []string{"jobs:2|c|#deployment:app,type:deploy", "healthy:2|g"}, s.lines(metrics))
+}
+
+func TestStatsDExport(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	s := NewStatsD(conn.LocalAddr().String())
+	assert.Nil(t, s.Export(context.Background(), []Metric{{Name: "healthy", Kind: GaugeKind, Value: 1}}))
+
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, maxStatsDPacketSize)
+	n, _, err := conn.ReadFrom(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "healthy:1|g", strings.TrimSpace(string(buf[:n])))
+}
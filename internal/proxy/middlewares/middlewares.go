@@ -2,7 +2,9 @@ package middlewares
 
 import (
 	"fmt"
+	"net"
 	"strconv"
+	"strings"
 )
 
 func RedirectToHTTPSLabels(deployment string) map[string]string {
@@ -16,8 +18,59 @@ func RedirectToHTTPSLabels(deployment string) map[string]string {
 	return labels
 }
 
-func RateLimitLabels(deployment string, rateLimit uint) map[string]string {
+// RateLimitLabels returns the labels of a middleware limiting the requests to a deployment to an average number of
+// requests per second, allowing bursts of up to burst requests. The burst is left to the Traefik default when 0.
+func RateLimitLabels(deployment string, average, burst int) map[string]string {
 	labels := make(map[string]string, 0)
-	labels[fmt.Sprintf("traefik.http.middlewares.%s-ratelimit.ratelimit.average", deployment)] = strconv.FormatUint(uint64(rateLimit), 10)
+	labels[fmt.Sprintf("traefik.http.middlewares.%s-ratelimit.ratelimit.average", deployment)] = strconv.Itoa(average)
+	if burst > 0 {
+		labels[fmt.Sprintf("traefik.http.middlewares.%s-ratelimit.ratelimit.burst", deployment)] = strconv.Itoa(burst)
+	}
+	return labels
+}
+
+// denyAllSourceRange is the source range of an allowlist denying every client, the unspecified
+// addresses are never the address of a client
+const denyAllSourceRange = "0.0.0.0/32,::/128"
+
+// ParseSourceRange returns the cidr range of an allowlist entry, either a cidr (ie. 10.0.0.0/8)
+// or a single ip address (ie. 10.0.0.1 is 10.0.0.1/32). Returns false if the entry is neither.
+func ParseSourceRange(entry string) (string, bool) {
+	if _, _, err := net.ParseCIDR(entry); err == nil {
+		return entry, true
+	}
+
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return "", false
+	}
+	if ip.To4() != nil {
+		return entry + "/32", true
+	}
+	return entry + "/128", true
+}
+
+// IPAllowListLabels returns the labels of a middleware denying the requests to a deployment from clients outside of
+// the allowed entries (cidr ranges or ip addresses). No labels are returned without entries, any client is allowed.
+// Invalid entries are left out and an allowlist without a valid entry denies every client, so a misconfigured
+// allowlist never opens a deployment to any client.
+func IPAllowListLabels(deployment string, entries []string) map[string]string {
+	labels := make(map[string]string, 0)
+	if len(entries) == 0 {
+		return labels
+	}
+
+	ranges := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if cidr, ok := ParseSourceRange(entry); ok {
+			ranges = append(ranges, cidr)
+		}
+	}
+
+	sourceRange := strings.Join(ranges, ",")
+	if len(ranges) == 0 {
+		sourceRange = denyAllSourceRange
+	}
+	labels[fmt.Sprintf("traefik.http.middlewares.%s-ipallowlist.ipallowlist.sourcerange", deployment)] = sourceRange
 	return labels
 }
@@ -1,40 +1,44 @@
 package proxy
 
 import (
-	"bytes"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/krane/krane/internal/proxy/middlewares"
 )
 
+// DefaultCertResolver is the cert resolver configured on the network proxy for secure deployments
+const DefaultCertResolver = "lets-encrypt"
+
 type TraefikLabel struct {
 	Key   string
 	Value string
 }
 
-func TraefikRouterLabels(deployment string, aliases []string, secure bool) map[string]string {
-	// configure aliases as Host('my-alias.example.com') labels
-	var hostRules bytes.Buffer
-	for i, alias := range aliases {
+// WeightedService is a service of a weighted service (ie. the service of a variant) and its share of the traffic
+type WeightedService struct {
+	Name   string
+	Weight uint
+}
+
+func TraefikRouterLabels(deployment string, aliases []string, secure bool, certResolver string) map[string]string {
+	// configure aliases as Host('my-alias.example.com') rules combined with the OR operator
+	hosts := make([]string, 0)
+	for _, alias := range aliases {
 		if alias == "" {
 			continue
 		}
-
-		if i == len(aliases)-1 {
-			// if last or only alias, just append the host with no OR operator
-			hostRules.WriteString(fmt.Sprintf("Host(`%s`)", alias))
-		} else {
-			// append OR operator if not the last alias
-			hostRules.WriteString(fmt.Sprintf("Host(`%s`) || ", alias))
-		}
+		hosts = append(hosts, fmt.Sprintf("Host(`%s`)", alias))
 	}
+	hostRules := strings.Join(hosts, " || ")
 
 	labels := make(map[string]string, 0)
 
 	// http
-	if hostRules.String() != "" {
-		labels[fmt.Sprintf("traefik.http.routers.%s-insecure.rule", deployment)] = hostRules.String()
+	if hostRules != "" {
+		labels[fmt.Sprintf("traefik.http.routers.%s-insecure.rule", deployment)] = hostRules
 	}
 	labels[fmt.Sprintf("traefik.http.routers.%s-insecure.entrypoints", deployment)] = "web"
 
@@ -42,9 +46,9 @@ func TraefikRouterLabels(deployment string, aliases []string, secure bool) map[s
 		// https
 		labels[fmt.Sprintf("traefik.http.routers.%s-secure.tls", deployment)] = "true"
 		labels[fmt.Sprintf("traefik.http.routers.%s-secure.entrypoints", deployment)] = "web-secure"
-		labels[fmt.Sprintf("traefik.http.routers.%s-secure.tls.certresolver", deployment)] = "lets-encrypt"
-		if hostRules.String() != "" {
-			labels[fmt.Sprintf("traefik.http.routers.%s-secure.rule", deployment)] = hostRules.String()
+		labels[fmt.Sprintf("traefik.http.routers.%s-secure.tls.certresolver", deployment)] = certResolver
+		if hostRules != "" {
+			labels[fmt.Sprintf("traefik.http.routers.%s-secure.rule", deployment)] = hostRules
 		}
 	}
 
@@ -67,29 +71,124 @@ func TraefikServiceLabels(deployment string, ports map[string]string, targetPort
 	return labels
 }
 
-func TraefikMiddlewareLabels(deployment string, secured bool, rateLimit uint) map[string]string {
+// TraefikWeightedServiceLabels returns the labels of the weighted services splitting the traffic of a deployment between
+// the services of its variants (ie. my-app-stable and my-app-canary) by weight, and routes the deployment to them.
+// Every service of the deployment (one per port without a target port) is weighted between the same services of each variant.
+func TraefikWeightedServiceLabels(deployment string, variants []WeightedService, ports map[string]string, targetPort string, secure bool) map[string]string {
 	labels := make(map[string]string, 0)
 
-	allMiddlewares := make([]string, 0)
+	services := traefikServices(deployment, ports, targetPort)
+	for i, service := range services {
+		for j, variant := range variants {
+			variantService := traefikServices(variant.Name, ports, targetPort)[i]
+			labels[fmt.Sprintf("traefik.http.services.%s.weighted.services[%d].name", service, j)] = variantService
+			labels[fmt.Sprintf("traefik.http.services.%s.weighted.services[%d].weight", service, j)] = strconv.Itoa(int(variant.Weight))
+		}
+	}
 
-	// http redirect
-	if secured {
-		for k, v := range middlewares.RedirectToHTTPSLabels(deployment) {
+	// routers only need their service set explicitly when the containers declare several services
+	if len(services) == 1 {
+		labels[fmt.Sprintf("traefik.http.routers.%s-insecure.service", deployment)] = services[0]
+		if secure {
+			labels[fmt.Sprintf("traefik.http.routers.%s-secure.service", deployment)] = services[0]
+		}
+	}
+
+	return labels
+}
+
+// traefikServices returns the names of the services of a deployment, one per port (sorted) without a target port
+func traefikServices(deployment string, ports map[string]string, targetPort string) []string {
+	if targetPort != "" {
+		return []string{deployment}
+	}
+
+	containerPorts := make([]string, 0, len(ports))
+	for _, containerPort := range ports {
+		containerPorts = append(containerPorts, containerPort)
+	}
+	sort.Strings(containerPorts)
+
+	services := make([]string, 0, len(containerPorts))
+	for _, containerPort := range containerPorts {
+		services = append(services, fmt.Sprintf("%s-%s", deployment, containerPort))
+	}
+	return services
+}
+
+// TraefikMiddlewareLabels returns the middleware labels of a deployment and chains the middlewares onto its routers.
+// A rate limit of 0 means no rate limit and no allowed ips means any client, the middlewares are not created then.
+func TraefikMiddlewareLabels(deployment string, secured bool, rateLimit uint, rateLimitBurst uint, allowedIPs []string) map[string]string {
+	labels := make(map[string]string, 0)
+
+	// middlewares applied to every request reaching the deployment
+	chain := make([]string, 0)
+
+	// ip allowlist, first so requests of denied clients are not counted by the rate limit
+	if allowList := middlewares.IPAllowListLabels(deployment, allowedIPs); len(allowList) > 0 {
+		for k, v := range allowList {
 			labels[k] = v
 		}
-		allMiddlewares = append(allMiddlewares, "redirect-to-https")
+		chain = append(chain, fmt.Sprintf("%s-ipallowlist", deployment))
 	}
 
 	// rate limit
-	for k, v := range middlewares.RateLimitLabels(deployment, rateLimit) {
-		labels[k] = v
+	if rateLimit > 0 {
+		for k, v := range middlewares.RateLimitLabels(deployment, int(rateLimit), int(rateLimitBurst)) {
+			labels[k] = v
+		}
+		chain = append(chain, fmt.Sprintf("%s-ratelimit", deployment))
+	}
+
+	// http redirect, only requests to the insecure router are redirected
+	insecure := chain
+	if secured {
+		for k, v := range middlewares.RedirectToHTTPSLabels(deployment) {
+			labels[k] = v
+		}
+		insecure = append([]string{"redirect-to-https"}, chain...)
 	}
-	allMiddlewares = append(allMiddlewares, fmt.Sprintf("%s-ratelimit", deployment))
 
 	// attach all middlewares to the deployment
-	mw := strings.Join(allMiddlewares[:], ",")
-	labels[fmt.Sprintf("traefik.http.routers.%s-insecure.middlewares", deployment)] = mw
-	labels[fmt.Sprintf("traefik.http.routers.%s-secure.middlewares", deployment)] = strings.Replace(mw, "redirect-to-https,", " ", 1)
+	if len(insecure) > 0 {
+		labels[fmt.Sprintf("traefik.http.routers.%s-insecure.middlewares", deployment)] = strings.Join(insecure, ",")
+	}
+	if secured && len(chain) > 0 {
+		labels[fmt.Sprintf("traefik.http.routers.%s-secure.middlewares", deployment)] = strings.Join(chain, ",")
+	}
+
+	return labels
+}
+
+// TraefikObservabilityLabels returns the router labels enabling or disabling access logs for a deployment.
+// Access logs must be enabled in the proxy static configuration, routers of deployments not enabling
+// access logs opt out so only the deployments that enable them are logged. Router observability options
+// require Traefik v3.1+, older proxies drop routers with unknown options so no labels are returned for them.
+func TraefikObservabilityLabels(deployment string, accessLog bool, version Version) map[string]string {
+	labels := make(map[string]string, 0)
+	if !version.AtLeast(3, 1) {
+		return labels
+	}
+
+	enabled := strconv.FormatBool(accessLog)
+	labels[fmt.Sprintf("traefik.http.routers.%s-insecure.observability.accesslogs", deployment)] = enabled
+	labels[fmt.Sprintf("traefik.http.routers.%s-secure.observability.accesslogs", deployment)] = enabled
+	return labels
+}
+
+// TraefikHealthCheckLabels returns the service labels configuring Traefik to health check the deployment containers.
+// Containers failing the health check are removed from the load balancer until they pass it again.
+func TraefikHealthCheckLabels(deployment string, ports map[string]string, targetPort string, path string, port string, interval string, timeout string) map[string]string {
+	labels := make(map[string]string, 0)
+
+	for _, service := range traefikServices(deployment, ports, targetPort) {
+		labels[fmt.Sprintf("traefik.http.services.%s.loadbalancer.healthcheck.path", service)] = path
+		labels[fmt.Sprintf("traefik.http.services.%s.loadbalancer.healthcheck.interval", service)] = interval
+		labels[fmt.Sprintf("traefik.http.services.%s.loadbalancer.healthcheck.timeout", service)] = timeout
+		if port != "" {
+			labels[fmt.Sprintf("traefik.http.services.%s.loadbalancer.healthcheck.port", service)] = port
+		}
+	}
 
 	return labels
 }
@@ -0,0 +1,44 @@
+package proxy
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DefaultVersion is the Traefik version the network proxy is assumed to run when it is not configured
+const DefaultVersion = "v2.11"
+
+// Version is the major and minor version of the Traefik network proxy, labels only understood
+// by newer Traefik versions are left out for older proxies which would drop the router otherwise
+type Version struct {
+	Major int
+	Minor int
+}
+
+// ParseVersion returns the Traefik version of an image tag or version (ie. v3.1, 2.11.3 or v3.1-alpine),
+// false if it does not start with a version
+func ParseVersion(version string) (Version, bool) {
+	version = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+
+	parts := strings.SplitN(version, ".", 3)
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return Version{}, false
+	}
+
+	minor := 0
+	if len(parts) > 1 {
+		if minor, err = strconv.Atoi(parts[1]); err != nil {
+			return Version{}, false
+		}
+	}
+	return Version{Major: major, Minor: minor}, true
+}
+
+// AtLeast returns true if the version is the same or newer than major.minor
+func (v Version) AtLeast(major, minor int) bool {
+	return v.Major > major || (v.Major == major && v.Minor >= minor)
+}
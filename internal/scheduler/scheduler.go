@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"context"
 	"time"
 
 	"github.com/pkg/errors"
@@ -64,7 +65,7 @@ func hasDesiredState(d deployment.Deployment) bool {
 		return false
 	}
 
-	if err := deployment.RetriableContainersHealthCheck(containers, 3); err != nil {
+	if err := deployment.RetriableContainersHealthCheck(context.Background(), config, containers, config.MinHealthyContainers(), 3); err != nil {
 		return false
 	}
 
@@ -0,0 +1,105 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Schema is a JSON Schema document
+type Schema map[string]interface{}
+
+// Draft is the JSON Schema version of the generated schemas
+const Draft = "http://json-schema.org/draft-07/schema#"
+
+// FromValue returns the JSON Schema for the type of a value.
+// Field names are taken from json tags, fields tagged with binding:"required" are required
+// and the allowed values of a field can be listed as comma separated values in an enum tag.
+// Fields tagged with schema:"duration" are durations given as a duration string (ie. 10m) or a number of seconds.
+func FromValue(v interface{}) Schema {
+	s := fromType(reflect.TypeOf(v))
+	s["$schema"] = Draft
+	return s
+}
+
+// fromType returns the JSON Schema for a Go type
+func fromType(t reflect.Type) Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Schema{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return Schema{"type": "array", "items": fromType(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": fromType(t.Elem())}
+	case reflect.Struct:
+		return fromStruct(t)
+	default:
+		return Schema{}
+	}
+}
+
+// fromStruct returns the JSON Schema for a struct using its exported json fields
+func fromStruct(t reflect.Type) Schema {
+	properties := Schema{}
+	required := make([]string, 0)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			tagName := strings.Split(tag, ",")[0]
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+
+		property := fromType(field.Type)
+		if field.Tag.Get("schema") == "duration" {
+			property = durationSchema()
+		}
+		if enum, ok := field.Tag.Lookup("enum"); ok {
+			values := make([]interface{}, 0)
+			for _, v := range strings.Split(enum, ",") {
+				values = append(values, v)
+			}
+			property["enum"] = values
+		}
+		properties[name] = property
+
+		if field.Tag.Get("binding") == "required" {
+			required = append(required, name)
+		}
+	}
+
+	s := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// durationSchema returns the JSON Schema of a duration given as a duration string (ie. 10m) or a number of seconds
+func durationSchema() Schema {
+	return Schema{"oneOf": []interface{}{
+		Schema{"type": "string", "pattern": `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`},
+		Schema{"type": "integer", "minimum": 0},
+	}}
+}
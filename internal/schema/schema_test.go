@@ -0,0 +1,35 @@
+package schema
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testConfig struct {
+	Name     string            `json:"name" binding:"required"`
+	Scale    uint              `json:"scale"`
+	Alias    []string          `json:"alias"`
+	Env      map[string]string `json:"env"`
+	Policy   string            `json:"policy" enum:"always,never"`
+	Timeout  time.Duration     `json:"-"`
+	Interval time.Duration     `json:"interval" schema:"duration"`
+	internal string
+}
+
+func TestFromValue(t *testing.T) {
+	s := FromValue(testConfig{})
+	properties := s["properties"].(Schema)
+
+	assert.Equal(t, Draft, s["$schema"])
+	assert.Equal(t, []string{"name"}, s["required"])
+	assert.Equal(t, Schema{"type": "string"}, properties["name"])
+	assert.Equal(t, Schema{"type": "integer", "minimum": 0}, properties["scale"])
+	assert.Equal(t, Schema{"type": "array", "items": Schema{"type": "string"}}, properties["alias"])
+	assert.Equal(t, Schema{"type": "object", "additionalProperties": Schema{"type": "string"}}, properties["env"])
+	assert.Equal(t, []interface{}{"always", "never"}, properties["policy"].(Schema)["enum"])
+	assert.Len(t, properties["interval"].(Schema)["oneOf"], 2)
+	assert.NotContains(t, properties, "Timeout")
+	assert.NotContains(t, properties, "internal")
+}
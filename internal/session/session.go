@@ -13,12 +13,23 @@ import (
 	"github.com/krane/krane/internal/utils"
 )
 
+// AdminUser is the user of sessions authenticated with the server key pair, the user is reserved
+// and cannot be used by sessions created through the api (ie. CI access tokens)
+const AdminUser = "root"
+
 // Session represents an authenticated user session
 type Session struct {
 	ID        string `json:"id"`
 	User      string `json:"user"`
 	Token     string `json:"token"`
 	ExpiresAt string `json:"expires_at"`
+	Admin     bool   `json:"admin"` // only set on sessions authenticated with the server key pair
+}
+
+// IsAdmin returns true if the session was authenticated with the server key pair, sessions created
+// for other users (ie. CI access tokens) cannot use admin only endpoints
+func (s Session) IsAdmin() bool {
+	return s.Admin
 }
 
 func (s Session) IsValid() bool {
@@ -0,0 +1,15 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsAdmin(t *testing.T) {
+	assert.True(t, Session{User: AdminUser, Admin: true}.IsAdmin())
+
+	// the admin user alone does not make a session admin
+	assert.False(t, Session{User: AdminUser}.IsAdmin())
+	assert.False(t, Session{User: "ci"}.IsAdmin())
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -35,8 +36,13 @@ var (
 	fileMode os.FileMode = 0600
 )
 
-// Client boltdb client instance
-func Client() Store { return instance }
+// Client boltdb client instance, when not connected a store failing every operation with ErrStoreUnavailable is returned
+func Client() Store {
+	if instance == nil {
+		return unavailableStore{}
+	}
+	return instance
+}
 
 // Connect connect to boltdb
 func Connect(path string) *BoltDB {
@@ -78,16 +84,46 @@ func (b *BoltDB) Disconnect() {
 	}
 }
 
+// Ping returns an error if the store cannot be accessed
+func (b *BoltDB) Ping() error {
+	return storeError(instance.View(func(tx *bolt.Tx) error { return nil }))
+}
+
+// storeError wraps errors caused by the store being closed or locked with ErrStoreUnavailable
+func storeError(err error) error {
+	if errors.Is(err, bolt.ErrDatabaseNotOpen) || errors.Is(err, bolt.ErrTimeout) {
+		return fmt.Errorf("%w: %v", ErrStoreUnavailable, err)
+	}
+	return err
+}
+
 // Put upsert a key/value pair
 func (b *BoltDB) Put(collection string, key string, value []byte) error {
-	return instance.Update(func(tx *bolt.Tx) error {
+	return storeError(instance.Update(func(tx *bolt.Tx) error {
 		bkt, err := tx.CreateBucketIfNotExists([]byte(collection))
 		if err != nil {
 			return fmt.Errorf("unable to create bucket for %s", collection)
 		}
 
 		return bkt.Put([]byte(key), value)
-	})
+	}))
+}
+
+// PutAll upsert key/value pairs in a single transaction, either every pair is saved or none
+func (b *BoltDB) PutAll(collection string, values map[string][]byte) error {
+	return storeError(instance.Update(func(tx *bolt.Tx) error {
+		bkt, err := tx.CreateBucketIfNotExists([]byte(collection))
+		if err != nil {
+			return fmt.Errorf("unable to create bucket for %s", collection)
+		}
+
+		for key, value := range values {
+			if err := bkt.Put([]byte(key), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
 }
 
 // Get get a key/value pair from a bucket
@@ -103,6 +139,7 @@ func (b *BoltDB) Get(collection, key string) (data []byte, err error) {
 	})
 
 	if err != nil {
+		err = storeError(err)
 		return
 	}
 
@@ -126,12 +163,32 @@ func (b *BoltDB) GetAll(collection string) (data [][]byte, err error) {
 	})
 
 	if err != nil {
+		err = storeError(err)
 		return
 	}
 
 	return
 }
 
+// GetAllWithKeys get all key/value pairs in a collection keyed by their key
+func (b *BoltDB) GetAllWithKeys(collection string) (data map[string][]byte, err error) {
+	data = make(map[string][]byte)
+	err = instance.View(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket([]byte(collection))
+		if bkt == nil {
+			return nil
+		}
+
+		return bkt.ForEach(func(k, v []byte) error {
+			// values are only valid for the life of the transaction
+			data[string(k)] = append([]byte{}, v...)
+			return nil
+		})
+	})
+	err = storeError(err)
+	return
+}
+
 // GetInRange get key/value pairs within a time range
 // minDate: RFC3339 sortable time string ie. 1990-01-01T00:00:00Z
 // maxDate example: RFC3339 sortable time string ie. 2000-01-01T00:00:00Z
@@ -149,29 +206,30 @@ func (b *BoltDB) GetInRange(collection, minDate, maxDate string) (data [][]byte,
 		}
 		return
 	})
+	err = storeError(err)
 	return
 }
 
 func (b *BoltDB) Remove(collection string, key string) error {
-	return instance.Update(func(tx *bolt.Tx) error {
+	return storeError(instance.Update(func(tx *bolt.Tx) error {
 		bkt := tx.Bucket([]byte(collection))
 		if bkt == nil {
 			// dont return err if bkt does not exists
 			return nil
 		}
 		return bkt.Delete([]byte(key))
-	})
+	}))
 }
 
 func (b *BoltDB) DeleteCollection(collection string) error {
-	return instance.Update(func(tx *bolt.Tx) error {
+	return storeError(instance.Update(func(tx *bolt.Tx) error {
 		return tx.DeleteBucket([]byte(collection))
-	})
+	}))
 }
 
 func (b *BoltDB) CreateCollection(collection string) error {
-	return instance.Update(func(tx *bolt.Tx) error {
+	return storeError(instance.Update(func(tx *bolt.Tx) error {
 		_, err := tx.CreateBucketIfNotExists([]byte(collection))
 		return err
-	})
+	}))
 }
@@ -2,10 +2,12 @@ package store
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/boltdb/bolt"
 	"github.com/docker/distribution/uuid"
 	"github.com/stretchr/testify/assert"
 
@@ -157,3 +159,53 @@ func TestBoltGetAll(t *testing.T) {
 		assert.NotNil(t, hero.CreatedAt)
 	}
 }
+
+func TestBoltGetAllWithKeys(t *testing.T) {
+	bkt := "avengers-keyed"
+
+	assert.Nil(t, Client().Put(bkt, "thor", []byte("Thor Odinson")))
+	assert.Nil(t, Client().Put(bkt, "tony", []byte("Tony Stark")))
+
+	all, err := Client().GetAllWithKeys(bkt)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]byte{"thor": []byte("Thor Odinson"), "tony": []byte("Tony Stark")}, all)
+
+	// missing collections have no key/value pairs
+	all, err = Client().GetAllWithKeys("avengers-missing")
+	assert.Nil(t, err)
+	assert.Empty(t, all)
+}
+
+func TestBoltPutAll(t *testing.T) {
+	bkt := "avengers-batch"
+
+	assert.Nil(t, Client().Put(bkt, "thor", []byte("Thor")))
+	assert.Nil(t, Client().PutAll(bkt, map[string][]byte{"thor": []byte("Thor Odinson"), "bruce": []byte("Bruce Banner")}))
+
+	all, err := Client().GetAllWithKeys(bkt)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]byte{"thor": []byte("Thor Odinson"), "bruce": []byte("Bruce Banner")}, all)
+}
+
+func TestBoltPing(t *testing.T) {
+	assert.Nil(t, Client().Ping())
+}
+
+func TestStoreErrorWrapsUnavailable(t *testing.T) {
+	err := storeError(bolt.ErrDatabaseNotOpen)
+	assert.True(t, errors.Is(err, ErrStoreUnavailable))
+	assert.Equal(t, "state store unavailable: database not open", err.Error())
+
+	other := errors.New("bucket not found")
+	assert.Equal(t, other, storeError(other))
+	assert.Nil(t, storeError(nil))
+}
+
+func TestUnavailableStore(t *testing.T) {
+	var s Store = unavailableStore{}
+	assert.Equal(t, ErrStoreUnavailable, s.Ping())
+
+	_, err := s.Get(constants.DeploymentsCollectionName, "key")
+	assert.Equal(t, ErrStoreUnavailable, err)
+	assert.Equal(t, ErrStoreUnavailable, s.Put(constants.DeploymentsCollectionName, "key", []byte("value")))
+}
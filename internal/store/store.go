@@ -1,11 +1,19 @@
 package store
 
+import "errors"
+
+// ErrStoreUnavailable is returned when the state store is not connected or cannot be accessed
+var ErrStoreUnavailable = errors.New("state store unavailable")
+
 type Store interface {
 	Disconnect()
+	Ping() error
 	Get(collection, key string) ([]byte, error)
 	GetAll(collection string) ([][]byte, error)
+	GetAllWithKeys(collection string) (map[string][]byte, error)
 	GetInRange(collection, minTime, maxTime string) ([][]byte, error)
 	Put(collection string, key string, value []byte) error
+	PutAll(collection string, values map[string][]byte) error
 	Remove(collection string, key string) error
 	DeleteCollection(collection string) error
 	CreateCollection(collection string) error
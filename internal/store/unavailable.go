@@ -0,0 +1,23 @@
+package store
+
+// unavailableStore is the store returned when the state store is not connected,
+// every operation fails with ErrStoreUnavailable instead of dereferencing a nil store
+type unavailableStore struct{}
+
+func (unavailableStore) Disconnect()                        {}
+func (unavailableStore) Ping() error                        { return ErrStoreUnavailable }
+func (unavailableStore) Get(string, string) ([]byte, error) { return nil, ErrStoreUnavailable }
+func (unavailableStore) GetAll(string) ([][]byte, error)    { return nil, ErrStoreUnavailable }
+func (unavailableStore) Put(string, string, []byte) error   { return ErrStoreUnavailable }
+func (unavailableStore) Remove(string, string) error        { return ErrStoreUnavailable }
+func (unavailableStore) DeleteCollection(string) error      { return ErrStoreUnavailable }
+func (unavailableStore) CreateCollection(string) error      { return ErrStoreUnavailable }
+func (unavailableStore) GetAllWithKeys(string) (map[string][]byte, error) {
+	return nil, ErrStoreUnavailable
+}
+func (unavailableStore) GetInRange(string, string, string) ([][]byte, error) {
+	return nil, ErrStoreUnavailable
+}
+func (unavailableStore) PutAll(string, map[string][]byte) error {
+	return ErrStoreUnavailable
+}